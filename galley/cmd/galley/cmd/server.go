@@ -167,6 +167,11 @@ func serverCmd() *cobra.Command {
 		"Name of the validation service running in the same namespace as the deployment")
 	serverCmd.PersistentFlags().StringVar(&serverArgs.ValidationArgs.WebhookName, "webhook-name", "istio-galley",
 		"Name of the k8s validatingwebhookconfiguration")
+	serverCmd.PersistentFlags().BoolVar(&serverArgs.ValidationArgs.StrictUnknownFields, "validation-strict-unknown-fields", false,
+		"Reject Pilot configuration with fields unknown to its schema instead of discarding them")
+	serverCmd.PersistentFlags().StringSliceVar(&serverArgs.ValidationArgs.UnknownFieldsAllowlist, "validation-unknown-fields-allowlist", nil,
+		"Comma-separated list of unknown fields accepted with --validation-strict-unknown-fields, "+
+			"as the full name of their message followed by the field name, e.g. istio.networking.v1alpha3.TrafficPolicy.tls")
 
 	// Hidden, file only flags for validation specific TLS
 	serverCmd.PersistentFlags().StringVar(&serverArgs.ValidationArgs.CertFile, "validation.tls.clientCertificate", "",
@@ -227,4 +227,5 @@ const (
 	reasonUnknownType          = "unknown_type"
 	reasonCRDConversionError   = "crd_conversion_error"
 	reasonInvalidConfig        = "invalid_resource"
+	reasonUnknownField         = "unknown_field"
 )
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -39,6 +40,7 @@ import (
 	"istio.io/istio/mixer/pkg/config/store"
 	"istio.io/istio/pilot/pkg/config/kube/crd"
 	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config"
 )
 
 var (
@@ -120,6 +122,15 @@ type WebhookParameters struct {
 
 	// Disable reconcile validatingwebhookconfiguration
 	DisableReconcileWebhookConfiguration bool
+
+	// StrictUnknownFields rejects pilot configuration with fields unknown to its schema,
+	// which are otherwise silently discarded.
+	StrictUnknownFields bool
+
+	// UnknownFieldsAllowlist lists the unknown fields accepted when StrictUnknownFields is set,
+	// as the full name of their message followed by the field name,
+	// e.g. istio.networking.v1alpha3.TrafficPolicy.tls.
+	UnknownFieldsAllowlist []string
 }
 
 type createInformerEndpointSource func(cl clientset.Interface, namespace, name string) cache.ListerWatcher
@@ -150,6 +161,8 @@ func (p *WebhookParameters) String() string {
 	fmt.Fprintf(buf, "ServiceName: %s\n", p.ServiceName)
 	fmt.Fprintf(buf, "EnableValidation: %v\n", p.EnableValidation)
 	fmt.Fprintf(buf, "DisableReconcileWebhookConfiguration: %v\n", p.DisableReconcileWebhookConfiguration)
+	fmt.Fprintf(buf, "StrictUnknownFields: %v\n", p.StrictUnknownFields)
+	fmt.Fprintf(buf, "UnknownFieldsAllowlist: %v\n", p.UnknownFieldsAllowlist)
 
 	return buf.String()
 }
@@ -176,8 +189,10 @@ type Webhook struct {
 	cert *tls.Certificate
 
 	// pilot
-	descriptor   model.ConfigDescriptor
-	domainSuffix string
+	descriptor             model.ConfigDescriptor
+	domainSuffix           string
+	strictUnknownFields    bool
+	unknownFieldsAllowlist map[string]bool
 
 	// mixer
 	validator store.BackendValidator
@@ -213,6 +228,11 @@ func NewWebhook(p WebhookParameters) (*Webhook, error) {
 		webhookName:                   p.WebhookName,
 		deploymentAndServiceNamespace: p.DeploymentAndServiceNamespace,
 		createInformerEndpointSource:  defaultCreateInformerEndpointSource,
+		strictUnknownFields:           p.StrictUnknownFields,
+		unknownFieldsAllowlist:        make(map[string]bool, len(p.UnknownFieldsAllowlist)),
+	}
+	for _, field := range p.UnknownFieldsAllowlist {
+		wh.unknownFieldsAllowlist[field] = true
 	}
 
 	// mtls disabled because apiserver webhook cert usage is still TBD.
@@ -358,6 +378,15 @@ func (wh *Webhook) admitPilot(request *admissionv1beta1.AdmissionRequest) *admis
 		return toAdmissionResponse(fmt.Errorf("error decoding configuration: %v", err))
 	}
 
+	if wh.strictUnknownFields {
+		if unknown := config.UnknownFields(obj.Spec, out.Spec, wh.unknownFieldsAllowlist); len(unknown) > 0 {
+			scope.Infof("configuration has unknown fields: %v", unknown)
+			reportValidationFailed(request, reasonUnknownField)
+			return toAdmissionResponse(fmt.Errorf("configuration is invalid: unknown fields %s",
+				strings.Join(unknown, ", ")))
+		}
+	}
+
 	if err := schema.Validate(out.Name, out.Namespace, out.Spec); err != nil {
 		scope.Infof("configuration is invalid: %v", err)
 		reportValidationFailed(request, reasonInvalidConfig)
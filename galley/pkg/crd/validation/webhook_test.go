@@ -348,6 +348,40 @@ func TestAdmitPilot(t *testing.T) {
 	}
 }
 
+func TestAdmitPilotUnknownFields(t *testing.T) {
+	trial := make(map[string]interface{})
+	if err := json.Unmarshal(makePilotConfig(t, 0, true, false), &trial); err != nil {
+		t.Fatal(err)
+	}
+	trial["spec"].(map[string]interface{})["unknownField"] = "value"
+	raw, err := json.Marshal(trial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := &admissionv1beta1.AdmissionRequest{
+		Kind:      metav1.GroupVersionKind{Kind: "mock"},
+		Object:    runtime.RawExtension{Raw: raw},
+		Operation: admissionv1beta1.Create,
+	}
+
+	wh, cancel := createTestWebhook(t, dummyClient, createFakeEndpointsSource(), dummyConfig)
+	defer cancel()
+
+	if got := wh.admitPilot(request); !got.Allowed {
+		t.Fatalf("unknown field rejected without strict unknown fields: %v", got.Result)
+	}
+
+	wh.strictUnknownFields = true
+	if got := wh.admitPilot(request); got.Allowed {
+		t.Fatal("unknown field allowed with strict unknown fields")
+	}
+
+	wh.unknownFieldsAllowlist = map[string]bool{"test.MockConfig.unknownField": true}
+	if got := wh.admitPilot(request); !got.Allowed {
+		t.Fatalf("allowlisted unknown field rejected: %v", got.Result)
+	}
+}
+
 func makeMixerConfig(t *testing.T, i int, includeBogusKey bool) []byte {
 	t.Helper()
 	uns := &unstructured.Unstructured{}
@@ -714,6 +714,21 @@ func init() {
 
 	versions = append(versions, "v1alpha3")
 
+	b.Add(schema.ResourceSpec{
+		Kind:      "ProxyConfig",
+		ListKind:  "ProxyConfigList",
+		Singular:  "proxyconfig",
+		Plural:    "proxyconfigs",
+		Versions:  versions,
+		Group:     "networking.istio.io",
+		Target:    metadata.Types.Get("istio/networking/v1alpha3/proxyconfigs"),
+		Converter: converter.Get("identity"),
+	})
+
+	versions = make([]string, 0)
+
+	versions = append(versions, "v1alpha3")
+
 	b.Add(schema.ResourceSpec{
 		Kind:      "ServiceEntry",
 		ListKind:  "ServiceEntryList",
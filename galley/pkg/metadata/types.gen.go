@@ -14,6 +14,9 @@ import (
 	// Register protos in "istio.io/api/authentication/v1alpha1"
 	_ "istio.io/api/authentication/v1alpha1"
 
+	// Register protos in "istio.io/api/mesh/v1alpha1"
+	_ "istio.io/api/mesh/v1alpha1"
+
 	// Register protos in "istio.io/api/mixer/v1/config/client"
 	_ "istio.io/api/mixer/v1/config/client"
 
@@ -166,6 +169,9 @@ var (
 	// istio/networking/v1alpha3/gateways metadata
 	IstioNetworkingV1alpha3Gateways resource.Info
 
+	// istio/networking/v1alpha3/proxyconfigs metadata
+	IstioNetworkingV1alpha3Proxyconfigs resource.Info
+
 	// istio/networking/v1alpha3/serviceentries metadata
 	IstioNetworkingV1alpha3Serviceentries resource.Info
 
@@ -353,6 +359,9 @@ func init() {
 	IstioNetworkingV1alpha3Gateways = b.Register(
 		"istio/networking/v1alpha3/gateways",
 		"type.googleapis.com/istio.networking.v1alpha3.Gateway")
+	IstioNetworkingV1alpha3Proxyconfigs = b.Register(
+		"istio/networking/v1alpha3/proxyconfigs",
+		"type.googleapis.com/istio.mesh.v1alpha1.ProxyConfig")
 	IstioNetworkingV1alpha3Serviceentries = b.Register(
 		"istio/networking/v1alpha3/serviceentries",
 		"type.googleapis.com/istio.networking.v1alpha3.ServiceEntry")
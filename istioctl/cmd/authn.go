@@ -17,6 +17,7 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	multierror "github.com/hashicorp/go-multierror"
 	"github.com/spf13/cobra"
@@ -78,6 +79,70 @@ istioctl authn tls-check foo-656bd7df7c-5zp4s.default bar
 	return cmd
 }
 
+func tlsTraffic() *cobra.Command {
+	var interval time.Duration
+	cmd := &cobra.Command{
+		Use:   "tls-traffic <pod-name[.namespace]>",
+		Short: "Report how many inbound connections of a proxy are plaintext or mTLS",
+		Long: `
+Report, for each inbound service port of a proxy, the authentication mode pilot configured and how
+many of the connections accepted by the proxy during the interval were mTLS or plaintext, with the
+rate of the plaintext connections. Use it to check that no plaintext traffic is left on the
+PERMISSIVE ports before switching them to STRICT. The counters are the inbound listener stats of
+the proxy, included in the Envoy stats by default, sampled at the start and at the end of the
+interval.
+`,
+		Example: `
+# Report the plaintext and mTLS traffic of pod "foo-656bd7df7c-5zp4s" in namespace default:
+istioctl authn tls-traffic foo-656bd7df7c-5zp4s.default
+
+# Report the traffic of the last minute:
+istioctl authn tls-traffic foo-656bd7df7c-5zp4s.default --interval 1m
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kubeClient, err := clientExecFactory(kubeconfig, configContext)
+			if err != nil {
+				return err
+			}
+			podName, ns := handlers.InferPodInfo(args[0], handlers.HandleNamespace(namespace, defaultNamespace))
+			results, err := kubeClient.AllPilotsDiscoveryDo(istioNamespace, "GET",
+				fmt.Sprintf("/debug/inboundauthnz?proxyID=%s.%s", podName, ns), nil)
+			if err != nil {
+				return err
+			}
+
+			var inbound []v2.InboundAuthenticationDebug
+			for i := range results {
+				if err := json.Unmarshal(results[i], &inbound); err != nil {
+					return multierror.Prefix(err, "JSON response invalid:")
+				}
+				if len(inbound) > 0 {
+					break
+				}
+			}
+			if len(inbound) == 0 {
+				return fmt.Errorf("checked %d pilot instances and found no inbound ports for %s.%s, check proxy status",
+					len(results), podName, ns)
+			}
+			before, err := kubeClient.EnvoyDo(podName, ns, "GET", "stats", nil)
+			if err != nil {
+				return err
+			}
+			time.Sleep(interval)
+			after, err := kubeClient.EnvoyDo(podName, ns, "GET", "stats", nil)
+			if err != nil {
+				return err
+			}
+			tw := pilot.TLSTrafficWriter{Writer: cmd.OutOrStdout()}
+			return tw.PrintAll(inbound, before, after, interval)
+		},
+	}
+	cmd.PersistentFlags().DurationVar(&interval, "interval", 10*time.Second,
+		"Interval over which the connections are counted")
+	return cmd
+}
+
 // AuthN provides a command named authn that allows user to interact with Istio authentication policies.
 func AuthN() *cobra.Command {
 	cmd := &cobra.Command{
@@ -86,11 +151,13 @@ func AuthN() *cobra.Command {
 		Long: `
 A group of commands used to interact with Istio authentication policies.
   tls-check
+  tls-traffic
 `,
 		Example: `# Check whether TLS setting are matching between authentication policy and destination rules:
 istioctl authn tls-check`,
 	}
 
 	cmd.AddCommand(tlsCheck())
+	cmd.AddCommand(tlsTraffic())
 	return cmd
 }
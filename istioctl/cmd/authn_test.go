@@ -147,3 +147,108 @@ func TestAuthnTlsCheckNoKube(t *testing.T) {
 func mockExecClientAuthNoKube(_, _ string) (kubernetes.ExecClient, error) {
 	return nil, fmt.Errorf("unauthorized")
 }
+
+// mockTLSTrafficExecConfig answers the pilot and the Envoy requests separately. The Envoy stats
+// of a pod are its successive samples.
+type mockTLSTrafficExecConfig struct {
+	mockExecConfig
+	pilots  map[string][]byte
+	samples map[string][][]byte
+}
+
+// nolint: unparam
+func (client *mockTLSTrafficExecConfig) EnvoyDo(podName, podNamespace, method, path string, body []byte) ([]byte, error) {
+	samples, ok := client.samples[podName]
+	if !ok {
+		return client.mockExecConfig.EnvoyDo(podName, podNamespace, method, path, body)
+	}
+	client.samples[podName] = samples[1:]
+	return samples[0], nil
+}
+
+// nolint: unparam
+func (client mockTLSTrafficExecConfig) AllPilotsDiscoveryDo(pilotNamespace, method, path string, body []byte) (map[string][]byte, error) {
+	return client.pilots, nil
+}
+
+func TestAuthnTLSTraffic(t *testing.T) {
+	clientExecFactory = mockExecClientTLSTraffic
+
+	cases := []testCase{
+		{ // case 0
+			configs:        []model.Config{},
+			args:           strings.Split("authn tls-traffic", " "),
+			expectedOutput: "Error: accepts 1 arg(s), received 0\n",
+			wantException:  true,
+		},
+		{ // case 1
+			configs: []model.Config{},
+			args:    strings.Split("authn tls-traffic details-123456-7890 --interval 10ms", " "),
+			expectedOutput: `HOST:PORT                                  LISTENER           SERVER        CONNECTIONS     MTLS     PLAINTEXT     PLAINTEXT/S
+details.default.svc.cluster.local:8080     10.1.1.1_9080      HTTP/mTLS     5               3        2             200.00
+details.default.svc.cluster.local:9090     10.1.1.1_9090      HTTP/mTLS     -               -        -             -
+metrics.default.svc.cluster.local:9091     10.1.1.1_15090     mTLS          4               4        0             0.00
+`,
+		},
+		{ // case 2
+			configs:        []model.Config{},
+			args:           strings.Split("authn tls-traffic badpod-123456-7890", " "),
+			expectedRegexp: regexp.MustCompile("Error: unable to retrieve Pod"),
+			wantException:  true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("case %d %s", i, strings.Join(c.args, " ")), func(t *testing.T) {
+			verifyOutput(t, c)
+		})
+	}
+}
+
+func mockExecClientTLSTraffic(_, _ string) (kubernetes.ExecClient, error) {
+	// The counters of the 15090 listener were reset between the samples.
+	return &mockTLSTrafficExecConfig{
+		samples: map[string][][]byte{
+			"details-123456-7890": {[]byte(`cluster_manager.cds.update_success: 3
+listener.0.0.0.0_15001.downstream_cx_total: 40
+listener.10.1.1.1_9080.downstream_cx_total: 7
+listener.10.1.1.1_9080.ssl.handshake: 6
+listener.10.1.1.1_15090.downstream_cx_total: 10
+listener.10.1.1.1_15090.ssl.handshake: 10
+`), []byte(`cluster_manager.cds.update_success: 3
+listener.0.0.0.0_15001.downstream_cx_total: 40
+listener.10.1.1.1_9080.downstream_cx_total: 12
+listener.10.1.1.1_9080.ssl.handshake: 9
+listener.10.1.1.1_15090.downstream_cx_total: 4
+listener.10.1.1.1_15090.ssl.handshake: 4
+`)},
+		},
+		pilots: map[string][]byte{
+			"istio-pilot-123456-7890": []byte(`[
+{
+  "host": "details.default.svc.cluster.local",
+  "port": 9090,
+  "address": "10.1.1.1",
+  "endpoint_port": 9090,
+  "authentication_policy_name": "default/",
+  "server_protocol": "HTTP/mTLS"
+},
+{
+  "host": "details.default.svc.cluster.local",
+  "port": 8080,
+  "address": "10.1.1.1",
+  "endpoint_port": 9080,
+  "authentication_policy_name": "default/",
+  "server_protocol": "HTTP/mTLS"
+},
+{
+  "host": "metrics.default.svc.cluster.local",
+  "port": 9091,
+  "address": "10.1.1.1",
+  "endpoint_port": 15090,
+  "authentication_policy_name": "strict/default",
+  "server_protocol": "mTLS"
+}]`),
+		},
+	}, nil
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"istio.io/istio/istioctl/pkg/util/handlers"
+	"istio.io/istio/istioctl/pkg/writer/compare"
 	"istio.io/istio/istioctl/pkg/writer/envoy/clusters"
 	"istio.io/istio/istioctl/pkg/writer/envoy/configdump"
 	"istio.io/istio/pilot/pkg/model"
@@ -41,6 +42,8 @@ var (
 	routeName string
 
 	clusterName, status string
+
+	againstIstiod, colorDiff bool
 )
 
 func setupConfigdumpEnvoyConfigWriter(podName, podNamespace string, out io.Writer) (*configdump.ConfigWriter, error) {
@@ -81,6 +84,49 @@ func setupClustersEnvoyConfigWriter(podName, podNamespace string, out io.Writer)
 	return cw, nil
 }
 
+func setupPilotComparator(pod string, out io.Writer) (*compare.Comparator, error) {
+	kubeClient, err := clientExecFactory(kubeconfig, configContext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create k8s client: %v", err)
+	}
+	podName, ns := handlers.InferPodInfo(pod, handlers.HandleNamespace(namespace, defaultNamespace))
+	envoyDump, err := kubeClient.EnvoyDo(podName, ns, "GET", "config_dump", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute command on envoy: %v", err)
+	}
+	path := fmt.Sprintf("/debug/config_dump?proxyID=%s.%s", podName, ns)
+	pilotDumps, err := kubeClient.AllPilotsDiscoveryDo(istioNamespace, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return compare.NewComparator(out, pilotDumps, envoyDump)
+}
+
+func setupProxyComparator(fromPod, toPod string, out io.Writer) (*compare.Comparator, error) {
+	kubeClient, err := clientExecFactory(kubeconfig, configContext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create k8s client: %v", err)
+	}
+	dumps := make([]compare.ProxyDump, 0, 2)
+	for _, pod := range []string{fromPod, toPod} {
+		podName, ns := handlers.InferPodInfo(pod, handlers.HandleNamespace(namespace, defaultNamespace))
+		configDump, err := kubeClient.EnvoyDo(podName, ns, "GET", "config_dump", nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute command on envoy: %v", err)
+		}
+		clustersDump, err := kubeClient.EnvoyDo(podName, ns, "GET", "clusters?format=json", nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute command on envoy: %v", err)
+		}
+		dumps = append(dumps, compare.ProxyDump{
+			Name:       podName + "." + ns,
+			ConfigDump: configDump,
+			Clusters:   clustersDump,
+		})
+	}
+	return compare.NewProxyComparator(out, dumps[0], dumps[1])
+}
+
 func proxyConfig() *cobra.Command {
 	configCmd := &cobra.Command{
 		Use:   "proxy-config",
@@ -284,7 +330,47 @@ func proxyConfig() *cobra.Command {
 		},
 	}
 
-	configCmd.AddCommand(clusterConfigCmd, listenerConfigCmd, routeConfigCmd, bootstrapConfigCmd, endpointConfigCmd)
+	diffConfigCmd := &cobra.Command{
+		Use:   "diff <pod-name[.namespace]> [<pod-name[.namespace]>]",
+		Short: "Diffs the configuration of the Envoys in two pods, or of an Envoy and Pilot",
+		Long: `Diff the clusters, listeners, routes and endpoints of the Envoy instances in two pods, or the clusters,
+listeners and routes of the Envoy instance in a pod with the configuration Pilot generated for it.
+Versions, update times, endpoint stats and the order of the load assignments are ignored.`,
+		Example: `  # Diff the configuration of the Envoys in two pods.
+  istioctl proxy-config diff <pod-name[.namespace]> <pod-name[.namespace]>
+
+  # Diff the configuration of an Envoy with Pilot's view of it, with colors.
+  istioctl proxy-config diff <pod-name[.namespace]> --against-istiod --color
+`,
+		Aliases: []string{"d"},
+		Args: func(cmd *cobra.Command, args []string) error {
+			if againstIstiod {
+				return cobra.ExactArgs(1)(cmd, args)
+			}
+			return cobra.ExactArgs(2)(cmd, args)
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			var comparator *compare.Comparator
+			var err error
+			if againstIstiod {
+				comparator, err = setupPilotComparator(args[0], c.OutOrStdout())
+			} else {
+				comparator, err = setupProxyComparator(args[0], args[1], c.OutOrStdout())
+			}
+			if err != nil {
+				return err
+			}
+			comparator.SetColor(colorDiff)
+			return comparator.Diff()
+		},
+	}
+
+	diffConfigCmd.PersistentFlags().BoolVar(&againstIstiod, "against-istiod", false,
+		"Diff the configuration of the Envoy with the configuration Pilot generated for it")
+	diffConfigCmd.PersistentFlags().BoolVar(&colorDiff, "color", false, "Colorize the diff")
+
+	configCmd.AddCommand(clusterConfigCmd, listenerConfigCmd, routeConfigCmd, bootstrapConfigCmd, endpointConfigCmd,
+		diffConfigCmd)
 
 	return configCmd
 }
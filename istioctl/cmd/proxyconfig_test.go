@@ -125,6 +125,27 @@ inbound|9080||productpage.default.svc.cluster.local     1
 172.17.0.14:15014     UNHEALTHY     OK                outbound|15014||istio-policy.istio-system.svc.cluster.local
 `,
 		},
+		{ // case 12 diff needs two pods
+			args:           strings.Split("proxy-config diff details-v1-5b7f94f9bc-wp5tb", " "),
+			expectedString: "accepts 2 arg(s), received 1",
+			wantException:  true,
+		},
+		{ // case 13 diff against istiod needs one pod
+			args:           strings.Split("proxy-config diff a b --against-istiod", " "),
+			expectedString: "accepts 1 arg(s), received 2",
+			wantException:  true,
+		},
+		{ // case 14 diff invalid
+			execClientConfig: cannedConfig,
+			args:             strings.Split("proxy-config diff details-v1-5b7f94f9bc-wp5tb invalid", " "),
+			expectedString:   "unable to retrieve Pod: pods \"invalid\" not found",
+			wantException:    true,
+		},
+		{ // case 15 diff against istiod valid
+			execClientConfig: cannedConfig,
+			args:             strings.Split("proxy-config diff details-v1-5b7f94f9bc-wp5tb --against-istiod", " "),
+			expectedString:   "Clusters Match\nListeners Match\nRoutes Match",
+		},
 	}
 
 	for i, c := range cases {
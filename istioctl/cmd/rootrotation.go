@@ -0,0 +1,143 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/security/pkg/pki/ca"
+)
+
+// rootRotationClientFactory creates the Kubernetes client of the root rotation commands, replaced
+// in tests.
+var rootRotationClientFactory = createInterface
+
+func rootRotation() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "root-rotation",
+		Short: "Rotate the root certificate of the self-signed Citadel CA",
+		Long: `Rotate the root certificate of the self-signed Citadel CA without downtime.
+
+Citadel generates a new root and adds it to the trust bundle of the workloads, while the current
+root keeps signing the certificates. After the grace period set by the --root-rotation-grace-period
+flag of Citadel, or after the --max-workload-cert-ttl of Citadel if longer, once all the workloads
+trust the new root, it signs the certificates. The previous root is removed from the trust bundle
+after the same period, once the certificates it signed have expired.`,
+	}
+	cmd.AddCommand(rootRotationStart())
+	cmd.AddCommand(rootRotationStatus())
+	return cmd
+}
+
+func rootRotationStart() *cobra.Command {
+	return &cobra.Command{
+		Use:     "start",
+		Short:   "Start the rotation of the root certificate",
+		Example: "istioctl experimental root-rotation start",
+		Args:    cobra.NoArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			client, err := rootRotationClientFactory(kubeconfig)
+			if err != nil {
+				return err
+			}
+			secrets := client.CoreV1().Secrets(istioNamespace)
+			secret, err := getCASecret(secrets.Get)
+			if err != nil {
+				return err
+			}
+			if phase := secret.Annotations[ca.RootRotationPhaseAnnotation]; phase != "" {
+				return fmt.Errorf("a rotation of the root certificate is already in progress, in phase %s", phase)
+			}
+			if secret.Annotations == nil {
+				secret.Annotations = make(map[string]string)
+			}
+			secret.Annotations[ca.RootRotationPhaseAnnotation] = string(ca.RootRotationRequested)
+			secret.Annotations[ca.RootRotationTimeAnnotation] = time.Now().UTC().Format(time.RFC3339)
+			if _, err := secrets.Update(secret); err != nil {
+				return fmt.Errorf("failed to request the rotation: %v", err)
+			}
+			_, _ = fmt.Fprintln(c.OutOrStdout(), "Requested the rotation of the root certificate. "+
+				"Run 'istioctl experimental root-rotation status' to follow it.")
+			return nil
+		},
+	}
+}
+
+func rootRotationStatus() *cobra.Command {
+	return &cobra.Command{
+		Use:     "status",
+		Short:   "Show the phase of the rotation and the root certificates",
+		Example: "istioctl experimental root-rotation status",
+		Args:    cobra.NoArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			client, err := rootRotationClientFactory(kubeconfig)
+			if err != nil {
+				return err
+			}
+			secret, err := getCASecret(client.CoreV1().Secrets(istioNamespace).Get)
+			if err != nil {
+				return err
+			}
+			return printRootRotationStatus(c.OutOrStdout(), secret)
+		},
+	}
+}
+
+func getCASecret(get func(string, metav1.GetOptions) (*v1.Secret, error)) (*v1.Secret, error) {
+	secret, err := get(ca.CASecret, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the CA secret %s/%s, the root rotation requires a self-signed Citadel CA: %v",
+			istioNamespace, ca.CASecret, err)
+	}
+	return secret, nil
+}
+
+func printRootRotationStatus(w io.Writer, secret *v1.Secret) error {
+	phase := ca.RootRotationPhase(secret.Annotations[ca.RootRotationPhaseAnnotation])
+	if phase == ca.RootRotationIdle {
+		_, _ = fmt.Fprintln(w, "Phase: Idle")
+	} else {
+		_, _ = fmt.Fprintf(w, "Phase: %s since %s\n", phase, secret.Annotations[ca.RootRotationTimeAnnotation])
+	}
+
+	_, _ = fmt.Fprintln(w, "Root certificates:")
+	rest := ca.RootRotationTrustBundle(secret)
+	for i := 0; ; i++ {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("invalid root certificate in the CA secret: %v", err)
+		}
+		role := "signing"
+		if i > 0 && phase == ca.RootRotationActivated {
+			role = "previous"
+		} else if i > 0 {
+			role = "staged"
+		}
+		_, _ = fmt.Fprintf(w, "  %-8s serial %s, expires %s\n", role, cert.SerialNumber, cert.NotAfter.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
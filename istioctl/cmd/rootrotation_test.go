@@ -0,0 +1,83 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"istio.io/istio/security/pkg/pki/ca"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+func TestRootRotation(t *testing.T) {
+	defer func() { rootRotationClientFactory = createInterface }()
+
+	root, _, err := util.GenCertKeyFromOptions(util.CertOptions{
+		TTL:          time.Hour,
+		Org:          "cluster.local",
+		IsCA:         true,
+		IsSelfSigned: true,
+		RSAKeySize:   1024,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := fake.NewSimpleClientset(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: ca.CASecret, Namespace: "istio-system"},
+		Data:       map[string][]byte{"ca-cert.pem": root},
+	})
+	rootRotationClientFactory = func(string) (kubernetes.Interface, error) { return client, nil }
+
+	cases := []testCase{
+		{
+			args:           strings.Split("experimental root-rotation status", " "),
+			expectedRegexp: regexp.MustCompile(`^Phase: Idle\nRoot certificates:\n  signing  serial \d+, expires \S+\n$`),
+		},
+		{
+			args:           strings.Split("experimental root-rotation start", " "),
+			expectedRegexp: regexp.MustCompile("^Requested the rotation of the root certificate"),
+		},
+		{
+			args:           strings.Split("experimental root-rotation start", " "),
+			expectedRegexp: regexp.MustCompile("already in progress, in phase Requested"),
+			wantException:  true,
+		},
+		{
+			args:           strings.Split("experimental root-rotation status", " "),
+			expectedRegexp: regexp.MustCompile(`^Phase: Requested since \S+\n`),
+		},
+	}
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("case %d %s", i, strings.Join(c.args, " ")), func(t *testing.T) {
+			verifyOutput(t, c)
+		})
+	}
+
+	rootRotationClientFactory = func(string) (kubernetes.Interface, error) { return fake.NewSimpleClientset(), nil }
+	verifyOutput(t, testCase{
+		args:           strings.Split("experimental root-rotation status", " "),
+		expectedRegexp: regexp.MustCompile("requires a self-signed Citadel CA"),
+		wantException:  true,
+	})
+}
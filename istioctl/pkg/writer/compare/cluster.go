@@ -17,9 +17,10 @@ package compare
 import (
 	"bytes"
 	"fmt"
+	"sort"
 
+	adminapi "github.com/envoyproxy/go-control-plane/envoy/admin/v2alpha"
 	"github.com/gogo/protobuf/jsonpb"
-	"github.com/pmezard/go-difflib/difflib"
 )
 
 // ClusterDiff prints a diff between Pilot and Envoy clusters to the passed writer
@@ -29,23 +30,16 @@ func (c *Comparator) ClusterDiff() error {
 	envoyClusterDump, err := c.envoy.GetDynamicClusterDump(true)
 	if err != nil {
 		envoyBytes.WriteString(err.Error())
-	} else if err := jsonm.Marshal(envoyBytes, envoyClusterDump); err != nil {
+	} else if err := jsonm.Marshal(envoyBytes, sortLoadAssignments(envoyClusterDump)); err != nil {
 		return err
 	}
 	pilotClusterDump, err := c.pilot.GetDynamicClusterDump(true)
 	if err != nil {
 		pilotBytes.WriteString(err.Error())
-	} else if err := jsonm.Marshal(pilotBytes, pilotClusterDump); err != nil {
+	} else if err := jsonm.Marshal(pilotBytes, sortLoadAssignments(pilotClusterDump)); err != nil {
 		return err
 	}
-	diff := difflib.UnifiedDiff{
-		FromFile: "Pilot Clusters",
-		A:        difflib.SplitLines(pilotBytes.String()),
-		ToFile:   "Envoy Clusters",
-		B:        difflib.SplitLines(envoyBytes.String()),
-		Context:  c.context,
-	}
-	text, err := difflib.GetUnifiedDiffString(diff)
+	text, err := c.unifiedDiff("Clusters", pilotBytes, envoyBytes)
 	if err != nil {
 		return err
 	}
@@ -56,3 +50,27 @@ func (c *Comparator) ClusterDiff() error {
 	}
 	return nil
 }
+
+// sortLoadAssignments sorts the localities and endpoints of the load assignments of the clusters,
+// whose order is not significant, so that they don't show up in the diff
+func sortLoadAssignments(dump *adminapi.ClustersConfigDump) *adminapi.ClustersConfigDump {
+	for _, dac := range dump.DynamicActiveClusters {
+		if dac.Cluster == nil || dac.Cluster.LoadAssignment == nil {
+			continue
+		}
+		localities := dac.Cluster.LoadAssignment.Endpoints
+		for i := range localities {
+			lbEndpoints := localities[i].LbEndpoints
+			sort.SliceStable(lbEndpoints, func(i, j int) bool {
+				return lbEndpoints[i].String() < lbEndpoints[j].String()
+			})
+		}
+		sort.SliceStable(localities, func(i, j int) bool {
+			if localities[i].Priority != localities[j].Priority {
+				return localities[i].Priority < localities[j].Priority
+			}
+			return localities[i].Locality.String() < localities[j].Locality.String()
+		})
+	}
+	return dump
+}
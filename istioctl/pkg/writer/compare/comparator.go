@@ -15,19 +15,50 @@
 package compare
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"strings"
 
+	"github.com/pmezard/go-difflib/difflib"
+
+	"istio.io/istio/istioctl/pkg/util/clusters"
 	"istio.io/istio/istioctl/pkg/util/configdump"
 )
 
-// Comparator diffs between a config dump from Pilot and one from Envoy
+const (
+	colorReset  = "\x1b[0m"
+	colorBold   = "\x1b[1m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorCyan   = "\x1b[36m"
+	pilotName   = "Pilot"
+	envoyName   = "Envoy"
+	contextSize = 7
+)
+
+// Comparator diffs between a config dump from Pilot and one from Envoy, or between the config
+// dumps of two Envoys. The pilot and envoy dumps are the "from" and "to" sides of the diff.
 type Comparator struct {
 	envoy, pilot *configdump.Wrapper
-	w            io.Writer
-	context      int
-	location     string
+	// envoyClusters and pilotClusters are the endpoints of the proxies, only diffed between two Envoys
+	envoyClusters, pilotClusters *clusters.Wrapper
+	envoyName, pilotName         string
+	w                            io.Writer
+	context                      int
+	location                     string
+	color                        bool
+}
+
+// ProxyDump holds the admin responses of an Envoy compared by NewProxyComparator
+type ProxyDump struct {
+	// Name labels the Envoy in the diff, e.g. with its pod name
+	Name string
+	// ConfigDump is the response of the config_dump admin endpoint
+	ConfigDump []byte
+	// Clusters is the response of the clusters?format=json admin endpoint
+	Clusters []byte
 }
 
 // NewComparator is a comparator constructor
@@ -52,11 +83,47 @@ func NewComparator(w io.Writer, pilotResponses map[string][]byte, envoyResponse
 	}
 	c.envoy = envoyDump
 	c.w = w
-	c.context = 7
+	c.pilotName, c.envoyName = pilotName, envoyName
+	c.context = contextSize
 	c.location = "Local" // the time.Location for formatting time.Time instances
 	return c, nil
 }
 
+// NewProxyComparator is a constructor of a comparator between the config dumps, and the endpoints,
+// of two Envoys
+func NewProxyComparator(w io.Writer, from, to ProxyDump) (*Comparator, error) {
+	c := &Comparator{
+		pilot:     &configdump.Wrapper{},
+		envoy:     &configdump.Wrapper{},
+		pilotName: from.Name,
+		envoyName: to.Name,
+		w:         w,
+		context:   contextSize,
+		location:  "Local",
+	}
+	if err := json.Unmarshal(from.ConfigDump, c.pilot); err != nil {
+		return nil, fmt.Errorf("unable to parse the config dump of %s: %v", from.Name, err)
+	}
+	if err := json.Unmarshal(to.ConfigDump, c.envoy); err != nil {
+		return nil, fmt.Errorf("unable to parse the config dump of %s: %v", to.Name, err)
+	}
+	if len(from.Clusters) > 0 && len(to.Clusters) > 0 {
+		c.pilotClusters, c.envoyClusters = &clusters.Wrapper{}, &clusters.Wrapper{}
+		if err := json.Unmarshal(from.Clusters, c.pilotClusters); err != nil {
+			return nil, fmt.Errorf("unable to parse the clusters of %s: %v", from.Name, err)
+		}
+		if err := json.Unmarshal(to.Clusters, c.envoyClusters); err != nil {
+			return nil, fmt.Errorf("unable to parse the clusters of %s: %v", to.Name, err)
+		}
+	}
+	return c, nil
+}
+
+// SetColor sets whether the diffs are colorized with ANSI escape codes
+func (c *Comparator) SetColor(color bool) {
+	c.color = color
+}
+
 // Diff prints a diff between Pilot and Envoy to the passed writer
 func (c *Comparator) Diff() error {
 	if err := c.ClusterDiff(); err != nil {
@@ -65,5 +132,37 @@ func (c *Comparator) Diff() error {
 	if err := c.ListenerDiff(); err != nil {
 		return err
 	}
-	return c.RouteDiff()
+	if err := c.RouteDiff(); err != nil {
+		return err
+	}
+	return c.EndpointDiff()
+}
+
+// unifiedDiff returns the diff of a kind of config, e.g. Clusters, or an empty string if it matches
+func (c *Comparator) unifiedDiff(kind string, pilotBytes, envoyBytes *bytes.Buffer) (string, error) {
+	diff := difflib.UnifiedDiff{
+		FromFile: c.pilotName + " " + kind,
+		A:        difflib.SplitLines(pilotBytes.String()),
+		ToFile:   c.envoyName + " " + kind,
+		B:        difflib.SplitLines(envoyBytes.String()),
+		Context:  c.context,
+	}
+	text, err := difflib.GetUnifiedDiffString(diff)
+	if err != nil || text == "" || !c.color {
+		return text, err
+	}
+	lines := strings.SplitAfter(text, "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+			lines[i] = colorBold + strings.TrimSuffix(line, "\n") + colorReset + "\n"
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = colorCyan + strings.TrimSuffix(line, "\n") + colorReset + "\n"
+		case strings.HasPrefix(line, "-"):
+			lines[i] = colorRed + strings.TrimSuffix(line, "\n") + colorReset + "\n"
+		case strings.HasPrefix(line, "+"):
+			lines[i] = colorGreen + strings.TrimSuffix(line, "\n") + colorReset + "\n"
+		}
+	}
+	return strings.Join(lines, ""), nil
 }
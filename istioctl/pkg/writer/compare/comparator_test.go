@@ -17,6 +17,7 @@ package compare
 import (
 	"bytes"
 	"io/ioutil"
+	"strings"
 	"testing"
 )
 
@@ -89,3 +90,51 @@ func TestNewComparator(t *testing.T) {
 		})
 	}
 }
+
+func TestNewProxyComparator(t *testing.T) {
+	tests := []struct {
+		name          string
+		from, to      ProxyDump
+		wantEndpoints bool
+		wantErr       bool
+	}{
+		{
+			name:          "populates config dumps and endpoints",
+			from:          ProxyDump{Name: "a", ConfigDump: loadEnvoyDump(), Clusters: loadClusters()},
+			to:            ProxyDump{Name: "b", ConfigDump: loadDiffEnvoyDump(), Clusters: loadClusters()},
+			wantEndpoints: true,
+		},
+		{
+			name: "skips endpoints if a proxy has none",
+			from: ProxyDump{Name: "a", ConfigDump: loadEnvoyDump(), Clusters: loadClusters()},
+			to:   ProxyDump{Name: "b", ConfigDump: loadDiffEnvoyDump()},
+		},
+		{
+			name:    "errors if a config dump can't be parsed",
+			from:    ProxyDump{Name: "a", ConfigDump: loadEnvoyDump()},
+			to:      ProxyDump{Name: "b", ConfigDump: []byte("nope")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+			got, err := NewProxyComparator(w, tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewProxyComparator() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if tt.wantEndpoints != (got.envoyClusters != nil && got.pilotClusters != nil) {
+				t.Errorf("NewProxyComparator() endpoints = %v, wantEndpoints %v", got.envoyClusters, tt.wantEndpoints)
+			}
+			if err := got.Diff(); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(w.String(), "--- a Clusters\n+++ b Clusters\n") {
+				t.Errorf("Diff() => got %q, want a diff of the clusters of a and b", w.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compare
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	adminapi "github.com/envoyproxy/go-control-plane/envoy/admin/v2alpha"
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+
+	"istio.io/istio/istioctl/pkg/util/clusters"
+)
+
+// EndpointDiff prints a diff between the endpoints of two Envoys to the passed writer. Pilot has no
+// view of the endpoints of a proxy to compare with, so nothing is printed when comparing with Pilot.
+func (c *Comparator) EndpointDiff() error {
+	if c.pilotClusters == nil || c.envoyClusters == nil {
+		return nil
+	}
+	jsonm := &jsonpb.Marshaler{Indent: "   "}
+	envoyBytes, pilotBytes := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := jsonm.Marshal(envoyBytes, stripEndpointStats(c.envoyClusters)); err != nil {
+		return err
+	}
+	if err := jsonm.Marshal(pilotBytes, stripEndpointStats(c.pilotClusters)); err != nil {
+		return err
+	}
+	text, err := c.unifiedDiff("Endpoints", pilotBytes, envoyBytes)
+	if err != nil {
+		return err
+	}
+	if text != "" {
+		fmt.Fprintln(c.w, text)
+	} else {
+		fmt.Fprintln(c.w, "Endpoints Match")
+	}
+	return nil
+}
+
+// stripEndpointStats returns the clusters sorted by name, with their hosts sorted by address and
+// without the stats and success rates, which change with the traffic of each proxy
+func stripEndpointStats(w *clusters.Wrapper) *adminapi.Clusters {
+	stripped := proto.Clone(w.Clusters).(*adminapi.Clusters)
+	statuses := stripped.ClusterStatuses
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	for _, status := range statuses {
+		status.SuccessRateEjectionThreshold = nil
+		status.LocalOriginSuccessRateEjectionThreshold = nil
+		hosts := status.HostStatuses
+		for _, host := range hosts {
+			host.Stats = nil
+			host.SuccessRate = nil
+			host.LocalOriginSuccessRate = nil
+		}
+		sort.Slice(hosts, func(i, j int) bool {
+			return hosts[i].Address.String() < hosts[j].Address.String()
+		})
+	}
+	return stripped
+}
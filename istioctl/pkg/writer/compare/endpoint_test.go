@@ -0,0 +1,86 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compare
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func loadClusters() []byte {
+	bytes, _ := ioutil.ReadFile("../envoy/clusters/testdata/clusters.json")
+	return bytes
+}
+
+func TestComparator_EndpointDiff(t *testing.T) {
+	clusters := string(loadClusters())
+	tests := []struct {
+		name         string
+		fromClusters string
+		toClusters   string
+		color        bool
+		wantContains []string
+	}{
+		{
+			name:         "prints match",
+			fromClusters: clusters,
+			toClusters:   clusters,
+			wantContains: []string{"Endpoints Match\n"},
+		},
+		{
+			name:         "ignores stats",
+			fromClusters: clusters,
+			toClusters:   strings.Replace(clusters, `"name": "cx_total"`, `"name": "cx_total", "value": "42"`, 1),
+			wantContains: []string{"Endpoints Match\n"},
+		},
+		{
+			name:         "prints a diff",
+			fromClusters: clusters,
+			toClusters:   strings.Replace(clusters, `"edsHealthStatus": "HEALTHY"`, `"edsHealthStatus": "DRAINING"`, 1),
+			wantContains: []string{"--- a Endpoints\n", "+++ b Endpoints\n", `-                  "edsHealthStatus": "HEALTHY"`,
+				`+                  "edsHealthStatus": "DRAINING"`},
+		},
+		{
+			name:         "prints a colorized diff",
+			fromClusters: clusters,
+			toClusters:   strings.Replace(clusters, `"edsHealthStatus": "HEALTHY"`, `"edsHealthStatus": "DRAINING"`, 1),
+			color:        true,
+			wantContains: []string{colorBold + "--- a Endpoints" + colorReset, colorRed + `-                  "edsHealthStatus": "HEALTHY"` + colorReset,
+				colorGreen + `+                  "edsHealthStatus": "DRAINING"` + colorReset},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &bytes.Buffer{}
+			c, err := NewProxyComparator(got,
+				ProxyDump{Name: "a", ConfigDump: loadEnvoyDump(), Clusters: []byte(tt.fromClusters)},
+				ProxyDump{Name: "b", ConfigDump: loadEnvoyDump(), Clusters: []byte(tt.toClusters)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.SetColor(tt.color)
+			if err := c.EndpointDiff(); err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.wantContains {
+				if !strings.Contains(got.String(), want) {
+					t.Errorf("EndpointDiff() => got %q, want it to contain %q", got.String(), want)
+				}
+			}
+		})
+	}
+}
@@ -19,7 +19,6 @@ import (
 	"fmt"
 
 	"github.com/gogo/protobuf/jsonpb"
-	"github.com/pmezard/go-difflib/difflib"
 )
 
 // ListenerDiff prints a diff between Pilot and Envoy listeners to the passed writer
@@ -38,14 +37,7 @@ func (c *Comparator) ListenerDiff() error {
 	} else if err := jsonm.Marshal(pilotBytes, pilotListenerDump); err != nil {
 		return err
 	}
-	diff := difflib.UnifiedDiff{
-		FromFile: "Pilot Listeners",
-		A:        difflib.SplitLines(pilotBytes.String()),
-		ToFile:   "Envoy Listeners",
-		B:        difflib.SplitLines(envoyBytes.String()),
-		Context:  c.context,
-	}
-	text, err := difflib.GetUnifiedDiffString(diff)
+	text, err := c.unifiedDiff("Listeners", pilotBytes, envoyBytes)
 	if err != nil {
 		return err
 	}
@@ -20,7 +20,6 @@ import (
 	"time"
 
 	"github.com/gogo/protobuf/jsonpb"
-	"github.com/pmezard/go-difflib/difflib"
 )
 
 // RouteDiff prints a diff between Pilot and Envoy routes to the passed writer
@@ -39,14 +38,7 @@ func (c *Comparator) RouteDiff() error {
 	} else if err := jsonm.Marshal(pilotBytes, pilotRouteDump); err != nil {
 		return err
 	}
-	diff := difflib.UnifiedDiff{
-		FromFile: "Pilot Routes",
-		A:        difflib.SplitLines(pilotBytes.String()),
-		ToFile:   "Envoy Routes",
-		B:        difflib.SplitLines(envoyBytes.String()),
-		Context:  c.context,
-	}
-	text, err := difflib.GetUnifiedDiffString(diff)
+	text, err := c.unifiedDiff("Routes", pilotBytes, envoyBytes)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,129 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pilot
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	v2 "istio.io/istio/pilot/pkg/proxy/envoy/v2"
+)
+
+// listenerTLSStat matches the connections and TLS handshakes counters of the listeners, e.g.
+// listener.10.1.1.1_8080.downstream_cx_total: 12 or listener.[fd00__1]_8080.ssl.handshake: 9
+var listenerTLSStat = regexp.MustCompile(`^listener\.(.+_[0-9]+)\.(downstream_cx_total|ssl\.handshake): ([0-9]+)$`)
+
+// ListenerTLSStats are the connections accepted by a listener, and how many of them were mTLS.
+type ListenerTLSStats struct {
+	Connections uint64
+	MTLS        uint64
+}
+
+// Plaintext returns the number of connections which were not mTLS.
+func (s ListenerTLSStats) Plaintext() uint64 {
+	if s.MTLS > s.Connections {
+		return 0
+	}
+	return s.Connections - s.MTLS
+}
+
+// Since returns the connections accepted since the previous sample of the counters. If the
+// counters were reset, e.g. because the proxy restarted, they are returned unchanged.
+func (s ListenerTLSStats) Since(previous ListenerTLSStats) ListenerTLSStats {
+	if s.Connections < previous.Connections || s.MTLS < previous.MTLS {
+		return s
+	}
+	return ListenerTLSStats{Connections: s.Connections - previous.Connections, MTLS: s.MTLS - previous.MTLS}
+}
+
+// listenerStatName returns the name of the listener of the address and port in the Envoy stats,
+// where the colons of IPv6 addresses are replaced by underscores.
+func listenerStatName(address string, port int) string {
+	if strings.Contains(address, ":") {
+		address = "[" + strings.Replace(address, ":", "_", -1) + "]"
+	}
+	return fmt.Sprintf("%s_%d", address, port)
+}
+
+// ParseListenerTLSStats reads the listener connections and TLS handshakes from the Envoy stats,
+// keyed by listener name.
+func ParseListenerTLSStats(stats []byte) map[string]ListenerTLSStats {
+	out := map[string]ListenerTLSStats{}
+	scanner := bufio.NewScanner(bytes.NewReader(stats))
+	for scanner.Scan() {
+		match := listenerTLSStat.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if match == nil {
+			continue
+		}
+		value, err := strconv.ParseUint(match[3], 10, 64)
+		if err != nil {
+			continue
+		}
+		s := out[match[1]]
+		if match[2] == "downstream_cx_total" {
+			s.Connections = value
+		} else {
+			s.MTLS = value
+		}
+		out[match[1]] = s
+	}
+	return out
+}
+
+// TLSTrafficWriter enables printing of the plaintext and mTLS traffic of the inbound ports of a proxy
+type TLSTrafficWriter struct {
+	Writer io.Writer
+}
+
+// PrintAll takes a Pilot inboundauthnz response and two samples of the Envoy stats of the proxy
+// taken the interval apart, and outputs the connections accepted by each inbound port during the
+// interval, and their rate, using a tabwriter
+func (t *TLSTrafficWriter) PrintAll(inbound []v2.InboundAuthenticationDebug, before, after []byte, interval time.Duration) error {
+	previous := ParseListenerTLSStats(before)
+	listeners := ParseListenerTLSStats(after)
+	sort.Slice(inbound, func(i, j int) bool {
+		if inbound[i].Host == inbound[j].Host {
+			return inbound[i].Port < inbound[j].Port
+		}
+		return inbound[i].Host < inbound[j].Host
+	})
+	w := new(tabwriter.Writer).Init(t.Writer, 0, 8, 5, ' ', 0)
+	fmt.Fprintln(w, "HOST:PORT\tLISTENER\tSERVER\tCONNECTIONS\tMTLS\tPLAINTEXT\tPLAINTEXT/S")
+	for _, entry := range inbound {
+		listener := listenerStatName(entry.Address, entry.EndpointPort)
+		host := fmt.Sprintf("%s:%d", entry.Host, entry.Port)
+		s, ok := listeners[listener]
+		if !ok {
+			fmt.Fprintf(w, "%s\t%s\t%s\t-\t-\t-\t-\n", host, listener, entry.ServerProtocol)
+			continue
+		}
+		s = s.Since(previous[listener])
+		rate := "-"
+		if interval > 0 {
+			rate = fmt.Sprintf("%.2f", float64(s.Plaintext())/interval.Seconds())
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\t%s\n", host, listener, entry.ServerProtocol,
+			s.Connections, s.MTLS, s.Plaintext(), rate)
+	}
+	return w.Flush()
+}
@@ -17,11 +17,16 @@ package main
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net"
+	"net/url"
 	"os"
+	"path"
 	"strconv"
 	"strings"
 	"sync"
@@ -42,8 +47,10 @@ import (
 	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/proxy"
+	"istio.io/istio/pilot/pkg/proxy/dns"
 	"istio.io/istio/pilot/pkg/proxy/envoy"
 	"istio.io/istio/pilot/pkg/serviceregistry"
+	"istio.io/istio/pkg/bootstrap"
 	"istio.io/istio/pkg/cmd"
 	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/spiffe"
@@ -56,6 +63,7 @@ var (
 	proxyIP          string
 	registry         serviceregistry.ServiceRegistry
 	statusPort       uint16
+	agentAdminPort   uint16
 	applicationPorts []string
 
 	// proxy config flags (named identically)
@@ -66,6 +74,7 @@ var (
 	drainDuration                time.Duration
 	parentShutdownDuration       time.Duration
 	discoveryAddress             string
+	discoveryFailoverAddresses   []string
 	zipkinAddress                string
 	lightstepAddress             string
 	lightstepAccessToken         string
@@ -86,6 +95,9 @@ var (
 	templateFile                 string
 	disableInternalTelemetry     bool
 	tlsCertsToWatch              []string
+	dnsProxyAddress              string
+	dnsNameTableURL              string
+	dnsNameTableRefreshInterval  time.Duration
 	loggingOptions               = log.DefaultOptions()
 
 	wg sync.WaitGroup
@@ -300,6 +312,9 @@ var (
 			}
 
 			opts := make(map[string]interface{})
+			if len(discoveryFailoverAddresses) > 0 {
+				opts[bootstrap.DiscoveryFailoverAddresses] = discoveryFailoverAddresses
+			}
 			if sdsEnabled {
 				opts["sds_uds_path"] = sdsUdsPathVar.Get()
 				opts["sds_token_path"] = sdsTokenPath
@@ -363,16 +378,16 @@ var (
 				cancel()
 				wg.Wait()
 			}()
+			localHostAddr := "127.0.0.1"
+			if proxyIPv6 {
+				localHostAddr = "[::1]"
+			}
 			// If a status port was provided, start handling status probes.
 			if statusPort > 0 {
 				parsedPorts, err := parseApplicationPorts()
 				if err != nil {
 					return err
 				}
-				localHostAddr := "127.0.0.1"
-				if proxyIPv6 {
-					localHostAddr = "[::1]"
-				}
 				prober := kubeAppProberNameVar.Get()
 				statusServer, err := status.NewServer(status.Config{
 					LocalHostAddr:      localHostAddr,
@@ -394,6 +409,35 @@ var (
 			agent := proxy.NewAgent(envoyProxy, proxy.DefaultRetry, features.TerminationDrainDuration())
 			watcher := envoy.NewWatcher(tlsCertsToWatch, agent.ConfigCh())
 
+			// If an admin port was provided, serve the agent pprof, metrics and health summary on localhost.
+			if agentAdminPort > 0 {
+				parsedPorts, err := parseApplicationPorts()
+				if err != nil {
+					return err
+				}
+				adminServer, err := status.NewAdminServer(status.AdminConfig{
+					LocalHostAddr:    localHostAddr,
+					AdminServerPort:  agentAdminPort,
+					ProxyAdminPort:   proxyAdminPort,
+					ApplicationPorts: parsedPorts,
+					NodeType:         role.Type,
+					Agent:            agent,
+				})
+				if err != nil {
+					return err
+				}
+				go waitForCompletion(ctx, adminServer.Run)
+			}
+
+			// If a DNS proxy address was provided, answer the DNS queries for the hosts of the mesh.
+			if dnsProxyAddress != "" {
+				dnsServer, err := newDNSServer(pilotSAN)
+				if err != nil {
+					return err
+				}
+				go waitForCompletion(ctx, dnsServer.Run)
+			}
+
 			go waitForCompletion(ctx, agent.Run)
 			go waitForCompletion(ctx, watcher.Run)
 
@@ -403,6 +447,93 @@ var (
 	}
 )
 
+// newDNSServer creates the DNS proxy, forwarding to the nameservers of the pod. The name table of
+// the proxy is served on the secure port of Pilot, by default at the discovery address, to the
+// clients presenting the certificate of the proxy.
+func newDNSServer(pilotSAN []string) (*dns.Server, error) {
+	if controlPlaneAuthPolicy != meshconfig.AuthenticationPolicy_MUTUAL_TLS.String() {
+		return nil, fmt.Errorf("the DNS proxy requires the %v control plane auth policy",
+			meshconfig.AuthenticationPolicy_MUTUAL_TLS)
+	}
+	upstreams, err := dns.UpstreamResolvers("/etc/resolv.conf")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the upstream resolvers: %v", err)
+	}
+	nameTableURL := dnsNameTableURL
+	if nameTableURL == "" {
+		nameTableURL = "https://" + discoveryAddress + dns.NameTablePath
+	}
+	nameTableURL += "?proxyID=" + url.QueryEscape(role.ID)
+	return dns.NewServer(dns.Config{
+		Address:         dnsProxyAddress,
+		NameTableURL:    nameTableURL,
+		TLSConfig:       pilotTLSConfig(config.AuthCertsPath, pilotSAN),
+		RefreshInterval: dnsNameTableRefreshInterval,
+		Upstreams:       upstreams,
+	}), nil
+}
+
+// pilotTLSConfig returns the TLS config of the clients of Pilot, presenting the certificate of
+// the proxy and verifying the identity of Pilot. The certificates are read at each handshake, so
+// that their rotation is picked up.
+func pilotTLSConfig(certDir string, pilotSAN []string) *tls.Config {
+	return &tls.Config{
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(path.Join(certDir, config.CertChainFilename),
+				path.Join(certDir, config.KeyFilename))
+			return &cert, err
+		},
+		// The certificate of Pilot only has a SPIFFE identity, which is verified below instead of
+		// the hostname.
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return verifyPilotCertificate(path.Join(certDir, config.RootCertFilename), pilotSAN, rawCerts)
+		},
+	}
+}
+
+// verifyPilotCertificate verifies the chain of the certificate of Pilot with the root certificate,
+// and its identity if pilotSAN is not empty.
+func verifyPilotCertificate(rootCert string, pilotSAN []string, rawCerts [][]byte) error {
+	rootPEM, err := ioutil.ReadFile(rootCert)
+	if err != nil {
+		return err
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(rootPEM) {
+		return fmt.Errorf("no root certificate in %s", rootCert)
+	}
+	if len(rawCerts) == 0 {
+		return errors.New("no certificate presented by Pilot")
+	}
+	certs := make([]*x509.Certificate, 0, len(rawCerts))
+	for _, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return err
+		}
+		certs = append(certs, cert)
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := certs[0].Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates}); err != nil {
+		return err
+	}
+	if len(pilotSAN) == 0 {
+		return nil
+	}
+	for _, uri := range certs[0].URIs {
+		for _, san := range pilotSAN {
+			if uri.String() == san {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("the certificate of Pilot has none of the identities %v", pilotSAN)
+}
+
 func dedupeStrings(in []string) []string {
 	stringMap := map[string]bool{}
 	for _, c := range in {
@@ -557,6 +688,9 @@ func init() {
 
 	proxyCmd.PersistentFlags().Uint16Var(&statusPort, "statusPort", 0,
 		"HTTP Port on which to serve pilot agent status. If zero, agent status will not be provided.")
+	proxyCmd.PersistentFlags().Uint16Var(&agentAdminPort, "agentAdminPort", 0,
+		"HTTP Port on localhost on which to serve the pilot agent pprof endpoints, metrics and health summary. "+
+			"If zero, the admin endpoints will not be provided.")
 	proxyCmd.PersistentFlags().StringSliceVar(&applicationPorts, "applicationPorts", []string{},
 		"Ports exposed by the application. Used to determine that Envoy is configured and ready to receive traffic.")
 
@@ -576,6 +710,9 @@ func init() {
 		"The time in seconds that Envoy will wait before shutting down the parent process during a hot restart")
 	proxyCmd.PersistentFlags().StringVar(&discoveryAddress, "discoveryAddress", values.DiscoveryAddress,
 		"Address of the discovery service exposing xDS (e.g. istio-pilot:8080)")
+	proxyCmd.PersistentFlags().StringSliceVar(&discoveryFailoverAddresses, "discoveryFailoverAddresses", []string{},
+		"Ordered addresses of discovery services that Envoy fails over to when the discovery service at "+
+			"discoveryAddress, then at the previous failover addresses, fails its health checks")
 	proxyCmd.PersistentFlags().StringVar(&zipkinAddress, "zipkinAddress", "",
 		"Address of the Zipkin service (e.g. zipkin:9411)")
 	proxyCmd.PersistentFlags().StringVar(&lightstepAddress, "lightstepAddress", "",
@@ -620,6 +757,14 @@ func init() {
 		"Disable internal telemetry")
 	proxyCmd.PersistentFlags().BoolVar(&controlPlaneBootstrap, "controlPlaneBootstrap", true,
 		"Process bootstrap provided via templateFile to be used by control plane components.")
+	proxyCmd.PersistentFlags().StringVar(&dnsProxyAddress, "dnsProxyAddress", "",
+		"UDP address of the DNS proxy answering the queries for the hosts of the mesh, e.g. 127.0.0.1:15053. "+
+			"Disabled if empty")
+	proxyCmd.PersistentFlags().StringVar(&dnsNameTableURL, "dnsNameTableURL", "",
+		"URL of the name table of the DNS proxy on the secure port of Pilot, defaults to the discovery address. "+
+			"Requires the MUTUAL_TLS control plane auth policy")
+	proxyCmd.PersistentFlags().DurationVar(&dnsNameTableRefreshInterval, "dnsNameTableRefreshInterval", 30*time.Second,
+		"Interval at which the DNS proxy fetches the name table")
 
 	// Attach the Istio logging options to the command.
 	loggingOptions.AttachCobraFlags(rootCmd)
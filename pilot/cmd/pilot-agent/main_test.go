@@ -15,7 +15,11 @@
 package main
 
 import (
+	"crypto"
+	"crypto/x509"
+	"io/ioutil"
 	"os"
+	"path"
 	"testing"
 	"time"
 
@@ -25,6 +29,7 @@ import (
 	"istio.io/istio/pilot/pkg/proxy/envoy"
 	"istio.io/istio/pilot/pkg/serviceregistry"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/security/pkg/pki/util"
 )
 
 func TestNoPilotSanIfAuthenticationNone(t *testing.T) {
@@ -358,3 +363,60 @@ func TestIsIPv6Proxy(t *testing.T) {
 		}
 	}
 }
+
+func TestVerifyPilotCertificate(t *testing.T) {
+	const pilotIdentity = "spiffe://cluster.local/ns/istio-system/sa/istio-pilot-service-account"
+	newCA := func() (*x509.Certificate, crypto.PrivateKey, []byte) {
+		certPEM, keyPEM, err := util.GenCertKeyFromOptions(util.CertOptions{
+			Org: "cluster.local", TTL: time.Hour, RSAKeySize: 2048, IsCA: true, IsSelfSigned: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, _ := util.ParsePemEncodedCertificate(certPEM)
+		key, _ := util.ParsePemEncodedKey(keyPEM)
+		return cert, key, certPEM
+	}
+	caCert, caKey, caPEM := newCA()
+	_, _, otherPEM := newCA()
+	pilotPEM, _, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Host: pilotIdentity, TTL: time.Hour, RSAKeySize: 2048, IsServer: true, SignerCert: caCert, SignerPriv: caKey,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pilotCert, _ := util.ParsePemEncodedCertificate(pilotPEM)
+
+	dir, err := ioutil.TempDir("", "pilot-agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) // nolint: errcheck
+	root, other := path.Join(dir, "root-cert.pem"), path.Join(dir, "other-root-cert.pem")
+	if err := ioutil.WriteFile(root, caPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(other, otherPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		rootCert string
+		pilotSAN []string
+		wantErr  bool
+	}{
+		{name: "identity of Pilot", rootCert: root, pilotSAN: []string{pilotIdentity}},
+		{name: "no expected identity", rootCert: root},
+		{name: "other identity", rootCert: root, pilotSAN: []string{"spiffe://cluster.local/ns/default/sa/default"}, wantErr: true},
+		{name: "other root", rootCert: other, pilotSAN: []string{pilotIdentity}, wantErr: true},
+		{name: "missing root", rootCert: path.Join(dir, "missing.pem"), wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := verifyPilotCertificate(tc.rootCert, tc.pilotSAN, [][]byte{pilotCert.Raw})
+			if (err != nil) != tc.wantErr {
+				t.Errorf("verifyPilotCertificate() => got error %v, want error %t", err, tc.wantErr)
+			}
+		})
+	}
+}
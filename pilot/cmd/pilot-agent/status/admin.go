@@ -0,0 +1,151 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+
+	ocprom "contrib.go.opencensus.io/exporter/prometheus"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opencensus.io/stats/view"
+
+	"istio.io/istio/pilot/cmd/pilot-agent/status/ready"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/proxy"
+	"istio.io/pkg/log"
+)
+
+const (
+	// healthPath serves a JSON summary of the agent and Envoy health.
+	healthPath = "/healthz"
+	// metricsPath serves the agent metrics in the Prometheus format.
+	metricsPath = "/metrics"
+)
+
+// AdminConfig for the agent admin server.
+type AdminConfig struct {
+	LocalHostAddr    string
+	AdminServerPort  uint16
+	ProxyAdminPort   uint16
+	ApplicationPorts []uint16
+	NodeType         model.NodeType
+	Agent            proxy.Agent
+}
+
+// AdminServer serves the agent pprof endpoints, the agent metrics and a health summary on
+// localhost. Unlike the status port, the admin port is not exposed outside of the pod.
+type AdminServer struct {
+	localHostAddr string
+	port          uint16
+	agent         proxy.Agent
+	ready         *ready.Probe
+	mux           *http.ServeMux
+}
+
+// Health is the health summary served by the admin server.
+type Health struct {
+	// EnvoyReady is true if Envoy passes the readiness probe.
+	EnvoyReady bool `json:"envoyReady"`
+	// EnvoyError is the reason Envoy is not ready, if any.
+	EnvoyError string `json:"envoyError,omitempty"`
+	// Agent is the state of the proxy epochs managed by the agent.
+	Agent proxy.Status `json:"agent"`
+}
+
+// NewAdminServer creates a new admin server.
+func NewAdminServer(config AdminConfig) (*AdminServer, error) {
+	s := &AdminServer{
+		localHostAddr: config.LocalHostAddr,
+		port:          config.AdminServerPort,
+		agent:         config.Agent,
+		ready: &ready.Probe{
+			LocalHostAddr:    config.LocalHostAddr,
+			AdminPort:        config.ProxyAdminPort,
+			ApplicationPorts: config.ApplicationPorts,
+			NodeType:         config.NodeType,
+		},
+		mux: http.NewServeMux(),
+	}
+
+	registry, ok := prometheus.DefaultRegisterer.(*prometheus.Registry)
+	if !ok {
+		// the default registerer was replaced, only the agent metrics are exported
+		registry = prometheus.NewRegistry()
+	}
+	exporter, err := ocprom.NewExporter(ocprom.Options{Registry: registry})
+	if err != nil {
+		return nil, fmt.Errorf("could not set up prometheus exporter: %v", err)
+	}
+	view.RegisterExporter(exporter)
+	s.mux.Handle(metricsPath, exporter)
+
+	s.mux.HandleFunc(healthPath, s.handleHealth)
+	s.mux.HandleFunc("/debug/pprof/", pprof.Index)
+	s.mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	s.mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	s.mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	s.mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return s, nil
+}
+
+// Run opens the admin port on localhost and serves requests until the context is done.
+func (s *AdminServer) Run(ctx context.Context) {
+	addr := net.JoinHostPort(strings.Trim(s.localHostAddr, "[]"), fmt.Sprint(s.port))
+	log.Infof("Opening agent admin port %s", addr)
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Errorf("Error listening on agent admin port: %v", err)
+		return
+	}
+	server := &http.Server{Handler: s.mux}
+	go func() {
+		if err := server.Serve(l); err != nil && err != http.ErrServerClosed {
+			log.Errorf("Agent admin server terminated with error: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	_ = server.Close()
+	log.Info("Agent admin server has successfully terminated")
+}
+
+func (s *AdminServer) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	health := Health{EnvoyReady: true}
+	if err := s.ready.Check(); err != nil {
+		health.EnvoyReady = false
+		health.EnvoyError = err.Error()
+	}
+	if s.agent != nil {
+		health.Agent = s.agent.Status()
+	}
+
+	b, err := json.MarshalIndent(health, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if !health.EnvoyReady {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_, _ = w.Write(b)
+}
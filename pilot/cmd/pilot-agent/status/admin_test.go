@@ -0,0 +1,81 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"istio.io/istio/pilot/pkg/proxy"
+)
+
+type fakeAgent struct {
+	status proxy.Status
+}
+
+func (a *fakeAgent) ConfigCh() chan<- interface{} { return nil }
+func (a *fakeAgent) Run(context.Context)          {}
+func (a *fakeAgent) Status() proxy.Status         { return a.status }
+
+func TestAdminServer(t *testing.T) {
+	agent := &fakeAgent{status: proxy.Status{ActiveEpochs: []int{2}, LatestEpoch: 2, RetryBudget: 10}}
+	// Nothing listens on the Envoy admin port, so Envoy is not ready.
+	s, err := NewAdminServer(AdminConfig{LocalHostAddr: "127.0.0.1", ProxyAdminPort: 1, Agent: agent})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.mux.ServeHTTP(rec, httptest.NewRequest("GET", healthPath, nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("%s => got status %d, want %d", healthPath, rec.Code, http.StatusServiceUnavailable)
+	}
+	var health Health
+	if err := json.Unmarshal(rec.Body.Bytes(), &health); err != nil {
+		t.Fatal(err)
+	}
+	if health.EnvoyReady || health.EnvoyError == "" || health.Agent.LatestEpoch != 2 {
+		t.Errorf("%s => got %+v, want Envoy not ready and the agent status", healthPath, health)
+	}
+
+	for _, path := range []string{metricsPath, "/debug/pprof/"} {
+		rec := httptest.NewRecorder()
+		s.mux.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s => got status %d, want %d", path, rec.Code, http.StatusOK)
+		}
+	}
+}
+
+func TestAdminServerWrappedRegisterer(t *testing.T) {
+	defaultRegisterer := prometheus.DefaultRegisterer
+	prometheus.DefaultRegisterer = prometheus.WrapRegistererWithPrefix("test_", prometheus.NewRegistry())
+	defer func() { prometheus.DefaultRegisterer = defaultRegisterer }()
+
+	s, err := NewAdminServer(AdminConfig{LocalHostAddr: "127.0.0.1", ProxyAdminPort: 1, Agent: &fakeAgent{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	s.mux.ServeHTTP(rec, httptest.NewRequest("GET", metricsPath, nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("%s => got status %d, want %d", metricsPath, rec.Code, http.StatusOK)
+	}
+}
@@ -45,7 +45,7 @@ const (
 	quitPath = "/quitquitquit"
 	// KubeAppProberEnvName is the name of the command line flag for pilot agent to pass app prober config.
 	// The json encoded string to pass app HTTP probe information from injector(istioctl or webhook).
-	// For example, ISTIO_KUBE_APP_PROBERS='{"/app-health/httpbin/livez":{"path": "/hello", "port": 8080}.
+	// For example, ISTIO_KUBE_APP_PROBERS='{"/app-health/httpbin/livez":{"httpGet":{"path": "/hello", "port": 8080}}}'.
 	// indicates that httpbin container liveness prober port is 8080 and probing path is /hello.
 	// This environment variable should never be set manually.
 	KubeAppProberEnvName = "ISTIO_KUBE_APP_PROBERS"
@@ -55,11 +55,21 @@ var (
 	appProberPattern = regexp.MustCompile(`^/app-health/[^/]+/(livez|readyz)$`)
 )
 
+// defaultProbeTimeout is the timeout of an app probe without timeoutSeconds, same as the kubelet default.
+const defaultProbeTimeout = time.Second
+
 // KubeAppProbers holds the information about a Kubernetes pod prober.
 // It's a map from the prober URL path to the Kubernetes Prober config.
 // For example, "/app-health/hello-world/livez" entry contains livenss prober config for
 // container "hello-world".
-type KubeAppProbers map[string]*corev1.HTTPGetAction
+type KubeAppProbers map[string]*Prober
+
+// Prober is the application prober the agent takes over. Exactly one of HTTPGet and TCPSocket is set.
+type Prober struct {
+	HTTPGet        *corev1.HTTPGetAction   `json:"httpGet,omitempty"`
+	TCPSocket      *corev1.TCPSocketAction `json:"tcpSocket,omitempty"`
+	TimeoutSeconds int32                   `json:"timeoutSeconds,omitempty"`
+}
 
 // Config for the status server.
 type Config struct {
@@ -103,13 +113,33 @@ func NewServer(config Config) (*Server, error) {
 		if !appProberPattern.Match([]byte(path)) {
 			return nil, fmt.Errorf(`invalid key, must be in form of regex pattern ^/app-health/[^\/]+/(livez|readyz)$`)
 		}
-		if prober.Port.Type != intstr.Int {
-			return nil, fmt.Errorf("invalid prober config for %v, the port must be int type", path)
+		if err := validateProber(prober); err != nil {
+			return nil, fmt.Errorf("invalid prober config for %v, %v", path, err)
 		}
 	}
 	return s, nil
 }
 
+func validateProber(prober *Prober) error {
+	var port intstr.IntOrString
+	switch {
+	case prober == nil:
+		return fmt.Errorf("the prober must not be empty")
+	case prober.HTTPGet != nil && prober.TCPSocket != nil:
+		return fmt.Errorf("only one of httpGet and tcpSocket can be set")
+	case prober.HTTPGet != nil:
+		port = prober.HTTPGet.Port
+	case prober.TCPSocket != nil:
+		port = prober.TCPSocket.Port
+	default:
+		return fmt.Errorf("one of httpGet and tcpSocket must be set")
+	}
+	if port.Type != intstr.Int {
+		return fmt.Errorf("the port must be int type")
+	}
+	return nil
+}
+
 // FormatProberURL returns a pair of HTTP URLs that pilot agent will serve to take over Kubernetes
 // app probers.
 func FormatProberURL(container string) (string, string) {
@@ -216,10 +246,33 @@ func (s *Server) handleAppProbe(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	timeout := defaultProbeTimeout
+	if prober.TimeoutSeconds > 0 {
+		timeout = time.Duration(prober.TimeoutSeconds) * time.Second
+	}
+	if prober.TCPSocket != nil {
+		s.handleAppProbeTCPSocket(w, prober.TCPSocket, timeout)
+		return
+	}
+	s.handleAppProbeHTTPGet(w, req, path, prober.HTTPGet, timeout)
+}
+
+func (s *Server) handleAppProbeTCPSocket(w http.ResponseWriter, prober *corev1.TCPSocketAction, timeout time.Duration) {
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("localhost:%v", prober.Port.IntValue()), timeout)
+	if err != nil {
+		log.Errorf("Connection to probe app port %v failed: %v", prober.Port.IntValue(), err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	_ = conn.Close()
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleAppProbeHTTPGet(w http.ResponseWriter, req *http.Request, path string,
+	prober *corev1.HTTPGetAction, timeout time.Duration) {
 	// Construct a request sent to the application.
 	httpClient := &http.Client{
-		// TODO: figure out the appropriate timeout?
-		Timeout: 10 * time.Second,
+		Timeout: timeout,
 		// We skip the verification since kubelet skips the verification for HTTPS prober as well
 		// https://kubernetes.io/docs/tasks/configure-pod-container/configure-liveness-readiness-probes/#configure-probes
 		Transport: &http.Transport{
@@ -238,6 +291,8 @@ func (s *Server) handleAppProbe(w http.ResponseWriter, req *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+	// Stop probing the application when kubelet gives up on the probe.
+	appReq = appReq.WithContext(req.Context())
 
 	// Forward incoming headers to the application.
 	for name, values := range req.Header {
@@ -246,6 +301,16 @@ func (s *Server) handleAppProbe(w http.ResponseWriter, req *http.Request) {
 		appReq.Header[name] = newValues
 	}
 
+	// Set the custom headers of the prober. Kubelet sends them to the agent as well, except for
+	// the Host header which is not part of the forwarded headers.
+	for _, h := range prober.HTTPHeaders {
+		if strings.EqualFold(h.Name, "Host") {
+			appReq.Host = h.Value
+			continue
+		}
+		appReq.Header.Set(h.Name, h.Value)
+	}
+
 	// Send the request.
 	response, err := httpClient.Do(appReq)
 	if err != nil {
@@ -52,23 +52,34 @@ func TestNewServer(t *testing.T) {
 		},
 		// map key is not well formed.
 		{
-			httpProbe: `{"abc": {"path": "/app-foo/health"}}`,
+			httpProbe: `{"abc": {"httpGet": {"path": "/app-foo/health"}}}`,
 			err:       "invalid key",
 		},
 		// Port is not Int typed.
 		{
-			httpProbe: `{"/app-health/hello-world/readyz": {"path": "/hello/sunnyvale", "port": "container-port-dontknow"}}`,
+			httpProbe: `{"/app-health/hello-world/readyz": {"httpGet": {"path": "/hello/sunnyvale", "port": "container-port-dontknow"}}}`,
 			err:       "must be int type",
 		},
+		// Neither httpGet nor tcpSocket is set.
+		{
+			httpProbe: `{"/app-health/hello-world/readyz": {"timeoutSeconds": 1}}`,
+			err:       "must be set",
+		},
+		// Both httpGet and tcpSocket are set.
+		{
+			httpProbe: `{"/app-health/hello-world/readyz": {"httpGet": {"port": 8080}, "tcpSocket": {"port": 8080}}}`,
+			err:       "only one of",
+		},
 		// A valid input.
 		{
-			httpProbe: `{"/app-health/hello-world/readyz": {"path": "/hello/sunnyvale", "port": 8080},` +
-				`"/app-health/business/livez": {"path": "/buisiness/live", "port": 9090}}`,
+			httpProbe: `{"/app-health/hello-world/readyz": {"httpGet": {"path": "/hello/sunnyvale", "port": 8080}},` +
+				`"/app-health/business/livez": {"httpGet": {"path": "/buisiness/live", "port": 9090}, "timeoutSeconds": 5},` +
+				`"/app-health/database/livez": {"tcpSocket": {"port": 5432}}}`,
 		},
 		// A valid input with empty probing path, which happens when HTTPGetAction.Path is not specified.
 		{
-			httpProbe: `{"/app-health/hello-world/readyz": {"path": "/hello/sunnyvale", "port": 8080},
-"/app-health/business/livez": {"port": 9090}}`,
+			httpProbe: `{"/app-health/hello-world/readyz": {"httpGet": {"path": "/hello/sunnyvale", "port": 8080}},
+"/app-health/business/livez": {"httpGet": {"port": 9090}}}`,
 		},
 		// A valid input without any prober info.
 		{
@@ -108,8 +119,8 @@ func TestAppProbe(t *testing.T) {
 	// Starts the pilot agent status server.
 	server, err := NewServer(Config{
 		StatusPort: 0,
-		KubeAppHTTPProbers: fmt.Sprintf(`{"/app-health/hello-world/readyz": {"path": "/hello/sunnyvale", "port": %v},
-"/app-health/hello-world/livez": {"port": %v}}`, appPort, appPort),
+		KubeAppHTTPProbers: fmt.Sprintf(`{"/app-health/hello-world/readyz": {"httpGet": {"path": "/hello/sunnyvale", "port": %v}},
+"/app-health/hello-world/livez": {"httpGet": {"port": %v}}}`, appPort, appPort),
 	})
 	if err != nil {
 		t.Errorf("failed to create status server %v", err)
@@ -172,8 +183,8 @@ func TestHttpsAppProbe(t *testing.T) {
 	// Starts the pilot agent status server.
 	server, err := NewServer(Config{
 		StatusPort: 0,
-		KubeAppHTTPProbers: fmt.Sprintf(`{"/app-health/hello-world/readyz": {"path": "/hello/sunnyvale", "port": %v, "scheme": "HTTPS"},
-"/app-health/hello-world/livez": {"port": %v, "scheme": "HTTPS"}}`, appPort, appPort),
+		KubeAppHTTPProbers: fmt.Sprintf(`{"/app-health/hello-world/readyz": {"httpGet": {"path": "/hello/sunnyvale", "port": %v, "scheme": "HTTPS"}},
+"/app-health/hello-world/livez": {"httpGet": {"port": %v, "scheme": "HTTPS"}}}`, appPort, appPort),
 	})
 	if err != nil {
 		t.Errorf("failed to create status server %v", err)
@@ -294,3 +305,73 @@ func TestHandleQuit(t *testing.T) {
 		})
 	}
 }
+
+func TestAppProbeCustomHeaders(t *testing.T) {
+	var gotHost, gotHeader string
+	app := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHost, gotHeader = r.Host, r.Header.Get("X-Custom")
+	}))
+	defer app.Close()
+	appPort := app.Listener.Addr().(*net.TCPAddr).Port
+
+	server, err := NewServer(Config{
+		KubeAppHTTPProbers: fmt.Sprintf(`{"/app-health/hello-world/readyz": {"httpGet": {"path": "/", "port": %v,
+"httpHeaders": [{"name": "Host", "value": "hello.example.com"}, {"name": "X-Custom", "value": "probe"}]}}}`, appPort),
+	})
+	if err != nil {
+		t.Fatalf("failed to create status server %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	server.handleAppProbe(rec, httptest.NewRequest("GET", "/app-health/hello-world/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("probe => got status %d, want %d", rec.Code, http.StatusOK)
+	}
+	if gotHost != "hello.example.com" || gotHeader != "probe" {
+		t.Errorf("probe => app got host %q and header %q, want the prober custom headers", gotHost, gotHeader)
+	}
+}
+
+func TestAppProbeTCPSocket(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("failed to allocate unused port %v", err)
+	}
+	appPort := listener.Addr().(*net.TCPAddr).Port
+	closed, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("failed to allocate unused port %v", err)
+	}
+	closedPort := closed.Addr().(*net.TCPAddr).Port
+	_ = closed.Close()
+	defer listener.Close()
+
+	server, err := NewServer(Config{
+		KubeAppHTTPProbers: fmt.Sprintf(`{"/app-health/hello-world/readyz": {"tcpSocket": {"port": %v}},
+"/app-health/hello-world/livez": {"tcpSocket": {"port": %v}, "timeoutSeconds": 2}}`, appPort, closedPort),
+	})
+	if err != nil {
+		t.Fatalf("failed to create status server %v", err)
+	}
+
+	testCases := []struct {
+		probePath  string
+		statusCode int
+	}{
+		{
+			probePath:  "/app-health/hello-world/readyz",
+			statusCode: http.StatusOK,
+		},
+		{
+			probePath:  "/app-health/hello-world/livez",
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		rec := httptest.NewRecorder()
+		server.handleAppProbe(rec, httptest.NewRequest("GET", tc.probePath, nil))
+		if rec.Code != tc.statusCode {
+			t.Errorf("[%v] unexpected status code, want = %v, got = %v", tc.probePath, tc.statusCode, rec.Code)
+		}
+	}
+}
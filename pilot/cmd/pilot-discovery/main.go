@@ -26,8 +26,12 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"istio.io/istio/pilot/pkg/bootstrap"
+	"istio.io/istio/pilot/pkg/config/eastwest"
+	"istio.io/istio/pilot/pkg/federation"
 	"istio.io/istio/pilot/pkg/serviceregistry"
+	"istio.io/istio/pilot/pkg/serviceregistry/kube"
 	"istio.io/istio/pkg/cmd"
+	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/keepalive"
 	"istio.io/pkg/collateral"
 	"istio.io/pkg/ctrlz"
@@ -95,14 +99,16 @@ func hasKubeRegistry() bool {
 func init() {
 	discoveryCmd.PersistentFlags().StringSliceVar(&serverArgs.Service.Registries, "registries",
 		[]string{string(serviceregistry.KubernetesRegistry)},
-		fmt.Sprintf("Comma separated list of platform service registries to read from (choose one or more from {%s, %s, %s, %s})",
-			serviceregistry.KubernetesRegistry, serviceregistry.ConsulRegistry, serviceregistry.MCPRegistry, serviceregistry.MockRegistry))
+		fmt.Sprintf("Comma separated list of platform service registries to read from (choose one or more from {%s, %s, %s, %s, %s, %s})",
+			serviceregistry.KubernetesRegistry, serviceregistry.ConsulRegistry, serviceregistry.MCPRegistry,
+			serviceregistry.FileRegistry, serviceregistry.ExternalRegistry, serviceregistry.MockRegistry))
 	discoveryCmd.PersistentFlags().StringVar(&serverArgs.Config.ClusterRegistriesNamespace, "clusterRegistriesNamespace", metav1.NamespaceAll,
 		"Namespace for ConfigMap which stores clusters configs")
 	discoveryCmd.PersistentFlags().StringVar(&serverArgs.Config.KubeConfig, "kubeconfig", "",
 		"Use a Kubernetes configuration file instead of in-cluster configuration")
 	discoveryCmd.PersistentFlags().StringVar(&serverArgs.Mesh.ConfigFile, "meshConfig", "/etc/istio/config/mesh",
-		fmt.Sprintf("File name for Istio mesh configuration. If not specified, a default mesh will be used."))
+		"File name for Istio mesh configuration. If not specified, a default mesh will be used. "+
+			"The mesh configuration of a non-default revision is merged from <meshConfig>-<revision>, if it exists")
 	discoveryCmd.PersistentFlags().StringVar(&serverArgs.NetworksConfigFile, "networksConfig", "/etc/istio/config/meshNetworks",
 		fmt.Sprintf("File name for Istio mesh networks configuration. If not specified, a default mesh networks will be used."))
 	discoveryCmd.PersistentFlags().StringVarP(&serverArgs.Namespace, "namespace", "n", "",
@@ -133,10 +139,59 @@ func init() {
 		"DNS domain suffix")
 	discoveryCmd.PersistentFlags().StringVar(&serverArgs.Config.ControllerOptions.TrustDomain, "trust-domain", "",
 		"The domain serves to identify the system with spiffe")
+	discoveryCmd.PersistentFlags().StringVar(&serverArgs.Config.ControllerOptions.Revision, "revision", config.DefaultRevision,
+		"Control plane revision. Only config resources labeled with istio.io/rev set to this revision are processed, "+
+			"as well as unlabeled resources if this is the default revision")
 	discoveryCmd.PersistentFlags().StringVar(&serverArgs.Service.Consul.ServerURL, "consulserverURL", "",
 		"URL for the Consul server")
 	discoveryCmd.PersistentFlags().DurationVar(&serverArgs.Service.Consul.Interval, "consulserverInterval", 2*time.Second,
 		"Interval (in seconds) for polling the Consul service registry")
+	discoveryCmd.PersistentFlags().StringVar(&serverArgs.Service.FileDir, "serviceDefinitionsDir", "",
+		"Directory of the YAML or JSON service definitions loaded by the "+string(serviceregistry.FileRegistry)+
+			" registry, reloaded when the files change")
+	discoveryCmd.PersistentFlags().StringVar(&serverArgs.Service.External.Address, "externalRegistryAddress", "",
+		"gRPC address of the MCP server serving the ServiceEntries of the "+string(serviceregistry.ExternalRegistry)+
+			" registry")
+	discoveryCmd.PersistentFlags().StringVar(&serverArgs.Service.External.TLSMode, "externalRegistryTLSMode", "ISTIO_MUTUAL",
+		"TLS mode of the connection to the MCP server of the "+string(serviceregistry.ExternalRegistry)+
+			" registry, as in the TLS settings of the config sources: DISABLE, SIMPLE, MUTUAL or ISTIO_MUTUAL")
+	discoveryCmd.PersistentFlags().StringVar(&serverArgs.Service.External.CACertificates, "externalRegistryCACertificates", "",
+		"File holding the root certificates verifying the MCP server of the "+string(serviceregistry.ExternalRegistry)+
+			" registry in MUTUAL mode")
+	discoveryCmd.PersistentFlags().StringVar(&serverArgs.Service.External.ClientCertificate, "externalRegistryClientCertificate", "",
+		"File holding the client certificate presented to the MCP server of the "+string(serviceregistry.ExternalRegistry)+
+			" registry in MUTUAL mode")
+	discoveryCmd.PersistentFlags().StringVar(&serverArgs.Service.External.PrivateKey, "externalRegistryPrivateKey", "",
+		"File holding the private key of the client certificate in MUTUAL mode")
+	discoveryCmd.PersistentFlags().StringVar(&serverArgs.Service.External.Sni, "externalRegistrySni", "",
+		"Name the certificate of the MCP server of the "+string(serviceregistry.ExternalRegistry)+
+			" registry is verified against, the host of its address if not set")
+
+	// Federation options
+	discoveryCmd.PersistentFlags().StringSliceVar(&serverArgs.Federation.ExportHosts, "federationExportHosts", nil,
+		"Hostnames, possibly wildcards, of the services exported to the peer meshes")
+	discoveryCmd.PersistentFlags().StringSliceVar(&serverArgs.Federation.Gateways, "federationGateways", nil,
+		"Addresses (host:port) of the gateways by which the peer meshes reach the exported services")
+	discoveryCmd.PersistentFlags().StringVar(&serverArgs.Federation.Addr, "federationAddr", ":15016",
+		"Address of the federation endpoint serving the exported services to the peer meshes, and the SPIFFE bundle")
+	discoveryCmd.PersistentFlags().StringSliceVar(&serverArgs.Federation.Peers, "federationPeers", nil,
+		"URLs of the federation endpoints of the peer meshes, e.g. https://istio-pilot.peer.example.com:15016")
+	discoveryCmd.PersistentFlags().StringVar(&serverArgs.Federation.PeerCAFile, "federationPeerCAFile", "",
+		"File holding the root certificates of the peer meshes")
+	discoveryCmd.PersistentFlags().DurationVar(&serverArgs.Federation.SyncInterval, "federationSyncInterval", 30*time.Second,
+		"Interval for polling the peer meshes")
+	discoveryCmd.PersistentFlags().BoolVar(&serverArgs.Federation.ServeTrustBundle, "federationServeTrustBundle", false,
+		"Serve the root certificate of the mesh as a SPIFFE bundle on "+federation.BundlePath+" of the federation endpoint")
+	discoveryCmd.PersistentFlags().StringToStringVar(&serverArgs.Federation.TrustDomains, "federationTrustDomains", nil,
+		"SPIFFE bundle endpoints of the federated trust domains, e.g. example.org=https://example.org/spiffe/bundle. "+
+			"The bundles are added to the trust bundle of the proxies by Citadel")
+
+	// East-west gateway options
+	discoveryCmd.PersistentFlags().StringToStringVar(&serverArgs.EastWestGateway.Selector, "eastWestGatewaySelector", nil,
+		"Labels of the east-west gateway workloads, e.g. istio=ingressgateway. If set, the services annotated with "+
+			kube.ExposeToNetworksAnnotation+"=true are exposed to the other networks through the gateway")
+	discoveryCmd.PersistentFlags().Uint32Var(&serverArgs.EastWestGateway.Port, "eastWestGatewayPort", eastwest.DefaultPort,
+		"Port of the east-west gateway receiving the traffic of the other networks")
 
 	// using address, so it can be configured as localhost:.. (possibly UDS in future)
 	discoveryCmd.PersistentFlags().StringVar(&serverArgs.DiscoveryOptions.HTTPAddr, "httpAddr", ":8080",
@@ -33,7 +33,10 @@ import (
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	"k8s.io/client-go/tools/cache"
 
+	crdcontroller "istio.io/istio/pilot/pkg/config/kube/crd/controller"
 	"istio.io/istio/pilot/pkg/kube/inject"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/serviceregistry/kube/controller"
 	"istio.io/istio/pkg/cmd"
 	"istio.io/istio/pkg/kube"
 	"istio.io/istio/pkg/util"
@@ -60,6 +63,7 @@ var (
 		kubeconfigFile      string
 		webhookConfigName   string
 		webhookName         string
+		enableProxyConfig   bool
 	}{
 		loggingOptions: log.DefaultOptions(),
 	}
@@ -87,12 +91,20 @@ var (
 				HealthCheckInterval: flags.healthCheckInterval,
 				HealthCheckFile:     flags.healthCheckFile,
 			}
+			stop := make(chan struct{})
+			if flags.enableProxyConfig {
+				proxyConfigs, err := proxyConfigController(stop)
+				if err != nil {
+					return multierror.Prefix(err, "failed to watch proxy configs")
+				}
+				parameters.ProxyConfigs = proxyConfigs
+			}
+
 			wh, err := inject.NewWebhook(parameters)
 			if err != nil {
 				return multierror.Prefix(err, "failed to create injection webhook")
 			}
 
-			stop := make(chan struct{})
 			if err := patchCertLoop(stop); err != nil {
 				return multierror.Prefix(err, "failed to start patch cert loop")
 			}
@@ -120,6 +132,21 @@ var (
 	}
 )
 
+// proxyConfigController starts a cache of the ProxyConfig resources in the cluster, and waits for
+// its initial sync so that the pods are not injected without their ProxyConfig.
+func proxyConfigController(stopCh <-chan struct{}) (model.ConfigStore, error) {
+	client, err := crdcontroller.NewClient(flags.kubeconfigFile, "", model.ConfigDescriptor{model.ProxyConfig}, "")
+	if err != nil {
+		return nil, err
+	}
+	store := crdcontroller.NewController(client, controller.Options{})
+	go store.Run(stopCh)
+	if !cache.WaitForCacheSync(stopCh, store.HasSynced) {
+		return nil, errors.New("failed to sync the proxy configs cache")
+	}
+	return store, nil
+}
+
 func patchCertLoop(stopCh <-chan struct{}) error {
 	client, err := kube.CreateClientset(flags.kubeconfigFile, "")
 	if err != nil {
@@ -225,6 +252,8 @@ func init() {
 		"Name of the mutatingwebhookconfiguration resource in Kubernetes.")
 	rootCmd.PersistentFlags().StringVar(&flags.webhookName, "webhookName", "sidecar-injector.istio.io",
 		"Name of the webhook entry in the webhook config.")
+	rootCmd.PersistentFlags().BoolVar(&flags.enableProxyConfig, "enableProxyConfig", false,
+		"Apply ProxyConfig resources selecting the workload when injecting the proxy.")
 	// Attach the Istio logging options to the command.
 	flags.loggingOptions.AttachCobraFlags(rootCmd)
 
@@ -0,0 +1,131 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"istio.io/pkg/log"
+)
+
+// servingCerts holds the serving certificate of pilot and the root certificates which verify the
+// client certificates of a listener, e.g. those of the peer meshes on the federation endpoint.
+// The certificates are loaded again when their files change, so that rotated certificates are
+// used by new connections without restarting pilot.
+type servingCerts struct {
+	certFile, keyFile string
+	caFiles           []string
+
+	mu       sync.Mutex
+	modTimes []time.Time
+	cert     *tls.Certificate
+	caPool   *x509.CertPool
+}
+
+// newServingCerts loads the certificates, and fails if they are not ready.
+func newServingCerts(certFile, keyFile string, caFiles ...string) (*servingCerts, error) {
+	s := &servingCerts{certFile: certFile, keyFile: keyFile, caFiles: caFiles}
+	modTimes, err := s.fileModTimes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.load(modTimes); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// current returns the certificates, loading them again if their files changed. If the new
+// certificates cannot be loaded, e.g. because they are being written, the previous ones are
+// returned and the load is retried on the next call.
+func (s *servingCerts) current() (*tls.Certificate, *x509.CertPool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	modTimes, err := s.fileModTimes()
+	if err == nil && s.changed(modTimes) {
+		if err = s.load(modTimes); err == nil {
+			log.Infof("Reloaded the pilot serving certificates from %s", s.certFile)
+		}
+	}
+	if err != nil {
+		log.Warnf("Failed to reload the pilot serving certificates, using the previous ones: %v", err)
+	}
+	return s.cert, s.caPool
+}
+
+// tlsConfig returns a copy of the base config which uses the current certificates.
+func (s *servingCerts) tlsConfig(base *tls.Config) *tls.Config {
+	config := base.Clone()
+	config.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		cert, _ := s.current()
+		return cert, nil
+	}
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		cert, caPool := s.current()
+		c := base.Clone()
+		c.Certificates = []tls.Certificate{*cert}
+		c.ClientCAs = caPool
+		return c, nil
+	}
+	return config
+}
+
+func (s *servingCerts) fileModTimes() ([]time.Time, error) {
+	modTimes := make([]time.Time, 0, 2+len(s.caFiles))
+	for _, file := range append([]string{s.certFile, s.keyFile}, s.caFiles...) {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		modTimes = append(modTimes, info.ModTime())
+	}
+	return modTimes, nil
+}
+
+func (s *servingCerts) changed(modTimes []time.Time) bool {
+	for i, t := range modTimes {
+		if !t.Equal(s.modTimes[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *servingCerts) load(modTimes []time.Time) error {
+	cert, err := tls.LoadX509KeyPair(s.certFile, s.keyFile)
+	if err != nil {
+		return err
+	}
+	caPool := x509.NewCertPool()
+	for _, caFile := range s.caFiles {
+		caCert, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return err
+		}
+		if !caPool.AppendCertsFromPEM(caCert) {
+			return fmt.Errorf("no certificate found in %s", caFile)
+		}
+	}
+
+	s.cert, s.caPool, s.modTimes = &cert, caPool, modTimes
+	return nil
+}
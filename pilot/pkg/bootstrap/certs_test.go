@@ -0,0 +1,121 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+// writeCerts writes a self-signed certificate with the serial number, used as serving and root
+// certificate.
+func writeCerts(t *testing.T, dir string, serial int64, modTime time.Time) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	files := map[string][]byte{
+		"cert-chain.pem": certPEM,
+		"root-cert.pem":  certPEM,
+		"key.pem":        pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}),
+	}
+	for name, data := range files {
+		file := path.Join(dir, name)
+		if err := ioutil.WriteFile(file, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(file, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func serial(t *testing.T, cert *tls.Certificate) int64 {
+	t.Helper()
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed.SerialNumber.Int64()
+}
+
+func TestServingCertsReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pilot-certs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := newServingCerts(path.Join(dir, "cert-chain.pem"), path.Join(dir, "key.pem"), path.Join(dir, "root-cert.pem")); err == nil {
+		t.Fatal("newServingCerts() without certificates => got no error")
+	}
+
+	now := time.Now()
+	writeCerts(t, dir, 1, now.Add(-time.Minute))
+	certs, err := newServingCerts(path.Join(dir, "cert-chain.pem"), path.Join(dir, "key.pem"), path.Join(dir, "root-cert.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert, _ := certs.current(); serial(t, cert) != 1 {
+		t.Errorf("current() => got serial %d, want 1", serial(t, cert))
+	}
+
+	// Rotated certificates are reloaded.
+	writeCerts(t, dir, 2, now)
+	config, err := certs.tlsConfig(&tls.Config{ClientAuth: tls.RequireAndVerifyClientCert}).GetConfigForClient(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := serial(t, &config.Certificates[0]); got != 2 {
+		t.Errorf("GetConfigForClient() => got serial %d, want 2", got)
+	}
+	if config.ClientAuth != tls.RequireAndVerifyClientCert || config.ClientCAs == nil {
+		t.Errorf("GetConfigForClient() => got %v, want client certificates verified by the root certificate", config)
+	}
+
+	// Invalid certificates keep the previous ones in use.
+	if err := ioutil.WriteFile(path.Join(dir, "key.pem"), []byte("invalid"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if cert, _ := certs.current(); serial(t, cert) != 2 {
+		t.Errorf("current() with an invalid key => got serial %d, want the previous serial 2", serial(t, cert))
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"time"
+
+	configaggregate "istio.io/istio/pilot/pkg/config/aggregate"
+	"istio.io/istio/pilot/pkg/config/eastwest"
+	"istio.io/istio/pilot/pkg/config/memory"
+	configmonitor "istio.io/istio/pilot/pkg/config/monitor"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/pkg/log"
+)
+
+// eastWestGatewayCheckInterval is the interval between two regenerations of the east-west
+// Gateway, in addition to the regenerations triggered by service changes.
+const eastWestGatewayCheckInterval = time.Minute
+
+// initEastWestGateway adds the Gateway exposing the services marked with the
+// networking.istio.io/exposeToNetworks annotation on the east-west gateway to the config
+// controller. The Gateway is regenerated when services change.
+func (s *Server) initEastWestGateway(args *PilotArgs) error {
+	if len(args.EastWestGateway.Selector) == 0 {
+		return nil
+	}
+
+	store := memory.NewController(memory.Make(model.ConfigDescriptor{model.Gateway}))
+	exposer := eastwest.NewExposer(args.Namespace, args.EastWestGateway.Selector, args.EastWestGateway.Port)
+	// The service controllers are created after the config controller, and are only used once
+	// the server starts.
+	monitor := configmonitor.NewMonitor("eastwest-gateway", store, eastWestGatewayCheckInterval,
+		func() ([]*model.Config, error) {
+			return exposer.Configs(s.ServiceController)
+		})
+	s.addStartFunc(func(stop <-chan struct{}) error {
+		if err := s.ServiceController.AppendServiceHandler(func(*model.Service, model.Event) {
+			monitor.ScheduleCheck()
+		}); err != nil {
+			return err
+		}
+		monitor.Start(stop)
+		return nil
+	})
+	log.Infof("Exposing services on the east-west gateway %v", args.EastWestGateway.Selector)
+
+	configController, err := configaggregate.MakeCache([]model.ConfigStoreCache{
+		s.configController,
+		store,
+	})
+	if err != nil {
+		return err
+	}
+	s.configController = configController
+	return nil
+}
@@ -0,0 +1,288 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"path"
+	"time"
+
+	networking "istio.io/api/networking/v1alpha3"
+
+	configaggregate "istio.io/istio/pilot/pkg/config/aggregate"
+	"istio.io/istio/pilot/pkg/config/memory"
+	configmonitor "istio.io/istio/pilot/pkg/config/monitor"
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/federation"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/spiffe"
+	"istio.io/istio/security/pkg/k8s/configmap"
+	"istio.io/pkg/log"
+)
+
+// defaultFederationSyncInterval is the interval between two fetches of the peer snapshots, if
+// not set.
+const defaultFederationSyncInterval = 30 * time.Second
+
+// pilotCertDir returns the directory of the certificates of pilot.
+func pilotCertDir() string {
+	if features.CertDir != "" {
+		return features.CertDir
+	}
+	return PilotCertDir
+}
+
+// initFederationExport serves the federation port over TLS. It serves the exporter of the services
+// selected for the peer meshes, whose clients must present a certificate issued by the root
+// certificates of the peer meshes: the workloads of the peers are not trusted by the secure port
+// serving xDS and the debug endpoints. The root certificate of pilot is exported as the trust
+// bundle. It also serves the SPIFFE bundle endpoint, which is public: its clients, e.g. SPIFFE
+// implementations of other trust domains, only authenticate pilot.
+func (s *Server) initFederationExport(args *PilotArgs) error {
+	if len(args.Federation.ExportHosts) == 0 && !args.Federation.ServeTrustBundle {
+		return nil
+	}
+	certDir := pilotCertDir()
+	mux := http.NewServeMux()
+	var caFiles []string
+	if len(args.Federation.ExportHosts) > 0 {
+		if args.Federation.PeerCAFile == "" {
+			return fmt.Errorf("exporting services requires the root certificates of the peer meshes")
+		}
+		gateways, err := federation.ParseGateways(args.Federation.Gateways)
+		if err != nil {
+			return err
+		}
+		if len(gateways) == 0 {
+			return fmt.Errorf("exporting services requires the addresses of the gateways of the mesh")
+		}
+		exporter := federation.NewExporter(s.ServiceController, args.Federation.ExportHosts, gateways,
+			path.Join(certDir, config.RootCertFilename))
+		mux.Handle(federation.Path, requireClientCert(exporter))
+		caFiles = append(caFiles, args.Federation.PeerCAFile)
+		log.Infof("Exporting services %v to the peer meshes through %v", args.Federation.ExportHosts, args.Federation.Gateways)
+	}
+	if args.Federation.ServeTrustBundle {
+		interval := args.Federation.SyncInterval
+		if interval <= 0 {
+			interval = defaultFederationSyncInterval
+		}
+		mux.Handle(federation.BundlePath, federation.NewBundleEndpoint(
+			path.Join(certDir, config.RootCertFilename), interval))
+		log.Infof("Serving the trust bundle of trust domain %s on %s", spiffe.GetTrustDomain(), federation.BundlePath)
+	}
+
+	s.addStartFunc(func(stop <-chan struct{}) error {
+		certs, err := newServingCerts(path.Join(certDir, config.CertChainFilename), path.Join(certDir, config.KeyFilename),
+			caFiles...)
+		if err != nil {
+			return fmt.Errorf("federation endpoint certificates: %v", err)
+		}
+		listener, err := net.Listen("tcp", args.Federation.Addr)
+		if err != nil {
+			return err
+		}
+		server := &http.Server{
+			// The client certificates are verified if given, and required by the exporter only.
+			TLSConfig: certs.tlsConfig(&tls.Config{ClientAuth: tls.VerifyClientCertIfGiven}),
+			Handler:   mux,
+		}
+		go func() {
+			if err := server.ServeTLS(listener, "", ""); err != nil && err != http.ErrServerClosed {
+				log.Errorf("Federation endpoint stopped: %v", err)
+			}
+		}()
+		go func() {
+			<-stop
+			_ = server.Close()
+		}()
+		log.Infof("Serving the federation endpoint at %s", listener.Addr())
+		return nil
+	})
+	return nil
+}
+
+// requireClientCert rejects the requests whose client did not present a certificate verified by
+// the TLS config of the server.
+func requireClientCert(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 {
+			http.Error(w, "a client certificate issued by a peer mesh is required", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, req)
+	})
+}
+
+// initFederationImport adds the ServiceEntries of the services exported by the peer meshes to the
+// config controller. The snapshot of each peer is polled by a config monitor. The trust bundles
+// of the peers are written to the ConfigMap from which Citadel adds them to the trust bundle of
+// the proxies, constrained to the trust domain of each peer.
+func (s *Server) initFederationImport(args *PilotArgs) error {
+	if len(args.Federation.Peers) == 0 {
+		return nil
+	}
+	if args.Federation.PeerCAFile == "" {
+		return fmt.Errorf("importing services requires the root certificates of the peer meshes")
+	}
+
+	interval := args.Federation.SyncInterval
+	if interval <= 0 {
+		interval = defaultFederationSyncInterval
+	}
+	store := memory.NewController(memory.Make(model.ConfigDescriptor{model.ServiceEntry, model.DestinationRule}))
+	isLocal := s.localHostname(s.configController)
+	var writer federation.TrustBundleWriter
+	if s.kubeClient != nil {
+		writer = configmap.NewController(args.Namespace, s.kubeClient.CoreV1())
+	} else {
+		log.Warn("No Kubernetes client: the trust bundles of the peer meshes are not distributed to the proxies")
+	}
+	for _, peer := range args.Federation.Peers {
+		importer, err := federation.NewImporter(peer, args.Namespace, writer,
+			federationClientTLSConfig(args.Federation.PeerCAFile), isLocal)
+		if err != nil {
+			return err
+		}
+		monitor := configmonitor.NewMonitor("federation-"+importer.Peer(), store, interval, importer.Configs)
+		s.addStartFunc(func(stop <-chan struct{}) error {
+			monitor.Start(stop)
+			return nil
+		})
+		log.Infof("Importing services from peer mesh %s", peer)
+	}
+
+	configController, err := configaggregate.MakeCache([]model.ConfigStoreCache{
+		s.configController,
+		store,
+	})
+	if err != nil {
+		return err
+	}
+	s.configController = configController
+	return nil
+}
+
+// localHostname returns a function checking whether a hostname is defined by the local service
+// registries or by the ServiceEntries of the local config store, which must not be shadowed by
+// the services imported from the peer meshes.
+func (s *Server) localHostname(localConfig model.ConfigStore) func(hostname string) bool {
+	return func(hostname string) bool {
+		if s.ServiceController != nil {
+			for _, r := range s.ServiceController.GetRegistries() {
+				// The ServiceEntries registry also holds the imported services.
+				if r.Name == serviceEntriesRegistry {
+					continue
+				}
+				if svc, err := r.GetService(config.Hostname(hostname)); err == nil && svc != nil {
+					return true
+				}
+			}
+		}
+		entries, err := localConfig.List(model.ServiceEntry.Type, "")
+		if err != nil {
+			return false
+		}
+		for _, entry := range entries {
+			for _, host := range entry.Spec.(*networking.ServiceEntry).Hosts {
+				if host == hostname {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+// federationClientTLSConfig returns a function loading the TLS config which authenticates pilot
+// to the peer meshes with its certificate, and verifies them with their root certificates.
+func federationClientTLSConfig(peerCAFile string) func() (*tls.Config, error) {
+	return func() (*tls.Config, error) {
+		certDir := pilotCertDir()
+		cert, err := tls.LoadX509KeyPair(path.Join(certDir, config.CertChainFilename), path.Join(certDir, config.KeyFilename))
+		if err != nil {
+			return nil, err
+		}
+		caCert, err := ioutil.ReadFile(peerCAFile)
+		if err != nil {
+			return nil, err
+		}
+		caPool := x509.NewCertPool()
+		if !caPool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no certificate found in %s", peerCAFile)
+		}
+		return &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			RootCAs:      caPool,
+		}, nil
+	}
+}
+
+// initSpiffeFederation fetches the bundles of the federated trust domains, and writes them to the
+// ConfigMap from which Citadel adds them to the trust bundle of the proxies.
+func (s *Server) initSpiffeFederation(args *PilotArgs) error {
+	if len(args.Federation.TrustDomains) == 0 {
+		return nil
+	}
+	if s.kubeClient == nil {
+		return fmt.Errorf("federating trust domains requires a Kubernetes client")
+	}
+	interval := args.Federation.SyncInterval
+	if interval <= 0 {
+		interval = defaultFederationSyncInterval
+	}
+	tlsConfig, err := bundleEndpointTLSConfig(args.Federation.PeerCAFile)
+	if err != nil {
+		return err
+	}
+	writer := configmap.NewController(args.Namespace, s.kubeClient.CoreV1())
+	for trustDomain, endpoint := range args.Federation.TrustDomains {
+		fetcher, err := federation.NewBundleFetcher(trustDomain, endpoint, writer, tlsConfig)
+		if err != nil {
+			return err
+		}
+		s.addStartFunc(func(stop <-chan struct{}) error {
+			go fetcher.Run(interval, stop)
+			return nil
+		})
+		log.Infof("Federating trust domain %s with bundle endpoint %s", trustDomain, endpoint)
+	}
+	return nil
+}
+
+// bundleEndpointTLSConfig returns the TLS config verifying the bundle endpoints with the system
+// root certificates, and with the root certificates of the peer meshes if set.
+func bundleEndpointTLSConfig(peerCAFile string) (*tls.Config, error) {
+	caPool, err := x509.SystemCertPool()
+	if err != nil {
+		caPool = x509.NewCertPool()
+	}
+	if peerCAFile != "" {
+		caCert, err := ioutil.ReadFile(peerCAFile)
+		if err != nil {
+			return nil, err
+		}
+		if !caPool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no certificate found in %s", peerCAFile)
+		}
+	}
+	return &tls.Config{RootCAs: caPool}, nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"google.golang.org/grpc"
+
+	istio_networking_v1alpha3 "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/mcp/creds"
+	"istio.io/pkg/log"
+)
+
+var errInvalidTLSMode = errors.New("invalid tls setting mode")
+
+// tlsSettings returns the TLS settings of the connection to the MCP server of the external registry.
+func (a ExternalRegistryArgs) tlsSettings() (*istio_networking_v1alpha3.TLSSettings, error) {
+	mode, ok := istio_networking_v1alpha3.TLSSettings_TLSmode_value[a.TLSMode]
+	if !ok {
+		return nil, fmt.Errorf("invalid TLS mode %q of the external registry", a.TLSMode)
+	}
+	return &istio_networking_v1alpha3.TLSSettings{
+		Mode:              istio_networking_v1alpha3.TLSSettings_TLSmode(mode),
+		CaCertificates:    a.CACertificates,
+		ClientCertificate: a.ClientCertificate,
+		PrivateKey:        a.PrivateKey,
+		Sni:               a.Sni,
+	}, nil
+}
+
+// mcpSecurityOption returns the dial option securing the connection to an MCP server with the TLS
+// settings. The certificates of the MUTUAL and ISTIO_MUTUAL modes are waited for until they exist
+// or the context is done, and reloaded when they change.
+func mcpSecurityOption(ctx context.Context, tlsSettings *istio_networking_v1alpha3.TLSSettings) (grpc.DialOption, error) {
+	if tlsSettings == nil || tlsSettings.Mode == istio_networking_v1alpha3.TLSSettings_DISABLE {
+		return grpc.WithInsecure(), nil
+	}
+
+	var credentialOption *creds.Options
+	switch tlsSettings.Mode {
+	case istio_networking_v1alpha3.TLSSettings_SIMPLE:
+	case istio_networking_v1alpha3.TLSSettings_MUTUAL:
+		credentialOption = &creds.Options{
+			CertificateFile:   tlsSettings.ClientCertificate,
+			KeyFile:           tlsSettings.PrivateKey,
+			CACertificateFile: tlsSettings.CaCertificates,
+		}
+	case istio_networking_v1alpha3.TLSSettings_ISTIO_MUTUAL:
+		credentialOption = &creds.Options{
+			CertificateFile:   path.Join(config.AuthCertsPath, config.CertChainFilename),
+			KeyFile:           path.Join(config.AuthCertsPath, config.KeyFilename),
+			CACertificateFile: path.Join(config.AuthCertsPath, config.RootCertFilename),
+		}
+	default:
+		return nil, errInvalidTLSMode
+	}
+
+	if credentialOption == nil {
+		transportCreds := creds.CreateForClientSkipVerify()
+		return grpc.WithTransportCredentials(transportCreds), nil
+	}
+
+	requiredFiles := []string{credentialOption.CACertificateFile, credentialOption.KeyFile, credentialOption.CertificateFile}
+	log.Infof("Secure MCP configured. Waiting for required certificate files to become available: %v",
+		requiredFiles)
+	for len(requiredFiles) > 0 {
+		if _, err := os.Stat(requiredFiles[0]); os.IsNotExist(err) {
+			log.Infof("%v not found. Checking again in %v", requiredFiles[0], requiredMCPCertCheckFreq)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(requiredMCPCertCheckFreq):
+				// retry
+			}
+			continue
+		}
+		log.Infof("%v found", requiredFiles[0])
+		requiredFiles = requiredFiles[1:]
+	}
+
+	watcher, err := creds.WatchFiles(ctx.Done(), credentialOption)
+	if err != nil {
+		return nil, err
+	}
+	transportCreds := creds.CreateForClient(tlsSettings.Sni, watcher)
+	return grpc.WithTransportCredentials(transportCreds), nil
+}
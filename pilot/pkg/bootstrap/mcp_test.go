@@ -0,0 +1,66 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"context"
+	"testing"
+
+	istio_networking_v1alpha3 "istio.io/api/networking/v1alpha3"
+)
+
+func TestExternalRegistryTLSSettings(t *testing.T) {
+	args := ExternalRegistryArgs{TLSMode: "MUTUAL", CACertificates: "ca.pem", ClientCertificate: "cert.pem", PrivateKey: "key.pem"}
+	settings, err := args.tlsSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.Mode != istio_networking_v1alpha3.TLSSettings_MUTUAL || settings.CaCertificates != "ca.pem" ||
+		settings.ClientCertificate != "cert.pem" || settings.PrivateKey != "key.pem" {
+		t.Errorf("tlsSettings() => got %v, want the MUTUAL mode with the files", settings)
+	}
+
+	if _, err := (ExternalRegistryArgs{TLSMode: "INSECURE"}).tlsSettings(); err == nil {
+		t.Errorf("tlsSettings() of an invalid mode => got no error")
+	}
+}
+
+func TestMCPSecurityOption(t *testing.T) {
+	for _, mode := range []istio_networking_v1alpha3.TLSSettings_TLSmode{
+		istio_networking_v1alpha3.TLSSettings_DISABLE,
+		istio_networking_v1alpha3.TLSSettings_SIMPLE,
+	} {
+		if option, err := mcpSecurityOption(context.Background(), &istio_networking_v1alpha3.TLSSettings{Mode: mode}); err != nil || option == nil {
+			t.Errorf("mcpSecurityOption(%v) => got %v, %v", mode, option, err)
+		}
+	}
+
+	if _, err := mcpSecurityOption(context.Background(), &istio_networking_v1alpha3.TLSSettings{Mode: 42}); err != errInvalidTLSMode {
+		t.Errorf("mcpSecurityOption() of an invalid mode => got %v, want %v", err, errInvalidTLSMode)
+	}
+
+	// the certificates are waited for until the context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	settings := &istio_networking_v1alpha3.TLSSettings{
+		Mode:              istio_networking_v1alpha3.TLSSettings_MUTUAL,
+		CaCertificates:    "testdata/missing/ca.pem",
+		ClientCertificate: "testdata/missing/cert.pem",
+		PrivateKey:        "testdata/missing/key.pem",
+	}
+	if _, err := mcpSecurityOption(ctx, settings); err != context.Canceled {
+		t.Errorf("mcpSecurityOption() of missing certificates => got %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+
+	"github.com/davecgh/go-spew/spew"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+
+	"istio.io/istio/pilot/cmd"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/spiffe"
+	"istio.io/pkg/filewatcher"
+	"istio.io/pkg/log"
+)
+
+// meshOverlayFile returns the file holding the mesh config of the revision of the control plane,
+// which is merged on top of the mesh config file. The default revision has no overlay.
+func meshOverlayFile(args *PilotArgs) string {
+	revision := args.Config.ControllerOptions.Revision
+	if args.Mesh.ConfigFile == "" || revision == "" || revision == config.DefaultRevision {
+		return ""
+	}
+	return args.Mesh.ConfigFile + "-" + revision
+}
+
+// readMeshConfig reads the mesh config file, and merges the overlay of the revision if it exists.
+func readMeshConfig(args *PilotArgs) (*meshconfig.MeshConfig, error) {
+	mesh, err := cmd.ReadMeshConfig(args.Mesh.ConfigFile)
+	if err != nil {
+		return nil, err
+	}
+	overlay := meshOverlayFile(args)
+	if overlay == "" {
+		return mesh, nil
+	}
+	yaml, err := ioutil.ReadFile(overlay)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return mesh, nil
+		}
+		return nil, err
+	}
+	return config.ApplyMeshConfig(string(yaml), *mesh)
+}
+
+// addMeshHandler registers a handler called after the mesh config has changed.
+func (s *Server) addMeshHandler(h func()) {
+	s.meshHandlers = append(s.meshHandlers, h)
+}
+
+// reloadMeshConfig re-reads the mesh config files, and notifies the mesh handlers if the merged
+// mesh config has changed.
+func (s *Server) reloadMeshConfig(args *PilotArgs) {
+	mesh, err := readMeshConfig(args)
+	if err != nil {
+		log.Warnf("failed to read mesh configuration, keeping the current one: %v", err)
+		return
+	}
+	if reflect.DeepEqual(mesh, s.mesh) {
+		return
+	}
+	log.Infof("mesh configuration updated to: %s", spew.Sdump(mesh))
+	if !reflect.DeepEqual(mesh.ConfigSources, s.mesh.ConfigSources) {
+		log.Infof("mesh configuration sources have changed")
+		//TODO Need to re-create or reload initConfigController()
+	}
+	s.mesh = mesh
+	for _, h := range s.meshHandlers {
+		h()
+	}
+}
+
+// watchMeshConfig reloads the mesh config when the mesh config file or the overlay of the
+// revision changes, including when the overlay is created after startup.
+func (s *Server) watchMeshConfig(args *PilotArgs) {
+	s.addFileWatcher(args.Mesh.ConfigFile, func() { s.reloadMeshConfig(args) })
+	if overlay := meshOverlayFile(args); overlay != "" {
+		log.Infof("merging the mesh configuration of revision %s from %s",
+			args.Config.ControllerOptions.Revision, overlay)
+		// A file watcher only accepts a missing file if it is the first one watched in its
+		// directory, so the overlay, next to the mesh config file, has a watcher of its own.
+		watchFile(filewatcher.NewWatcher(), overlay, func() { s.reloadMeshConfig(args) })
+	}
+}
+
+// meshTrustDomainHandler uses the trust domain of the mesh config, unless it is set on the
+// command line.
+func (s *Server) meshTrustDomainHandler(args *PilotArgs) func() {
+	return func() {
+		if args.Config.ControllerOptions.TrustDomain != "" || s.mesh.TrustDomain == "" {
+			return
+		}
+		if s.mesh.TrustDomain != spiffe.GetTrustDomain() {
+			log.Infof("using trust domain %s of the mesh configuration", s.mesh.TrustDomain)
+			spiffe.SetTrustDomain(s.mesh.TrustDomain)
+		}
+	}
+}
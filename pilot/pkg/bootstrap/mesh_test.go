@@ -0,0 +1,101 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/config"
+	"istio.io/pkg/filewatcher"
+)
+
+func TestReadMeshConfigOverlay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mesh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	meshFile := path.Join(dir, "mesh")
+	if err := ioutil.WriteFile(meshFile, []byte("accessLogFile: /dev/stdout\ntrustDomain: example.com\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(meshFile+"-canary", []byte("trustDomain: canary.example.com\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	args := &PilotArgs{}
+	args.Mesh.ConfigFile = meshFile
+	for _, tc := range []struct {
+		revision    string
+		trustDomain string
+	}{
+		{config.DefaultRevision, "example.com"},
+		{"canary", "canary.example.com"},
+		{"stable", "example.com"},
+	} {
+		args.Config.ControllerOptions.Revision = tc.revision
+		mesh, err := readMeshConfig(args)
+		if err != nil {
+			t.Fatalf("readMeshConfig() for revision %s failed: %v", tc.revision, err)
+		}
+		if mesh.TrustDomain != tc.trustDomain || mesh.AccessLogFile != "/dev/stdout" {
+			t.Errorf("readMeshConfig() for revision %s => got trust domain %q and access log %q, want %q and /dev/stdout",
+				tc.revision, mesh.TrustDomain, mesh.AccessLogFile, tc.trustDomain)
+		}
+	}
+}
+
+func TestWatchMeshConfigOverlayCreated(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mesh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	meshFile := path.Join(dir, "mesh")
+	if err := ioutil.WriteFile(meshFile, []byte("trustDomain: example.com\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	args := &PilotArgs{}
+	args.Mesh.ConfigFile = meshFile
+	args.Config.ControllerOptions.Revision = "canary"
+
+	mesh, err := readMeshConfig(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{mesh: mesh, fileWatcher: filewatcher.NewWatcher()}
+	reloaded := make(chan string, 1)
+	s.addMeshHandler(func() { reloaded <- s.mesh.TrustDomain })
+	s.watchMeshConfig(args)
+
+	// The overlay missing at startup is merged once created.
+	if err := ioutil.WriteFile(meshFile+"-canary", []byte("trustDomain: canary.example.com\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case trustDomain := <-reloaded:
+		if trustDomain != "canary.example.com" {
+			t.Errorf("mesh config reloaded with trust domain %q, want canary.example.com", trustDomain)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("mesh config not reloaded after the overlay was created")
+	}
+}
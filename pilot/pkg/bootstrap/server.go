@@ -19,11 +19,9 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
-	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
-	"os"
 	"path"
 	"reflect"
 	"strings"
@@ -43,7 +41,6 @@ import (
 
 	mcpapi "istio.io/api/mcp/v1alpha1"
 	meshconfig "istio.io/api/mesh/v1alpha1"
-	istio_networking_v1alpha3 "istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pilot/cmd"
 	configaggregate "istio.io/istio/pilot/pkg/config/aggregate"
 	"istio.io/istio/pilot/pkg/config/clusterregistry"
@@ -54,9 +51,11 @@ import (
 	configmonitor "istio.io/istio/pilot/pkg/config/monitor"
 	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
+	pilotmonitoring "istio.io/istio/pilot/pkg/monitoring"
 	istio_networking "istio.io/istio/pilot/pkg/networking/core"
 	"istio.io/istio/pilot/pkg/networking/plugin"
 	"istio.io/istio/pilot/pkg/networking/util"
+	"istio.io/istio/pilot/pkg/proxy/dns"
 	"istio.io/istio/pilot/pkg/proxy/envoy"
 	envoyv2 "istio.io/istio/pilot/pkg/proxy/envoy/v2"
 	authn_model "istio.io/istio/pilot/pkg/security/model"
@@ -64,13 +63,14 @@ import (
 	"istio.io/istio/pilot/pkg/serviceregistry/aggregate"
 	"istio.io/istio/pilot/pkg/serviceregistry/consul"
 	"istio.io/istio/pilot/pkg/serviceregistry/external"
+	"istio.io/istio/pilot/pkg/serviceregistry/file"
 	controller2 "istio.io/istio/pilot/pkg/serviceregistry/kube/controller"
 	srmemory "istio.io/istio/pilot/pkg/serviceregistry/memory"
+	"istio.io/istio/pilot/pkg/status"
 	"istio.io/istio/pkg/config"
 	istiokeepalive "istio.io/istio/pkg/keepalive"
 	kubelib "istio.io/istio/pkg/kube"
 	configz "istio.io/istio/pkg/mcp/configz/client"
-	"istio.io/istio/pkg/mcp/creds"
 	"istio.io/istio/pkg/mcp/monitoring"
 	"istio.io/istio/pkg/mcp/sink"
 	"istio.io/pkg/ctrlz"
@@ -104,6 +104,12 @@ const (
 	// URL types supported by the config store
 	// example fs:///tmp/configroot
 	fsScheme = "fs"
+	// example k8s:// or k8s:///etc/kubeconfig, using the given kubeconfig file if a path is set
+	k8sScheme = "k8s"
+
+	// Kinds of sources whose initial sync gates readiness
+	registrySourceKind = "registry"
+	configSourceKind   = "config"
 )
 
 var (
@@ -167,10 +173,58 @@ type ConsulArgs struct {
 	Interval  time.Duration
 }
 
+// ExternalRegistryArgs provides configuration for the external service registry.
+type ExternalRegistryArgs struct {
+	// Address is the gRPC address of the MCP server of the external registry.
+	Address string
+	// TLSMode is the TLS mode of the connection to the MCP server, as in the TLS settings of the
+	// config sources: DISABLE, SIMPLE, MUTUAL or ISTIO_MUTUAL.
+	TLSMode string
+	// CACertificates, ClientCertificate and PrivateKey are the files of the MUTUAL mode.
+	CACertificates    string
+	ClientCertificate string
+	PrivateKey        string
+	// Sni is the name the certificate of the MCP server is verified against, if set.
+	Sni string
+}
+
 // ServiceArgs provides the composite configuration for all service registries in the system.
 type ServiceArgs struct {
 	Registries []string
 	Consul     ConsulArgs
+	// FileDir is the directory of the service definitions of the file registry.
+	FileDir  string
+	External ExternalRegistryArgs
+}
+
+// FederationArgs configures the exchange of services and trust with peer meshes.
+type FederationArgs struct {
+	// ExportHosts are the hostnames, possibly wildcards, of the services exported to the peers.
+	ExportHosts []string
+	// Gateways are the host:port addresses by which the peers reach the exported services.
+	Gateways []string
+	// Addr is the address of the federation endpoint. The exported services are served over mTLS
+	// to the peer meshes only, and the SPIFFE bundle over TLS.
+	Addr string
+	// Peers are the URLs of the federation endpoints of the peer meshes.
+	Peers []string
+	// PeerCAFile holds the root certificates of the peer meshes, which verify their pilots.
+	PeerCAFile string
+	// SyncInterval is the interval between two fetches of the peer snapshots.
+	SyncInterval time.Duration
+	// ServeTrustBundle serves the root certificate of the mesh as a SPIFFE bundle.
+	ServeTrustBundle bool
+	// TrustDomains are the URLs of the SPIFFE bundle endpoints of the federated trust domains,
+	// keyed by trust domain. Their bundles are added to the trust bundle of the proxies by Citadel.
+	TrustDomains map[string]string
+}
+
+// EastWestGatewayArgs configures the exposure of services to the other networks of the mesh.
+type EastWestGatewayArgs struct {
+	// Selector selects the east-west gateway workloads. Services are exposed only if it is set.
+	Selector map[string]string
+	// Port is the port of the east-west gateway receiving the traffic of the other networks.
+	Port uint32
 }
 
 // PilotArgs provides all of the configuration parameters for the Pilot discovery service.
@@ -180,6 +234,8 @@ type PilotArgs struct {
 	Mesh                     MeshArgs
 	Config                   ConfigArgs
 	Service                  ServiceArgs
+	Federation               FederationArgs
+	EastWestGateway          EastWestGatewayArgs
 	MeshConfig               *meshconfig.MeshConfig
 	NetworksConfigFile       string
 	CtrlZOptions             *ctrlz.Options
@@ -205,7 +261,14 @@ type Server struct {
 
 	mesh             *meshconfig.MeshConfig
 	meshNetworks     *meshconfig.MeshNetworks
+	meshHandlers     []func()
 	configController model.ConfigStoreCache
+	// statusWriter writes the status of Kubernetes config resources, if any, and statusStore
+	// holds those resources, as the configs of the other sources have no status.
+	statusWriter status.Writer
+	statusStore  model.ConfigStore
+	// syncSources are the registries and config stores whose initial sync gates readiness.
+	syncSources []envoyv2.SyncSource
 
 	kubeClient       kubernetes.Interface
 	startFuncs       []startFunc
@@ -243,7 +306,8 @@ func NewServer(args PilotArgs) (*Server, error) {
 		fileWatcher: filewatcher.NewWatcher(),
 	}
 
-	prometheus.EnableHandlingTimeHistogram()
+	prometheus.EnableHandlingTimeHistogram(prometheus.WithHistogramBuckets(
+		pilotmonitoring.Buckets("grpc_server_handling_seconds", prom.DefBuckets)))
 
 	// Apply the arguments to the configuration.
 	if err := s.initKubeClient(&args); err != nil {
@@ -261,9 +325,15 @@ func NewServer(args PilotArgs) (*Server, error) {
 	if err := s.initServiceControllers(&args); err != nil {
 		return nil, fmt.Errorf("service controllers: %v", err)
 	}
+	if err := s.initFederationExport(&args); err != nil {
+		return nil, fmt.Errorf("federation: %v", err)
+	}
 	if err := s.initDiscoveryService(&args); err != nil {
 		return nil, fmt.Errorf("discovery service: %v", err)
 	}
+	if err := s.initSpiffeFederation(&args); err != nil {
+		return nil, fmt.Errorf("spiffe federation: %v", err)
+	}
 	if err := s.initMonitor(&args); err != nil {
 		return nil, fmt.Errorf("monitor: %v", err)
 	}
@@ -380,32 +450,13 @@ func (s *Server) initMesh(args *PilotArgs) error {
 	var err error
 
 	if args.Mesh.ConfigFile != "" {
-		mesh, err = cmd.ReadMeshConfig(args.Mesh.ConfigFile)
+		mesh, err = readMeshConfig(args)
 		if err != nil {
 			log.Warnf("failed to read mesh configuration, using default: %v", err)
 		}
 
-		// Watch the config file for changes and reload if it got modified
-		s.addFileWatcher(args.Mesh.ConfigFile, func() {
-			// Reload the config file
-			mesh, err = cmd.ReadMeshConfig(args.Mesh.ConfigFile)
-			if err != nil {
-				log.Warnf("failed to read mesh configuration, using default: %v", err)
-				return
-			}
-			if !reflect.DeepEqual(mesh, s.mesh) {
-				log.Infof("mesh configuration updated to: %s", spew.Sdump(mesh))
-				if !reflect.DeepEqual(mesh.ConfigSources, s.mesh.ConfigSources) {
-					log.Infof("mesh configuration sources have changed")
-					//TODO Need to re-create or reload initConfigController()
-				}
-				s.mesh = mesh
-				if s.EnvoyXdsServer != nil {
-					s.EnvoyXdsServer.Env.Mesh = mesh
-					s.EnvoyXdsServer.ConfigUpdate(true)
-				}
-			}
-		})
+		// Watch the config files for changes and reload if they got modified
+		s.watchMeshConfig(args)
 	}
 
 	if mesh == nil {
@@ -428,6 +479,9 @@ func (s *Server) initMesh(args *PilotArgs) error {
 	log.Infof("flags %s", spew.Sdump(args))
 
 	s.mesh = mesh
+	trustDomainHandler := s.meshTrustDomainHandler(args)
+	trustDomainHandler()
+	s.addMeshHandler(trustDomainHandler)
 	return nil
 }
 
@@ -499,18 +553,6 @@ func (s *Server) initKubeClient(args *PilotArgs) error {
 	return nil
 }
 
-type mockController struct{}
-
-func (c *mockController) AppendServiceHandler(f func(*model.Service, model.Event)) error {
-	return nil
-}
-
-func (c *mockController) AppendInstanceHandler(f func(*model.ServiceInstance, model.Event)) error {
-	return nil
-}
-
-func (c *mockController) Run(<-chan struct{}) {}
-
 func (s *Server) initMCPConfigController(args *PilotArgs) error {
 	clientNodeID := ""
 	collections := make([]sink.CollectionOptions, len(model.IstioConfigTypes))
@@ -532,7 +574,28 @@ func (s *Server) initMCPConfigController(args *PilotArgs) error {
 
 	reporter := monitoring.NewStatsContext("pilot/mcp/sink")
 
+	// Config sources are listed in decreasing order of precedence, the aggregate cache
+	// uses the config of the first source defining it and reports the conflict.
 	for _, configSource := range s.mesh.ConfigSources {
+		if strings.HasPrefix(configSource.Address, k8sScheme+"://") {
+			srcAddress, err := url.Parse(configSource.Address)
+			if err != nil {
+				cancel()
+				return fmt.Errorf("invalid config URL %s %v", configSource.Address, err)
+			}
+			kubeCfgFile := srcAddress.Path
+			if kubeCfgFile == "" {
+				kubeCfgFile = s.getKubeCfgFile(args)
+			}
+			configController, err := s.makeKubeConfigController(args, kubeCfgFile)
+			if err != nil {
+				cancel()
+				return err
+			}
+			s.addSyncSource(configSourceKind, configSource.Address, configController.HasSynced)
+			configStores = append(configStores, configController)
+			continue
+		}
 		if strings.Contains(configSource.Address, fsScheme+"://") {
 			srcAddress, err := url.Parse(configSource.Address)
 			if err != nil {
@@ -552,65 +615,20 @@ func (s *Server) initMCPConfigController(args *PilotArgs) error {
 					cancel()
 					return err
 				}
+				s.addSyncSource(configSourceKind, configSource.Address, configController.HasSynced)
 				configStores = append(configStores, configController)
 				continue
 			}
 		}
 
-		securityOption := grpc.WithInsecure()
-		if configSource.TlsSettings != nil &&
-			configSource.TlsSettings.Mode != istio_networking_v1alpha3.TLSSettings_DISABLE {
-			var credentialOption *creds.Options
-			switch configSource.TlsSettings.Mode {
-			case istio_networking_v1alpha3.TLSSettings_SIMPLE:
-			case istio_networking_v1alpha3.TLSSettings_MUTUAL:
-				credentialOption = &creds.Options{
-					CertificateFile:   configSource.TlsSettings.ClientCertificate,
-					KeyFile:           configSource.TlsSettings.PrivateKey,
-					CACertificateFile: configSource.TlsSettings.CaCertificates,
-				}
-			case istio_networking_v1alpha3.TLSSettings_ISTIO_MUTUAL:
-				credentialOption = &creds.Options{
-					CertificateFile:   path.Join(config.AuthCertsPath, config.CertChainFilename),
-					KeyFile:           path.Join(config.AuthCertsPath, config.KeyFilename),
-					CACertificateFile: path.Join(config.AuthCertsPath, config.RootCertFilename),
-				}
-			default:
-				log.Errorf("invalid tls setting mode %d", configSource.TlsSettings.Mode)
-				continue
-			}
-
-			if credentialOption == nil {
-				transportCreds := creds.CreateForClientSkipVerify()
-				securityOption = grpc.WithTransportCredentials(transportCreds)
-			} else {
-				requiredFiles := []string{credentialOption.CACertificateFile, credentialOption.KeyFile, credentialOption.CertificateFile}
-				log.Infof("Secure MCP configured. Waiting for required certificate files to become available: %v",
-					requiredFiles)
-				for len(requiredFiles) > 0 {
-					if _, err := os.Stat(requiredFiles[0]); os.IsNotExist(err) {
-						log.Infof("%v not found. Checking again in %v", requiredFiles[0], requiredMCPCertCheckFreq)
-						select {
-						case <-ctx.Done():
-							cancel()
-							return ctx.Err()
-						case <-time.After(requiredMCPCertCheckFreq):
-							// retry
-						}
-						continue
-					}
-					log.Infof("%v found", requiredFiles[0])
-					requiredFiles = requiredFiles[1:]
-				}
-
-				watcher, err := creds.WatchFiles(ctx.Done(), credentialOption)
-				if err != nil {
-					cancel()
-					return err
-				}
-				transportCreds := creds.CreateForClient(configSource.TlsSettings.Sni, watcher)
-				securityOption = grpc.WithTransportCredentials(transportCreds)
-			}
+		securityOption, err := mcpSecurityOption(ctx, configSource.TlsSettings)
+		if err == errInvalidTLSMode {
+			log.Errorf("invalid tls setting mode %d", configSource.TlsSettings.Mode)
+			continue
+		}
+		if err != nil {
+			cancel()
+			return err
 		}
 
 		keepaliveOption := grpc.WithKeepaliveParams(keepalive.ClientParameters{
@@ -645,6 +663,7 @@ func (s *Server) initMCPConfigController(args *PilotArgs) error {
 		clients = append(clients, mcpClient)
 
 		conns = append(conns, conn)
+		s.addSyncSource(configSourceKind, configSource.Address, mcpController.HasSynced)
 		configStores = append(configStores, mcpController)
 	}
 
@@ -696,6 +715,7 @@ func (s *Server) initConfigController(args *PilotArgs) error {
 		}
 	} else if args.Config.Controller != nil {
 		s.configController = args.Config.Controller
+		s.addSyncSource(configSourceKind, "controller", s.configController.HasSynced)
 	} else if args.Config.FileDir != "" {
 		store := memory.Make(model.IstioConfigTypes)
 		configController := memory.NewController(store)
@@ -706,13 +726,15 @@ func (s *Server) initConfigController(args *PilotArgs) error {
 		}
 
 		s.configController = configController
+		s.addSyncSource(configSourceKind, fsScheme+"://"+args.Config.FileDir, s.configController.HasSynced)
 	} else {
-		cfgController, err := s.makeKubeConfigController(args)
+		cfgController, err := s.makeKubeConfigController(args, s.getKubeCfgFile(args))
 		if err != nil {
 			return err
 		}
 
 		s.configController = cfgController
+		s.addSyncSource(configSourceKind, k8sScheme+"://", s.configController.HasSynced)
 	}
 
 	// Defer starting the controller until after the service is created.
@@ -724,13 +746,15 @@ func (s *Server) initConfigController(args *PilotArgs) error {
 	// If running in ingress mode (requires k8s), wrap the config controller.
 	if hasKubeRegistry(args) && s.mesh.IngressControllerMode != meshconfig.MeshConfig_OFF {
 		// Wrap the config controller with a cache.
+		ingressController := ingress.NewController(s.kubeClient, s.mesh, args.Config.ControllerOptions)
 		configController, err := configaggregate.MakeCache([]model.ConfigStoreCache{
 			s.configController,
-			ingress.NewController(s.kubeClient, s.mesh, args.Config.ControllerOptions),
+			ingressController,
 		})
 		if err != nil {
 			return err
 		}
+		s.addSyncSource(configSourceKind, "ingress", ingressController.HasSynced)
 
 		// Update the config controller
 		s.configController = configController
@@ -746,14 +770,20 @@ func (s *Server) initConfigController(args *PilotArgs) error {
 		}
 	}
 
+	if err := s.initFederationImport(args); err != nil {
+		return err
+	}
+	if err := s.initEastWestGateway(args); err != nil {
+		return err
+	}
+
 	// Create the config store.
 	s.istioConfigStore = model.MakeIstioStore(s.configController)
 
 	return nil
 }
 
-func (s *Server) makeKubeConfigController(args *PilotArgs) (model.ConfigStoreCache, error) {
-	kubeCfgFile := s.getKubeCfgFile(args)
+func (s *Server) makeKubeConfigController(args *PilotArgs, kubeCfgFile string) (model.ConfigStoreCache, error) {
 	configClient, err := controller.NewClient(kubeCfgFile, "", model.IstioConfigTypes, args.Config.ControllerOptions.DomainSuffix)
 	if err != nil {
 		return nil, multierror.Prefix(err, "failed to open a config client.")
@@ -765,7 +795,12 @@ func (s *Server) makeKubeConfigController(args *PilotArgs) (model.ConfigStoreCac
 		}
 	}
 
-	return controller.NewController(configClient, args.Config.ControllerOptions), nil
+	configController := controller.NewController(configClient, args.Config.ControllerOptions)
+	if s.statusWriter == nil {
+		s.statusWriter = configClient
+		s.statusStore = configController
+	}
+	return configController, nil
 }
 
 func (s *Server) makeFileMonitor(fileDir string, configController model.ConfigStore) error {
@@ -774,6 +809,11 @@ func (s *Server) makeFileMonitor(fileDir string, configController model.ConfigSt
 
 	// Defer starting the file monitor until after the service is created.
 	s.addStartFunc(func(stop <-chan struct{}) error {
+		// Changed files are applied as soon as they are written. Polling remains as a fallback
+		// for file systems that do not support notifications.
+		if err := fileSnapshot.Watch(stop, fileMonitor.ScheduleCheck); err != nil {
+			log.Warnf("failed to watch %s, falling back to polling: %v", fileDir, err)
+		}
 		fileMonitor.Start(stop)
 		return nil
 	})
@@ -808,6 +848,9 @@ func hasKubeRegistry(args *PilotArgs) bool {
 	return false
 }
 
+// serviceEntriesRegistry is the name of the registry of the ServiceEntries.
+const serviceEntriesRegistry = "ServiceEntries"
+
 // initServiceControllers creates and initializes the service controllers
 func (s *Server) initServiceControllers(args *PilotArgs) error {
 	serviceControllers := aggregate.NewController()
@@ -833,6 +876,14 @@ func (s *Server) initServiceControllers(args *PilotArgs) error {
 			}
 		case serviceregistry.MCPRegistry:
 			log.Infof("no-op: get service info from MCP ServiceEntries.")
+		case serviceregistry.FileRegistry:
+			if err := s.initFileRegistry(serviceControllers, args); err != nil {
+				return err
+			}
+		case serviceregistry.ExternalRegistry:
+			if err := s.initExternalRegistry(serviceControllers, args); err != nil {
+				return err
+			}
 		default:
 			return fmt.Errorf("service registry %s is not supported", r)
 		}
@@ -842,12 +893,22 @@ func (s *Server) initServiceControllers(args *PilotArgs) error {
 
 	// add service entry registry to aggregator by default
 	serviceEntryRegistry := aggregate.Registry{
-		Name:             "ServiceEntries",
+		Name:             serviceEntriesRegistry,
 		Controller:       serviceEntryStore,
 		ServiceDiscovery: serviceEntryStore,
 	}
 	serviceControllers.AddRegistry(serviceEntryRegistry)
 
+	for _, r := range serviceControllers.GetRegistries() {
+		if synced, ok := r.Controller.(interface{ HasSynced() bool }); ok {
+			name := string(r.Name)
+			if r.ClusterID != "" {
+				name += "/" + r.ClusterID
+			}
+			s.addSyncSource(registrySourceKind, name, synced.HasSynced)
+		}
+	}
+
 	s.ServiceController = serviceControllers
 
 	// Defer running of the service controllers.
@@ -864,23 +925,25 @@ func (s *Server) initMemoryRegistry(serviceControllers *aggregate.Controller) {
 	discovery1 := srmemory.NewDiscovery(
 		map[config.Hostname]*model.Service{ // srmemory.HelloService.Hostname: srmemory.HelloService,
 		}, 2)
+	discovery1.ClusterID = "mockAdapter1"
 
 	discovery2 := srmemory.NewDiscovery(
 		map[config.Hostname]*model.Service{ // srmemory.WorldService.Hostname: srmemory.WorldService,
 		}, 2)
+	discovery2.ClusterID = "mockAdapter2"
 
 	registry1 := aggregate.Registry{
 		Name:             serviceregistry.ServiceRegistry("mockAdapter1"),
 		ClusterID:        "mockAdapter1",
 		ServiceDiscovery: discovery1,
-		Controller:       &mockController{},
+		Controller:       discovery1,
 	}
 
 	registry2 := aggregate.Registry{
 		Name:             serviceregistry.ServiceRegistry("mockAdapter2"),
 		ClusterID:        "mockAdapter2",
 		ServiceDiscovery: discovery2,
-		Controller:       &mockController{},
+		Controller:       discovery2,
 	}
 	serviceControllers.AddRegistry(registry1)
 	serviceControllers.AddRegistry(registry2)
@@ -908,7 +971,17 @@ func (s *Server) initDiscoveryService(args *PilotArgs) error {
 	s.EnvoyXdsServer = envoyv2.NewDiscoveryServer(environment,
 		istio_networking.NewConfigGenerator(args.Plugins),
 		s.ServiceController, s.kubeRegistry, s.configController)
+	s.EnvoyXdsServer.SyncSources = s.syncSources
 	s.EnvoyXdsServer.InitDebug(s.mux, s.ServiceController)
+	// The name table of the DNS proxy of the agents is only served to the clients with a mesh
+	// certificate, on the secure port.
+	s.mux.HandleFunc(dns.NameTablePath, s.EnvoyXdsServer.NameTable)
+	s.addMeshHandler(func() {
+		prev := environment.Mesh
+		environment.Mesh = s.mesh
+		// Only push the resources affected by the change, not everything to all the proxies.
+		s.EnvoyXdsServer.MeshConfigUpdate(prev, s.mesh)
+	})
 	if s.kubeRegistry != nil {
 		// kubeRegistry may use the environment for push status reporting.
 		// TODO: maybe all registries should have this as an optional field ?
@@ -916,6 +989,15 @@ func (s *Server) initDiscoveryService(args *PilotArgs) error {
 		s.kubeRegistry.InitNetworkLookup(s.meshNetworks)
 		s.kubeRegistry.XDSUpdater = s.EnvoyXdsServer
 	}
+	// The memory registries push the instances added at runtime incrementally.
+	for _, r := range s.ServiceController.GetRegistries() {
+		switch registry := r.ServiceDiscovery.(type) {
+		case *srmemory.ServiceDiscovery:
+			registry.XDSUpdater = s.EnvoyXdsServer
+		case *file.Controller:
+			registry.XDSUpdater = s.EnvoyXdsServer
+		}
+	}
 
 	// Implement EnvoyXdsServer grace shutdown
 	s.addStartFunc(func(stop <-chan struct{}) error {
@@ -923,6 +1005,15 @@ func (s *Server) initDiscoveryService(args *PilotArgs) error {
 		return nil
 	})
 
+	if features.EnableStatus() && s.statusWriter != nil {
+		statusController := status.NewController(s.statusStore, s.statusWriter, envoyv2.AckedVersions)
+		s.EnvoyXdsServer.StatusController = statusController
+		s.addStartFunc(func(stop <-chan struct{}) error {
+			go statusController.Run(features.StatusUpdateInterval, stop)
+			return nil
+		})
+	}
+
 	// create grpc/http server
 	s.initGrpcServer(args.KeepaliveOptions)
 	s.httpServer = &http.Server{
@@ -1052,6 +1143,100 @@ func (s *Server) initConsulRegistry(serviceControllers *aggregate.Controller, ar
 	return nil
 }
 
+// initExternalRegistry adds the services and instances of an external registry, like Eureka or a
+// custom registry, to the aggregate controller. The external process serves them as ServiceEntries
+// over MCP, the versioned gRPC streaming API of the config sources: each update of the collection
+// registers, updates or removes ServiceEntries, and the endpoints which are not healthy are left out.
+func (s *Server) initExternalRegistry(serviceControllers *aggregate.Controller, args *PilotArgs) error {
+	address := args.Service.External.Address
+	if address == "" {
+		return fmt.Errorf("the address of the %s registry is not set", serviceregistry.ExternalRegistry)
+	}
+	log.Infof("External registry address: %v", address)
+	tlsSettings, err := args.Service.External.tlsSettings()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	securityOption, err := mcpSecurityOption(ctx, tlsSettings)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("invalid TLS settings of the %s registry: %v", serviceregistry.ExternalRegistry, err)
+	}
+	conn, err := grpc.DialContext(ctx, address,
+		securityOption,
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    args.KeepaliveOptions.Time,
+			Timeout: args.KeepaliveOptions.Timeout,
+		}),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(args.MCPMaxMessageSize)))
+	if err != nil {
+		cancel()
+		return fmt.Errorf("unable to dial the %s registry %q: %v", serviceregistry.ExternalRegistry, address, err)
+	}
+
+	mcpController := coredatamodel.NewController(coredatamodel.Options{
+		DomainSuffix: args.Config.ControllerOptions.DomainSuffix,
+		ClearDiscoveryServerCache: func() {
+			s.EnvoyXdsServer.ConfigUpdate(true)
+		},
+		Collections: []string{model.ServiceEntry.Collection},
+	})
+	reporter := monitoring.NewStatsContext("pilot/mcp/registry")
+	mcpClient := sink.NewClient(mcpapi.NewResourceSourceClient(conn), &sink.Options{
+		CollectionOptions: []sink.CollectionOptions{{Name: model.ServiceEntry.Collection}},
+		Updater:           mcpController,
+		Reporter:          reporter,
+	})
+	configz.Register(mcpClient)
+
+	s.addStartFunc(func(stop <-chan struct{}) error {
+		done := make(chan struct{})
+		go func() {
+			mcpClient.Run(ctx)
+			close(done)
+		}()
+		go func() {
+			<-stop
+			cancel()
+			<-done
+			_ = conn.Close()
+			_ = reporter.Close()
+		}()
+		return nil
+	})
+
+	store := external.NewServiceDiscovery(mcpController, model.MakeIstioStore(mcpController))
+	s.addSyncSource(registrySourceKind, string(serviceregistry.ExternalRegistry), mcpController.HasSynced)
+	serviceControllers.AddRegistry(
+		aggregate.Registry{
+			Name:             serviceregistry.ExternalRegistry,
+			ServiceDiscovery: store,
+			Controller:       store,
+		})
+
+	return nil
+}
+
+func (s *Server) initFileRegistry(serviceControllers *aggregate.Controller, args *PilotArgs) error {
+	if args.Service.FileDir == "" {
+		return fmt.Errorf("the directory of the service definitions of the %s registry is not set",
+			serviceregistry.FileRegistry)
+	}
+	log.Infof("Loading the service definitions of %s", args.Service.FileDir)
+	filectl := file.NewController(args.Service.FileDir, string(serviceregistry.FileRegistry))
+	serviceControllers.AddRegistry(
+		aggregate.Registry{
+			Name:             serviceregistry.FileRegistry,
+			ClusterID:        string(serviceregistry.FileRegistry),
+			ServiceDiscovery: filectl,
+			Controller:       filectl,
+		})
+
+	return nil
+}
+
 func (s *Server) initGrpcServer(options *istiokeepalive.Options) {
 	grpcOptions := s.grpcServerOptions(options)
 	s.grpcServer = grpc.NewServer(grpcOptions...)
@@ -1060,11 +1245,7 @@ func (s *Server) initGrpcServer(options *istiokeepalive.Options) {
 
 // initialize secureGRPCServer
 func (s *Server) initSecureGrpcServer(options *istiokeepalive.Options) error {
-	certDir := features.CertDir
-	if certDir == "" {
-		certDir = PilotCertDir
-	}
-
+	certDir := pilotCertDir()
 	ca := path.Join(certDir, config.RootCertFilename)
 	key := path.Join(certDir, config.KeyFilename)
 	cert := path.Join(certDir, config.CertChainFilename)
@@ -1075,26 +1256,19 @@ func (s *Server) initSecureGrpcServer(options *istiokeepalive.Options) error {
 		return err
 	}
 
-	// TODO: parse the file to determine expiration date. Restart listener before expiration
-	certificate, err := tls.LoadX509KeyPair(cert, key)
+	// The serving certificate and the root certificate are reloaded when rotated.
+	certs, err := newServingCerts(cert, key, ca)
 	if err != nil {
 		return err
 	}
 
-	caCert, err := ioutil.ReadFile(ca)
-	if err != nil {
-		return err
-	}
-	caCertPool := x509.NewCertPool()
-	caCertPool.AppendCertsFromPEM(caCert)
-
 	opts := s.grpcServerOptions(options)
 	opts = append(opts, grpc.Creds(tlsCreds))
 	s.secureGRPCServer = grpc.NewServer(opts...)
 	s.EnvoyXdsServer.Register(s.secureGRPCServer)
 	s.secureHTTPServer = &http.Server{
-		TLSConfig: &tls.Config{
-			Certificates: []tls.Certificate{certificate},
+		// Clients must present a certificate issued by the mesh CA.
+		TLSConfig: certs.tlsConfig(&tls.Config{
 			VerifyPeerCertificate: func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
 				// For now accept any certs - pilot is not authenticating the caller, TLS used for
 				// privacy
@@ -1102,8 +1276,7 @@ func (s *Server) initSecureGrpcServer(options *istiokeepalive.Options) error {
 			},
 			NextProtos: []string{"h2", "http/1.1"},
 			ClientAuth: tls.RequireAndVerifyClientCert,
-			ClientCAs:  caCertPool,
-		},
+		}),
 		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if r.ProtoMajor == 2 && strings.HasPrefix(
 				r.Header.Get("Content-Type"), "application/grpc") {
@@ -1130,12 +1303,18 @@ func (s *Server) grpcServerOptions(options *istiokeepalive.Options) []grpc.Serve
 	grpcOptions := []grpc.ServerOption{
 		grpc.UnaryInterceptor(middleware.ChainUnaryServer(interceptors...)),
 		grpc.MaxConcurrentStreams(uint32(maxStreams)),
+		grpc.MaxRecvMsgSize(features.MaxRecvMsgSize),
+		grpc.MaxSendMsgSize(features.MaxSendMsgSize),
 		grpc.KeepaliveParams(keepalive.ServerParameters{
 			Time:                  options.Time,
 			Timeout:               options.Timeout,
 			MaxConnectionAge:      options.MaxServerConnectionAge,
 			MaxConnectionAgeGrace: options.MaxServerConnectionAgeGrace,
 		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             options.MinClientPingInterval,
+			PermitWithoutStream: options.PermitPingWithoutStream,
+		}),
 	}
 
 	return grpcOptions
@@ -1150,7 +1329,13 @@ func (s *Server) addStartFunc(fn startFunc) {
 // Using a debouncing mechanism to avoid calling the callback multiple times
 // per event.
 func (s *Server) addFileWatcher(file string, callback func()) {
-	_ = s.fileWatcher.Add(file)
+	watchFile(s.fileWatcher, file, callback)
+}
+
+// watchFile adds the file to the watcher and executes the callback, debounced, on any change
+// event for this file.
+func watchFile(watcher filewatcher.FileWatcher, file string, callback func()) {
+	_ = watcher.Add(file)
 	go func() {
 		var timerC <-chan time.Time
 		for {
@@ -1158,7 +1343,7 @@ func (s *Server) addFileWatcher(file string, callback func()) {
 			case <-timerC:
 				timerC = nil
 				callback()
-			case <-s.fileWatcher.Events(file):
+			case <-watcher.Events(file):
 				// Use a timer to debounce configuration updates
 				if timerC == nil {
 					timerC = time.After(100 * time.Millisecond)
@@ -1168,6 +1353,11 @@ func (s *Server) addFileWatcher(file string, callback func()) {
 	}()
 }
 
+// addSyncSource registers a registry or config store whose initial sync gates readiness.
+func (s *Server) addSyncSource(kind, name string, hasSynced func() bool) {
+	s.syncSources = append(s.syncSources, envoyv2.SyncSource{Kind: kind, Name: name, HasSynced: hasSynced})
+}
+
 func (s *Server) waitForCacheSync(stop <-chan struct{}) bool {
 	// TODO: remove dependency on k8s lib
 	if !cache.WaitForCacheSync(stop, func() bool {
@@ -1179,6 +1369,11 @@ func (s *Server) waitForCacheSync(stop <-chan struct{}) bool {
 		if !s.configController.HasSynced() {
 			return false
 		}
+		for _, src := range s.syncSources {
+			if !src.HasSynced() {
+				return false
+			}
+		}
 		return true
 	}) {
 		log.Errorf("Failed waiting for cache sync")
@@ -18,16 +18,36 @@ package aggregate
 import (
 	"errors"
 	"fmt"
+	"sync"
 
 	multierror "github.com/hashicorp/go-multierror"
 
 	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/monitoring"
+	"istio.io/pkg/log"
 )
 
 var errorUnsupported = errors.New("unsupported operation: the config aggregator is read-only")
 
+var (
+	typeTag = monitoring.MustCreateTag("type")
+
+	configConflicts = monitoring.NewSum(
+		"pilot_config_source_conflicts",
+		"Number of configs defined by more than one config source. The config of the source with the highest "+
+			"precedence is used.",
+		typeTag,
+	)
+)
+
+func init() {
+	monitoring.MustRegisterViews(configConflicts)
+}
+
 // Make creates an aggregate config store from several config stores and
-// unifies their descriptors
+// unifies their descriptors. Stores are listed in decreasing order of precedence:
+// when several stores hold a config with the same type, namespace and name, the
+// config of the first store is used and the conflict is reported.
 func Make(stores []model.ConfigStore) (model.ConfigStore, error) {
 	union := model.ConfigDescriptor{}
 	storeTypes := make(map[string][]model.ConfigStore)
@@ -45,6 +65,7 @@ func Make(stores []model.ConfigStore) (model.ConfigStore, error) {
 	return &store{
 		descriptor: union,
 		stores:     storeTypes,
+		conflicts:  make(map[string]model.ConfigMeta),
 	}, nil
 }
 
@@ -55,12 +76,22 @@ func MakeCache(caches []model.ConfigStoreCache) (model.ConfigStoreCache, error)
 	for _, cache := range caches {
 		stores = append(stores, cache)
 	}
-	store, err := Make(stores)
+	configStore, err := Make(stores)
 	if err != nil {
 		return nil, err
 	}
+	// Forget the conflicts of the deleted configs, so that they are reported again if they recur.
+	for _, cache := range caches {
+		for _, typ := range cache.ConfigDescriptor().Types() {
+			cache.RegisterEventHandler(typ, func(config model.Config, event model.Event) {
+				if event == model.EventDelete {
+					configStore.(*store).forgetConflict(config.ConfigMeta)
+				}
+			})
+		}
+	}
 	return &storeCache{
-		ConfigStore: store,
+		ConfigStore: configStore,
 		caches:      caches,
 	}, nil
 }
@@ -71,6 +102,11 @@ type store struct {
 
 	// stores is a mapping from config type to a store
 	stores map[string][]model.ConfigStore
+
+	// conflicts holds the configs already reported as conflicting, by key. They are forgotten
+	// when they are deleted or no longer conflicting.
+	conflictsMu sync.Mutex
+	conflicts   map[string]model.ConfigMeta
 }
 
 func (cr *store) ConfigDescriptor() model.ConfigDescriptor {
@@ -97,6 +133,7 @@ func (cr *store) List(typ, namespace string) ([]model.Config, error) {
 	var configs []model.Config
 	// Used to remove duplicated config
 	configMap := make(map[string]struct{})
+	conflicts := make(map[string]bool)
 
 	for _, store := range cr.stores[typ] {
 		storeConfigs, err := store.List(typ, namespace)
@@ -104,17 +141,60 @@ func (cr *store) List(typ, namespace string) ([]model.Config, error) {
 			errs = multierror.Append(errs, err)
 		}
 		for _, config := range storeConfigs {
-			key := config.Type + config.Namespace + config.Name
+			key := configKey(config.ConfigMeta)
 			if _, exist := configMap[key]; exist {
+				cr.recordConflict(key, config)
+				conflicts[key] = true
 				continue
 			}
 			configs = append(configs, config)
 			configMap[key] = struct{}{}
 		}
 	}
+	// A config missing from a store which failed to list may still be conflicting.
+	if errs == nil {
+		cr.pruneConflicts(typ, namespace, conflicts)
+	}
 	return configs, errs.ErrorOrNil()
 }
 
+func configKey(meta model.ConfigMeta) string {
+	return meta.Type + "/" + meta.Namespace + "/" + meta.Name
+}
+
+// recordConflict reports a config shadowed by a config of a store with higher precedence.
+// Each conflict is reported once.
+func (cr *store) recordConflict(key string, config model.Config) {
+	cr.conflictsMu.Lock()
+	defer cr.conflictsMu.Unlock()
+	if _, reported := cr.conflicts[key]; reported {
+		return
+	}
+	cr.conflicts[key] = config.ConfigMeta
+	configConflicts.With(typeTag.Value(config.Type)).Increment()
+	log.Warnf("%s %s/%s is defined by multiple config sources, using the one with the highest precedence",
+		config.Type, config.Namespace, config.Name)
+}
+
+// pruneConflicts forgets the configs of the type, in the namespace or all namespaces if empty,
+// which are no longer conflicting.
+func (cr *store) pruneConflicts(typ, namespace string, conflicts map[string]bool) {
+	cr.conflictsMu.Lock()
+	defer cr.conflictsMu.Unlock()
+	for key, meta := range cr.conflicts {
+		if meta.Type == typ && (namespace == "" || meta.Namespace == namespace) && !conflicts[key] {
+			delete(cr.conflicts, key)
+		}
+	}
+}
+
+// forgetConflict forgets the config, which is deleted from one of the stores.
+func (cr *store) forgetConflict(meta model.ConfigMeta) {
+	cr.conflictsMu.Lock()
+	defer cr.conflictsMu.Unlock()
+	delete(cr.conflicts, configKey(meta))
+}
+
 func (cr *store) Delete(typ, name, namespace string) error {
 	return errorUnsupported
 }
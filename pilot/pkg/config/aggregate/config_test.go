@@ -155,6 +155,59 @@ func TestAggregateStoreList(t *testing.T) {
 	g.Expect(l).To(gomega.HaveLen(2))
 }
 
+func TestAggregateStoreListPrecedence(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	storeOne := &fakes.ConfigStoreCache{}
+	storeTwo := &fakes.ConfigStoreCache{}
+
+	descriptor := []model.ProtoSchema{{
+		Type:        "some-config",
+		Plural:      "some-configs",
+		MessageName: "istio.networking.v1alpha3.Gateway",
+	}}
+	storeOne.ConfigDescriptorReturns(descriptor)
+	storeTwo.ConfigDescriptorReturns(descriptor)
+
+	storeOne.ListReturns([]model.Config{
+		{
+			ConfigMeta: model.ConfigMeta{
+				Type:            "some-config",
+				Name:            "shared",
+				ResourceVersion: "one",
+			},
+		},
+	}, nil)
+	storeTwo.ListReturns([]model.Config{
+		{
+			ConfigMeta: model.ConfigMeta{
+				Type:            "some-config",
+				Name:            "shared",
+				ResourceVersion: "two",
+			},
+		},
+		{
+			ConfigMeta: model.ConfigMeta{
+				Type: "some-config",
+				Name: "other",
+			},
+		},
+	}, nil)
+
+	store, err := aggregate.Make([]model.ConfigStore{storeOne, storeTwo})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// Listing repeatedly reports the conflict once but always resolves it the same way.
+	for i := 0; i < 2; i++ {
+		l, err := store.List("some-config", "")
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(l).To(gomega.HaveLen(2))
+		g.Expect(l[0].Name).To(gomega.Equal("shared"))
+		g.Expect(l[0].ResourceVersion).To(gomega.Equal("one"))
+		g.Expect(l[1].Name).To(gomega.Equal("other"))
+	}
+}
+
 func TestAggregateStoreFails(t *testing.T) {
 	g := gomega.NewGomegaWithT(t)
 
@@ -0,0 +1,71 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aggregate
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/onsi/gomega"
+
+	"istio.io/istio/pilot/pkg/config/aggregate/fakes"
+	"istio.io/istio/pilot/pkg/model"
+)
+
+func TestConflictsPruned(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	storeOne := &fakes.ConfigStoreCache{}
+	storeTwo := &fakes.ConfigStoreCache{}
+	descriptor := []model.ProtoSchema{{
+		Type:        "some-config",
+		Plural:      "some-configs",
+		MessageName: "istio.networking.v1alpha3.Gateway",
+	}}
+	storeOne.ConfigDescriptorReturns(descriptor)
+	storeTwo.ConfigDescriptorReturns(descriptor)
+	shared := model.Config{ConfigMeta: model.ConfigMeta{Type: "some-config", Namespace: "default", Name: "shared"}}
+	other := model.Config{ConfigMeta: model.ConfigMeta{Type: "some-config", Namespace: "default", Name: "other"}}
+	storeOne.ListReturns([]model.Config{shared}, nil)
+	storeTwo.ListReturns([]model.Config{shared, other}, nil)
+
+	cache, err := MakeCache([]model.ConfigStoreCache{storeOne, storeTwo})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	s := cache.(*storeCache).ConfigStore.(*store)
+
+	_, err = s.List("some-config", "")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(s.conflicts).To(gomega.HaveKey(configKey(shared.ConfigMeta)))
+
+	// A store failing to list does not resolve the conflict.
+	storeTwo.ListReturns(nil, errors.New("failed"))
+	_, _ = s.List("some-config", "")
+	g.Expect(s.conflicts).To(gomega.HaveLen(1))
+
+	// The conflict is resolved when listing, in any namespace.
+	storeTwo.ListReturns([]model.Config{other}, nil)
+	_, err = s.List("some-config", "default")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(s.conflicts).To(gomega.BeEmpty())
+
+	// The conflict is resolved when one of the configs is deleted.
+	storeTwo.ListReturns([]model.Config{shared, other}, nil)
+	_, _ = s.List("some-config", "")
+	g.Expect(s.conflicts).To(gomega.HaveLen(1))
+	typ, handler := storeTwo.RegisterEventHandlerArgsForCall(0)
+	g.Expect(typ).To(gomega.Equal("some-config"))
+	handler(shared, model.EventDelete)
+	g.Expect(s.conflicts).To(gomega.BeEmpty())
+}
@@ -15,13 +15,16 @@
 package clusterregistry
 
 import (
+	"fmt"
 	"sync"
 	"time"
 
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
 
 	meshconfig "istio.io/api/mesh/v1alpha1"
 	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/monitoring"
 	"istio.io/istio/pilot/pkg/serviceregistry"
 	"istio.io/istio/pilot/pkg/serviceregistry/aggregate"
 	"istio.io/istio/pilot/pkg/serviceregistry/kube/controller"
@@ -29,9 +32,64 @@ import (
 	"istio.io/pkg/log"
 )
 
+const (
+	// defaultHealthCheckInterval is the interval between two checks of a reachable remote API
+	// server.
+	defaultHealthCheckInterval = 10 * time.Second
+	// maxHealthCheckInterval bounds the exponential backoff of the checks of an unreachable
+	// remote cluster.
+	maxHealthCheckInterval = 5 * time.Minute
+	// probeTimeout bounds the time of a check of a remote API server.
+	probeTimeout = 5 * time.Second
+	// syncTimeout bounds the time the informers of another endpoint of a remote cluster
+	// take to sync before the watches are switched to it.
+	syncTimeout = time.Minute
+)
+
+var (
+	clusterTag = monitoring.MustCreateTag("cluster")
+
+	remoteClusterStaleness = monitoring.NewGauge(
+		"pilot_remote_cluster_staleness_seconds",
+		"Time since the API server of the remote cluster was last reachable, 0 if it is reachable.",
+		clusterTag,
+	)
+	remoteClusterReconnects = monitoring.NewSum(
+		"pilot_remote_cluster_reconnects_total",
+		"Number of times the watches of the remote cluster were re-established.",
+		clusterTag,
+	)
+)
+
+func init() {
+	monitoring.MustRegisterViews(remoteClusterStaleness, remoteClusterReconnects)
+}
+
+// probeAPIServer checks that the API server of the client is reachable.
+func probeAPIServer(client kubernetes.Interface) error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.Discovery().ServerVersion()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(probeTimeout):
+		return fmt.Errorf("no response within %v", probeTimeout)
+	}
+}
+
 type kubeController struct {
 	rc     *controller.Controller
 	stopCh chan struct{}
+
+	// clients of the API server endpoints of the remote cluster, in order of preference.
+	clients []kubernetes.Interface
+	// active is the index of the client used by rc.
+	active int
+	// rcStopCh stops rc, which is replaced when the watches are re-established.
+	rcStopCh chan struct{}
 }
 
 // Multicluster structure holds the remote kube Controllers and multicluster specific attributes.
@@ -45,6 +103,9 @@ type Multicluster struct {
 	m                     sync.Mutex // protects remoteKubeControllers
 	remoteKubeControllers map[string]*kubeController
 	meshNetworks          *meshconfig.MeshNetworks
+
+	healthCheckInterval time.Duration
+	probeAPIServer      func(kubernetes.Interface) error
 }
 
 // NewMulticluster initializes data structure to store multicluster information
@@ -67,9 +128,11 @@ func NewMulticluster(kc kubernetes.Interface, secretNamespace string,
 		XDSUpdater:            xds,
 		remoteKubeControllers: remoteKubeController,
 		meshNetworks:          meshNetworks,
+		healthCheckInterval:   defaultHealthCheckInterval,
+		probeAPIServer:        probeAPIServer,
 	}
 
-	err := secretcontroller.StartSecretController(kc,
+	err := secretcontroller.StartEndpointsSecretController(kc,
 		mc.AddMemberCluster,
 		mc.DeleteMemberCluster,
 		secretNamespace)
@@ -79,13 +142,35 @@ func NewMulticluster(kc kubernetes.Interface, secretNamespace string,
 // AddMemberCluster is passed to the secret controller as a callback to be called
 // when a remote cluster is added.  This function needs to set up all the handlers
 // to watch for resources being added, deleted or changed on remote clusters.
-func (m *Multicluster) AddMemberCluster(clientset kubernetes.Interface, clusterID string) error {
-	// stopCh to stop controller created here when cluster removed.
-	stopCh := make(chan struct{})
-	var remoteKubeController kubeController
-	remoteKubeController.stopCh = stopCh
+// The clientsets are the API server endpoints of the remote cluster, in order of
+// preference: the watches fail over to the next reachable endpoint when the active
+// one becomes unreachable. There is a single endpoint unless the secret of the
+// cluster opts in to the failover.
+func (m *Multicluster) AddMemberCluster(clientsets []kubernetes.Interface, clusterID string) error {
+	if len(clientsets) == 0 {
+		return fmt.Errorf("no API server endpoint for cluster %s", clusterID)
+	}
+	// stopCh to stop the controllers created here when cluster removed.
+	remoteKubeController := &kubeController{
+		stopCh:  make(chan struct{}),
+		clients: clientsets,
+	}
 	m.m.Lock()
-	kubectl := controller.NewController(clientset, controller.Options{
+	remoteKubeController.rc, remoteKubeController.rcStopCh = m.startRemoteController(clusterID, clientsets[0])
+	m.serviceController.AddRegistry(m.remoteRegistry(clusterID, remoteKubeController.rc))
+	m.remoteKubeControllers[clusterID] = remoteKubeController
+	m.m.Unlock()
+
+	if len(clientsets) > 1 {
+		go m.watchRemoteCluster(clusterID, remoteKubeController)
+	}
+	return nil
+}
+
+// startRemoteController creates and runs a controller of the remote cluster watching the
+// API server endpoint of the client.
+func (m *Multicluster) startRemoteController(clusterID string, client kubernetes.Interface) (*controller.Controller, chan struct{}) {
+	kubectl := controller.NewController(client, controller.Options{
 		WatchedNamespace: m.WatchedNamespace,
 		ResyncPeriod:     m.ResyncPeriod,
 		DomainSuffix:     m.DomainSuffix,
@@ -94,21 +179,128 @@ func (m *Multicluster) AddMemberCluster(clientset kubernetes.Interface, clusterI
 	})
 	kubectl.InitNetworkLookup(m.meshNetworks)
 
-	remoteKubeController.rc = kubectl
-	m.serviceController.AddRegistry(
-		aggregate.Registry{
-			Name:             serviceregistry.KubernetesRegistry,
-			ClusterID:        clusterID,
-			ServiceDiscovery: kubectl,
-			Controller:       kubectl,
-		})
-
-	m.remoteKubeControllers[clusterID] = &remoteKubeController
-	m.m.Unlock()
 	_ = kubectl.AppendServiceHandler(func(*model.Service, model.Event) { m.XDSUpdater.ConfigUpdate(true) })
 	_ = kubectl.AppendInstanceHandler(func(*model.ServiceInstance, model.Event) { m.XDSUpdater.ConfigUpdate(true) })
+	stopCh := make(chan struct{})
 	go kubectl.Run(stopCh)
-	return nil
+	return kubectl, stopCh
+}
+
+func (m *Multicluster) remoteRegistry(clusterID string, kubectl *controller.Controller) aggregate.Registry {
+	return aggregate.Registry{
+		Name:             serviceregistry.KubernetesRegistry,
+		ClusterID:        clusterID,
+		ServiceDiscovery: kubectl,
+		Controller:       kubectl,
+	}
+}
+
+// switchRemoteController replaces the controller of the remote cluster by one watching
+// the endpoint. The registry of the previous controller is kept until the informers of
+// the new one are synced, so that the services of the cluster do not disappear meanwhile.
+func (m *Multicluster) switchRemoteController(clusterID string, remoteKubeController *kubeController, endpoint int) {
+	kubectl, rcStopCh := m.startRemoteController(clusterID, remoteKubeController.clients[endpoint])
+	syncStopCh := make(chan struct{})
+	go func() {
+		select {
+		case <-remoteKubeController.stopCh:
+		case <-time.After(syncTimeout):
+		}
+		close(syncStopCh)
+	}()
+	if !cache.WaitForCacheSync(syncStopCh, kubectl.HasSynced) {
+		// The cluster was removed meanwhile, or the endpoint is checked again later.
+		log.Warnf("Failed to sync the watches of cluster %s on API server endpoint %d", clusterID, endpoint)
+		close(rcStopCh)
+		return
+	}
+
+	m.m.Lock()
+	if m.remoteKubeControllers[clusterID] != remoteKubeController {
+		// The cluster was removed meanwhile.
+		m.m.Unlock()
+		close(rcStopCh)
+		return
+	}
+	close(remoteKubeController.rcStopCh)
+	m.serviceController.ReplaceRegistry(m.remoteRegistry(clusterID, kubectl))
+	remoteKubeController.rc = kubectl
+	remoteKubeController.rcStopCh = rcStopCh
+	remoteKubeController.active = endpoint
+	m.m.Unlock()
+
+	remoteClusterReconnects.With(clusterTag.Value(clusterID)).Increment()
+	if m.XDSUpdater != nil {
+		m.XDSUpdater.ConfigUpdate(true)
+	}
+}
+
+// watchRemoteCluster periodically checks the API server endpoints of a remote cluster
+// with several endpoints until it is removed. When the active endpoint is unreachable,
+// the checks back off exponentially and the watches are switched to the first reachable
+// endpoint. The watches are not re-established when the active endpoint becomes
+// reachable again, the informers reconnect to it. The staleness of the cluster is reset
+// when it is removed.
+func (m *Multicluster) watchRemoteCluster(clusterID string, remoteKubeController *kubeController) {
+	staleness := remoteClusterStaleness.With(clusterTag.Value(clusterID))
+	staleness.Record(0)
+	defer staleness.Record(0)
+
+	interval := m.healthCheckInterval
+	lastReachable := time.Now()
+	unreachable := false
+	for {
+		select {
+		case <-remoteKubeController.stopCh:
+			return
+		case <-time.After(interval):
+		}
+
+		m.m.Lock()
+		active := remoteKubeController.active
+		m.m.Unlock()
+
+		endpoint, err := m.reachableEndpoint(remoteKubeController, active)
+		if err != nil {
+			if !unreachable {
+				log.Warnf("API server of cluster %s is unreachable: %v", clusterID, err)
+			}
+			unreachable = true
+			staleness.Record(time.Since(lastReachable).Seconds())
+			interval *= 2
+			if interval > maxHealthCheckInterval {
+				interval = maxHealthCheckInterval
+			}
+			continue
+		}
+
+		if endpoint != active {
+			log.Infof("Switching the watches of cluster %s to API server endpoint %d", clusterID, endpoint)
+			m.switchRemoteController(clusterID, remoteKubeController, endpoint)
+		}
+		unreachable = false
+		lastReachable = time.Now()
+		staleness.Record(0)
+		interval = m.healthCheckInterval
+	}
+}
+
+// reachableEndpoint returns the first reachable endpoint of the remote cluster, checking
+// the active endpoint first.
+func (m *Multicluster) reachableEndpoint(remoteKubeController *kubeController, active int) (int, error) {
+	err := m.probeAPIServer(remoteKubeController.clients[active])
+	if err == nil {
+		return active, nil
+	}
+	for i, client := range remoteKubeController.clients {
+		if i == active {
+			continue
+		}
+		if m.probeAPIServer(client) == nil {
+			return i, nil
+		}
+	}
+	return active, err
 }
 
 // DeleteMemberCluster is passed to the secret controller as a callback to be called
@@ -123,6 +315,7 @@ func (m *Multicluster) DeleteMemberCluster(clusterID string) error {
 		log.Infof("cluster %s does not exist, maybe caused by invalid kubeconfig", clusterID)
 		return nil
 	}
+	close(m.remoteKubeControllers[clusterID].rcStopCh)
 	close(m.remoteKubeControllers[clusterID].stopCh)
 	delete(m.remoteKubeControllers, clusterID)
 	if m.XDSUpdater != nil {
@@ -15,10 +15,13 @@
 package clusterregistry
 
 import (
+	"errors"
 	"os"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"go.opencensus.io/stats/view"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -124,3 +127,134 @@ func Test_KubeSecretController(t *testing.T) {
 	verifyControllers(t, mc, 0, "delete remote controller")
 
 }
+
+func Test_RemoteClusterFailover(t *testing.T) {
+
+	primary, secondary := fake.NewSimpleClientset(), fake.NewSimpleClientset()
+	var down atomic.Value
+	down.Store(false)
+	mc := &Multicluster{
+		serviceController:     aggregate.NewController(),
+		remoteKubeControllers: map[string]*kubeController{},
+		healthCheckInterval:   10 * time.Millisecond,
+		probeAPIServer: func(client kubernetes.Interface) error {
+			if client == primary && down.Load().(bool) {
+				return errors.New("connection refused")
+			}
+			return nil
+		},
+	}
+	if err := mc.AddMemberCluster([]kubernetes.Interface{primary, secondary}, "remote"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mc.DeleteMemberCluster("remote") }()
+
+	activeEndpoint := func() int {
+		mc.m.Lock()
+		defer mc.m.Unlock()
+		return mc.remoteKubeControllers["remote"].active
+	}
+	if activeEndpoint() != 0 {
+		t.Fatalf("got active endpoint %d, want the primary endpoint", activeEndpoint())
+	}
+
+	down.Store(true)
+	pkgtest.NewEventualOpts(10*time.Millisecond, 5*time.Second).Eventually(t, "fail over to the secondary endpoint", func() bool {
+		return activeEndpoint() == 1
+	})
+	if registries := mc.serviceController.GetRegistries(); len(registries) != 1 || registries[0].ClusterID != "remote" {
+		t.Errorf("got registries %v, want the registry of the remote cluster", registries)
+	}
+}
+
+func clusterStaleness(t *testing.T, clusterID string) float64 {
+	t.Helper()
+	rows, err := view.RetrieveData("pilot_remote_cluster_staleness_seconds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		for _, tag := range row.Tags {
+			if tag.Key.Name() == "cluster" && tag.Value == clusterID {
+				return row.Data.(*view.LastValueData).Value
+			}
+		}
+	}
+	return 0
+}
+
+func Test_RemoteClusterStalenessReset(t *testing.T) {
+
+	mc := &Multicluster{
+		serviceController:     aggregate.NewController(),
+		remoteKubeControllers: map[string]*kubeController{},
+		healthCheckInterval:   10 * time.Millisecond,
+		probeAPIServer: func(kubernetes.Interface) error {
+			return errors.New("connection refused")
+		},
+	}
+	endpoints := []kubernetes.Interface{fake.NewSimpleClientset(), fake.NewSimpleClientset()}
+	if err := mc.AddMemberCluster(endpoints, "stale"); err != nil {
+		t.Fatal(err)
+	}
+	pkgtest.NewEventualOpts(10*time.Millisecond, 5*time.Second).Eventually(t, "cluster is stale", func() bool {
+		return clusterStaleness(t, "stale") > 0
+	})
+
+	if err := mc.DeleteMemberCluster("stale"); err != nil {
+		t.Fatal(err)
+	}
+	pkgtest.NewEventualOpts(10*time.Millisecond, 5*time.Second).Eventually(t, "staleness reset", func() bool {
+		return clusterStaleness(t, "stale") == 0
+	})
+}
+
+func Test_RemoteClusterWatches(t *testing.T) {
+
+	var probes int32
+	var down atomic.Value
+	down.Store(true)
+	mc := &Multicluster{
+		serviceController:     aggregate.NewController(),
+		remoteKubeControllers: map[string]*kubeController{},
+		healthCheckInterval:   10 * time.Millisecond,
+		probeAPIServer: func(kubernetes.Interface) error {
+			atomic.AddInt32(&probes, 1)
+			if down.Load().(bool) {
+				return errors.New("connection refused")
+			}
+			return nil
+		},
+	}
+
+	// The clusters which did not opt in to the failover are not checked.
+	if err := mc.AddMemberCluster([]kubernetes.Interface{fake.NewSimpleClientset()}, "single"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mc.DeleteMemberCluster("single") }()
+	time.Sleep(50 * time.Millisecond)
+	if n := atomic.LoadInt32(&probes); n != 0 {
+		t.Fatalf("got %d checks of a cluster with a single endpoint, want none", n)
+	}
+
+	// The watches are kept when the active endpoint is reachable again.
+	if err := mc.AddMemberCluster([]kubernetes.Interface{fake.NewSimpleClientset(), fake.NewSimpleClientset()}, "remote"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = mc.DeleteMemberCluster("remote") }()
+	mc.m.Lock()
+	rc := mc.remoteKubeControllers["remote"].rc
+	mc.m.Unlock()
+	pkgtest.NewEventualOpts(10*time.Millisecond, 5*time.Second).Eventually(t, "cluster is unreachable", func() bool {
+		return clusterStaleness(t, "remote") > 0
+	})
+	down.Store(false)
+	pkgtest.NewEventualOpts(10*time.Millisecond, 5*time.Second).Eventually(t, "cluster is reachable", func() bool {
+		return clusterStaleness(t, "remote") == 0
+	})
+	mc.m.Lock()
+	defer mc.m.Unlock()
+	if current := mc.remoteKubeControllers["remote"]; current.rc != rc || current.active != 0 {
+		t.Errorf("got the watches of cluster remote re-established on endpoint %d, want them kept", current.active)
+	}
+}
@@ -41,6 +41,9 @@ type CoreDataModel interface {
 type Options struct {
 	DomainSuffix              string
 	ClearDiscoveryServerCache func()
+	// Collections are the MCP collections applied by the controller, and waited for by HasSynced.
+	// All the Istio config collections if empty.
+	Collections []string
 }
 
 // Controller is a temporary storage for the changes received
@@ -61,7 +64,14 @@ type Controller struct {
 func NewController(options Options) CoreDataModel {
 	descriptorsByMessageName := make(map[string]model.ProtoSchema, len(model.IstioConfigTypes))
 	synced := make(map[string]bool)
+	collections := make(map[string]bool, len(options.Collections))
+	for _, collection := range options.Collections {
+		collections[collection] = true
+	}
 	for _, descriptor := range model.IstioConfigTypes {
+		if len(collections) > 0 && !collections[descriptor.Collection] {
+			continue
+		}
 		// don't register duplicate descriptors for the same collection
 		if _, ok := descriptorsByMessageName[descriptor.Collection]; !ok {
 			descriptorsByMessageName[descriptor.Collection] = descriptor
@@ -164,6 +164,26 @@ func TestHasSynced(t *testing.T) {
 	g.Expect(controller.HasSynced()).To(gomega.BeFalse())
 }
 
+func TestCollections(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	options := testControllerOptions
+	options.Collections = []string{model.ServiceEntry.Collection}
+	controller := coredatamodel.NewController(options)
+	g.Expect(controller.HasSynced()).To(gomega.BeFalse())
+
+	message := convertToResource(g, model.Gateway.MessageName, []proto.Message{gateway})
+	change := convert([]proto.Message{message[0]}, []string{"some-gateway"}, model.Gateway.Collection, model.Gateway.MessageName)
+	err := controller.Apply(change)
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("apply type not supported"))
+	g.Expect(controller.HasSynced()).To(gomega.BeFalse())
+
+	change = convert([]proto.Message{}, []string{}, model.ServiceEntry.Collection, model.ServiceEntry.MessageName)
+	err = controller.Apply(change)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(controller.HasSynced()).To(gomega.BeTrue())
+}
+
 func TestConfigDescriptor(t *testing.T) {
 	g := gomega.NewGomegaWithT(t)
 	controller := coredatamodel.NewController(testControllerOptions)
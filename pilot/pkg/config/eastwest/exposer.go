@@ -0,0 +1,94 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package eastwest exposes services to the other networks of the mesh through the east-west
+// gateway, which replaces the Gateway previously applied by hand for each exposed service.
+package eastwest
+
+import (
+	"sort"
+
+	networking "istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/pilot/pkg/model"
+)
+
+const (
+	// GatewayName is the name of the generated Gateway.
+	GatewayName = "istio-eastwest-exposed-services"
+
+	// DefaultPort is the port of the east-west gateway which receives the traffic of the other
+	// networks.
+	DefaultPort = 15443
+)
+
+// Exposer generates the Gateway exposing the services marked as exposed to the other networks.
+// The gateway passes the mTLS traffic through to the services, routing it by SNI. The sidecars
+// of the other networks send the SNI outbound_.<port>_.<subset>_.<hostname>, so each service is
+// exposed as *.<hostname>.
+type Exposer struct {
+	namespace string
+	selector  map[string]string
+	port      uint32
+}
+
+// NewExposer creates an exposer generating the Gateway in the namespace, for the gateway
+// workloads matching the selector.
+func NewExposer(namespace string, selector map[string]string, port uint32) *Exposer {
+	if port == 0 {
+		port = DefaultPort
+	}
+	return &Exposer{namespace: namespace, selector: selector, port: port}
+}
+
+// Configs returns the Gateway exposing the exposed services, or no config if no service is
+// exposed.
+func (e *Exposer) Configs(services model.ServiceDiscovery) ([]*model.Config, error) {
+	svcs, err := services.Services()
+	if err != nil {
+		return nil, err
+	}
+	var hosts []string
+	for _, svc := range svcs {
+		if svc.Attributes.ExposedToNetworks && !svc.MeshExternal {
+			hosts = append(hosts, "*."+string(svc.Hostname))
+		}
+	}
+	if len(hosts) == 0 {
+		return nil, nil
+	}
+	sort.Strings(hosts)
+
+	return []*model.Config{{
+		ConfigMeta: model.ConfigMeta{
+			Type:      model.Gateway.Type,
+			Group:     model.Gateway.Group,
+			Version:   model.Gateway.Version,
+			Name:      GatewayName,
+			Namespace: e.namespace,
+		},
+		Spec: &networking.Gateway{
+			Selector: e.selector,
+			Servers: []*networking.Server{{
+				Port: &networking.Port{
+					Number:   e.port,
+					Protocol: "TLS",
+					Name:     "tls-eastwest",
+				},
+				Hosts: hosts,
+				Tls:   &networking.Server_TLSOptions{Mode: networking.Server_TLSOptions_AUTO_PASSTHROUGH},
+			}},
+		},
+	}}, nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eastwest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	networking "istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/serviceregistry/memory"
+	"istio.io/istio/pkg/config"
+)
+
+func TestExposerConfigs(t *testing.T) {
+	reviews := memory.MakeService("reviews.bookinfo.svc.cluster.local", "10.0.0.1")
+	reviews.Attributes.ExposedToNetworks = true
+	ratings := memory.MakeService("ratings.bookinfo.svc.cluster.local", "10.0.0.2")
+	ratings.Attributes.ExposedToNetworks = true
+	sleep := memory.MakeService("sleep.default.svc.cluster.local", "10.0.0.3")
+	services := memory.NewDiscovery(map[config.Hostname]*model.Service{
+		reviews.Hostname: reviews,
+		ratings.Hostname: ratings,
+		sleep.Hostname:   sleep,
+	}, 1)
+
+	exposer := NewExposer("istio-system", map[string]string{"istio": "ingressgateway"}, 0)
+	configs, err := exposer.Configs(services)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configs) != 1 {
+		t.Fatalf("Configs() => got %d configs, want 1 Gateway", len(configs))
+	}
+	gw := configs[0]
+	if err := model.Gateway.Validate(gw.Name, gw.Namespace, gw.Spec); err != nil {
+		t.Errorf("Configs() => got an invalid Gateway: %v", err)
+	}
+	server := gw.Spec.(*networking.Gateway).Servers[0]
+	wantHosts := []string{"*.ratings.bookinfo.svc.cluster.local", "*.reviews.bookinfo.svc.cluster.local"}
+	if !reflect.DeepEqual(server.Hosts, wantHosts) || server.Port.Number != DefaultPort ||
+		server.Tls.Mode != networking.Server_TLSOptions_AUTO_PASSTHROUGH {
+		t.Errorf("Configs() => got server %v, want hosts %v on port %d", server, wantHosts, DefaultPort)
+	}
+
+	for _, svc := range []*model.Service{reviews, ratings} {
+		sni := model.BuildDNSSrvSubsetKey(model.TrafficDirectionOutbound, "", svc.Hostname, 9080)
+		if !matchServerNames(server.Hosts, sni) {
+			t.Errorf("Configs() => hosts %v do not match the outbound SNI %s", server.Hosts, sni)
+		}
+	}
+	sni := model.BuildDNSSrvSubsetKey(model.TrafficDirectionOutbound, "v1", sleep.Hostname, 9080)
+	if matchServerNames(server.Hosts, sni) {
+		t.Errorf("Configs() => hosts %v match the SNI %s of a service not exposed", server.Hosts, sni)
+	}
+
+	reviews.Attributes.ExposedToNetworks = false
+	ratings.Attributes.ExposedToNetworks = false
+	if configs, err := exposer.Configs(services); err != nil || len(configs) != 0 {
+		t.Errorf("Configs() without exposed services => got %v, %v", configs, err)
+	}
+}
+
+// matchServerNames matches the SNI against the server names of a filter chain the way Envoy does:
+// exactly, then against the wildcards of the SNI with its leading labels stripped one by one.
+func matchServerNames(serverNames []string, sni string) bool {
+	names := make(map[string]bool, len(serverNames))
+	for _, name := range serverNames {
+		names[name] = true
+	}
+	if names[sni] {
+		return true
+	}
+	for i := strings.Index(sni, "."); i >= 0; {
+		if names["*"+sni[i:]] {
+			return true
+		}
+		next := strings.Index(sni[i+1:], ".")
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return false
+}
@@ -18,6 +18,7 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -37,6 +38,7 @@ import (
 
 	"istio.io/istio/pilot/pkg/config/kube/crd"
 	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/status"
 	kubecfg "istio.io/istio/pkg/kube"
 	"istio.io/pkg/log"
 )
@@ -232,6 +234,11 @@ func (rc *restClient) registerResources() error {
 				},
 			},
 		}
+		if hasStatus(schema.Type) {
+			crd.Spec.Subresources = &apiextensionsv1beta1.CustomResourceSubresources{
+				Status: &apiextensionsv1beta1.CustomResourceSubresourceStatus{},
+			}
+		}
 		log.Infof("registering CRD %q", name)
 		_, err = cs.ApiextensionsV1beta1().CustomResourceDefinitions().Create(crd)
 		if err != nil && !apierrors.IsAlreadyExists(err) {
@@ -421,6 +428,53 @@ func (cl *Client) Update(config model.Config) (string, error) {
 	return obj.GetObjectMeta().ResourceVersion, nil
 }
 
+// UpdateStatus replaces the status of a config resource, using the status subresource.
+func (cl *Client) UpdateStatus(config model.Config, status map[string]interface{}) error {
+	rc, ok := cl.clientset[crd.APIVersionFromConfig(&config)]
+	if !ok {
+		return fmt.Errorf("unrecognized apiVersion %q", config)
+	}
+	schema, exists := rc.descriptor.GetByType(config.Type)
+	if !exists {
+		return fmt.Errorf("unrecognized type %q", config.Type)
+	}
+	if !hasStatus(config.Type) {
+		return fmt.Errorf("type %q has no status", config.Type)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"apiVersion": crd.APIVersionFromConfig(&config),
+		"kind":       crd.KebabCaseToCamelCase(schema.Type),
+		"metadata": map[string]interface{}{
+			"name":            config.Name,
+			"namespace":       config.Namespace,
+			"resourceVersion": config.ResourceVersion,
+		},
+		"status": status,
+	})
+	if err != nil {
+		return err
+	}
+
+	return rc.dynamic.Put().
+		Namespace(config.Namespace).
+		Resource(crd.ResourceName(schema.Plural)).
+		Name(config.Name).
+		SubResource("status").
+		Body(body).
+		Do().Error()
+}
+
+// hasStatus returns true if the status subresource is enabled for the config type.
+func hasStatus(typ string) bool {
+	for _, t := range status.Types {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
 // Delete implements store interface
 func (cl *Client) Delete(typ, name, namespace string) error {
 	s, ok := crd.KnownTypes[typ]
@@ -0,0 +1,89 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"sync"
+
+	"github.com/gogo/protobuf/proto"
+	"k8s.io/client-go/tools/cache"
+
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/serviceregistry/kube"
+)
+
+// configCache caches the configs converted from the objects of an informer, so that Get and
+// List do not convert the objects again on every push. Configs are keyed by namespace/name and
+// converted again when the resource version of their object changes.
+//
+// The cached configs are not returned to the callers of Get and List, which receive copies: the
+// push context modifies the specs of the configs it lists, e.g. when merging destination rules.
+type configCache struct {
+	schema       model.ProtoSchema
+	domainSuffix string
+
+	mu      sync.RWMutex
+	configs map[string]*model.Config
+}
+
+func newConfigCache(schema model.ProtoSchema, domainSuffix string) *configCache {
+	return &configCache{
+		schema:       schema,
+		domainSuffix: domainSuffix,
+		configs:      make(map[string]*model.Config),
+	}
+}
+
+// convert returns the config of the object, converting it only if it changed since it was
+// last converted.
+func (cc *configCache) convert(item crd.IstioObject) (*model.Config, error) {
+	meta := item.GetObjectMeta()
+	key := kube.KeyFunc(meta.Name, meta.Namespace)
+
+	cc.mu.RLock()
+	cached, ok := cc.configs[key]
+	cc.mu.RUnlock()
+	if ok && meta.ResourceVersion != "" && cached.ResourceVersion == meta.ResourceVersion {
+		return cached, nil
+	}
+
+	config, err := crd.ConvertObject(cc.schema, item, cc.domainSuffix)
+	if err != nil {
+		return nil, err
+	}
+	cc.mu.Lock()
+	cc.configs[key] = config
+	cc.mu.Unlock()
+	return config, nil
+}
+
+// delete removes the config of a deleted object, or of the tombstone of a deleted object.
+func (cc *configCache) delete(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+	cc.mu.Lock()
+	delete(cc.configs, key)
+	cc.mu.Unlock()
+}
+
+// copyConfig returns a copy of the cached config, with its own copy of the spec.
+func copyConfig(config *model.Config) model.Config {
+	out := *config
+	out.Spec = proto.Clone(config.Spec)
+	return out
+}
@@ -0,0 +1,63 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"testing"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pilot/pkg/model"
+)
+
+func TestConfigCache(t *testing.T) {
+	cc := newConfigCache(model.VirtualService, "cluster.local")
+	obj := &crd.VirtualService{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "reviews", Namespace: "default", ResourceVersion: "1"},
+		Spec:       map[string]interface{}{"hosts": []interface{}{"reviews"}},
+	}
+
+	first, err := cc.convert(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second, _ := cc.convert(obj); second != first {
+		t.Errorf("convert() of an unchanged object => got a new config, want the cached one")
+	}
+
+	obj.ResourceVersion = "2"
+	obj.Spec = map[string]interface{}{"hosts": []interface{}{"ratings"}}
+	updated, err := cc.convert(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated == first || updated.ResourceVersion != "2" {
+		t.Errorf("convert() of an updated object => got %v, want a new config", updated.ConfigMeta)
+	}
+
+	cc.delete(cache.DeletedFinalStateUnknown{Key: "default/reviews", Obj: obj})
+	if len(cc.configs) != 0 {
+		t.Errorf("delete() => got %d cached configs, want none", len(cc.configs))
+	}
+
+	// Objects without a resource version are always converted.
+	obj.ResourceVersion = ""
+	first, _ = cc.convert(obj)
+	if second, _ := cc.convert(obj); second == first {
+		t.Errorf("convert() of an object without resource version => got the cached config")
+	}
+}
@@ -34,19 +34,22 @@ import (
 	"istio.io/istio/pilot/pkg/monitoring"
 	"istio.io/istio/pilot/pkg/serviceregistry/kube"
 	controller2 "istio.io/istio/pilot/pkg/serviceregistry/kube/controller"
+	"istio.io/istio/pkg/config"
 )
 
 // controller is a collection of synchronized resource watchers.
 // Caches are thread-safe
 type controller struct {
-	client *Client
-	queue  kube.Queue
-	kinds  map[string]cacheHandler
+	client   *Client
+	queue    kube.Queue
+	kinds    map[string]cacheHandler
+	revision string
 }
 
 type cacheHandler struct {
 	informer cache.SharedIndexInformer
 	handler  *kube.ChainHandler
+	configs  *configCache
 }
 
 var (
@@ -83,9 +86,10 @@ func NewController(client *Client, options controller2.Options) model.ConfigStor
 
 	// Queue requires a time duration for a retry delay after a handler error
 	out := &controller{
-		client: client,
-		queue:  kube.NewQueue(1 * time.Second),
-		kinds:  make(map[string]cacheHandler),
+		client:   client,
+		queue:    kube.NewQueue(1 * time.Second),
+		kinds:    make(map[string]cacheHandler),
+		revision: options.Revision,
 	}
 
 	// add stores for CRD kinds
@@ -97,7 +101,7 @@ func NewController(client *Client, options controller2.Options) model.ConfigStor
 }
 
 func (c *controller) addInformer(schema model.ProtoSchema, namespace string, resyncPeriod time.Duration) {
-	c.kinds[schema.Type] = c.createInformer(crd.KnownTypes[schema.Type].Object.DeepCopyObject(), schema.Type, resyncPeriod,
+	ch := c.createInformer(crd.KnownTypes[schema.Type].Object.DeepCopyObject(), schema.Type, resyncPeriod,
 		func(opts meta_v1.ListOptions) (result runtime.Object, err error) {
 			result = crd.KnownTypes[schema.Type].Collection.DeepCopyObject()
 			rc, ok := c.client.clientset[crd.APIVersion(&schema)]
@@ -128,6 +132,9 @@ func (c *controller) addInformer(schema model.ProtoSchema, namespace string, res
 			}
 			return req.Watch()
 		})
+	ch.configs = newConfigCache(schema, c.client.domainSuffix)
+	ch.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{DeleteFunc: ch.configs.delete})
+	c.kinds[schema.Type] = ch
 }
 
 // notify is the first handler in the handler chain.
@@ -143,6 +150,52 @@ func (c *controller) notify(obj interface{}, event model.Event) error {
 	return nil
 }
 
+// inRevision returns true if the object is processed by the revision of the controller.
+func (c *controller) inRevision(obj interface{}) bool {
+	item, ok := obj.(crd.IstioObject)
+	if !ok {
+		// Tombstones of deleted objects are always processed.
+		return true
+	}
+	return config.InRevision(item.GetObjectMeta().Labels, c.revision)
+}
+
+// updateEvent returns the object and event to dispatch for an update from old to cur, along with
+// the name of the event for metrics. An object whose revision label changes moves in or out of the
+// revision of the controller, so handlers see it being added or deleted rather than updated.
+// A nil object means that the update is skipped.
+func (c *controller) updateEvent(old, cur interface{}) (interface{}, model.Event, string) {
+	oldIn, curIn := c.inRevision(old), c.inRevision(cur)
+	switch {
+	case !oldIn && !curIn:
+		return nil, model.EventUpdate, "updateskip"
+	case !oldIn:
+		return cur, model.EventAdd, "add"
+	case !curIn:
+		return old, model.EventDelete, "delete"
+	case reflect.DeepEqual(old, cur) || isStatusUpdate(old, cur):
+		return nil, model.EventUpdate, "updatesame"
+	default:
+		return cur, model.EventUpdate, "update"
+	}
+}
+
+// isStatusUpdate returns true if the update only changed the status of the object, which is
+// not part of the configuration.
+func isStatusUpdate(old, cur interface{}) bool {
+	oldObj, ok := old.(crd.IstioObject)
+	if !ok {
+		return false
+	}
+	curObj, ok := cur.(crd.IstioObject)
+	if !ok {
+		return false
+	}
+	oldMeta, curMeta := oldObj.GetObjectMeta(), curObj.GetObjectMeta()
+	oldMeta.ResourceVersion = curMeta.ResourceVersion
+	return reflect.DeepEqual(oldMeta, curMeta) && reflect.DeepEqual(oldObj.GetSpec(), curObj.GetSpec())
+}
+
 func (c *controller) createInformer(
 	o runtime.Object,
 	otype string,
@@ -152,27 +205,34 @@ func (c *controller) createInformer(
 	handler := &kube.ChainHandler{}
 	handler.Append(c.notify)
 
-	// TODO: finer-grained index (perf)
+	// Objects are indexed by namespace, so that listing a namespace does not scan all objects.
 	informer := cache.NewSharedIndexInformer(
 		&cache.ListWatch{ListFunc: lf, WatchFunc: wf}, o,
-		resyncPeriod, cache.Indexers{})
+		resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
 
 	informer.AddEventHandler(
 		cache.ResourceEventHandlerFuncs{
 			// TODO: filtering functions to skip over un-referenced resources (perf)
 			AddFunc: func(obj interface{}) {
+				if !c.inRevision(obj) {
+					incrementEvent(otype, "addskip")
+					return
+				}
 				incrementEvent(otype, "add")
 				c.queue.Push(kube.NewTask(handler.Apply, obj, model.EventAdd))
 			},
 			UpdateFunc: func(old, cur interface{}) {
-				if !reflect.DeepEqual(old, cur) {
-					incrementEvent(otype, "update")
-					c.queue.Push(kube.NewTask(handler.Apply, cur, model.EventUpdate))
-				} else {
-					incrementEvent(otype, "updatesame")
+				obj, event, name := c.updateEvent(old, cur)
+				incrementEvent(otype, name)
+				if obj != nil {
+					c.queue.Push(kube.NewTask(handler.Apply, obj, event))
 				}
 			},
 			DeleteFunc: func(obj interface{}) {
+				if !c.inRevision(obj) {
+					incrementEvent(otype, "deleteskip")
+					return
+				}
 				incrementEvent(otype, "delete")
 				c.queue.Push(kube.NewTask(handler.Apply, obj, model.EventDelete))
 			},
@@ -190,10 +250,11 @@ func (c *controller) RegisterEventHandler(typ string, f func(model.Config, model
 	if !exists {
 		return
 	}
+	configs := c.kinds[typ].configs
 	c.kinds[typ].handler.Append(func(object interface{}, ev model.Event) error {
 		item, ok := object.(crd.IstioObject)
 		if ok {
-			config, err := crd.ConvertObject(schema, item, c.client.domainSuffix)
+			config, err := configs.convert(item)
 			if err != nil {
 				log.Warnf("error translating object for schema %#v : %v\n Object:\n%#v", schema, err, object)
 			} else {
@@ -233,8 +294,7 @@ func (c *controller) ConfigDescriptor() model.ConfigDescriptor {
 }
 
 func (c *controller) Get(typ, name, namespace string) *model.Config {
-	schema, exists := c.client.ConfigDescriptor().GetByType(typ)
-	if !exists {
+	if _, exists := c.client.ConfigDescriptor().GetByType(typ); !exists {
 		return nil
 	}
 
@@ -253,13 +313,17 @@ func (c *controller) Get(typ, name, namespace string) *model.Config {
 		log.Warn("Cannot convert to config from store")
 		return nil
 	}
+	if !config.InRevision(obj.GetObjectMeta().Labels, c.revision) {
+		return nil
+	}
 
-	config, err := crd.ConvertObject(schema, obj, c.client.domainSuffix)
+	config, err := c.kinds[typ].configs.convert(obj)
 	if err != nil {
 		return nil
 	}
 
-	return config
+	out := copyConfig(config)
+	return &out
 }
 
 func (c *controller) Create(config model.Config) (string, error) {
@@ -275,11 +339,21 @@ func (c *controller) Delete(typ, name, namespace string) error {
 }
 
 func (c *controller) List(typ, namespace string) ([]model.Config, error) {
-	schema, ok := c.client.ConfigDescriptor().GetByType(typ)
-	if !ok {
+	if _, ok := c.client.ConfigDescriptor().GetByType(typ); !ok {
 		return nil, fmt.Errorf("missing type %q", typ)
 	}
 
+	informer := c.kinds[typ].informer
+	var items []interface{}
+	if namespace == "" {
+		items = informer.GetStore().List()
+	} else {
+		var err error
+		if items, err = informer.GetIndexer().ByIndex(cache.NamespaceIndex, namespace); err != nil {
+			return nil, err
+		}
+	}
+
 	var newErrors sync.Map
 	var errs error
 	out := make([]model.Config, 0)
@@ -290,17 +364,17 @@ func (c *controller) List(typ, namespace string) ([]model.Config, error) {
 			return true
 		})
 	}
-	for _, data := range c.kinds[typ].informer.GetStore().List() {
+	for _, data := range items {
 		item, ok := data.(crd.IstioObject)
 		if !ok {
 			continue
 		}
 
-		if namespace != "" && namespace != item.GetObjectMeta().Namespace {
+		if !config.InRevision(item.GetObjectMeta().Labels, c.revision) {
 			continue
 		}
 
-		config, err := crd.ConvertObject(schema, item, c.client.domainSuffix)
+		config, err := c.kinds[typ].configs.convert(item)
 		if err != nil {
 			key := item.GetObjectMeta().Namespace + "/" + item.GetObjectMeta().Name
 			log.Errorf("Failed to convert %s object, ignoring: %s %v %v", typ, key, err, item.GetSpec())
@@ -310,7 +384,7 @@ func (c *controller) List(typ, namespace string) ([]model.Config, error) {
 			newErrors.Store(key, err)
 			k8sErrors.With(nameTag.Value(key)).Record(1)
 		} else {
-			out = append(out, *config)
+			out = append(out, copyConfig(config))
 		}
 	}
 	InvalidCRDs.Store(&newErrors)
@@ -0,0 +1,157 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"testing"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	networking "istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config"
+)
+
+func TestUpdateEvent(t *testing.T) {
+	c := &controller{revision: "canary"}
+	object := func(revision, version string) *crd.VirtualService {
+		obj := &crd.VirtualService{
+			ObjectMeta: meta_v1.ObjectMeta{Name: "reviews", Namespace: "default", ResourceVersion: version},
+			Spec:       map[string]interface{}{"hosts": []interface{}{"reviews"}},
+		}
+		if revision != "" {
+			obj.Labels = map[string]string{config.RevisionLabel: revision}
+		}
+		return obj
+	}
+
+	stable, canary, unlabeled := object("stable", "2"), object("canary", "2"), object("", "2")
+	changed := object("canary", "2")
+	changed.Spec = map[string]interface{}{"hosts": []interface{}{"ratings"}}
+
+	cases := []struct {
+		name      string
+		old, cur  *crd.VirtualService
+		wantObj   *crd.VirtualService
+		wantEvent model.Event
+		wantName  string
+	}{
+		{
+			name:     "outside the revision",
+			old:      object("", "1"),
+			cur:      stable,
+			wantName: "updateskip",
+		},
+		{
+			name:      "moved into the revision",
+			old:       object("stable", "1"),
+			cur:       canary,
+			wantObj:   canary,
+			wantEvent: model.EventAdd,
+			wantName:  "add",
+		},
+		{
+			name:      "moved out of the revision",
+			old:       canary,
+			cur:       unlabeled,
+			wantObj:   canary,
+			wantEvent: model.EventDelete,
+			wantName:  "delete",
+		},
+		{
+			name:     "unchanged within the revision",
+			old:      object("canary", "1"),
+			cur:      canary,
+			wantName: "updatesame",
+		},
+		{
+			name:      "updated within the revision",
+			old:       object("canary", "1"),
+			cur:       changed,
+			wantObj:   changed,
+			wantEvent: model.EventUpdate,
+			wantName:  "update",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			obj, event, name := c.updateEvent(tc.old, tc.cur)
+			if name != tc.wantName {
+				t.Errorf("updateEvent() => got event name %q, want %q", name, tc.wantName)
+			}
+			if tc.wantObj == nil {
+				if obj != nil {
+					t.Errorf("updateEvent() => got object %v, want the update skipped", obj)
+				}
+				return
+			}
+			if obj != tc.wantObj || event != tc.wantEvent {
+				t.Errorf("updateEvent() => got %v %v, want %v %v", event, obj, tc.wantEvent, tc.wantObj)
+			}
+		})
+	}
+}
+
+func TestListReturnsCopies(t *testing.T) {
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &crd.DestinationRule{}, 0,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	c := &controller{
+		client: &Client{clientset: map[string]*restClient{
+			"networking.istio.io/v1alpha3": {descriptor: model.ConfigDescriptor{model.DestinationRule}},
+		}},
+		kinds: map[string]cacheHandler{
+			model.DestinationRule.Type: {informer: informer, configs: newConfigCache(model.DestinationRule, "cluster.local")},
+		},
+	}
+	for i, subset := range []string{"v1", "v2"} {
+		if err := informer.GetIndexer().Add(&crd.DestinationRule{
+			ObjectMeta: meta_v1.ObjectMeta{Name: "reviews-" + subset, Namespace: "default", ResourceVersion: "1",
+				CreationTimestamp: meta_v1.Unix(int64(i), 0)},
+			Spec: map[string]interface{}{
+				"host":    "reviews",
+				"subsets": []interface{}{map[string]interface{}{"name": subset}},
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	before, err := c.List(model.DestinationRule.Type, "default")
+	if err != nil || len(before) != 2 {
+		t.Fatalf("List() => got %d configs, %v, want 2", len(before), err)
+	}
+	// the push context resolves the hosts and merges the subsets of the rules in place
+	push := model.NewPushContext()
+	push.SetDestinationRules(before)
+
+	after, err := c.List(model.DestinationRule.Type, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, config := range after {
+		rule := config.Spec.(*networking.DestinationRule)
+		if rule.Host != "reviews" || len(rule.Subsets) != 1 {
+			t.Errorf("List() after a push => got %s with host %s and %d subsets, want the unchanged spec",
+				config.Name, rule.Host, len(rule.Subsets))
+		}
+	}
+	if got := c.Get(model.DestinationRule.Type, "reviews-v1", "default"); got.Spec.(*networking.DestinationRule).Host != "reviews" {
+		t.Errorf("Get() after a push => got host %s, want the unchanged spec", got.Spec.(*networking.DestinationRule).Host)
+	}
+}
@@ -50,6 +50,7 @@ func ConvertObject(schema model.ProtoSchema, object IstioObject, domain string)
 			Labels:            meta.Labels,
 			Annotations:       meta.Annotations,
 			ResourceVersion:   meta.ResourceVersion,
+			Generation:        meta.Generation,
 			CreationTimestamp: meta.CreationTimestamp.Time,
 		},
 		Spec: data,
@@ -75,6 +76,7 @@ func ConvertObjectFromUnstructured(schema model.ProtoSchema, un *unstructured.Un
 			Labels:            un.GetLabels(),
 			Annotations:       un.GetAnnotations(),
 			ResourceVersion:   un.GetResourceVersion(),
+			Generation:        un.GetGeneration(),
 			CreationTimestamp: un.GetCreationTimestamp().Time,
 		},
 		Spec: data,
@@ -105,6 +105,16 @@ var KnownTypes = map[string]SchemaType{
 		},
 		Collection: &SidecarList{},
 	},
+	model.ProxyConfig.Type: {
+		Schema: model.ProxyConfig,
+		Object: &ProxyConfig{
+			TypeMeta: meta_v1.TypeMeta{
+				Kind:       "ProxyConfig",
+				APIVersion: APIVersion(&model.ProxyConfig),
+			},
+		},
+		Collection: &ProxyConfigList{},
+	},
 	model.HTTPAPISpec.Type: {
 		Schema: model.HTTPAPISpec,
 		Object: &HTTPAPISpec{
@@ -938,6 +948,109 @@ func (in *SidecarList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// ProxyConfig is the generic Kubernetes API Object wrapper
+type ProxyConfig struct {
+	meta_v1.TypeMeta   `json:",inline"`
+	meta_v1.ObjectMeta `json:"metadata"`
+	Spec               map[string]interface{} `json:"spec"`
+}
+
+// GetSpec from a wrapper
+func (in *ProxyConfig) GetSpec() map[string]interface{} {
+	return in.Spec
+}
+
+// SetSpec for a wrapper
+func (in *ProxyConfig) SetSpec(spec map[string]interface{}) {
+	in.Spec = spec
+}
+
+// GetObjectMeta from a wrapper
+func (in *ProxyConfig) GetObjectMeta() meta_v1.ObjectMeta {
+	return in.ObjectMeta
+}
+
+// SetObjectMeta for a wrapper
+func (in *ProxyConfig) SetObjectMeta(metadata meta_v1.ObjectMeta) {
+	in.ObjectMeta = metadata
+}
+
+// ProxyConfigList is the generic Kubernetes API list wrapper
+type ProxyConfigList struct {
+	meta_v1.TypeMeta `json:",inline"`
+	meta_v1.ListMeta `json:"metadata"`
+	Items            []ProxyConfig `json:"items"`
+}
+
+// GetItems from a wrapper
+func (in *ProxyConfigList) GetItems() []IstioObject {
+	out := make([]IstioObject, len(in.Items))
+	for i := range in.Items {
+		out[i] = &in.Items[i]
+	}
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProxyConfig) DeepCopyInto(out *ProxyConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxyConfig.
+func (in *ProxyConfig) DeepCopy() *ProxyConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ProxyConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ProxyConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProxyConfigList) DeepCopyInto(out *ProxyConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ProxyConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxyConfigList.
+func (in *ProxyConfigList) DeepCopy() *ProxyConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(ProxyConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ProxyConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+
+	return nil
+}
+
 // HTTPAPISpec is the generic Kubernetes API Object wrapper
 type HTTPAPISpec struct {
 	meta_v1.TypeMeta   `json:",inline"`
@@ -19,9 +19,14 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
 
 	"istio.io/istio/pilot/pkg/config/kube/crd"
 	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/monitoring"
 	"istio.io/pkg/log"
 )
 
@@ -30,13 +35,39 @@ var (
 		".yaml": true,
 		".yml":  true,
 	}
+
+	fileTag = monitoring.MustCreateTag("file")
+
+	fileParseErrors = monitoring.NewGauge(
+		"pilot_file_config_parse_errors",
+		"Whether the last read of a config file failed (1) or succeeded (0).",
+		fileTag,
+	)
 )
 
+func init() {
+	monitoring.MustRegisterViews(fileParseErrors)
+}
+
 // FileSnapshot holds a reference to a file directory that contains crd
 // config and filter criteria for which of those configs will be parsed.
 type FileSnapshot struct {
 	root             string
 	configTypeFilter map[string]bool
+
+	mu sync.Mutex
+	// files caches the configs parsed from each file, so that only changed files are read.
+	files map[string]*fileState
+}
+
+// fileState holds the configs last parsed from a file.
+type fileState struct {
+	modTime time.Time
+	size    int64
+	configs []*model.Config
+	// failed is set when the last read of the file failed, in which case configs holds
+	// the configs of the last successful read.
+	failed bool
 }
 
 // NewFileSnapshot returns a snapshotter.
@@ -45,6 +76,7 @@ func NewFileSnapshot(root string, descriptor model.ConfigDescriptor) *FileSnapsh
 	snapshot := &FileSnapshot{
 		root:             root,
 		configTypeFilter: make(map[string]bool),
+		files:            make(map[string]*fileState),
 	}
 
 	types := descriptor.Types()
@@ -63,8 +95,15 @@ func NewFileSnapshot(root string, descriptor model.ConfigDescriptor) *FileSnapsh
 
 // ReadConfigFiles parses files in the root directory and returns a sorted slice of
 // eligible model.Config. This can be used as a configFunc when creating a Monitor.
+// Only files that changed since the previous call are parsed. A file that fails to
+// parse is reported and keeps contributing the configs of its last successful read,
+// without affecting the other files.
 func (f *FileSnapshot) ReadConfigFiles() ([]*model.Config, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
 	var result []*model.Config
+	seen := make(map[string]bool, len(f.files))
 
 	err := filepath.Walk(f.root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -72,33 +111,125 @@ func (f *FileSnapshot) ReadConfigFiles() ([]*model.Config, error) {
 		} else if !supportedExtensions[filepath.Ext(path)] || (info.Mode()&os.ModeType) != 0 {
 			return nil
 		}
-		data, err := ioutil.ReadFile(path)
-		if err != nil {
-			log.Warnf("Failed to read %s: %v", path, err)
-			return err
-		}
-		configs, err := parseInputs(data)
-		if err != nil {
-			log.Warnf("Failed to parse %s: %v", path, err)
-			return err
-		}
+		seen[path] = true
+		state := f.readFile(path, info)
 
 		// Filter any unsupported types before appending to the result.
-		for _, cfg := range configs {
+		for _, cfg := range state.configs {
 			if !f.configTypeFilter[cfg.Type] {
 				continue
 			}
-			result = append(result, cfg)
+			// Copy the config so that callers do not modify the cache.
+			cpy := *cfg
+			result = append(result, &cpy)
 		}
 		return nil
 	})
 	if err != nil {
 		log.Warnf("failure during filepath.Walk: %v", err)
+		return nil, err
+	}
+
+	for path, state := range f.files {
+		if !seen[path] {
+			if state.failed {
+				fileParseErrors.With(fileTag.Value(path)).Record(0)
+			}
+			delete(f.files, path)
+		}
 	}
 
 	// Sort by the config IDs.
 	sort.Sort(byKey(result))
-	return result, err
+	return result, nil
+}
+
+// readFile returns the state of the file, parsing it only if it changed since it was last read.
+func (f *FileSnapshot) readFile(path string, info os.FileInfo) *fileState {
+	state, ok := f.files[path]
+	if ok && state.modTime.Equal(info.ModTime()) && state.size == info.Size() {
+		return state
+	}
+	if !ok {
+		state = &fileState{}
+		f.files[path] = state
+	}
+	state.modTime = info.ModTime()
+	state.size = info.Size()
+
+	data, err := ioutil.ReadFile(path)
+	if err == nil {
+		var configs []*model.Config
+		if configs, err = parseInputs(data); err == nil {
+			state.configs = configs
+		}
+	}
+	if err != nil {
+		log.Warnf("Failed to read %s, keeping its previous configs: %v", path, err)
+		fileParseErrors.With(fileTag.Value(path)).Record(1)
+		state.failed = true
+		return state
+	}
+	if state.failed {
+		fileParseErrors.With(fileTag.Value(path)).Record(0)
+		state.failed = false
+	}
+	return state
+}
+
+// Watch watches the root directory and its subdirectories, calling notify whenever a file or
+// directory below the root is created, written, removed or renamed, until the stop channel is
+// closed. Directories created after the watch started are watched as well.
+func (f *FileSnapshot) Watch(stop <-chan struct{}, notify func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := addWatches(watcher, f.root); err != nil {
+		_ = watcher.Close()
+		return err
+	}
+
+	go func() {
+		defer watcher.Close() // nolint: errcheck
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op&fsnotify.Create != 0 {
+					if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+						if err := addWatches(watcher, event.Name); err != nil {
+							log.Warnf("Failed to watch %s: %v", event.Name, err)
+						}
+					}
+				}
+				notify()
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Warnf("Error watching %s: %v", f.root, err)
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// addWatches adds a watch for the directory and each of its subdirectories.
+func addWatches(watcher *fsnotify.Watcher, root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return watcher.Add(path)
+		}
+		return nil
+	})
 }
 
 // parseInputs is identical to crd.ParseInputs, except that it returns an array of config pointers.
@@ -19,6 +19,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/onsi/gomega"
 
@@ -124,6 +125,87 @@ func TestFileSnapshotSorting(t *testing.T) {
 	g.Expect(configs[1].Spec).To(gomega.BeAssignableToTypeOf(&networking.VirtualService{}))
 }
 
+func TestFileSnapshotParseErrorIsolated(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	ts := &testState{
+		ConfigFiles: map[string][]byte{
+			"gateway.yml":         []byte(gatewayYAML),
+			"virtual_service.yml": []byte(virtualServiceYAML),
+		},
+	}
+	ts.testSetup(t)
+	defer ts.testTeardown(t)
+
+	fileWatcher := monitor.NewFileSnapshot(ts.rootPath, model.ConfigDescriptor{})
+	configs, err := fileWatcher.ReadConfigFiles()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(configs).To(gomega.HaveLen(2))
+
+	// A broken file keeps its previous configs and does not affect the other files.
+	writeFile(t, filepath.Join(ts.rootPath, "virtual_service.yml"), "kind: VirtualService\n\tbroken")
+	writeFile(t, filepath.Join(ts.rootPath, "invalid.yml"), "kind: Gateway\n\tbroken")
+	configs, err = fileWatcher.ReadConfigFiles()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(configs).To(gomega.HaveLen(2))
+	g.Expect(configs[1].Type).To(gomega.Equal(model.VirtualService.Type))
+
+	// Deleted files no longer contribute configs.
+	if err := os.Remove(filepath.Join(ts.rootPath, "virtual_service.yml")); err != nil {
+		t.Fatal(err)
+	}
+	configs, err = fileWatcher.ReadConfigFiles()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(configs).To(gomega.HaveLen(1))
+	g.Expect(configs[0].Type).To(gomega.Equal(model.Gateway.Type))
+}
+
+func TestFileSnapshotWatch(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	ts := &testState{
+		ConfigFiles: map[string][]byte{
+			"gateway.yml": []byte(gatewayYAML),
+		},
+	}
+	ts.testSetup(t)
+	defer ts.testTeardown(t)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	notified := make(chan struct{}, 100)
+	fileWatcher := monitor.NewFileSnapshot(ts.rootPath, model.ConfigDescriptor{})
+	err := fileWatcher.Watch(stop, func() { notified <- struct{}{} })
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// Files in directories created after the watch started are read as well.
+	nested := filepath.Join(ts.rootPath, "nested")
+	if err := os.Mkdir(nested, 0700); err != nil {
+		t.Fatal(err)
+	}
+	g.Eventually(notified, time.Second).Should(gomega.Receive())
+	g.Eventually(func() error {
+		writeFile(t, filepath.Join(nested, "virtual_service.yml"), virtualServiceYAML)
+		select {
+		case <-notified:
+			return nil
+		case <-time.After(100 * time.Millisecond):
+			return os.ErrNotExist
+		}
+	}, time.Second).Should(gomega.Succeed())
+
+	configs, err := fileWatcher.ReadConfigFiles()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(configs).To(gomega.HaveLen(2))
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
 type testState struct {
 	ConfigFiles map[string][]byte
 	rootPath    string
@@ -33,6 +33,8 @@ type Monitor struct {
 	checkDuration   time.Duration
 	configs         []*model.Config
 	getSnapshotFunc func() ([]*model.Config, error)
+	// updateCh triggers a check outside of the polling interval.
+	updateCh chan struct{}
 }
 
 // NewMonitor creates a Monitor and will delegate to a passed in controller.
@@ -44,13 +46,15 @@ func NewMonitor(name string, delegateStore model.ConfigStore, checkInterval time
 		store:           delegateStore,
 		getSnapshotFunc: getSnapshotFunc,
 		checkDuration:   checkInterval,
+		updateCh:        make(chan struct{}, 1),
 	}
 	return monitor
 }
 
 // Start starts a new Monitor. Immediately checks the Monitor getSnapshotFunc
 // and updates the controller. It then kicks off an asynchronous event loop that
-// periodically polls the getSnapshotFunc for changes until a close event is sent.
+// periodically polls the getSnapshotFunc for changes, or checks it as soon as a
+// change is signaled with ScheduleCheck, until a close event is sent.
 func (m *Monitor) Start(stop <-chan struct{}) {
 	m.checkAndUpdate()
 	tick := time.NewTicker(m.checkDuration)
@@ -64,11 +68,22 @@ func (m *Monitor) Start(stop <-chan struct{}) {
 				return
 			case <-tick.C:
 				m.checkAndUpdate()
+			case <-m.updateCh:
+				m.checkAndUpdate()
 			}
 		}
 	}()
 }
 
+// ScheduleCheck signals the Monitor to check the getSnapshotFunc without waiting for the
+// next poll. Signals received while a check is pending are coalesced.
+func (m *Monitor) ScheduleCheck() {
+	select {
+	case m.updateCh <- struct{}{}:
+	default:
+	}
+}
+
 func (m *Monitor) checkAndUpdate() {
 	newConfigs, err := m.getSnapshotFunc()
 	//If an error exists then log it and return to running the check and update
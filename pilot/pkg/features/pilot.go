@@ -15,6 +15,7 @@
 package features
 
 import (
+	"math"
 	"strconv"
 	"time"
 
@@ -35,6 +36,16 @@ var (
 	// Default is 100000.
 	MaxConcurrentStreams = env.RegisterIntVar("ISTIO_GPRC_MAXSTREAMS", 100000, "").Get()
 
+	// MaxRecvMsgSize is the max size in bytes of the messages received by the pilot grpc servers.
+	// Default is 4MB, as in grpc.
+	MaxRecvMsgSize = env.RegisterIntVar("PILOT_GRPC_MAX_RECV_MSG_SIZE", 4*1024*1024,
+		"Max size in bytes of the messages received by the pilot grpc servers.").Get()
+
+	// MaxSendMsgSize is the max size in bytes of the messages sent by the pilot grpc servers.
+	// Default is unlimited (math.MaxInt32), as in grpc.
+	MaxSendMsgSize = env.RegisterIntVar("PILOT_GRPC_MAX_SEND_MSG_SIZE", math.MaxInt32,
+		"Max size in bytes of the messages sent by the pilot grpc servers.").Get()
+
 	// TraceSampling sets mesh-wide trace sampling
 	// percentage, should be 0.0 - 100.0 Precision to 0.01
 	// Default is 100%, not recommended for production use.
@@ -69,6 +80,27 @@ var (
 	// Default is 10s, Example: "300ms", "10s" or "2h45m".
 	DebounceMax = env.RegisterDurationVar("PILOT_DEBOUNCE_MAX", 10*time.Second, "").Get()
 
+	// EnableEDSDebounce debounces the endpoint updates like the config updates. If disabled, the
+	// endpoint updates are pushed right away, and do not delay the config pushes debounced with them.
+	// One EDS push runs at a time, and the updates received meanwhile are coalesced into the next.
+	EnableEDSDebounce = env.RegisterBoolVar(
+		"PILOT_ENABLE_EDS_DEBOUNCE",
+		true,
+		"If enabled, Pilot will include EDS pushes in the push debouncing, configured by PILOT_DEBOUNCE_AFTER and "+
+			"PILOT_DEBOUNCE_MAX. EDS pushes may be delayed, but there will be fewer pushes. Disabled, the EDS "+
+			"pushes are sent as soon as the endpoints change, one at a time: the changes made during a push are "+
+			"sent together by the next one.",
+	).Get()
+
+	// PushQueueFairness pushes the proxies of the namespaces in turn, so that the churn of a
+	// namespace with many proxies does not delay the pushes to the other namespaces.
+	PushQueueFairness = env.RegisterBoolVar(
+		"PILOT_PUSH_QUEUE_FAIRNESS",
+		false,
+		"If enabled, the proxies pending a push are dequeued one namespace at a time in turn, instead of in the order "+
+			"they were enqueued.",
+	).Get()
+
 	// BaseDir is the base directory for locating configs.
 	// File based certificates are located under $BaseDir/etc/certs/. If not set, the original 1.0 locations will
 	// be used, "/"
@@ -139,6 +171,56 @@ var (
 		false,
 		"EnableMysqlFilter enables injection of `envoy.filters.network.mysql_proxy` in the filter chain.")
 
+	// EnableDualStack enables dual-stack listener and cluster generation for proxies that have
+	// both ipv4 and ipv6 addresses.
+	EnableDualStack = enableDualStack.Get
+	enableDualStack = env.RegisterBoolVar(
+		"PILOT_ENABLE_DUAL_STACK",
+		false,
+		"EnableDualStack enables dual-stack listeners and clusters for proxies with both ipv4 and ipv6 addresses.")
+
+	// EnableTLSModeFiltering adapts Istio mutual TLS to the TLS capability of the endpoints: plaintext
+	// endpoints are removed from the clusters using Istio mutual TLS, and the clusters without TLS
+	// settings whose endpoints all accept Istio mutual TLS use it. The endpoints must accept it
+	// with a permissive or strict authentication policy.
+	EnableTLSModeFiltering = enableTLSModeFiltering.Get
+	enableTLSModeFiltering = env.RegisterBoolVar(
+		"PILOT_ENABLE_TLS_MODE_FILTERING",
+		false,
+		"EnableTLSModeFiltering adapts Istio mutual TLS to the TLS capability of the endpoints.")
+
+	// EnableStatus enables writing the distribution status and the Reconciled condition of
+	// VirtualServices, DestinationRules, Gateways and Sidecars to their status field. Requires the
+	// status subresource on the resource definitions.
+	EnableStatus = enableStatus.Get
+	enableStatus = env.RegisterBoolVar(
+		"PILOT_ENABLE_STATUS",
+		false,
+		"EnableStatus enables writing the config distribution status to the status of Istio resources.")
+
+	// StatusUpdateInterval is the interval between updates of the config distribution status.
+	StatusUpdateInterval = env.RegisterDurationVar(
+		"PILOT_STATUS_UPDATE_INTERVAL",
+		10*time.Second,
+		"Interval between updates of the config distribution status.").Get()
+
+	// EnableDeltaXDS enables the incremental variant of ADS, which only sends the clusters,
+	// endpoints, listeners and routes that changed. The proxies using state of the world ADS are
+	// not affected.
+	EnableDeltaXDS = enableDeltaXDS.Get
+	enableDeltaXDS = env.RegisterBoolVar(
+		"PILOT_ENABLE_DELTA_XDS",
+		false,
+		"EnableDeltaXDS enables the incremental variant of ADS.")
+
+	// EnableXDSCache enables the cache of the clusters and routes generated for the groups of
+	// proxies having the same inputs to the generation, e.g. the replicas of a deployment.
+	EnableXDSCache = enableXDSCache.Get
+	enableXDSCache = env.RegisterBoolVar(
+		"PILOT_ENABLE_XDS_CACHE",
+		true,
+		"EnableXDSCache enables the cache of the clusters and routes generated for identical proxies.")
+
 	// EnableRedisFilter enables injection of `envoy.filters.network.redis_proxy` in the filter chain.
 	// Pilot injects this outbound filter if the service port name is `redis`.
 	EnableRedisFilter = enableRedisFilter.Get
@@ -0,0 +1,111 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package federation
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sort"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/spiffe"
+)
+
+// Exporter serves the snapshot of the mesh to its peers.
+type Exporter struct {
+	services        model.ServiceDiscovery
+	hosts           []config.Hostname
+	gateways        []Gateway
+	trustBundleFile string
+	trustDomain     string
+}
+
+// NewExporter creates an exporter of the services matching the hosts, which may be wildcards,
+// e.g. "*.bookinfo.svc.cluster.local". The trust bundle is read from the file on every request,
+// so that a rotated root certificate is exported.
+func NewExporter(services model.ServiceDiscovery, hosts []string, gateways []Gateway, trustBundleFile string) *Exporter {
+	e := &Exporter{
+		services:        services,
+		gateways:        gateways,
+		trustBundleFile: trustBundleFile,
+		trustDomain:     spiffe.GetTrustDomain(),
+	}
+	for _, h := range hosts {
+		e.hosts = append(e.hosts, config.Hostname(h))
+	}
+	return e
+}
+
+// Snapshot returns the current snapshot of the mesh.
+func (e *Exporter) Snapshot() (*MeshSnapshot, error) {
+	services, err := e.services.Services()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &MeshSnapshot{Services: []Service{}, Gateways: e.gateways}
+	for _, svc := range services {
+		if svc.MeshExternal || !e.exported(svc.Hostname) {
+			continue
+		}
+		exported := Service{Hostname: string(svc.Hostname)}
+		for _, p := range svc.Ports {
+			exported.Ports = append(exported.Ports, Port{Name: p.Name, Number: uint32(p.Port), Protocol: string(p.Protocol)})
+		}
+		snapshot.Services = append(snapshot.Services, exported)
+	}
+	sort.Slice(snapshot.Services, func(i, j int) bool { return snapshot.Services[i].Hostname < snapshot.Services[j].Hostname })
+
+	if e.trustBundleFile != "" {
+		bundle, err := ioutil.ReadFile(e.trustBundleFile)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.TrustBundle = string(bundle)
+		snapshot.TrustDomain = e.trustDomain
+	}
+	return snapshot, nil
+}
+
+func (e *Exporter) exported(hostname config.Hostname) bool {
+	for _, h := range e.hosts {
+		if hostname.SubsetOf(h) {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeHTTP serves the snapshot as JSON.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	snapshot, err := e.Snapshot()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	b, err := json.Marshal(snapshot)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
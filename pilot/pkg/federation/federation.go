@@ -0,0 +1,84 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package federation lets meshes share services and trust. A mesh exports a snapshot of
+// selected services, of the gateways by which other meshes reach them, and of its root
+// certificate. Peer meshes poll the snapshot over mTLS and import the services as ServiceEntries
+// routed to the gateways, so that no YAML is synchronized by hand. Trust is also federated with
+// SPIFFE trust domains: the root certificate of the mesh is served as a SPIFFE bundle, and the
+// bundles of the federated trust domains are fetched from their bundle endpoints.
+package federation
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+)
+
+const (
+	// Path is the path of the federation endpoint on the federation port of pilot.
+	Path = "/federation/v1/mesh"
+
+	// PeerLabel is set on the imported ServiceEntries to the name of the mesh that exported them.
+	PeerLabel = "federation.istio.io/peer"
+)
+
+// MeshSnapshot is the state of a mesh exported to its peers.
+type MeshSnapshot struct {
+	// Services are the exported services.
+	Services []Service `json:"services"`
+	// Gateways are the addresses by which the peers reach the exported services.
+	Gateways []Gateway `json:"gateways"`
+	// TrustBundle holds the PEM root certificates of the mesh.
+	TrustBundle string `json:"trustBundle,omitempty"`
+	// TrustDomain is the trust domain of the mesh, whose identities the trust bundle verifies.
+	TrustDomain string `json:"trustDomain,omitempty"`
+}
+
+// Service is an exported service.
+type Service struct {
+	Hostname string `json:"hostname"`
+	Ports    []Port `json:"ports"`
+}
+
+// Port is a port of an exported service.
+type Port struct {
+	Name     string `json:"name"`
+	Number   uint32 `json:"number"`
+	Protocol string `json:"protocol"`
+}
+
+// Gateway is the address of a gateway of the mesh, which routes the traffic of the peers to the
+// exported services based on SNI.
+type Gateway struct {
+	Address string `json:"address"`
+	Port    uint32 `json:"port"`
+}
+
+// ParseGateways parses gateway addresses given as host:port.
+func ParseGateways(addresses []string) ([]Gateway, error) {
+	gateways := make([]Gateway, 0, len(addresses))
+	for _, addr := range addresses {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gateway address %q: %v", addr, err)
+		}
+		p, err := strconv.ParseUint(port, 10, 16)
+		if err != nil || p == 0 {
+			return nil, fmt.Errorf("invalid gateway port in %q", addr)
+		}
+		gateways = append(gateways, Gateway{Address: host, Port: uint32(p)})
+	}
+	return gateways, nil
+}
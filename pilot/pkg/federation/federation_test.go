@@ -0,0 +1,154 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package federation
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	networking "istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/serviceregistry/memory"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/spiffe"
+)
+
+func TestParseGateways(t *testing.T) {
+	got, err := ParseGateways([]string{"1.2.3.4:15443", "gateway.example.com:443"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Gateway{{Address: "1.2.3.4", Port: 15443}, {Address: "gateway.example.com", Port: 443}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseGateways() => got %v, want %v", got, want)
+	}
+
+	for _, addr := range []string{"1.2.3.4", "1.2.3.4:0", "1.2.3.4:http"} {
+		if _, err := ParseGateways([]string{addr}); err == nil {
+			t.Errorf("ParseGateways(%q) => got no error", addr)
+		}
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "federation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	rootCert := filepath.Join(dir, "root-cert.pem")
+	if err := ioutil.WriteFile(rootCert, []byte("root"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	external := memory.MakeService("api.example.com", "10.0.0.3")
+	external.MeshExternal = true
+	services := memory.NewDiscovery(map[config.Hostname]*model.Service{
+		"reviews.bookinfo.svc.cluster.local": memory.MakeService("reviews.bookinfo.svc.cluster.local", "10.0.0.1"),
+		"sleep.default.svc.cluster.local":    memory.MakeService("sleep.default.svc.cluster.local", "10.0.0.2"),
+		"api.example.com":                    external,
+	}, 1)
+	exporter := NewExporter(services, []string{"*.bookinfo.svc.cluster.local", "api.example.com"},
+		[]Gateway{{Address: "1.2.3.4", Port: 15443}}, rootCert)
+	exporter.trustDomain = "peer.example.org"
+
+	server := httptest.NewTLSServer(exporter)
+	defer server.Close()
+	caPool := x509.NewCertPool()
+	caPool.AddCert(server.Certificate())
+
+	writer := fakeTrustBundleWriter{}
+	importer, err := NewImporter(server.URL, "istio-system", writer, func() (*tls.Config, error) {
+		return &tls.Config{RootCAs: caPool}, nil
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configs, err := importer.Configs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configs) != 2 {
+		t.Fatalf("Configs() => got %d configs, want the reviews DestinationRule and ServiceEntry", len(configs))
+	}
+	dr, se := configs[0], configs[1]
+	if err := model.DestinationRule.Validate(dr.Name, dr.Namespace, dr.Spec); err != nil {
+		t.Errorf("Configs() => got an invalid DestinationRule: %v", err)
+	}
+	if drSpec := dr.Spec.(*networking.DestinationRule); drSpec.Host != "reviews.bookinfo.svc.cluster.local" ||
+		drSpec.TrafficPolicy.Tls.Mode != networking.TLSSettings_ISTIO_MUTUAL || dr.Labels[PeerLabel] != importer.Peer() {
+		t.Errorf("Configs() => got DestinationRule %v, want ISTIO_MUTUAL to the reviews service", drSpec)
+	}
+	if se.Labels[PeerLabel] != importer.Peer() || se.Name != "federation-"+importer.Peer()+"-reviews-bookinfo-svc-cluster-local" {
+		t.Errorf("Configs() => got %s with labels %v", se.Name, se.Labels)
+	}
+	if err := model.ServiceEntry.Validate(se.Name, se.Namespace, se.Spec); err != nil {
+		t.Errorf("Configs() => got an invalid ServiceEntry: %v", err)
+	}
+	spec := se.Spec.(*networking.ServiceEntry)
+	if spec.Hosts[0] != "reviews.bookinfo.svc.cluster.local" || spec.Resolution != networking.ServiceEntry_STATIC ||
+		len(spec.Ports) != 6 || len(spec.Endpoints) != 1 || spec.Endpoints[0].Ports["http"] != 15443 {
+		t.Errorf("Configs() => got %v", spec)
+	}
+
+	if bundle := writer["peer.example.org"]; bundle != "root" {
+		t.Errorf("got trust bundle %q, want the root certificate of the peer in its trust domain", bundle)
+	}
+
+	// The roots of a peer claiming the local trust domain are not trusted.
+	exporter.trustDomain = spiffe.GetTrustDomain()
+	writer = fakeTrustBundleWriter{}
+	importer, err = NewImporter(server.URL, "istio-system", writer, func() (*tls.Config, error) {
+		return &tls.Config{RootCAs: caPool}, nil
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if configs, err := importer.Configs(); err != nil || len(configs) != 2 {
+		t.Fatalf("Configs() => got %d configs (%v), want the configs of the peer", len(configs), err)
+	}
+	if len(writer) != 0 {
+		t.Errorf("got trust bundles %v, want none for a peer claiming the local trust domain", writer)
+	}
+
+	// The services of the peer do not shadow the local services.
+	importer, err = NewImporter(server.URL, "istio-system", nil, func() (*tls.Config, error) {
+		return &tls.Config{RootCAs: caPool}, nil
+	}, func(hostname string) bool {
+		return hostname == "reviews.bookinfo.svc.cluster.local"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if configs, err := importer.Configs(); err != nil || len(configs) != 0 {
+		t.Errorf("Configs() => got %d configs (%v), want none for a local service", len(configs), err)
+	}
+}
+
+func TestNewImporterInvalidURL(t *testing.T) {
+	for _, u := range []string{"http://pilot:15011", "pilot:15011", "https://"} {
+		if _, err := NewImporter(u, "istio-system", nil, nil, nil); err == nil {
+			t.Errorf("NewImporter(%q) => got no error", u)
+		}
+	}
+}
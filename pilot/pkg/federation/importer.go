@@ -0,0 +1,235 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package federation
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	networking "istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/spiffe"
+	"istio.io/pkg/log"
+)
+
+// fetchTimeout bounds the time to fetch the snapshot of a peer.
+const fetchTimeout = 10 * time.Second
+
+// Importer converts the snapshot of a peer mesh to ServiceEntries. It is used as the snapshot
+// function of a config monitor, which keeps the ServiceEntries of the peer up to date.
+type Importer struct {
+	peer      string
+	url       string
+	namespace string
+	writer    TrustBundleWriter
+	tlsConfig func() (*tls.Config, error)
+	isLocal   func(hostname string) bool
+
+	trustBundle string
+}
+
+// NewImporter creates an importer of the peer mesh whose federation endpoint is at the URL, e.g.
+// https://istio-pilot.peer.example.com:15016. The ServiceEntries are created in the namespace,
+// and the trust bundle of the peer is written to the trust bundle of its trust domain if the
+// writer is set, from which the CA distributes it to the proxies. The TLS config authenticates pilot to the peer and verifies the peer; it is called for
+// every fetch, so that rotated certificates are used. The services of the peer whose hostname
+// isLocal returns true for are not imported, so that a peer cannot shadow the local services.
+func NewImporter(peerURL, namespace string, writer TrustBundleWriter, tlsConfig func() (*tls.Config, error),
+	isLocal func(hostname string) bool) (*Importer, error) {
+	u, err := url.Parse(peerURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid peer URL %q: %v", peerURL, err)
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid peer URL %q: an https URL is required", peerURL)
+	}
+	u.Path = Path
+	return &Importer{
+		peer:      PeerName(u.Host),
+		url:       u.String(),
+		namespace: namespace,
+		writer:    writer,
+		tlsConfig: tlsConfig,
+		isLocal:   isLocal,
+	}, nil
+}
+
+// PeerName returns the name of the peer at the address, which is used in the names and labels of
+// its ServiceEntries.
+func PeerName(address string) string {
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		address = host
+	}
+	return strings.ToLower(strings.NewReplacer(".", "-", ":", "-", "[", "", "]", "", "*", "wildcard").Replace(address))
+}
+
+// Peer returns the name of the peer.
+func (i *Importer) Peer() string {
+	return i.peer
+}
+
+// Configs fetches the snapshot of the peer, and returns its ServiceEntries and the
+// DestinationRules enabling mTLS to them, sorted by key.
+func (i *Importer) Configs() ([]*model.Config, error) {
+	snapshot, err := i.fetch()
+	if err != nil {
+		return nil, err
+	}
+	if err := i.saveTrustBundle(snapshot.TrustDomain, snapshot.TrustBundle); err != nil {
+		log.Warnf("Failed to save the trust bundle of peer %s: %v", i.peer, err)
+	}
+	if i.isLocal != nil {
+		services := make([]Service, 0, len(snapshot.Services))
+		for _, svc := range snapshot.Services {
+			if i.isLocal(svc.Hostname) {
+				log.Warnf("Not importing service %s of peer %s, which is also a local service", svc.Hostname, i.peer)
+				continue
+			}
+			services = append(services, svc)
+		}
+		snapshot.Services = services
+	}
+	configs := append(ServiceEntries(i.peer, i.namespace, snapshot), DestinationRules(i.peer, i.namespace, snapshot)...)
+	sort.Slice(configs, func(a, b int) bool { return configs[a].Key() < configs[b].Key() })
+	return configs, nil
+}
+
+func (i *Importer) fetch() (*MeshSnapshot, error) {
+	tlsConfig, err := i.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{
+		Timeout:   fetchTimeout,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}
+	defer client.CloseIdleConnections()
+
+	resp, err := client.Get(i.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: unexpected status %s", i.url, resp.Status)
+	}
+	snapshot := &MeshSnapshot{}
+	if err := json.NewDecoder(resp.Body).Decode(snapshot); err != nil {
+		return nil, fmt.Errorf("decoding the snapshot of %s: %v", i.url, err)
+	}
+	return snapshot, nil
+}
+
+// saveTrustBundle writes the trust bundle of the peer to its trust domain. The roots of a peer
+// only verify the identities of its trust domain, so a peer claiming the local trust domain is
+// rejected.
+func (i *Importer) saveTrustBundle(trustDomain, bundle string) error {
+	if i.writer == nil || bundle == "" || bundle == i.trustBundle {
+		return nil
+	}
+	if err := config.ValidateFQDN(trustDomain); err != nil {
+		return fmt.Errorf("invalid trust domain %q: %v", trustDomain, err)
+	}
+	if trustDomain == spiffe.GetTrustDomain() {
+		return fmt.Errorf("the peer claims the local trust domain %s", trustDomain)
+	}
+	if err := i.writer.InsertTrustBundle(trustDomain, []byte(bundle)); err != nil {
+		return err
+	}
+	log.Infof("Updated the trust bundle of peer %s in trust domain %s", i.peer, trustDomain)
+	i.trustBundle = bundle
+	return nil
+}
+
+// ServiceEntries converts the services of the peer snapshot to ServiceEntries in the namespace,
+// sorted by key. The endpoints of the services are the gateways of the peer.
+func ServiceEntries(peer, namespace string, snapshot *MeshSnapshot) []*model.Config {
+	resolution := networking.ServiceEntry_STATIC
+	for _, gw := range snapshot.Gateways {
+		if net.ParseIP(gw.Address) == nil {
+			resolution = networking.ServiceEntry_DNS
+		}
+	}
+
+	configs := make([]*model.Config, 0, len(snapshot.Services))
+	for _, svc := range snapshot.Services {
+		se := &networking.ServiceEntry{
+			Hosts:      []string{svc.Hostname},
+			Location:   networking.ServiceEntry_MESH_INTERNAL,
+			Resolution: resolution,
+		}
+		for _, p := range svc.Ports {
+			name := p.Name
+			if name == "" {
+				name = fmt.Sprintf("port-%d", p.Number)
+			}
+			se.Ports = append(se.Ports, &networking.Port{Name: name, Number: p.Number, Protocol: p.Protocol})
+		}
+		for _, gw := range snapshot.Gateways {
+			ep := &networking.ServiceEntry_Endpoint{Address: gw.Address, Ports: map[string]uint32{}}
+			for _, p := range se.Ports {
+				ep.Ports[p.Name] = gw.Port
+			}
+			se.Endpoints = append(se.Endpoints, ep)
+		}
+
+		configs = append(configs, &model.Config{
+			ConfigMeta: importedConfigMeta(model.ServiceEntry, peer, namespace, svc.Hostname),
+			Spec:       se,
+		})
+	}
+	sort.Slice(configs, func(i, j int) bool { return configs[i].Key() < configs[j].Key() })
+	return configs
+}
+
+// DestinationRules returns the DestinationRules of the services of the peer snapshot, sorted by
+// key. The traffic to the gateways of the peer uses Istio mTLS, which the gateways pass through
+// to the workloads of the peer based on SNI.
+func DestinationRules(peer, namespace string, snapshot *MeshSnapshot) []*model.Config {
+	configs := make([]*model.Config, 0, len(snapshot.Services))
+	for _, svc := range snapshot.Services {
+		configs = append(configs, &model.Config{
+			ConfigMeta: importedConfigMeta(model.DestinationRule, peer, namespace, svc.Hostname),
+			Spec: &networking.DestinationRule{
+				Host: svc.Hostname,
+				TrafficPolicy: &networking.TrafficPolicy{
+					Tls: &networking.TLSSettings{Mode: networking.TLSSettings_ISTIO_MUTUAL},
+				},
+			},
+		})
+	}
+	sort.Slice(configs, func(i, j int) bool { return configs[i].Key() < configs[j].Key() })
+	return configs
+}
+
+func importedConfigMeta(schema model.ProtoSchema, peer, namespace, hostname string) model.ConfigMeta {
+	return model.ConfigMeta{
+		Type:      schema.Type,
+		Group:     schema.Group,
+		Version:   schema.Version,
+		Name:      fmt.Sprintf("federation-%s-%s", peer, PeerName(hostname)),
+		Namespace: namespace,
+		Labels:    map[string]string{PeerLabel: peer},
+	}
+}
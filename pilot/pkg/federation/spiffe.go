@@ -0,0 +1,149 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package federation
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/spiffe"
+	"istio.io/pkg/log"
+)
+
+// BundlePath is the path of the SPIFFE bundle endpoint of pilot.
+const BundlePath = "/spiffe/bundle"
+
+// BundleEndpoint serves the root certificates of the mesh as a SPIFFE bundle, so that meshes and
+// SPIFFE implementations with other trust domains can verify the identities of the mesh.
+type BundleEndpoint struct {
+	rootCertFile string
+	refreshHint  time.Duration
+}
+
+// NewBundleEndpoint creates the endpoint of the root certificates of the file. The file is read
+// on every request, so that a rotated root certificate is served.
+func NewBundleEndpoint(rootCertFile string, refreshHint time.Duration) *BundleEndpoint {
+	return &BundleEndpoint{rootCertFile: rootCertFile, refreshHint: refreshHint}
+}
+
+// ServeHTTP serves the SPIFFE bundle.
+func (b *BundleEndpoint) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	rootCerts, err := ioutil.ReadFile(b.rootCertFile)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	bundle, err := spiffe.MarshalBundle(rootCerts, b.refreshHint)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(bundle)
+}
+
+// TrustBundleWriter stores the root certificates of a federated trust domain where the CA
+// distributes them to the proxies, e.g. the ConfigMap read by Citadel.
+type TrustBundleWriter interface {
+	InsertTrustBundle(trustDomain string, bundle []byte) error
+}
+
+// BundleFetcher fetches the SPIFFE bundle of a federated trust domain, and writes its root
+// certificates to the trust bundle of the proxies.
+type BundleFetcher struct {
+	trustDomain string
+	endpoint    string
+	writer      TrustBundleWriter
+	client      *http.Client
+
+	bundle string
+}
+
+// NewBundleFetcher creates a fetcher of the bundle of the trust domain served at the endpoint
+// URL. The TLS config verifies the bundle endpoint; no client certificate is presented, as the
+// bundle endpoint only authenticates itself.
+func NewBundleFetcher(trustDomain, endpoint string, writer TrustBundleWriter, tlsConfig *tls.Config) (*BundleFetcher, error) {
+	if err := config.ValidateFQDN(trustDomain); err != nil {
+		return nil, fmt.Errorf("invalid trust domain of bundle endpoint %q: %v", endpoint, err)
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundle endpoint %q of trust domain %s: %v", endpoint, trustDomain, err)
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid bundle endpoint %q of trust domain %s: an https URL is required", endpoint, trustDomain)
+	}
+	return &BundleFetcher{
+		trustDomain: trustDomain,
+		endpoint:    endpoint,
+		writer:      writer,
+		client: &http.Client{
+			Timeout:   fetchTimeout,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+	}, nil
+}
+
+// TrustDomain returns the federated trust domain.
+func (f *BundleFetcher) TrustDomain() string {
+	return f.trustDomain
+}
+
+// Fetch fetches the bundle, and writes its root certificates if they changed.
+func (f *BundleFetcher) Fetch() error {
+	certs, err := spiffe.FetchBundle(f.client, f.endpoint)
+	if err != nil {
+		return err
+	}
+	var bundle []byte
+	for _, cert := range certs {
+		bundle = append(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+	if string(bundle) == f.bundle {
+		return nil
+	}
+	if err := f.writer.InsertTrustBundle(f.trustDomain, bundle); err != nil {
+		return err
+	}
+	log.Infof("Updated the trust bundle of trust domain %s", f.trustDomain)
+	f.bundle = string(bundle)
+	return nil
+}
+
+// Run fetches the bundle, and fetches it again at the interval until the stop channel is closed.
+func (f *BundleFetcher) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := f.Fetch(); err != nil {
+			log.Warnf("Failed to fetch the trust bundle of trust domain %s: %v", f.trustDomain, err)
+		}
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package federation
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBundleEndpointAndFetcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "federation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	rootCert := filepath.Join(dir, "root-cert.pem")
+
+	server := httptest.NewTLSServer(NewBundleEndpoint(rootCert, time.Minute))
+	defer server.Close()
+	// The certificate of the test server stands for the root certificate of the mesh.
+	root := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := ioutil.WriteFile(rootCert, root, 0644); err != nil {
+		t.Fatal(err)
+	}
+	caPool := x509.NewCertPool()
+	caPool.AddCert(server.Certificate())
+
+	writer := fakeTrustBundleWriter{}
+	fetcher, err := NewBundleFetcher("example.org", server.URL+BundlePath, writer, &tls.Config{RootCAs: caPool})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fetcher.Fetch(); err != nil {
+		t.Fatal(err)
+	}
+	if bundle := writer["example.org"]; bundle != string(root) {
+		t.Errorf("got trust bundle %q, want the root certificate of the trust domain", bundle)
+	}
+
+	if err := os.Remove(rootCert); err != nil {
+		t.Fatal(err)
+	}
+	if err := fetcher.Fetch(); err == nil {
+		t.Error("Fetch() => got no error for an endpoint failing to read the root certificate")
+	}
+}
+
+func TestNewBundleFetcherInvalid(t *testing.T) {
+	for _, tc := range []struct{ trustDomain, endpoint string }{
+		{"", "https://example.org/spiffe/bundle"},
+		{"example.org", "http://example.org/spiffe/bundle"},
+		{"example.org", "https://"},
+		{"../example.org", "https://example.org/spiffe/bundle"},
+		{"example.org/bundle", "https://example.org/spiffe/bundle"},
+	} {
+		if _, err := NewBundleFetcher(tc.trustDomain, tc.endpoint, fakeTrustBundleWriter{}, nil); err == nil {
+			t.Errorf("NewBundleFetcher(%q, %q) => got no error", tc.trustDomain, tc.endpoint)
+		}
+	}
+}
+
+type fakeTrustBundleWriter map[string]string
+
+func (w fakeTrustBundleWriter) InsertTrustBundle(trustDomain string, bundle []byte) error {
+	w[trustDomain] = string(bundle)
+	return nil
+}
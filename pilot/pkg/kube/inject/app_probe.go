@@ -105,8 +105,18 @@ func extractStatusPort(sidecar *corev1.Container) int {
 }
 
 // convertAppProber returns a overwritten `HTTPGetAction` for pilot agent to take over.
+// A TCP socket prober is replaced by a HTTP prober of the pilot agent as well.
 func convertAppProber(probe *corev1.Probe, newURL string, statusPort int) *corev1.HTTPGetAction {
-	if probe == nil || probe.HTTPGet == nil {
+	if probe == nil {
+		return nil
+	}
+	if probe.TCPSocket != nil {
+		return &corev1.HTTPGetAction{
+			Path: newURL,
+			Port: intstr.FromInt(statusPort),
+		}
+	}
+	if probe.HTTPGet == nil {
 		return nil
 	}
 	c := probe.HTTPGet.DeepCopy()
@@ -126,19 +136,31 @@ func convertAppProber(probe *corev1.Probe, newURL string, statusPort int) *corev
 // Also update the probers so that all usages of named port will be resolved to integer.
 func DumpAppProbers(podspec *corev1.PodSpec) string {
 	out := status.KubeAppProbers{}
-	updateNamedPort := func(p *corev1.Probe, portMap map[string]int32) *corev1.HTTPGetAction {
-		if p == nil || p.HTTPGet == nil {
+	updateNamedPort := func(p *corev1.Probe, portMap map[string]int32) *status.Prober {
+		if p == nil {
+			return nil
+		}
+		var port *intstr.IntOrString
+		switch {
+		case p.HTTPGet != nil:
+			port = &p.HTTPGet.Port
+		case p.TCPSocket != nil:
+			port = &p.TCPSocket.Port
+		default:
 			return nil
 		}
-		h := p.HTTPGet
-		if h.Port.Type == intstr.String {
-			port, exists := portMap[h.Port.StrVal]
+		if port.Type == intstr.String {
+			containerPort, exists := portMap[port.StrVal]
 			if !exists {
 				return nil
 			}
-			h.Port = intstr.FromInt(int(port))
+			*port = intstr.FromInt(int(containerPort))
+		}
+		return &status.Prober{
+			HTTPGet:        p.HTTPGet,
+			TCPSocket:      p.TCPSocket,
+			TimeoutSeconds: p.TimeoutSeconds,
 		}
-		return h
 	}
 	for _, c := range podspec.Containers {
 		if c.Name == ProxyContainerName {
@@ -193,10 +215,10 @@ func rewriteAppHTTPProbe(annotations map[string]string, podSpec *corev1.PodSpec,
 		}
 		readyz, livez := status.FormatProberURL(c.Name)
 		if hg := convertAppProber(c.ReadinessProbe, readyz, statusPort); hg != nil {
-			*c.ReadinessProbe.HTTPGet = *hg
+			c.ReadinessProbe.HTTPGet, c.ReadinessProbe.TCPSocket = hg, nil
 		}
 		if hg := convertAppProber(c.LivenessProbe, livez, statusPort); hg != nil {
-			*c.LivenessProbe.HTTPGet = *hg
+			c.LivenessProbe.HTTPGet, c.LivenessProbe.TCPSocket = hg, nil
 		}
 	}
 }
@@ -227,19 +249,35 @@ func createProbeRewritePatch(annotations map[string]string, podSpec *corev1.PodS
 		}
 		readyz, livez := status.FormatProberURL(c.Name)
 		if after := convertAppProber(c.ReadinessProbe, readyz, statusPort); after != nil {
-			patch = append(patch, rfc6902PatchOperation{
-				Op:    "replace",
-				Path:  fmt.Sprintf("/spec/containers/%v/readinessProbe/httpGet", i),
-				Value: *after,
-			})
+			patch = append(patch, probeRewritePatch(fmt.Sprintf("/spec/containers/%v/readinessProbe", i),
+				c.ReadinessProbe, after)...)
 		}
 		if after := convertAppProber(c.LivenessProbe, livez, statusPort); after != nil {
-			patch = append(patch, rfc6902PatchOperation{
-				Op:    "replace",
-				Path:  fmt.Sprintf("/spec/containers/%v/livenessProbe/httpGet", i),
-				Value: *after,
-			})
+			patch = append(patch, probeRewritePatch(fmt.Sprintf("/spec/containers/%v/livenessProbe", i),
+				c.LivenessProbe, after)...)
 		}
 	}
 	return patch
 }
+
+// probeRewritePatch replaces the handler of the probe at path by the pilot agent HTTP prober.
+func probeRewritePatch(path string, probe *corev1.Probe, after *corev1.HTTPGetAction) []rfc6902PatchOperation {
+	if probe.TCPSocket == nil {
+		return []rfc6902PatchOperation{{
+			Op:    "replace",
+			Path:  path + "/httpGet",
+			Value: *after,
+		}}
+	}
+	return []rfc6902PatchOperation{
+		{
+			Op:   "remove",
+			Path: path + "/tcpSocket",
+		},
+		{
+			Op:    "add",
+			Path:  path + "/httpGet",
+			Value: *after,
+		},
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build gofuzz
+
+package inject
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/test/env"
+)
+
+var fuzzTemplate, fuzzValues = loadFuzzChart()
+
+// loadFuzzChart loads the sidecar template and the values of the Istio Helm chart.
+func loadFuzzChart() (string, string) {
+	chart := filepath.Join(env.IstioSrc, "install/kubernetes/helm/istio")
+	template, err := ioutil.ReadFile(filepath.Join(chart, "files/injection-template.yaml"))
+	if err != nil {
+		panic(err)
+	}
+	values, err := ioutil.ReadFile(filepath.Join(chart, "values.yaml"))
+	if err != nil {
+		panic(err)
+	}
+	return string(template), string(values)
+}
+
+// Fuzz injects the sidecar into the Kubernetes resources in the YAML data, rendering the sidecar
+// template of the Helm chart with the annotations of the resources, for go-fuzz:
+//
+//     go-fuzz-build istio.io/istio/pilot/pkg/kube/inject
+//     go-fuzz -bin inject-fuzz.zip -workdir fuzz
+func Fuzz(data []byte) int {
+	mesh := config.DefaultMeshConfig()
+	var out bytes.Buffer
+	if err := IntoResourceFile(fuzzTemplate, fuzzValues, &mesh, bytes.NewReader(data), &out); err != nil {
+		return 0
+	}
+	return 1
+}
@@ -83,6 +83,7 @@ var (
 		annotation.SidecarTrafficExcludeInboundPorts.Name:         ValidateExcludeInboundPorts,
 		annotation.SidecarTrafficExcludeOutboundPorts.Name:        ValidateExcludeOutboundPorts,
 		annotation.SidecarTrafficKubevirtInterfaces.Name:          alwaysValidFunc,
+		injectTemplatesAnnotation:                                 alwaysValidFunc,
 	}
 )
 
@@ -279,6 +280,10 @@ type Config struct {
 	// expansion over the `SidecarTemplateData`.
 	Template string `json:"template"`
 
+	// Templates are additional named templates, used instead of Template for the pods
+	// selecting them with the inject.istio.io/templates annotation, e.g. gateway.
+	Templates map[string]string `json:"templates,omitempty"`
+
 	// NeverInjectSelector: Refuses the injection on pods whose labels match this selector.
 	// It's an array of label selectors, that will be OR'ed, meaning we will iterate
 	// over it and stop at the first match
@@ -435,7 +440,9 @@ func injectRequired(ignored []string, config *Config, podSpec *corev1.PodSpec, m
 	case "y", "yes", "true", "on":
 		inject = true
 	case "":
-		useDefault = true
+		// Pods selecting a template explicitly ask for injection.
+		useDefault = annos[injectTemplatesAnnotation] == ""
+		inject = !useDefault
 	}
 
 	// If an annotation is not explicitly given, check the LabelSelectors, starting with NeverInject
@@ -643,6 +650,14 @@ func parseTemplate(tmplStr string, funcMap map[string]interface{}, data SidecarT
 // IntoResourceFile injects the istio proxy into the specified
 // kubernetes YAML file.
 func IntoResourceFile(sidecarTemplate string, valuesConfig string, meshconfig *meshconfig.MeshConfig, in io.Reader, out io.Writer) error {
+	return IntoResourceFileWithConfig(&Config{Template: sidecarTemplate}, valuesConfig, meshconfig, in, out)
+}
+
+// IntoResourceFileWithConfig injects the istio proxy into the specified kubernetes YAML file
+// with the templates of the injection config. As with the webhook, the pods select a named
+// template with the inject.istio.io/templates annotation, and their containers named after the
+// injected containers are merged into them.
+func IntoResourceFileWithConfig(c *Config, valuesConfig string, meshconfig *meshconfig.MeshConfig, in io.Reader, out io.Writer) error {
 	reader := yamlDecoder.NewYAMLReader(bufio.NewReaderSize(in, 4096))
 	for {
 		raw, err := reader.Read()
@@ -660,7 +675,7 @@ func IntoResourceFile(sidecarTemplate string, valuesConfig string, meshconfig *m
 
 		var updated []byte
 		if err == nil {
-			outObject, err := intoObject(sidecarTemplate, valuesConfig, meshconfig, obj) // nolint: vetshadow
+			outObject, err := intoObject(c, valuesConfig, meshconfig, obj) // nolint: vetshadow
 			if err != nil {
 				return err
 			}
@@ -699,7 +714,7 @@ func fromRawToObject(raw []byte) (runtime.Object, error) {
 	return obj, nil
 }
 
-func intoObject(sidecarTemplate string, valuesConfig string, meshconfig *meshconfig.MeshConfig, in runtime.Object) (interface{}, error) {
+func intoObject(c *Config, valuesConfig string, meshconfig *meshconfig.MeshConfig, in runtime.Object) (interface{}, error) {
 	out := in.DeepCopyObject()
 
 	var deploymentMetadata *metav1.ObjectMeta
@@ -719,7 +734,7 @@ func intoObject(sidecarTemplate string, valuesConfig string, meshconfig *meshcon
 				return nil, err
 			}
 
-			r, err := intoObject(sidecarTemplate, valuesConfig, meshconfig, obj) // nolint: vetshadow
+			r, err := intoObject(c, valuesConfig, meshconfig, obj) // nolint: vetshadow
 			if err != nil {
 				return nil, err
 			}
@@ -771,10 +786,13 @@ func intoObject(sidecarTemplate string, valuesConfig string, meshconfig *meshcon
 			metadata.Name)
 		return out, nil
 	}
-	//skip injection for injected pods
-	if len(podSpec.Containers) > 1 {
-		for _, c := range podSpec.Containers {
-			if c.Name == ProxyContainerName {
+	//skip injection for injected pods. The istio-proxy container of a pod selecting a template is
+	//a placeholder, unless the pod was injected.
+	_, injected := metadata.Annotations[annotation.SidecarStatus.Name]
+	placeholders := metadata.Annotations[injectTemplatesAnnotation] != "" && !injected
+	if len(podSpec.Containers) > 1 && !placeholders {
+		for _, container := range podSpec.Containers {
+			if container.Name == ProxyContainerName {
 				_, _ = fmt.Fprintf(os.Stderr, "Skipping injection because %q has injected %q sidecar already\n",
 					metadata.Name, ProxyContainerName)
 				return out, nil
@@ -782,6 +800,10 @@ func intoObject(sidecarTemplate string, valuesConfig string, meshconfig *meshcon
 		}
 	}
 
+	sidecarTemplate, err := selectTemplate(c, metadata)
+	if err != nil {
+		return nil, err
+	}
 	spec, status, err := InjectionData(
 		sidecarTemplate,
 		valuesConfig,
@@ -797,6 +819,19 @@ func intoObject(sidecarTemplate string, valuesConfig string, meshconfig *meshcon
 
 	podSpec.InitContainers = append(podSpec.InitContainers, spec.InitContainers...)
 
+	if placeholders {
+		merged := map[string]bool{}
+		for _, name := range mergePlaceholders(podSpec.Containers, nil, spec.Containers) {
+			merged[name] = true
+		}
+		containers := podSpec.Containers[:0]
+		for _, container := range podSpec.Containers {
+			if !merged[container.Name] {
+				containers = append(containers, container)
+			}
+		}
+		podSpec.Containers = containers
+	}
 	podSpec.Containers = append(podSpec.Containers, spec.Containers...)
 	podSpec.Volumes = append(podSpec.Volumes, spec.Volumes...)
 
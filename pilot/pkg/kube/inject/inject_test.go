@@ -624,6 +624,11 @@ func TestRewriteAppProbe(t *testing.T) {
 			rewriteAppHTTPProbe: true,
 			want:                "https-probes.yaml.injected",
 		},
+		{
+			in:                  "tcp-probes.yaml",
+			rewriteAppHTTPProbe: true,
+			want:                "tcp-probes.yaml.injected",
+		},
 		{
 			in:                  "hello-probes-with-flag-set-in-annotation.yaml",
 			rewriteAppHTTPProbe: false,
@@ -680,6 +685,55 @@ func TestRewriteAppProbe(t *testing.T) {
 	}
 }
 
+// TestIntoResourceFileTemplates verifies that kube-inject selects the template of the pods, and
+// merges their placeholder containers, as the webhook does.
+func TestIntoResourceFileTemplates(t *testing.T) {
+	mesh := config.DefaultMeshConfig()
+	params := &Params{
+		InitImage:                    InitImageName(unitTestHub, unitTestTag, false),
+		ProxyImage:                   ProxyImageName(unitTestHub, unitTestTag, false),
+		ImagePullPolicy:              "IfNotPresent",
+		SidecarProxyUID:              DefaultSidecarProxyUID,
+		Version:                      "12345678",
+		Mesh:                         &mesh,
+		IncludeIPRanges:              DefaultIncludeIPRanges,
+		IncludeInboundPorts:          DefaultIncludeInboundPorts,
+		StatusPort:                   DefaultStatusPort,
+		ReadinessInitialDelaySeconds: DefaultReadinessInitialDelaySeconds,
+		ReadinessPeriodSeconds:       DefaultReadinessPeriodSeconds,
+		ReadinessFailureThreshold:    DefaultReadinessFailureThreshold,
+	}
+	c := &Config{
+		Template:  loadSidecarTemplate(t),
+		Templates: map[string]string{"gateway": string(util.ReadFile(gatewayInjectorConfig, t))},
+	}
+	valuesConfig := getValues(params, t)
+
+	for _, file := range []string{"gateway-template.yaml", "gateway-template.yaml.injected"} {
+		t.Run(file, func(t *testing.T) {
+			inputFilePath := "testdata/inject/" + file
+			wantFilePath := "testdata/inject/gateway-template.yaml.injected"
+			in, err := os.Open(inputFilePath)
+			if err != nil {
+				t.Fatalf("Failed to open %q: %v", inputFilePath, err)
+			}
+			defer func() { _ = in.Close() }()
+			var got bytes.Buffer
+			if err = IntoResourceFileWithConfig(c, valuesConfig, &mesh, in, &got); err != nil {
+				t.Fatalf("IntoResourceFileWithConfig(%v) returned an error: %v", inputFilePath, err)
+			}
+			gotBytes := stripVersion(got.Bytes())
+			util.CompareBytes(gotBytes, stripVersion(util.ReadGoldenFile(gotBytes, wantFilePath, t)), wantFilePath, t)
+		})
+	}
+
+	in := strings.NewReader(strings.Replace(string(util.ReadFile("testdata/inject/gateway-template.yaml", t)),
+		"templates: gateway", "templates: unknown", 1))
+	if err := IntoResourceFileWithConfig(c, valuesConfig, &mesh, in, &bytes.Buffer{}); err == nil {
+		t.Error("IntoResourceFileWithConfig() => got no error for an unknown template")
+	}
+}
+
 func stripVersion(yaml []byte) []byte {
 	return statusPattern.ReplaceAllLiteral(yaml, []byte(statusReplacement))
 }
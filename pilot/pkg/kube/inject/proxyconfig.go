@@ -0,0 +1,88 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inject
+
+import (
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config"
+	"istio.io/pkg/log"
+)
+
+const (
+	// proxyConfigSourcesEnv is exposed to the proxy as node metadata, listing the ProxyConfig
+	// resources applied at injection time.
+	proxyConfigSourcesEnv = "ISTIO_META_PROXY_CONFIG_SOURCES"
+)
+
+// workloadProxyConfig returns the proxy configuration for the pod, taking the ProxyConfig
+// resources in the store into account. A nil store yields the mesh default config.
+func workloadProxyConfig(store model.ConfigStore, mesh *meshconfig.MeshConfig, metadata *metav1.ObjectMeta) *model.WorkloadProxyConfig {
+	var configs []model.Config
+	if store != nil {
+		var err error
+		if configs, err = store.List(model.ProxyConfig.Type, model.NamespaceAll); err != nil {
+			log.Warnf("Failed to list proxy configs, using mesh defaults: %v", err)
+			configs = nil
+		}
+	}
+	return model.EffectiveProxyConfig(mesh.DefaultConfig, configs, mesh.RootNamespace,
+		metadata.Namespace, config.Labels(metadata.Labels))
+}
+
+// applyProxyConfigOverrides sets the image and environment of the injected proxy container
+// according to the workload proxy config.
+func applyProxyConfigOverrides(containers []corev1.Container, override *model.WorkloadProxyConfig) {
+	if override == nil || len(override.Sources) == 0 {
+		return
+	}
+	for i := range containers {
+		c := &containers[i]
+		if c.Name != ProxyContainerName {
+			continue
+		}
+		if override.Image != "" {
+			c.Image = override.Image
+		}
+
+		names := make([]string, 0, len(override.Env))
+		for name := range override.Env {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			setEnv(c, name, override.Env[name])
+		}
+		setEnv(c, proxyConfigSourcesEnv, strings.Join(override.Sources, ","))
+		return
+	}
+}
+
+// setEnv sets an environment variable on the container, replacing any existing value.
+func setEnv(c *corev1.Container, name, value string) {
+	for i := range c.Env {
+		if c.Env[i].Name == name {
+			c.Env[i] = corev1.EnvVar{Name: name, Value: value}
+			return
+		}
+	}
+	c.Env = append(c.Env, corev1.EnvVar{Name: name, Value: value})
+}
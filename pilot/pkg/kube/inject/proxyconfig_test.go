@@ -0,0 +1,95 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inject
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/api/annotation"
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	"istio.io/istio/pilot/pkg/config/memory"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config"
+)
+
+func TestWorkloadProxyConfig(t *testing.T) {
+	mesh := config.DefaultMeshConfig()
+	mesh.RootNamespace = "istio-system"
+
+	store := memory.Make(model.ConfigDescriptor{model.ProxyConfig})
+	if _, err := store.Create(model.Config{
+		ConfigMeta: model.ConfigMeta{
+			Type:      model.ProxyConfig.Type,
+			Name:      "fast",
+			Namespace: "ns",
+			Annotations: map[string]string{
+				config.AlphaWorkloadSelector.Name: "app=foo",
+				config.AlphaProxyEnvironment.Name: "FOO=bar",
+				annotation.SidecarProxyImage.Name: "example.com/proxy:debug",
+			},
+		},
+		Spec: &meshconfig.ProxyConfig{Concurrency: 4},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := workloadProxyConfig(store, &mesh, &metav1.ObjectMeta{Namespace: "ns", Labels: map[string]string{"app": "foo"}})
+	if got.ProxyConfig.Concurrency != 4 {
+		t.Errorf("got concurrency %d, want 4", got.ProxyConfig.Concurrency)
+	}
+	if got.ProxyConfig.DiscoveryAddress != mesh.DefaultConfig.DiscoveryAddress {
+		t.Errorf("default discovery address was not preserved: %q", got.ProxyConfig.DiscoveryAddress)
+	}
+
+	containers := []corev1.Container{
+		{Name: "app", Image: "app"},
+		{
+			Name:  ProxyContainerName,
+			Image: "docker.io/istio/proxyv2:1.3",
+			Env:   []corev1.EnvVar{{Name: "FOO", Value: "baz"}, {Name: "POD_NAME", Value: "foo"}},
+		},
+	}
+	applyProxyConfigOverrides(containers, got)
+
+	want := []corev1.Container{
+		{Name: "app", Image: "app"},
+		{
+			Name:  ProxyContainerName,
+			Image: "example.com/proxy:debug",
+			Env: []corev1.EnvVar{
+				{Name: "FOO", Value: "bar"},
+				{Name: "POD_NAME", Value: "foo"},
+				{Name: proxyConfigSourcesEnv, Value: "ns/fast"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(containers, want) {
+		t.Errorf("got containers %+v, want %+v", containers, want)
+	}
+
+	unselected := workloadProxyConfig(store, &mesh, &metav1.ObjectMeta{Namespace: "ns", Labels: map[string]string{"app": "bar"}})
+	if !reflect.DeepEqual(unselected.ProxyConfig, mesh.DefaultConfig) || len(unselected.Sources) != 0 {
+		t.Errorf("unselected workload got overrides: %v", unselected)
+	}
+
+	defaults := workloadProxyConfig(nil, &mesh, &metav1.ObjectMeta{Namespace: "ns"})
+	if !reflect.DeepEqual(defaults.ProxyConfig, mesh.DefaultConfig) {
+		t.Errorf("got %v, want mesh defaults", defaults.ProxyConfig)
+	}
+}
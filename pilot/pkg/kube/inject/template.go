@@ -0,0 +1,94 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inject
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pkg/config"
+)
+
+var injectTemplatesAnnotation = config.InjectTemplates.Name
+
+// selectTemplate returns the template used to inject the pod: the named template selected by
+// the inject.istio.io/templates annotation if set, the sidecar template otherwise.
+func selectTemplate(c *Config, metadata *metav1.ObjectMeta) (string, error) {
+	name := metadata.GetAnnotations()[injectTemplatesAnnotation]
+	if name == "" {
+		return c.Template, nil
+	}
+	tmpl, ok := c.Templates[name]
+	if !ok {
+		return "", fmt.Errorf("unknown injection template %q", name)
+	}
+	return tmpl, nil
+}
+
+// mergePlaceholders merges the containers of the pod named after the injected containers into
+// them, so that e.g. a gateway Deployment declares an istio-proxy container with its ports and
+// resources and the injector fills in the rest. The image, command and args of the injected
+// container are kept, the environment variables of the pod override the injected ones. The
+// previously injected containers are not merged. Returns the names of the merged containers
+// of the pod, which are replaced by the injected containers.
+func mergePlaceholders(containers []corev1.Container, previouslyInjected []string, injected []corev1.Container) []string {
+	previous := map[string]bool{}
+	for _, name := range previouslyInjected {
+		previous[name] = true
+	}
+
+	var merged []string
+	for i := range injected {
+		for _, c := range containers {
+			if c.Name != injected[i].Name || previous[c.Name] {
+				continue
+			}
+			mergeContainer(&injected[i], c)
+			merged = append(merged, c.Name)
+		}
+	}
+	return merged
+}
+
+func mergeContainer(injected *corev1.Container, placeholder corev1.Container) {
+	ports := map[int32]bool{}
+	for _, p := range injected.Ports {
+		ports[p.ContainerPort] = true
+	}
+	for _, p := range placeholder.Ports {
+		if !ports[p.ContainerPort] {
+			injected.Ports = append(injected.Ports, p)
+		}
+	}
+
+	env := map[string]int{}
+	for i, e := range injected.Env {
+		env[e.Name] = i
+	}
+	for _, e := range placeholder.Env {
+		if i, ok := env[e.Name]; ok {
+			injected.Env[i] = e
+		} else {
+			injected.Env = append(injected.Env, e)
+		}
+	}
+
+	injected.VolumeMounts = append(injected.VolumeMounts, placeholder.VolumeMounts...)
+	if len(placeholder.Resources.Limits) > 0 || len(placeholder.Resources.Requests) > 0 {
+		injected.Resources = placeholder.Resources
+	}
+}
@@ -33,6 +33,7 @@ import (
 	meshconfig "istio.io/api/mesh/v1alpha1"
 	"istio.io/istio/pilot/cmd"
 	"istio.io/istio/pilot/cmd/pilot-agent/status"
+	"istio.io/istio/pilot/pkg/model"
 	"istio.io/pkg/log"
 
 	"k8s.io/api/admission/v1beta1"
@@ -76,6 +77,8 @@ type Webhook struct {
 	certFile   string
 	keyFile    string
 	cert       *tls.Certificate
+
+	proxyConfigs model.ConfigStore
 }
 
 func loadConfig(injectFile, meshFile, valuesFile string) (*Config, *meshconfig.MeshConfig, string, error) {
@@ -136,6 +139,10 @@ type WebhookParameters struct {
 	// HealthCheckFile specifies the path to the health check file
 	// that is periodically updated.
 	HealthCheckFile string
+
+	// ProxyConfigs, if set, provides the ProxyConfig resources overriding the
+	// mesh default proxy config of injected workloads.
+	ProxyConfigs model.ConfigStore
 }
 
 // NewWebhook creates a new instance of a mutating webhook for automatic sidecar injection.
@@ -179,6 +186,7 @@ func NewWebhook(p WebhookParameters) (*Webhook, error) {
 		certFile:               p.CertFile,
 		keyFile:                p.KeyFile,
 		cert:                   &pair,
+		proxyConfigs:           p.ProxyConfigs,
 	}
 	// mtls disabled because apiserver webhook cert usage is still TBD.
 	wh.server.TLSConfig = &tls.Config{GetCertificate: wh.getCert}
@@ -451,7 +459,14 @@ func createPatch(pod *corev1.Pod, prevStatus *SidecarInjectionStatus, annotation
 	// Remove any containers previously injected by kube-inject using
 	// container and volume name as unique key for removal.
 	patch = append(patch, removeContainers(pod.Spec.InitContainers, prevStatus.InitContainers, "/spec/initContainers")...)
-	patch = append(patch, removeContainers(pod.Spec.Containers, prevStatus.Containers, "/spec/containers")...)
+	// Without the status annotation, the previous status holds the legacy names, which may be
+	// the names of placeholders to merge.
+	var previouslyInjected []string
+	if _, ok := pod.Annotations[annotation.SidecarStatus.Name]; ok {
+		previouslyInjected = prevStatus.Containers
+	}
+	placeholders := mergePlaceholders(pod.Spec.Containers, previouslyInjected, sic.Containers)
+	patch = append(patch, removeContainers(pod.Spec.Containers, append(prevStatus.Containers, placeholders...), "/spec/containers")...)
 	patch = append(patch, removeVolumes(pod.Spec.Volumes, prevStatus.Volumes, "/spec/volumes")...)
 	patch = append(patch, removeImagePullSecrets(pod.Spec.ImagePullSecrets, prevStatus.ImagePullSecrets, "/spec/imagePullSecrets")...)
 
@@ -574,11 +589,23 @@ func (wh *Webhook) inject(ar *v1beta1.AdmissionReview) *v1beta1.AdmissionRespons
 		}
 	}
 
-	spec, iStatus, err := InjectionData(wh.sidecarConfig.Template, wh.valuesConfig, wh.sidecarTemplateVersion, &pod.ObjectMeta, &pod.Spec, &pod.ObjectMeta, wh.meshConfig.DefaultConfig, wh.meshConfig) // nolint: lll
+	tmpl, err := selectTemplate(wh.sidecarConfig, &pod.ObjectMeta)
+	if err != nil {
+		log.Infof("Injection template: err=%v", err)
+		return toAdmissionResponse(err)
+	}
+	version := wh.sidecarTemplateVersion
+	if tmpl != wh.sidecarConfig.Template {
+		version = sidecarTemplateVersionHash(tmpl)
+	}
+
+	proxyConfig := workloadProxyConfig(wh.proxyConfigs, wh.meshConfig, &pod.ObjectMeta)
+	spec, iStatus, err := InjectionData(tmpl, wh.valuesConfig, version, &pod.ObjectMeta, &pod.Spec, &pod.ObjectMeta, proxyConfig.ProxyConfig, wh.meshConfig) // nolint: lll
 	if err != nil {
 		log.Infof("Injection data: err=%v spec=%v\n", err, iStatus)
 		return toAdmissionResponse(err)
 	}
+	applyProxyConfigOverrides(spec.Containers, proxyConfig)
 
 	annotations := map[string]string{annotation.SidecarStatus.Name: iStatus}
 
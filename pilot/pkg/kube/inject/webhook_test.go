@@ -24,6 +24,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -55,6 +56,7 @@ const (
 	helmChartDirectory     = "../../../../install/kubernetes/helm/istio"
 	helmConfigMapKey       = "istio/templates/sidecar-injector-configmap.yaml"
 	injectorConfig         = "../../../../install/kubernetes/helm/istio/files/injection-template.yaml"
+	gatewayInjectorConfig  = "../../../../install/kubernetes/helm/istio/files/gateway-injection-template.yaml"
 	helmValuesFile         = "values.yaml"
 	yamlSeparator          = "\n---"
 	minimalSidecarTemplate = `
@@ -163,6 +165,33 @@ func TestInjectRequired(t *testing.T) {
 			},
 			want: true,
 		},
+		{
+			config: &Config{
+				Policy: InjectionPolicyDisabled,
+			},
+			podSpec: podSpec,
+			meta: &metav1.ObjectMeta{
+				Name:        "template-selected",
+				Namespace:   "test-namespace",
+				Annotations: map[string]string{config.InjectTemplates.Name: "gateway"},
+			},
+			want: true,
+		},
+		{
+			config: &Config{
+				Policy: InjectionPolicyEnabled,
+			},
+			podSpec: podSpec,
+			meta: &metav1.ObjectMeta{
+				Name:      "template-selected-force-off",
+				Namespace: "test-namespace",
+				Annotations: map[string]string{
+					config.InjectTemplates.Name:   "gateway",
+					annotation.SidecarInject.Name: "false",
+				},
+			},
+			want: false,
+		},
 		{
 			config: &Config{
 				Policy: InjectionPolicyEnabled,
@@ -614,6 +643,11 @@ func TestWebhookInject(t *testing.T) {
 			wantFile:     "TestWebhookInject_https_probe_rewrite.patch",
 			templateFile: "TestWebhookInject_https_probe_rewrite_template.yaml",
 		},
+		{
+			inputFile:    "TestWebhookInject_tcp_probe_rewrite.yaml",
+			wantFile:     "TestWebhookInject_tcp_probe_rewrite.patch",
+			templateFile: "TestWebhookInject_tcp_probe_rewrite_template.yaml",
+		},
 		{
 			inputFile:    "TestWebhookInject_http_probe_rewrite_enabled_via_annotation.yaml",
 			wantFile:     "TestWebhookInject_http_probe_rewrite_enabled_via_annotation.patch",
@@ -657,6 +691,69 @@ func TestWebhookInject(t *testing.T) {
 	}
 }
 
+func TestWebhookInjectGatewayTemplate(t *testing.T) {
+	wh, cleanup := createTestWebhook(t, minimalSidecarTemplate)
+	defer cleanup()
+	wh.sidecarConfig.Templates = map[string]string{"gateway": string(util.ReadFile(gatewayInjectorConfig, t))}
+
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "gateway",
+			Namespace:   "apps",
+			Labels:      map[string]string{"app": "apps-gateway"},
+			Annotations: map[string]string{config.InjectTemplates.Name: "gateway"},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:  "istio-proxy",
+				Image: "auto",
+				Ports: []corev1.ContainerPort{{Name: "https", ContainerPort: 8443}},
+			}},
+		},
+	}
+	podJSON := convertToJSON(&pod, t)
+	got := wh.inject(&v1beta1.AdmissionReview{
+		Request: &v1beta1.AdmissionRequest{Object: runtime.RawExtension{Raw: podJSON}},
+	})
+	if got.Result != nil {
+		t.Fatalf("inject() => got error %v", got.Result.Message)
+	}
+
+	injected := corev1.Pod{}
+	if err := json.Unmarshal(applyJSONPatch(podJSON, got.Patch, t), &injected); err != nil {
+		t.Fatal(err)
+	}
+	if len(injected.Spec.InitContainers) != 0 {
+		t.Errorf("got init containers %v, want none for a gateway", injected.Spec.InitContainers)
+	}
+	var names []string
+	for _, c := range injected.Spec.Containers {
+		names = append(names, c.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"istio-proxy", "ingress-sds"}) {
+		t.Fatalf("got containers %v, want the injected istio-proxy and ingress-sds", names)
+	}
+	proxy := injected.Spec.Containers[0]
+	if proxy.Image == "auto" || len(proxy.Args) < 2 || proxy.Args[1] != "router" {
+		t.Errorf("got proxy image %s and args %v, want the gateway proxy", proxy.Image, proxy.Args)
+	}
+	ports := map[int32]bool{}
+	for _, p := range proxy.Ports {
+		ports[p.ContainerPort] = true
+	}
+	if !ports[8443] || !ports[15090] {
+		t.Errorf("got proxy ports %v, want the declared and the injected ports", proxy.Ports)
+	}
+
+	pod.Annotations[config.InjectTemplates.Name] = "unknown"
+	got = wh.inject(&v1beta1.AdmissionReview{
+		Request: &v1beta1.AdmissionRequest{Object: runtime.RawExtension{Raw: convertToJSON(&pod, t)}},
+	})
+	if got.Result == nil {
+		t.Errorf("inject() => got no error for an unknown template")
+	}
+}
+
 // TestHelmInject tests the webhook injector with the installation configmap.yaml. It runs through many of the
 // same tests as TestIntoResourceFile in order to verify that the webhook performs the same way as the manual injector.
 func TestHelmInject(t *testing.T) {
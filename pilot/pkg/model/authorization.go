@@ -21,6 +21,14 @@ import (
 	istiolog "istio.io/pkg/log"
 )
 
+const (
+	// AuthorizationPolicyDryRunAnnotation marks the AuthorizationPolicy configs in dry-run: they are
+	// only evaluated by the shadow rules of the RBAC filter, as if they were the only policies of the
+	// workload, which report the requests they would deny in the stats of the filter and the
+	// permissive attributes sent to Mixer.
+	AuthorizationPolicyDryRunAnnotation = "istio.io/dry-run"
+)
+
 var (
 	rbacLog = istiolog.RegisterScope("rbac", "rbac debugging", 0)
 )
@@ -38,6 +46,9 @@ type RolesAndBindings struct {
 type AuthorizationPolicyConfig struct {
 	Name   string
 	Policy *rbacproto.AuthorizationPolicy
+
+	// DryRun is true if the policy is not enforced, see AuthorizationPolicyDryRunAnnotation.
+	DryRun bool
 }
 
 // AuthorizationConfigV2 stores a list of AuthorizationPolicyConfig and ServiceRole in a given namespace.
@@ -141,6 +152,7 @@ func (policy *AuthorizationPolicies) addAuthorizationPolicy(authzPolicy *Config)
 	authzV2.AuthzPolicies = append(authzV2.AuthzPolicies, &AuthorizationPolicyConfig{
 		Name:   authzPolicy.Name,
 		Policy: authzPolicy.Spec.(*rbacproto.AuthorizationPolicy),
+		DryRun: authzPolicy.Annotations[AuthorizationPolicyDryRunAnnotation] == "true",
 	})
 }
 
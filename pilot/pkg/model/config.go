@@ -80,6 +80,11 @@ type ConfigMeta struct {
 	// not been stored and assigned a revision.
 	ResourceVersion string `json:"resourceVersion,omitempty"`
 
+	// Generation is a sequence number representing a specific generation of the
+	// desired state, incremented by the data store when the spec changes. Zero if
+	// the data store does not track generations.
+	Generation int64 `json:"generation,omitempty"`
+
 	// CreationTimestamp records the creation time
 	CreationTimestamp time.Time `json:"creationTimestamp,omitempty"`
 }
@@ -393,6 +398,17 @@ var (
 		Collection:  metadata.IstioNetworkingV1alpha3Sidecars.Collection.String(),
 	}
 
+	// ProxyConfig describes per-workload overrides of the mesh default proxy configuration
+	ProxyConfig = ProtoSchema{
+		Type:        "proxy-config",
+		Plural:      "proxy-configs",
+		Group:       "networking",
+		Version:     "v1alpha3",
+		MessageName: "istio.mesh.v1alpha1.ProxyConfig",
+		Validate:    config.ValidateWorkloadProxyConfig,
+		Collection:  metadata.IstioNetworkingV1alpha3Proxyconfigs.Collection.String(),
+	}
+
 	// HTTPAPISpec describes an HTTP API specification.
 	HTTPAPISpec = ProtoSchema{
 		Type:        "http-api-spec",
@@ -530,6 +546,7 @@ var (
 		DestinationRule,
 		EnvoyFilter,
 		Sidecar,
+		ProxyConfig,
 		HTTPAPISpec,
 		HTTPAPISpecBinding,
 		QuotaSpec,
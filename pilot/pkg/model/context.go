@@ -462,6 +462,11 @@ const (
 	// NodeMetadataIdleTimeout specifies the idle timeout for the proxy, in duration format (10s).
 	// If not set, no timeout is set.
 	NodeMetadataIdleTimeout = "IDLE_TIMEOUT"
+
+	// NodeMetadataAccessLogServiceSampling is the percentage of the requests and connections of the
+	// proxy logged to the Envoy access log service, from 0 to 100 (100). It can be overridden at
+	// runtime with the istio.access_log_service.sampling key, in millionths.
+	NodeMetadataAccessLogServiceSampling = "ACCESS_LOG_SERVICE_SAMPLING"
 )
 
 // TrafficInterceptionMode indicates how traffic to/from the workload is captured and
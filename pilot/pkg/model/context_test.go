@@ -117,6 +117,39 @@ defaultConfig:
 	}
 }
 
+func TestApplyMeshConfigOverlay(t *testing.T) {
+	base, err := config.ApplyMeshConfigDefaults(`
+accessLogFile: /dev/stdout
+defaultConfig:
+  configPath: /test/config/patch
+  concurrency: 4
+`)
+	if err != nil {
+		t.Fatalf("ApplyMeshConfigDefaults() failed: %v", err)
+	}
+
+	got, err := config.ApplyMeshConfig(`
+trustDomain: canary.example.com
+defaultConfig:
+  concurrency: 2
+`, *base)
+	if err != nil {
+		t.Fatalf("ApplyMeshConfig() failed: %v", err)
+	}
+
+	want := config.DefaultMeshConfig()
+	want.AccessLogFile = "/dev/stdout"
+	want.TrustDomain = "canary.example.com"
+	want.DefaultConfig.ConfigPath = "/test/config/patch"
+	want.DefaultConfig.Concurrency = 2
+	if !reflect.DeepEqual(got, &want) {
+		t.Fatalf("Wrong merged values:\n got %#v \nwant %#v", got, &want)
+	}
+	if base.DefaultConfig.Concurrency != 4 || base.TrustDomain != "" {
+		t.Errorf("ApplyMeshConfig() modified the base config")
+	}
+}
+
 func TestApplyMeshNetworksDefaults(t *testing.T) {
 	yml := fmt.Sprintf(`
 networks:
@@ -0,0 +1,119 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+
+	"istio.io/api/annotation"
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	"istio.io/istio/pkg/config"
+)
+
+// WorkloadProxyConfig is the proxy configuration in effect for a single workload, computed by
+// layering the ProxyConfig resources that apply to the workload over the mesh default config.
+type WorkloadProxyConfig struct {
+	// ProxyConfig is the merged proxy configuration.
+	ProxyConfig *meshconfig.ProxyConfig
+
+	// Image overrides the proxy image, if set.
+	Image string
+
+	// Env holds additional environment variables for the proxy container.
+	Env map[string]string
+
+	// Sources lists the namespace/name of the ProxyConfig resources applied, in order.
+	Sources []string
+}
+
+// EffectiveProxyConfig computes the proxy configuration of a workload in the given namespace
+// with the given labels. ProxyConfig resources are applied in increasing order of precedence:
+// resources without a workload selector in the root namespace, resources without a workload
+// selector in the workload namespace, and finally resources in the workload namespace whose
+// selector matches the workload labels. Within the same level, resources are applied in
+// creation order.
+func EffectiveProxyConfig(defaults *meshconfig.ProxyConfig, configs []Config, rootNamespace, namespace string,
+	workloadLabels config.Labels) *WorkloadProxyConfig {
+	out := &WorkloadProxyConfig{
+		ProxyConfig: &meshconfig.ProxyConfig{},
+		Env:         make(map[string]string),
+	}
+	if defaults != nil {
+		out.ProxyConfig = proto.Clone(defaults).(*meshconfig.ProxyConfig)
+	}
+
+	var meshWide, namespaceWide, workloadSpecific []Config
+	for _, c := range configs {
+		selector, hasSelector := c.Annotations[config.AlphaWorkloadSelector.Name]
+		switch {
+		case c.Namespace == namespace && hasSelector:
+			if config.ParseLabelsString(selector).SubsetOf(workloadLabels) {
+				workloadSpecific = append(workloadSpecific, c)
+			}
+		case c.Namespace == namespace:
+			namespaceWide = append(namespaceWide, c)
+		case c.Namespace == rootNamespace && !hasSelector:
+			meshWide = append(meshWide, c)
+		}
+	}
+
+	for _, level := range [][]Config{meshWide, namespaceWide, workloadSpecific} {
+		for _, c := range sortConfigByCreationTime(level) {
+			out.apply(c)
+		}
+	}
+	return out
+}
+
+func (w *WorkloadProxyConfig) apply(c Config) {
+	if spec, ok := c.Spec.(*meshconfig.ProxyConfig); ok {
+		// Tracing is a oneof of tracer specific settings, replace rather than merge it
+		// so that switching tracers does not leave stale settings behind.
+		if spec.Tracing != nil {
+			w.ProxyConfig.Tracing = nil
+		}
+		proto.Merge(w.ProxyConfig, spec)
+	}
+	if image, ok := c.Annotations[annotation.SidecarProxyImage.Name]; ok {
+		w.Image = image
+	}
+	for name, value := range parseEnvironment(c.Annotations[config.AlphaProxyEnvironment.Name]) {
+		w.Env[name] = value
+	}
+	w.Sources = append(w.Sources, c.Namespace+"/"+c.Name)
+}
+
+// parseEnvironment parses a comma separated list of NAME=value pairs.
+func parseEnvironment(s string) map[string]string {
+	out := make(map[string]string)
+	if s == "" {
+		return out
+	}
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		name := strings.TrimSpace(kv[0])
+		if name == "" {
+			continue
+		}
+		if len(kv) > 1 {
+			out[name] = kv[1]
+		} else {
+			out[name] = ""
+		}
+	}
+	return out
+}
@@ -0,0 +1,134 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"istio.io/api/annotation"
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	"istio.io/istio/pkg/config"
+)
+
+func makeProxyConfig(name, namespace string, annotations map[string]string, spec *meshconfig.ProxyConfig) Config {
+	return Config{
+		ConfigMeta: ConfigMeta{
+			Type:              ProxyConfig.Type,
+			Name:              name,
+			Namespace:         namespace,
+			Annotations:       annotations,
+			CreationTimestamp: time.Unix(0, 0),
+		},
+		Spec: spec,
+	}
+}
+
+func TestEffectiveProxyConfig(t *testing.T) {
+	defaults := &meshconfig.ProxyConfig{
+		Concurrency:    2,
+		ServiceCluster: "istio-proxy",
+		Tracing: &meshconfig.Tracing{
+			Tracer: &meshconfig.Tracing_Zipkin_{Zipkin: &meshconfig.Tracing_Zipkin{Address: "zipkin:9411"}},
+		},
+	}
+	datadog := &meshconfig.Tracing{
+		Tracer: &meshconfig.Tracing_Datadog_{Datadog: &meshconfig.Tracing_Datadog{Address: "datadog:8126"}},
+	}
+
+	configs := []Config{
+		makeProxyConfig("selected", "ns", map[string]string{
+			config.AlphaWorkloadSelector.Name: "app=foo",
+			config.AlphaProxyEnvironment.Name: "LOG=debug,EMPTY",
+		}, &meshconfig.ProxyConfig{Concurrency: 8}),
+		makeProxyConfig("mesh", "istio-system", map[string]string{
+			annotation.SidecarProxyImage.Name: "docker.io/istio/proxyv2:mesh",
+		}, &meshconfig.ProxyConfig{Concurrency: 4, Tracing: datadog}),
+		makeProxyConfig("namespace", "ns", map[string]string{
+			config.AlphaProxyEnvironment.Name: "LOG=info,REGION=us",
+		}, &meshconfig.ProxyConfig{Concurrency: 6}),
+		makeProxyConfig("other-namespace", "other", nil, &meshconfig.ProxyConfig{Concurrency: 10}),
+		makeProxyConfig("unselected", "ns", map[string]string{
+			config.AlphaWorkloadSelector.Name: "app=bar",
+		}, &meshconfig.ProxyConfig{Concurrency: 12}),
+	}
+
+	cases := []struct {
+		name        string
+		namespace   string
+		labels      config.Labels
+		concurrency int32
+		image       string
+		env         map[string]string
+		sources     []string
+	}{
+		{
+			name:        "selected workload",
+			namespace:   "ns",
+			labels:      config.Labels{"app": "foo", "version": "v1"},
+			concurrency: 8,
+			image:       "docker.io/istio/proxyv2:mesh",
+			env:         map[string]string{"LOG": "debug", "REGION": "us", "EMPTY": ""},
+			sources:     []string{"istio-system/mesh", "ns/namespace", "ns/selected"},
+		},
+		{
+			name:        "namespace wide",
+			namespace:   "ns",
+			labels:      config.Labels{"app": "baz"},
+			concurrency: 6,
+			image:       "docker.io/istio/proxyv2:mesh",
+			env:         map[string]string{"LOG": "info", "REGION": "us"},
+			sources:     []string{"istio-system/mesh", "ns/namespace"},
+		},
+		{
+			name:        "mesh wide",
+			namespace:   "default",
+			labels:      config.Labels{"app": "foo"},
+			concurrency: 4,
+			image:       "docker.io/istio/proxyv2:mesh",
+			env:         map[string]string{},
+			sources:     []string{"istio-system/mesh"},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EffectiveProxyConfig(defaults, configs, "istio-system", tt.namespace, tt.labels)
+			if got.ProxyConfig.Concurrency != tt.concurrency {
+				t.Errorf("got concurrency %d, want %d", got.ProxyConfig.Concurrency, tt.concurrency)
+			}
+			if got.ProxyConfig.ServiceCluster != "istio-proxy" {
+				t.Errorf("default service cluster was not preserved: %q", got.ProxyConfig.ServiceCluster)
+			}
+			if got.ProxyConfig.GetTracing().GetZipkin() != nil || got.ProxyConfig.GetTracing().GetDatadog() == nil {
+				t.Errorf("tracing was not replaced: %v", got.ProxyConfig.GetTracing())
+			}
+			if got.Image != tt.image {
+				t.Errorf("got image %q, want %q", got.Image, tt.image)
+			}
+			if !reflect.DeepEqual(got.Env, tt.env) {
+				t.Errorf("got env %v, want %v", got.Env, tt.env)
+			}
+			if !reflect.DeepEqual(got.Sources, tt.sources) {
+				t.Errorf("got sources %v, want %v", got.Sources, tt.sources)
+			}
+		})
+	}
+
+	if defaults.Concurrency != 2 || defaults.GetTracing().GetZipkin() == nil {
+		t.Errorf("defaults were modified: %v", defaults)
+	}
+}
@@ -16,8 +16,10 @@ package model
 
 import (
 	"encoding/json"
+	"fmt"
 	"sort"
 	"sync"
+	"time"
 
 	networking "istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pilot/pkg/monitoring"
@@ -85,12 +87,19 @@ type PushContext struct {
 	// ServiceAccounts contains a map of hostname and port to service accounts.
 	ServiceAccounts map[config.Hostname]map[int][]string `json:"-"`
 
+	// endpointsTLSModes caches, per service port and subset labels, the TLS mode shared by all the
+	// endpoints, so that the clusters of all the proxies share a single lookup per push.
+	endpointsTLSModesMutex sync.RWMutex
+	endpointsTLSModes      map[string]string
+
 	initDone bool
 }
 
 type processedDestRules struct {
 	// List of dest rule hosts. We match with the most specific host first
 	hosts []config.Hostname
+	// Index of the hosts above, used to find the most specific host matching a service
+	index *config.HostnameIndex
 	// Map of dest rule host and the merged destination rules for that host
 	destRule map[config.Hostname]*combinedDestinationRule
 }
@@ -256,6 +265,17 @@ var (
 	// LastPushMutex will protect the LastPushStatus
 	LastPushMutex sync.Mutex
 
+	indexTag = monitoring.MustCreateTag("index")
+
+	// initTime tracks the time taken to build each index of the push context, and
+	// the push context as a whole.
+	initTime = monitoring.NewDistribution(
+		"pilot_push_context_init_time",
+		"Time in seconds taken to build the push context indexes.",
+		[]float64{.001, .01, .1, .5, 1, 3, 5, 10, 30},
+		indexTag,
+	)
+
 	// All metrics we registered.
 	metrics = []monitoring.Metric{
 		EndpointNoPod,
@@ -276,6 +296,7 @@ func init() {
 	for _, m := range metrics {
 		monitoring.MustRegisterViews(m)
 	}
+	monitoring.MustRegisterViews(initTime)
 }
 
 // NewPushContext creates a new PushContext structure to track push status.
@@ -298,6 +319,8 @@ func NewPushContext() *PushContext {
 		ServiceByHostname: map[config.Hostname]*Service{},
 		ProxyStatus:       map[string]map[string]ProxyPushStatus{},
 		ServiceAccounts:   map[config.Hostname]map[int][]string{},
+
+		endpointsTLSModes: map[string]string{},
 	}
 }
 
@@ -463,7 +486,7 @@ func (ps *PushContext) GetAllSidecarScopes() map[string][]*SidecarScope {
 func (ps *PushContext) DestinationRule(proxy *Proxy, service *Service) *Config {
 	// FIXME: this code should be removed once the EDS issue is fixed
 	if proxy == nil {
-		if host, ok := MostSpecificHostMatch(service.Hostname, ps.allExportedDestRules.hosts); ok {
+		if host, ok := ps.allExportedDestRules.index.MostSpecificMatch(service.Hostname); ok {
 			return ps.allExportedDestRules.destRule[host].config
 		}
 		return nil
@@ -488,8 +511,7 @@ func (ps *PushContext) DestinationRule(proxy *Proxy, service *Service) *Config {
 	if proxy.ConfigNamespace != ps.Env.Mesh.RootNamespace {
 		// search through the DestinationRules in proxy's namespace first
 		if ps.namespaceLocalDestRules[proxy.ConfigNamespace] != nil {
-			if host, ok := MostSpecificHostMatch(service.Hostname,
-				ps.namespaceLocalDestRules[proxy.ConfigNamespace].hosts); ok {
+			if host, ok := ps.namespaceLocalDestRules[proxy.ConfigNamespace].index.MostSpecificMatch(service.Hostname); ok {
 				return ps.namespaceLocalDestRules[proxy.ConfigNamespace].destRule[host].config
 			}
 		}
@@ -498,8 +520,7 @@ func (ps *PushContext) DestinationRule(proxy *Proxy, service *Service) *Config {
 	// if no private/public rule matched in the calling proxy's namespace,
 	// check the target service's namespace for public rules
 	if service.Attributes.Namespace != "" && ps.namespaceExportedDestRules[service.Attributes.Namespace] != nil {
-		if host, ok := MostSpecificHostMatch(service.Hostname,
-			ps.namespaceExportedDestRules[service.Attributes.Namespace].hosts); ok {
+		if host, ok := ps.namespaceExportedDestRules[service.Attributes.Namespace].index.MostSpecificMatch(service.Hostname); ok {
 			return ps.namespaceExportedDestRules[service.Attributes.Namespace].destRule[host].config
 		}
 	}
@@ -508,8 +529,7 @@ func (ps *PushContext) DestinationRule(proxy *Proxy, service *Service) *Config {
 	// target service's namespace matched, search for any public destination rule in the config root namespace
 	// NOTE: This does mean that we are effectively ignoring private dest rules in the config root namespace
 	if ps.namespaceExportedDestRules[ps.Env.Mesh.RootNamespace] != nil {
-		if host, ok := MostSpecificHostMatch(service.Hostname,
-			ps.namespaceExportedDestRules[ps.Env.Mesh.RootNamespace].hosts); ok {
+		if host, ok := ps.namespaceExportedDestRules[ps.Env.Mesh.RootNamespace].index.MostSpecificMatch(service.Hostname); ok {
 			return ps.namespaceExportedDestRules[ps.Env.Mesh.RootNamespace].destRule[host].config
 		}
 	}
@@ -549,6 +569,7 @@ func (ps *PushContext) InitContext(env *Environment) error {
 		return nil
 	}
 	ps.Env = env
+	start := time.Now()
 	var err error
 
 	// Must be initialized first
@@ -556,36 +577,63 @@ func (ps *PushContext) InitContext(env *Environment) error {
 	// use the default export map
 	ps.initDefaultExportMaps()
 
-	if err = ps.initServiceRegistry(env); err != nil {
+	// The service registry and config indexes are independent of each other,
+	// so they are built in parallel.
+	if err = ps.initIndexes(env); err != nil {
 		return err
 	}
 
-	if err = ps.initVirtualServices(env); err != nil {
+	// Must be initialized in the end
+	if err = ps.timeInit("sidecarscopes", ps.initSidecarScopes, env); err != nil {
 		return err
 	}
 
-	if err = ps.initDestinationRules(env); err != nil {
-		return err
-	}
+	ps.initDone = true
+	initTime.With(indexTag.Value("total")).Record(time.Since(start).Seconds())
+	return nil
+}
 
-	if err = ps.initAuthorizationPolicies(env); err != nil {
-		rbacLog.Errorf("failed to initialize authorization policies: %v", err)
-		return err
+// initIndexes builds the service registry and config indexes in parallel, returning
+// the first error in the order the indexes are listed.
+func (ps *PushContext) initIndexes(env *Environment) error {
+	inits := []struct {
+		index string
+		fn    func(env *Environment) error
+	}{
+		{"services", ps.initServiceRegistry},
+		{"virtualservices", ps.initVirtualServices},
+		{"destinationrules", ps.initDestinationRules},
+		{"authorizationpolicies", ps.initAuthorizationPolicies},
+		{"envoyfilters", ps.initEnvoyFilters},
 	}
 
-	if err = ps.initEnvoyFilters(env); err != nil {
-		return err
+	errs := make([]error, len(inits))
+	var wg sync.WaitGroup
+	for i := range inits {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = ps.timeInit(inits[i].index, inits[i].fn, env)
+		}(i)
 	}
+	wg.Wait()
 
-	// Must be initialized in the end
-	if err = ps.initSidecarScopes(env); err != nil {
-		return err
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
 	}
-
-	ps.initDone = true
 	return nil
 }
 
+// timeInit runs the init function, recording its duration.
+func (ps *PushContext) timeInit(index string, fn func(env *Environment) error, env *Environment) error {
+	start := time.Now()
+	err := fn(env)
+	initTime.With(indexTag.Value(index)).Record(time.Since(start).Seconds())
+	return err
+}
+
 // Caches list of services in the registry, and creates a map
 // of hostname to service
 func (ps *PushContext) initServiceRegistry(env *Environment) error {
@@ -626,6 +674,41 @@ func sortServicesByCreationTime(services []*Service) []*Service {
 	return services
 }
 
+// EndpointsTLSMode returns the TLS mode of all the endpoints of the service port selected by the
+// labels, config.IstioMutualTLSModeLabel or config.DisabledTLSModeLabel, or an empty string if
+// there is no endpoint, or if the endpoints have different or unknown TLS modes. The result is
+// cached for the push.
+func (ps *PushContext) EndpointsTLSMode(hostname config.Hostname, port int, labels config.Labels) string {
+	key := fmt.Sprintf("%s|%d|%s", hostname, port, labels)
+	ps.endpointsTLSModesMutex.RLock()
+	mode, f := ps.endpointsTLSModes[key]
+	ps.endpointsTLSModesMutex.RUnlock()
+	if f {
+		return mode
+	}
+
+	if ps.Env != nil {
+		var collection config.LabelsCollection
+		if len(labels) > 0 {
+			collection = config.LabelsCollection{labels}
+		}
+		if instances, err := ps.Env.InstancesByPort(hostname, port, collection); err == nil && len(instances) > 0 {
+			mode = instances[0].Endpoint.TLSMode
+			for _, instance := range instances[1:] {
+				if instance.Endpoint.TLSMode != mode {
+					mode = ""
+					break
+				}
+			}
+		}
+	}
+
+	ps.endpointsTLSModesMutex.Lock()
+	ps.endpointsTLSModes[key] = mode
+	ps.endpointsTLSModesMutex.Unlock()
+	return mode
+}
+
 // Caches list of service accounts in the registry
 func (ps *PushContext) initServiceAccounts(env *Environment, services []*Service) {
 	for _, svc := range services {
@@ -933,13 +1016,18 @@ func (ps *PushContext) SetDestinationRules(configs []Config) {
 
 	// presort it so that we don't sort it for each DestinationRule call.
 	// sort.Sort for Hostnames will automatically sort from the most specific to least specific
+	// The hosts are also indexed, so that the most specific host matching a service is found
+	// without scanning them.
 	for ns := range namespaceLocalDestRules {
 		sort.Sort(config.Hostnames(namespaceLocalDestRules[ns].hosts))
+		namespaceLocalDestRules[ns].index = config.NewHostnameIndex(namespaceLocalDestRules[ns].hosts)
 	}
 	for ns := range namespaceExportedDestRules {
 		sort.Sort(config.Hostnames(namespaceExportedDestRules[ns].hosts))
+		namespaceExportedDestRules[ns].index = config.NewHostnameIndex(namespaceExportedDestRules[ns].hosts)
 	}
 	sort.Sort(config.Hostnames(allExportedDestRules.hosts))
+	allExportedDestRules.index = config.NewHostnameIndex(allExportedDestRules.hosts)
 
 	ps.namespaceLocalDestRules = namespaceLocalDestRules
 	ps.namespaceExportedDestRules = namespaceExportedDestRules
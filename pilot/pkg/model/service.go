@@ -218,6 +218,10 @@ type NetworkEndpoint struct {
 
 	// The load balancing weight associated with this endpoint.
 	LbWeight uint32
+
+	// TLSMode is the TLS capability of the endpoint, one of config.IstioMutualTLSModeLabel and
+	// config.DisabledTLSModeLabel, or empty if the registry does not know it.
+	TLSMode string
 }
 
 // Probe represents a health probe associated with an instance of service.
@@ -332,6 +336,10 @@ type IstioEndpoint struct {
 
 	// The load balancing weight associated with this endpoint.
 	LbWeight uint32
+
+	// TLSMode is the TLS capability of the endpoint, one of config.IstioMutualTLSModeLabel and
+	// config.DisabledTLSModeLabel, or empty if the registry does not know it.
+	TLSMode string
 }
 
 // ServiceAttributes represents a group of custom attributes of the service.
@@ -346,6 +354,10 @@ type ServiceAttributes struct {
 	// a namespace when the namespace is imported.
 	ExportTo map[config.Visibility]bool
 
+	// ExposedToNetworks is true if the service is exposed to the other networks of the mesh
+	// through the east-west gateway.
+	ExposedToNetworks bool
+
 	// For Kubernetes platform
 
 	// ClusterExternalAddresses is a mapping between a cluster name and the external
@@ -107,6 +107,11 @@ type IstioEgressListenerWrapper struct {
 	// Go's map/hash data structure doesn't do such semantic matches
 	listenerHosts map[string][]config.Hostname
 
+	// Index of the listenerHosts of each namespace, used to select the
+	// services and virtual services matching them without scanning all
+	// the imported hosts for each service.
+	listenerHostIndex map[string]*config.HostnameIndex
+
 	// List of services imported by this egress listener extracted from the
 	// listenerHosts above. This will be used by LDS and RDS code when
 	// building the set of virtual hosts or the tcp filterchain matches for
@@ -249,6 +254,11 @@ func convertIstioListenerToWrapper(ps *PushContext, configNamespace string,
 		}
 	}
 
+	out.listenerHostIndex = make(map[string]*config.HostnameIndex, len(out.listenerHosts))
+	for ns, hosts := range out.listenerHosts {
+		out.listenerHostIndex[ns] = config.NewHostnameIndex(hosts)
+	}
+
 	dummyNode := Proxy{
 		ConfigNamespace: configNamespace,
 	}
@@ -356,44 +366,28 @@ func (ilw *IstioEgressListenerWrapper) selectVirtualServices(virtualServices []C
 		// entry */virtualServiceHost, select the virtual service and break out of the loop.
 
 		// Check if there is an explicit import of form ns/* or ns/host
-		if importedHosts, nsFound := ilw.listenerHosts[configNamespace]; nsFound {
-			for _, importedHost := range importedHosts {
-				// Check if the hostnames match per usual hostname matching rules
-				hostFound := false
-				for _, h := range rule.Hosts {
-					// TODO: This is a bug. VirtualServices can have many hosts
-					// while the user might be importing only a single host
-					// We need to generate a new VirtualService with just the matched host
-					if importedHost.Matches(config.Hostname(h)) {
-						importedVirtualServices = append(importedVirtualServices, c)
-						hostFound = true
-						break
-					}
-				}
-
-				if hostFound {
+		if importedHosts, nsFound := ilw.listenerHostIndex[configNamespace]; nsFound {
+			// Check if the hostnames match per usual hostname matching rules
+			for _, h := range rule.Hosts {
+				// TODO: This is a bug. VirtualServices can have many hosts
+				// while the user might be importing only a single host
+				// We need to generate a new VirtualService with just the matched host
+				if _, ok := importedHosts.MostSpecificMatch(config.Hostname(h)); ok {
+					importedVirtualServices = append(importedVirtualServices, c)
 					break
 				}
 			}
 		}
 
 		// Check if there is an import of form */host or */*
-		if importedHosts, wnsFound := ilw.listenerHosts[wildcardNamespace]; wnsFound {
-			for _, importedHost := range importedHosts {
-				// Check if the hostnames match per usual hostname matching rules
-				hostFound := false
-				for _, h := range rule.Hosts {
-					// TODO: This is a bug. VirtualServices can have many hosts
-					// while the user might be importing only a single host
-					// We need to generate a new VirtualService with just the matched host
-					if importedHost.Matches(config.Hostname(h)) {
-						importedVirtualServices = append(importedVirtualServices, c)
-						hostFound = true
-						break
-					}
-				}
-
-				if hostFound {
+		if importedHosts, wnsFound := ilw.listenerHostIndex[wildcardNamespace]; wnsFound {
+			// Check if the hostnames match per usual hostname matching rules
+			for _, h := range rule.Hosts {
+				// TODO: This is a bug. VirtualServices can have many hosts
+				// while the user might be importing only a single host
+				// We need to generate a new VirtualService with just the matched host
+				if _, ok := importedHosts.MostSpecificMatch(config.Hostname(h)); ok {
+					importedVirtualServices = append(importedVirtualServices, c)
 					break
 				}
 			}
@@ -411,35 +405,25 @@ func (ilw *IstioEgressListenerWrapper) selectServices(services []*Service) []*Se
 	for _, s := range services {
 		configNamespace := s.Attributes.Namespace
 		// Check if there is an explicit import of form ns/* or ns/host
-		if importedHosts, nsFound := ilw.listenerHosts[configNamespace]; nsFound {
-			hostFound := false
-			for _, importedHost := range importedHosts {
-				// Check if the hostnames match per usual hostname matching rules
-				if importedHost.Matches(s.Hostname) {
-					// TODO: See if the service's ports match.
-					//   If there is a listener port for this Listener, then
-					//   check if the service has a port of same value.
-					//   If not, check if the service has a single port - and choose that port
-					//   if service has multiple ports none of which match the listener port, check if there is
-					//   a virtualService with match Port
-					importedServices = append(importedServices, s)
-					hostFound = true
-					break
-				}
-			}
-			if hostFound {
+		if importedHosts, nsFound := ilw.listenerHostIndex[configNamespace]; nsFound {
+			// Check if the hostnames match per usual hostname matching rules
+			if _, ok := importedHosts.MostSpecificMatch(s.Hostname); ok {
+				// TODO: See if the service's ports match.
+				//   If there is a listener port for this Listener, then
+				//   check if the service has a port of same value.
+				//   If not, check if the service has a single port - and choose that port
+				//   if service has multiple ports none of which match the listener port, check if there is
+				//   a virtualService with match Port
+				importedServices = append(importedServices, s)
 				continue
 			}
 		}
 
 		// Check if there is an import of form */host or */*
-		if importedHosts, wnsFound := ilw.listenerHosts[wildcardNamespace]; wnsFound {
-			for _, importedHost := range importedHosts {
-				// Check if the hostnames match per usual hostname matching rules
-				if importedHost.Matches(s.Hostname) {
-					importedServices = append(importedServices, s)
-					break
-				}
+		if importedHosts, wnsFound := ilw.listenerHostIndex[wildcardNamespace]; wnsFound {
+			// Check if the hostnames match per usual hostname matching rules
+			if _, ok := importedHosts.MostSpecificMatch(s.Hostname); ok {
+				importedServices = append(importedServices, s)
 			}
 		}
 	}
@@ -0,0 +1,49 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitoring
+
+import (
+	"sync"
+
+	"istio.io/istio/pkg/histogram"
+	"istio.io/pkg/env"
+	"istio.io/pkg/log"
+)
+
+var (
+	histogramBucketsVar = env.RegisterStringVar(
+		"PILOT_HISTOGRAM_BUCKETS",
+		"",
+		"Bucket boundaries of the pilot histograms, overriding the defaults, as a semicolon separated list of "+
+			"<metric>=<bound>,<bound>,... e.g. pilot_proxy_convergence_time=0.01,0.1,0.5,1,3,5,10")
+
+	histogramBucketsOnce sync.Once
+	histogramBuckets     map[string][]float64
+)
+
+// Buckets returns the bucket boundaries of the named histogram set in PILOT_HISTOGRAM_BUCKETS, or
+// the default boundaries if not set.
+func Buckets(name string, defaults []float64) []float64 {
+	histogramBucketsOnce.Do(func() {
+		var err error
+		if histogramBuckets, err = histogram.ParseBuckets(histogramBucketsVar.Get()); err != nil {
+			log.Errorf("ignoring the invalid PILOT_HISTOGRAM_BUCKETS: %v", err)
+		}
+	})
+	if bounds, ok := histogramBuckets[name]; ok {
+		return bounds
+	}
+	return defaults
+}
@@ -92,9 +92,10 @@ func NewGauge(name, description string, tags ...Tag) Metric {
 }
 
 // NewDistribution creates a new Metric with an aggregration type of Distribution. This means that the
-// data collected by the Metric will be collected and exported as a histogram, with the specified bounds.
+// data collected by the Metric will be collected and exported as a histogram, with the specified bounds,
+// unless other bounds are set for the metric in PILOT_HISTOGRAM_BUCKETS.
 func NewDistribution(name, description string, bounds []float64, tags ...Tag) Metric {
-	return newMetric(name, description, view.Distribution(bounds...), tags...)
+	return newMetric(name, description, view.Distribution(Buckets(name, bounds)...), tags...)
 }
 
 func newMetric(name, description string, aggregation *view.Aggregation, tags ...Tag) Metric {
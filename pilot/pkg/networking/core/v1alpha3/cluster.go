@@ -174,6 +174,8 @@ func (configgen *ConfigGeneratorImpl) buildOutboundClusters(env *model.Environme
 					clusterMode:     DefaultClusterMode,
 					direction:       model.TrafficDirectionOutbound,
 					proxy:           proxy,
+					push:            push,
+					service:         service,
 				}
 
 				applyTrafficPolicy(opts)
@@ -201,6 +203,9 @@ func (configgen *ConfigGeneratorImpl) buildOutboundClusters(env *model.Environme
 						clusterMode:     DefaultClusterMode,
 						direction:       model.TrafficDirectionOutbound,
 						proxy:           proxy,
+						push:            push,
+						service:         service,
+						subsetLabels:    subset.Labels,
 					}
 					applyTrafficPolicy(opts)
 
@@ -214,6 +219,9 @@ func (configgen *ConfigGeneratorImpl) buildOutboundClusters(env *model.Environme
 						clusterMode:     DefaultClusterMode,
 						direction:       model.TrafficDirectionOutbound,
 						proxy:           proxy,
+						push:            push,
+						service:         service,
+						subsetLabels:    subset.Labels,
 					}
 					applyTrafficPolicy(opts)
 
@@ -706,6 +714,11 @@ type buildClusterOpts struct {
 	clusterMode     ClusterMode
 	direction       model.TrafficDirection
 	proxy           *model.Proxy
+	// service and subsetLabels select the endpoints of outbound clusters, whose TLS capability
+	// is checked in the push before applying Istio mutual TLS.
+	push         *model.PushContext
+	service      *model.Service
+	subsetLabels config.Labels
 }
 
 func applyTrafficPolicy(opts buildClusterOpts) {
@@ -716,10 +729,24 @@ func applyTrafficPolicy(opts buildClusterOpts) {
 	applyLoadBalancer(opts.cluster, loadBalancer, opts.port)
 	if opts.clusterMode != SniDnatClusterMode {
 		tls = conditionallyConvertToIstioMtls(tls, opts.serviceAccounts, opts.sni, opts.proxy)
+		// The TLS settings of the user are kept, whatever the TLS mode of the endpoints.
+		if tls == nil && endpointsAcceptIstioMutualTLS(opts) {
+			log.Debugf("endpoints of cluster %s all accept Istio mutual TLS, applying it", opts.cluster.Name)
+			tls = buildIstioMutualTLS(opts.serviceAccounts, opts.sni, opts.proxy)
+		}
 		applyUpstreamTLSSettings(opts.env, opts.cluster, tls, opts.proxy.Metadata)
 	}
 }
 
+// endpointsAcceptIstioMutualTLS returns true if all the endpoints of the outbound cluster are known
+// to accept Istio mutual TLS, e.g. workloads with a sidecar.
+func endpointsAcceptIstioMutualTLS(opts buildClusterOpts) bool {
+	if opts.push == nil || opts.service == nil || opts.port == nil || !features.EnableTLSModeFiltering() {
+		return false
+	}
+	return opts.push.EndpointsTLSMode(opts.service.Hostname, opts.port.Port, opts.subsetLabels) == config.IstioMutualTLSModeLabel
+}
+
 // FIXME: there isn't a way to distinguish between unset values and zero values
 func applyConnectionPool(env *model.Environment, cluster *apiv2.Cluster, settings *networking.ConnectionPoolSettings, direction model.TrafficDirection) {
 	if settings == nil {
@@ -1101,6 +1128,10 @@ func buildDefaultCluster(env *model.Environment, name string, discoveryType apiv
 
 	if discoveryType == apiv2.Cluster_STRICT_DNS {
 		cluster.DnsLookupFamily = apiv2.Cluster_V4_ONLY
+		if proxy != nil && isDualStackProxy(proxy) {
+			// Resolve both address families, preferring ipv6.
+			cluster.DnsLookupFamily = apiv2.Cluster_AUTO
+		}
 		dnsRate := util.GogoDurationToDuration(env.Mesh.DnsRefreshRate)
 		cluster.DnsRefreshRate = &dnsRate
 	}
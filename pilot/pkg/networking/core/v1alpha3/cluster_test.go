@@ -721,6 +721,56 @@ func TestConditionallyConvertToIstioMtls(t *testing.T) {
 	}
 }
 
+func TestApplyTrafficPolicyEndpointsTLSMode(t *testing.T) {
+	_ = os.Setenv("PILOT_ENABLE_TLS_MODE_FILTERING", "true")
+	defer os.Unsetenv("PILOT_ENABLE_TLS_MODE_FILTERING")
+
+	port := &model.Port{Name: "http", Port: 8080, Protocol: config.ProtocolHTTP}
+	service := &model.Service{Hostname: "foo.example.org", Ports: model.PortList{port}}
+	instances := func(tlsMode string) []*model.ServiceInstance {
+		return []*model.ServiceInstance{{
+			Service:  service,
+			Endpoint: model.NetworkEndpoint{Address: "10.0.0.1", Port: 8080, ServicePort: port, TLSMode: tlsMode},
+		}}
+	}
+	istioMutual := &networking.TrafficPolicy{Tls: &networking.TLSSettings{Mode: networking.TLSSettings_ISTIO_MUTUAL}}
+
+	cases := []struct {
+		name    string
+		tlsMode string
+		policy  *networking.TrafficPolicy
+		wantTLS bool
+	}{
+		{"istio mutual to plaintext endpoints", config.DisabledTLSModeLabel, istioMutual, true},
+		{"istio mutual to sidecars", config.IstioMutualTLSModeLabel, istioMutual, true},
+		{"no TLS settings to sidecars", config.IstioMutualTLSModeLabel, nil, true},
+		{"no TLS settings to plaintext endpoints", config.DisabledTLSModeLabel, nil, false},
+		{"no TLS settings to unknown endpoints", "", nil, false},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			serviceDiscovery := &fakes.ServiceDiscovery{}
+			serviceDiscovery.InstancesByPortReturns(instances(tt.tlsMode), nil)
+			env := newTestEnvironment(serviceDiscovery, config.DefaultMeshConfig(), &fakes.IstioConfigStore{})
+			cluster := &apiv2.Cluster{Name: "outbound|8080||foo.example.org"}
+			applyTrafficPolicy(buildClusterOpts{
+				env:         env,
+				cluster:     cluster,
+				policy:      tt.policy,
+				port:        port,
+				clusterMode: DefaultClusterMode,
+				direction:   model.TrafficDirectionOutbound,
+				proxy:       &model.Proxy{Type: model.SidecarProxy, Metadata: map[string]string{}},
+				push:        env.PushContext,
+				service:     service,
+			})
+			if got := cluster.TlsContext != nil; got != tt.wantTLS {
+				t.Errorf("applyTrafficPolicy() => got TLS %v, want %v", got, tt.wantTLS)
+			}
+		})
+	}
+}
+
 func TestDisablePanicThresholdAsDefault(t *testing.T) {
 	g := NewGomegaWithT(t)
 
@@ -1017,3 +1067,23 @@ func TestRedisProtocolCluster(t *testing.T) {
 		}
 	}
 }
+
+func TestDualStackDNSLookupFamily(t *testing.T) {
+	g := NewGomegaWithT(t)
+	_ = os.Setenv("PILOT_ENABLE_DUAL_STACK", "true")
+	defer func() { _ = os.Unsetenv("PILOT_ENABLE_DUAL_STACK") }()
+
+	env := newTestEnvironment(&fakes.ServiceDiscovery{}, testMesh, &fakes.IstioConfigStore{})
+	for _, tt := range []struct {
+		name     string
+		proxy    *model.Proxy
+		expected apiv2.Cluster_DnsLookupFamily
+	}{
+		{"ipv4", &model.Proxy{IPAddresses: []string{"6.6.6.6"}}, apiv2.Cluster_V4_ONLY},
+		{"dual-stack", &model.Proxy{IPAddresses: []string{"6.6.6.6", "1111:2222::1"}}, apiv2.Cluster_AUTO},
+	} {
+		cluster := buildDefaultCluster(env, "outbound|8080||foo.com", apiv2.Cluster_STRICT_DNS, nil,
+			model.TrafficDirectionOutbound, tt.proxy, nil)
+		g.Expect(cluster.DnsLookupFamily).To(Equal(tt.expected), tt.name)
+	}
+}
@@ -17,9 +17,11 @@ package v1alpha3
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"net"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -90,6 +92,10 @@ const (
 	// This cluster is created in bootstrap.
 	EnvoyAccessLogCluster = "envoy_accesslog_service"
 
+	// accessLogServiceSamplingRuntimeKey is the runtime key overriding the sampling of the requests
+	// and connections logged to the Envoy ALS.
+	accessLogServiceSamplingRuntimeKey = "istio.access_log_service.sampling"
+
 	// ProxyInboundListenPort is the port on which all inbound traffic to the pod/vm will be captured to
 	// TODO: allow configuration through mesh config
 	ProxyInboundListenPort = 15006
@@ -167,6 +173,34 @@ func buildAccessLog(fl *accesslogconfig.FileAccessLog, env *model.Environment) {
 	}
 }
 
+// buildAccessLogServiceFilter returns the filter sampling the requests and connections of the proxy
+// logged to the Envoy ALS, as set in its metadata, or nil if all of them are logged.
+func buildAccessLogServiceFilter(node *model.Proxy) *accesslog.AccessLogFilter {
+	value, found := node.Metadata[model.NodeMetadataAccessLogServiceSampling]
+	if !found {
+		return nil
+	}
+	percent, err := strconv.ParseFloat(value, 64)
+	if err != nil || percent < 0 || percent > 100 {
+		log.Warnf("invalid access log service sampling %q for proxy %s, logging all the requests", value, node.ID)
+		return nil
+	}
+	if percent == 100 {
+		return nil
+	}
+	return &accesslog.AccessLogFilter{
+		FilterSpecifier: &accesslog.AccessLogFilter_RuntimeFilter{
+			RuntimeFilter: &accesslog.RuntimeFilter{
+				RuntimeKey: accessLogServiceSamplingRuntimeKey,
+				PercentSampled: &envoy_type.FractionalPercent{
+					Numerator:   uint32(math.Round(percent * 10000)),
+					Denominator: envoy_type.FractionalPercent_MILLION,
+				},
+			},
+		},
+	}
+}
+
 var (
 	// TODO: gauge should be reset on refresh, not the best way to represent errors but better
 	// than nothing.
@@ -1491,7 +1525,8 @@ func buildHTTPConnectionManager(node *model.Proxy, env *model.Environment, httpO
 		}
 
 		acc := &accesslog.AccessLog{
-			Name: xdsutil.HTTPGRPCAccessLog,
+			Name:   xdsutil.HTTPGRPCAccessLog,
+			Filter: buildAccessLogServiceFilter(node),
 		}
 
 		if util.IsXDSMarshalingToAnyEnabled(node) {
@@ -1603,7 +1638,7 @@ func buildListener(opts buildListenerOpts) *xdsapi.Listener {
 		// TODO: need to sanitize the opts.bind if its a UDS socket, as it could have colons, that envoy
 		// doesn't like
 		Name:            fmt.Sprintf("%s_%d", opts.bind, opts.port),
-		Address:         util.BuildAddress(opts.bind, uint32(opts.port)),
+		Address:         buildListenerAddress(opts.proxy, opts.bind, uint32(opts.port)),
 		ListenerFilters: listenerFilters,
 		FilterChains:    filterChains,
 		DeprecatedV1:    deprecatedV1,
@@ -1729,7 +1764,12 @@ func buildCompleteFilterChain(pluginParams *plugin.InputParams, mutable *plugin.
 // depending on value of proxy's IPAddresses. This function checks each element
 // and if there is at least one ipv4 address other than 127.0.0.1, it will use ipv4 address,
 // if all addresses are ipv6  addresses then ipv6 address will be used to get wildcard and local host address.
+// Dual-stack proxies use the ipv6 wildcard, which accepts ipv4 connections as well when the listener
+// address enables ipv4 compatibility, and the ipv4 local host.
 func getActualWildcardAndLocalHost(node *model.Proxy) (string, string) {
+	if isDualStackProxy(node) {
+		return WildcardIPv6Address, LocalhostAddress
+	}
 	for i := 0; i < len(node.IPAddresses); i++ {
 		addr := net.ParseIP(node.IPAddresses[i])
 		if addr == nil {
@@ -1744,11 +1784,46 @@ func getActualWildcardAndLocalHost(node *model.Proxy) (string, string) {
 	return WildcardIPv6Address, LocalhostIPv6Address
 }
 
+// isDualStackProxy returns true if dual-stack support is enabled and the proxy has both
+// ipv4 and ipv6 addresses, ignoring loopback addresses.
+func isDualStackProxy(node *model.Proxy) bool {
+	if !features.EnableDualStack() {
+		return false
+	}
+	var hasIPv4, hasIPv6 bool
+	for _, ipAddr := range node.IPAddresses {
+		addr := net.ParseIP(ipAddr)
+		if addr == nil || addr.IsLoopback() {
+			continue
+		}
+		if addr.To4() != nil {
+			hasIPv4 = true
+		} else {
+			hasIPv6 = true
+		}
+	}
+	return hasIPv4 && hasIPv6
+}
+
+// buildListenerAddress builds the address a listener binds to. Dual-stack proxies binding to the
+// ipv6 wildcard enable ipv4 compatibility, so that the listener accepts connections of both families.
+func buildListenerAddress(node *model.Proxy, bind string, port uint32) core.Address {
+	address := util.BuildAddress(bind, port)
+	if bind == WildcardIPv6Address && node != nil && isDualStackProxy(node) {
+		address.GetSocketAddress().Ipv4Compat = true
+	}
+	return address
+}
+
 // getSidecarInboundBindIP returns the IP that the proxy can bind to along with the sidecar specified port.
 // It looks for an unicast address, if none found, then the default wildcard address is used.
 // This will make the inbound listener bind to instance_ip:port instead of 0.0.0.0:port where applicable.
 func getSidecarInboundBindIP(node *model.Proxy) string {
 	defaultInboundIP, _ := getActualWildcardAndLocalHost(node)
+	// Binding to a single address would only accept connections of its family.
+	if isDualStackProxy(node) {
+		return defaultInboundIP
+	}
 	for _, ipAddr := range node.IPAddresses {
 		ip := net.ParseIP(ipAddr)
 		// Return the IP if its a global unicast address.
@@ -129,7 +129,7 @@ func (builder *ListenerBuilder) buildVirtualOutboundListener(
 	// add an extra listener that binds to the port that is the recipient of the iptables redirect
 	ipTablesListener := &xdsapi.Listener{
 		Name:           VirtualOutboundListenerName,
-		Address:        util.BuildAddress(actualWildcard, uint32(env.Mesh.ProxyListenPort)),
+		Address:        buildListenerAddress(node, actualWildcard, uint32(env.Mesh.ProxyListenPort)),
 		Transparent:    isTransparentProxy,
 		UseOriginalDst: proto.BoolTrue,
 		FilterChains: []listener.FilterChain{
@@ -157,7 +157,7 @@ func (builder *ListenerBuilder) buildVirtualInboundListener(env *model.Environme
 	// add an extra listener that binds to the port that is the recipient of the iptables redirect
 	builder.virtualInboundListener = &xdsapi.Listener{
 		Name:           VirtualInboundListenerName,
-		Address:        util.BuildAddress(actualWildcard, ProxyInboundListenPort),
+		Address:        buildListenerAddress(node, actualWildcard, ProxyInboundListenPort),
 		Transparent:    isTransparentProxy,
 		UseOriginalDst: proto.BoolTrue,
 		FilterChains: []listener.FilterChain{
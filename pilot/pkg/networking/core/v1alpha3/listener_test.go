@@ -17,12 +17,14 @@ package v1alpha3
 import (
 	"fmt"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
 	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
 	tcp_proxy "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/tcp_proxy/v2"
+	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type"
 	"github.com/envoyproxy/go-control-plane/pkg/util"
 	xdsutil "github.com/envoyproxy/go-control-plane/pkg/util"
 	"github.com/gogo/protobuf/proto"
@@ -265,6 +267,65 @@ func TestGetActualWildcardAndLocalHost(t *testing.T) {
 	}
 }
 
+func TestDualStackProxy(t *testing.T) {
+	_ = os.Setenv("PILOT_ENABLE_DUAL_STACK", "true")
+	defer func() { _ = os.Unsetenv("PILOT_ENABLE_DUAL_STACK") }()
+
+	dualStack := &model.Proxy{IPAddresses: []string{"1.1.1.1", "1111:2222::1"}}
+	ipv6 := &model.Proxy{IPAddresses: []string{"1111:2222::1"}}
+
+	for _, tt := range []struct {
+		name     string
+		proxy    *model.Proxy
+		expected [2]string
+	}{
+		{
+			name: "mixed ipv4 and ipv6",
+			proxy: &model.Proxy{
+				IPAddresses: []string{"1111:2222::1", "::1", "127.0.0.1", "2.2.2.2", "2222:3333::1"},
+			},
+			expected: [2]string{WildcardIPv6Address, LocalhostAddress},
+		},
+		{
+			name: "ipv4 with ipv6 loopback",
+			proxy: &model.Proxy{
+				IPAddresses: []string{"1.1.1.1", "::1"},
+			},
+			expected: [2]string{WildcardAddress, LocalhostAddress},
+		},
+		{
+			name:     "ipv6 only",
+			proxy:    ipv6,
+			expected: [2]string{WildcardIPv6Address, LocalhostIPv6Address},
+		},
+	} {
+		if wm, lh := getActualWildcardAndLocalHost(tt.proxy); wm != tt.expected[0] || lh != tt.expected[1] {
+			t.Errorf("%s: got %s / %s, want %s / %s", tt.name, wm, lh, tt.expected[0], tt.expected[1])
+		}
+	}
+
+	if got := getSidecarInboundBindIP(dualStack); got != WildcardIPv6Address {
+		t.Errorf("dual-stack inbound bind: got %s, want %s", got, WildcardIPv6Address)
+	}
+	if got := getSidecarInboundBindIP(ipv6); got != "1111:2222::1" {
+		t.Errorf("ipv6 inbound bind: got %s, want 1111:2222::1", got)
+	}
+
+	address := buildListenerAddress(dualStack, WildcardIPv6Address, 15001)
+	if !address.GetSocketAddress().Ipv4Compat {
+		t.Errorf("dual-stack listener address should enable ipv4 compatibility: %v", address)
+	}
+	address = buildListenerAddress(ipv6, WildcardIPv6Address, 15001)
+	if address.GetSocketAddress().Ipv4Compat {
+		t.Errorf("ipv6 listener address should not enable ipv4 compatibility: %v", address)
+	}
+
+	_ = os.Setenv("PILOT_ENABLE_DUAL_STACK", "false")
+	if wm, lh := getActualWildcardAndLocalHost(dualStack); wm != WildcardAddress || lh != LocalhostAddress {
+		t.Errorf("dual-stack disabled: got %s / %s, want %s / %s", wm, lh, WildcardAddress, LocalhostAddress)
+	}
+}
+
 func testOutboundListenerConflict(t *testing.T, services ...*model.Service) {
 	t.Helper()
 
@@ -588,6 +649,44 @@ func TestOutboundListenerAccessLogs(t *testing.T) {
 	}
 }
 
+func TestAccessLogServiceSampling(t *testing.T) {
+	defer delete(proxy.Metadata, model.NodeMetadataAccessLogServiceSampling)
+
+	cases := []struct {
+		sampling string
+		want     *envoy_type.FractionalPercent
+	}{
+		{sampling: "12.5", want: &envoy_type.FractionalPercent{Numerator: 125000, Denominator: envoy_type.FractionalPercent_MILLION}},
+		{sampling: "0", want: &envoy_type.FractionalPercent{Numerator: 0, Denominator: envoy_type.FractionalPercent_MILLION}},
+		{sampling: "100"},
+		{sampling: "120"},
+		{sampling: "invalid"},
+	}
+	for _, c := range cases {
+		t.Run(c.sampling, func(t *testing.T) {
+			proxy.Metadata[model.NodeMetadataAccessLogServiceSampling] = c.sampling
+			for _, l := range buildAllListeners(&fakePlugin{}, nil) {
+				if l.Name != "virtual" {
+					continue
+				}
+				fc := &tcp_proxy.TcpProxy{}
+				if err := getFilterConfig(l.FilterChains[0].Filters[0], fc); err != nil {
+					t.Fatalf("failed to get TCP Proxy config: %s", err)
+				}
+				var got *envoy_type.FractionalPercent
+				for _, acc := range fc.AccessLog {
+					if acc.Name == xdsutil.HTTPGRPCAccessLog && acc.Filter != nil {
+						got = acc.Filter.GetRuntimeFilter().PercentSampled
+					}
+				}
+				if !reflect.DeepEqual(got, c.want) {
+					t.Errorf("got access log service sampling %v, want %v", got, c.want)
+				}
+			}
+		})
+	}
+}
+
 func verifyOutboundTCPListenerHostname(t *testing.T, l *xdsapi.Listener, hostname config.Hostname) {
 	t.Helper()
 	if len(l.FilterChains) != 1 {
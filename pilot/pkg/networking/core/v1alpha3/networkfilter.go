@@ -91,7 +91,8 @@ func setAccessLog(env *model.Environment, node *model.Proxy, config *tcp_proxy.T
 		}
 
 		acc := &accesslog.AccessLog{
-			Name: xdsutil.HTTPGRPCAccessLog,
+			Name:   xdsutil.HTTPGRPCAccessLog,
+			Filter: buildAccessLogServiceFilter(node),
 		}
 
 		if util.IsXDSMarshalingToAnyEnabled(node) {
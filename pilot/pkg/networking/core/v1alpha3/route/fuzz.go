@@ -0,0 +1,77 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build gofuzz
+
+package route
+
+import (
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config"
+)
+
+var (
+	fuzzServices = map[config.Hostname]*model.Service{
+		"reviews.default.svc.cluster.local": {
+			Hostname:    "reviews.default.svc.cluster.local",
+			Address:     "10.0.0.1",
+			ClusterVIPs: make(map[string]string),
+			Ports: model.PortList{
+				{Name: "http", Port: 80, Protocol: config.ProtocolHTTP},
+			},
+		},
+	}
+	fuzzProxy = &model.Proxy{
+		Type:        model.SidecarProxy,
+		IPAddresses: []string{"10.0.0.2"},
+		ID:          "productpage.default",
+		DNSDomain:   "default.svc.cluster.local",
+		Metadata:    map[string]string{model.NodeMetadataIstioProxyVersion: "1.2"},
+	}
+)
+
+// Fuzz builds the routes of the VirtualService in the YAML data, for go-fuzz:
+//
+//     go-fuzz-build istio.io/istio/pilot/pkg/networking/core/v1alpha3/route
+//     go-fuzz -bin route-fuzz.zip -workdir fuzz
+//
+// VirtualServices rejected by validation are skipped, since they are not applied to the mesh.
+func Fuzz(data []byte) int {
+	spec, err := model.VirtualService.FromYAML(string(data))
+	if err != nil {
+		return 0
+	}
+	if err := model.VirtualService.Validate("fuzz", "default", spec); err != nil {
+		return 0
+	}
+	virtualService := model.Config{
+		ConfigMeta: model.ConfigMeta{
+			Type:      model.VirtualService.Type,
+			Version:   model.VirtualService.Version,
+			Name:      "fuzz",
+			Namespace: "default",
+		},
+		Spec: spec,
+	}
+
+	meshConfig := config.DefaultMeshConfig()
+	push := model.NewPushContext()
+	push.Env = &model.Environment{Mesh: &meshConfig}
+	gatewayNames := map[string]bool{"default/gateway": true, config.IstioMeshGateway: true}
+	if _, err := BuildHTTPRoutesForVirtualService(fuzzProxy, push, virtualService, fuzzServices, 80,
+		config.LabelsCollection{}, gatewayNames); err != nil {
+		return 0
+	}
+	return 1
+}
@@ -373,6 +373,8 @@ func translateRoute(push *model.PushContext, node *model.Proxy, in *networking.H
 			action.MaxGrpcTimeout = &d
 		}
 
+		action.IdleTimeout = translateIdleTimeout(virtualService)
+
 		out.Action = &route.Route_Route{Route: action}
 
 		if rewrite := in.Rewrite; rewrite != nil {
@@ -402,7 +404,10 @@ func translateRoute(push *model.PushContext, node *model.Proxy, in *networking.H
 
 		if in.Mirror != nil {
 			n := GetDestinationCluster(in.Mirror, serviceRegistry[config.Hostname(in.Mirror.Host)], port)
-			action.RequestMirrorPolicy = &route.RouteAction_RequestMirrorPolicy{Cluster: n}
+			action.RequestMirrorPolicy = &route.RouteAction_RequestMirrorPolicy{
+				Cluster:         n,
+				RuntimeFraction: translateMirrorPercent(virtualService),
+			}
 		}
 
 		// TODO: eliminate this logic and use the total_weight option in envoy route
@@ -715,6 +720,40 @@ func translatePercentToFractionalPercent(p *networking.Percent) *xdstype.Fractio
 	}
 }
 
+// translateMirrorPercent returns the runtime fraction of requests to mirror, as configured by the
+// mirrorPercent annotation of the virtual service. A nil result mirrors all requests.
+func translateMirrorPercent(virtualService model.Config) *core.RuntimeFractionalPercent {
+	value, ok := virtualService.Annotations[config.AlphaNetworkingMirrorPercent.Name]
+	if !ok {
+		return nil
+	}
+	percent, err := strconv.ParseFloat(value, 64)
+	if err != nil || percent < 0 || percent > 100 {
+		log.Warnf("Invalid %s annotation %q on virtual service %s/%s, mirroring all requests",
+			config.AlphaNetworkingMirrorPercent.Name, value, virtualService.Namespace, virtualService.Name)
+		return nil
+	}
+	return &core.RuntimeFractionalPercent{
+		DefaultValue: translatePercentToFractionalPercent(&networking.Percent{Value: percent}),
+	}
+}
+
+// translateIdleTimeout returns the route idle timeout configured by the idleTimeout annotation of
+// the virtual service. A nil result keeps the stream idle timeout of the connection manager.
+func translateIdleTimeout(virtualService model.Config) *time.Duration {
+	value, ok := virtualService.Annotations[config.AlphaNetworkingIdleTimeout.Name]
+	if !ok {
+		return nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		log.Warnf("Invalid %s annotation %q on virtual service %s/%s, ignoring",
+			config.AlphaNetworkingIdleTimeout.Name, value, virtualService.Namespace, virtualService.Name)
+		return nil
+	}
+	return &d
+}
+
 // translateIntegerToFractionalPercent translates an int32 instance to an
 // envoy.type.FractionalPercent instance.
 func translateIntegerToFractionalPercent(p int32) *xdstype.FractionalPercent {
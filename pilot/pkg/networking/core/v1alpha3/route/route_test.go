@@ -299,6 +299,48 @@ func TestBuildHTTPRoutes(t *testing.T) {
 		g.Expect(ok).NotTo(gomega.BeFalse())
 		g.Expect(redirectAction.Redirect.ResponseCode).To(gomega.Equal(envoyroute.RedirectAction_PERMANENT_REDIRECT))
 	})
+
+	t.Run("for mirror with percentage", func(t *testing.T) {
+		g := gomega.NewGomegaWithT(t)
+
+		routes, err := route.BuildHTTPRoutesForVirtualService(node, nil, virtualServiceWithMirrorPercent,
+			serviceRegistry, 8080, config.LabelsCollection{}, gatewayNames)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(len(routes)).To(gomega.Equal(1))
+
+		mirror := routes[0].GetRoute().GetRequestMirrorPolicy()
+		g.Expect(mirror.GetCluster()).To(gomega.Equal("outbound|9090||*.example.org"))
+		g.Expect(mirror.GetRuntimeFraction().GetDefaultValue().GetNumerator()).To(gomega.Equal(uint32(125000)))
+	})
+
+	t.Run("for mirror with invalid percentage", func(t *testing.T) {
+		g := gomega.NewGomegaWithT(t)
+
+		vs := virtualServiceWithMirrorPercent
+		vs.Annotations = map[string]string{config.AlphaNetworkingMirrorPercent.Name: "120"}
+		routes, err := route.BuildHTTPRoutesForVirtualService(node, nil, vs,
+			serviceRegistry, 8080, config.LabelsCollection{}, gatewayNames)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(len(routes)).To(gomega.Equal(1))
+		g.Expect(routes[0].GetRoute().GetRequestMirrorPolicy().GetRuntimeFraction()).To(gomega.BeNil())
+	})
+
+	t.Run("for idle timeout", func(t *testing.T) {
+		g := gomega.NewGomegaWithT(t)
+
+		vs := virtualServicePlain
+		vs.Annotations = map[string]string{config.AlphaNetworkingIdleTimeout.Name: "1h"}
+		routes, err := route.BuildHTTPRoutesForVirtualService(node, nil, vs,
+			serviceRegistry, 8080, config.LabelsCollection{}, gatewayNames)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(len(routes)).To(gomega.Equal(1))
+		g.Expect(*routes[0].GetRoute().GetIdleTimeout()).To(gomega.Equal(time.Hour))
+
+		routes, err = route.BuildHTTPRoutesForVirtualService(node, nil, virtualServicePlain,
+			serviceRegistry, 8080, config.LabelsCollection{}, gatewayNames)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(routes[0].GetRoute().GetIdleTimeout()).To(gomega.BeNil())
+	})
 }
 
 func loadBalancerPolicy(name string) *networking.LoadBalancerSettings_ConsistentHash {
@@ -409,6 +451,44 @@ var virtualServiceWithRedirect = model.Config{
 	},
 }
 
+var virtualServiceWithMirrorPercent = model.Config{
+	ConfigMeta: model.ConfigMeta{
+		Type:        model.VirtualService.Type,
+		Version:     model.VirtualService.Version,
+		Name:        "acme",
+		Annotations: map[string]string{config.AlphaNetworkingMirrorPercent.Name: "12.5"},
+	},
+	Spec: &networking.VirtualService{
+		Hosts:    []string{},
+		Gateways: []string{"some-gateway"},
+		Http: []*networking.HTTPRoute{
+			{
+				Route: []*networking.HTTPRouteDestination{
+					{
+						Destination: &networking.Destination{
+							Host: "*.example.org",
+							Port: &networking.PortSelector{
+								Port: &networking.PortSelector_Number{
+									Number: 8484,
+								},
+							},
+						},
+						Weight: 100,
+					},
+				},
+				Mirror: &networking.Destination{
+					Host: "*.example.org",
+					Port: &networking.PortSelector{
+						Port: &networking.PortSelector_Number{
+							Number: 9090,
+						},
+					},
+				},
+			},
+		},
+	},
+}
+
 var portLevelDestinationRule = &networking.DestinationRule{
 	Host:    "*.example.org",
 	Subsets: []*networking.Subset{},
@@ -18,6 +18,8 @@ import (
 	"context"
 	"errors"
 	"reflect"
+	"sort"
+	"sync"
 	"time"
 
 	"golang.org/x/time/rate"
@@ -67,6 +69,27 @@ type Agent interface {
 	// Run starts the agent control loop and awaits for a signal on the input
 	// channel to exit the loop.
 	Run(ctx context.Context)
+
+	// Status returns a summary of the proxy epochs managed by the agent.
+	Status() Status
+}
+
+// Status summarizes the state of the proxy epochs managed by the agent.
+type Status struct {
+	// ActiveEpochs are the epochs currently running, in increasing order.
+	ActiveEpochs []int `json:"activeEpochs"`
+
+	// LatestEpoch is the latest running epoch, or -1 if no epoch is running.
+	LatestEpoch int `json:"latestEpoch"`
+
+	// RetryBudget is the number of retries left to apply the desired configuration.
+	RetryBudget int `json:"retryBudget"`
+
+	// NextRestart is the time of the next scheduled restart attempt, if any.
+	NextRestart *time.Time `json:"nextRestart,omitempty"`
+
+	// LastError is the error of the latest epoch that terminated with an error, if any.
+	LastError string `json:"lastError,omitempty"`
 }
 
 var (
@@ -95,6 +118,7 @@ func NewAgent(proxy Proxy, retry Retry, terminationDrainDuration time.Duration)
 		statusCh:                 make(chan exitStatus),
 		abortCh:                  make(map[int]chan error),
 		terminationDrainDuration: terminationDrainDuration,
+		status:                   Status{LatestEpoch: -1},
 	}
 }
 
@@ -157,6 +181,10 @@ type agent struct {
 
 	// time to allow for the proxy to drain before terminating all remaining proxy processes
 	terminationDrainDuration time.Duration
+
+	// statusMutex guards status, the summary of the state above updated by the control loop
+	statusMutex sync.RWMutex
+	status      Status
 }
 
 type exitStatus struct {
@@ -168,6 +196,34 @@ func (a *agent) ConfigCh() chan<- interface{} {
 	return a.configCh
 }
 
+func (a *agent) Status() Status {
+	a.statusMutex.RLock()
+	defer a.statusMutex.RUnlock()
+	status := a.status
+	status.ActiveEpochs = append([]int(nil), a.status.ActiveEpochs...)
+	return status
+}
+
+// updateStatus publishes the state of the epochs and retries to Status callers.
+func (a *agent) updateStatus(lastErr error) {
+	epochs := make([]int, 0, len(a.epochs))
+	for epoch := range a.epochs {
+		epochs = append(epochs, epoch)
+	}
+	sort.Ints(epochs)
+	activeEpochs.Record(float64(len(epochs)))
+
+	a.statusMutex.Lock()
+	defer a.statusMutex.Unlock()
+	a.status.ActiveEpochs = epochs
+	a.status.LatestEpoch = a.latestEpoch()
+	a.status.RetryBudget = a.retry.budget
+	a.status.NextRestart = a.retry.restart
+	if lastErr != nil {
+		a.status.LastError = lastErr.Error()
+	}
+}
+
 func (a *agent) Run(ctx context.Context) {
 	log.Info("Starting proxy agent")
 
@@ -213,8 +269,10 @@ func (a *agent) Run(ctx context.Context) {
 
 			if status.err == errAbort {
 				log.Infof("Epoch %d aborted", status.epoch)
+				epochExits.With(outcomeTag.Value("aborted")).Increment()
 			} else if status.err != nil {
 				log.Warnf("Epoch %d terminated with an error: %v", status.epoch, status.err)
+				epochExits.With(outcomeTag.Value("error")).Increment()
 
 				// NOTE: due to Envoy hot restart race conditions, an error from the
 				// process requires aggressive non-graceful restarts by killing all
@@ -222,6 +280,7 @@ func (a *agent) Run(ctx context.Context) {
 				a.abortAll()
 			} else {
 				log.Infof("Epoch %d exited normally", status.epoch)
+				epochExits.With(outcomeTag.Value("normal")).Increment()
 			}
 
 			// cleanup for the epoch
@@ -239,6 +298,7 @@ func (a *agent) Run(ctx context.Context) {
 						restart := time.Now().Add(delayDuration)
 						a.retry.restart = &restart
 						a.retry.budget--
+						restartRetries.Increment()
 						log.Infof("Epoch %d: set retry delay to %v, budget to %d", status.epoch, delayDuration, a.retry.budget)
 					} else {
 						log.Error("Permanent error: budget exhausted trying to fulfill the desired configuration")
@@ -249,6 +309,11 @@ func (a *agent) Run(ctx context.Context) {
 					log.Debugf("Epoch %d: restart already scheduled", status.epoch)
 				}
 			}
+			lastErr := status.err
+			if lastErr == errAbort {
+				lastErr = nil
+			}
+			a.updateStatus(lastErr)
 
 		case <-reconcileTimer.C:
 			a.reconcile()
@@ -272,6 +337,8 @@ func (a *agent) terminate() {
 }
 
 func (a *agent) reconcile() {
+	defer a.updateStatus(nil)
+
 	// cancel any scheduled restart
 	a.retry.restart = nil
 
@@ -290,6 +357,7 @@ func (a *agent) reconcile() {
 	a.epochs[epoch] = a.desiredConfig
 	a.abortCh[epoch] = abortCh
 	a.currentConfig = a.desiredConfig
+	epochStarts.Increment()
 	go a.runWait(a.desiredConfig, epoch, abortCh)
 }
 
@@ -427,3 +427,44 @@ func TestLockup(t *testing.T) {
 		t.Error("liveness check failed")
 	}
 }
+
+// TestStatus checks the status summary of a failed then running epoch
+func TestStatus(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	running := make(chan struct{})
+	failed := false
+	start := func(config interface{}, epoch int, abort <-chan error) error {
+		if _, ok := config.(DrainConfig); ok {
+			return nil
+		}
+		if !failed {
+			failed = true
+			return errors.New("bad config")
+		}
+		close(running)
+		return <-abort
+	}
+	a := NewAgent(TestProxy{start, func(_ int) {}, nil}, testRetry, 0)
+	if status := a.Status(); status.LatestEpoch != -1 || len(status.ActiveEpochs) != 0 {
+		t.Errorf("Status() before start => got %+v, want no epoch", status)
+	}
+	go a.Run(ctx)
+	a.ConfigCh() <- "test"
+	<-running
+
+	var status Status
+	for i := 0; i < 100; i++ {
+		if status = a.Status(); status.LatestEpoch == 0 && status.NextRestart == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if status.LatestEpoch != 0 || len(status.ActiveEpochs) != 1 {
+		t.Errorf("Status() => got epochs %v (latest %d), want [0]", status.ActiveEpochs, status.LatestEpoch)
+	}
+	if status.LastError != "bad config" || status.RetryBudget != testRetry.MaxRetries-1 {
+		t.Errorf("Status() => got error %q and budget %d, want the failed epoch error and one retry",
+			status.LastError, status.RetryBudget)
+	}
+}
@@ -0,0 +1,317 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dns implements the DNS proxy of the agent. It answers the A and AAAA queries for the
+// hosts of the mesh, including the ServiceEntry hosts which are not resolvable by the cluster DNS,
+// from the name table served by Pilot, and forwards the other queries to the upstream resolvers.
+package dns
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"istio.io/pkg/log"
+)
+
+const (
+	// NameTablePath is the path of the name table on the secure port of Pilot. The ID of the proxy
+	// is passed in the proxyID query parameter.
+	NameTablePath = "/v1/nametable"
+
+	// ttl is the TTL of the answers from the name table, short enough to follow the changes of the
+	// table.
+	ttl = 30
+
+	upstreamTimeout = 2 * time.Second
+	maxMessageSize  = 65535
+	// maxUDPSize is the size of the largest answer sent over UDP, above which the answer is
+	// truncated.
+	maxUDPSize = 512
+)
+
+// NameTable maps the hostnames of the mesh to their addresses.
+type NameTable struct {
+	Hosts map[string][]string `json:"hosts"`
+}
+
+// Config for the DNS proxy.
+type Config struct {
+	// Address is the UDP address of the proxy, e.g. 127.0.0.1:15053.
+	Address string
+	// NameTableURL is the URL of the name table of the proxy served by Pilot.
+	NameTableURL string
+	// TLSConfig is the client TLS config presenting the certificate of the proxy to Pilot.
+	TLSConfig *tls.Config
+	// RefreshInterval is the interval at which the name table is fetched.
+	RefreshInterval time.Duration
+	// Upstreams are the addresses of the resolvers to which the other queries are forwarded.
+	Upstreams []string
+}
+
+// Server is the DNS proxy.
+type Server struct {
+	config Config
+	client *http.Client
+
+	mutex sync.RWMutex
+	hosts map[string][]net.IP
+}
+
+// NewServer creates a DNS proxy. Run starts serving.
+func NewServer(config Config) *Server {
+	return &Server{
+		config: config,
+		client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: config.TLSConfig},
+		},
+		hosts: map[string][]net.IP{},
+	}
+}
+
+// Run refreshes the name table and serves the queries until the context is cancelled.
+func (s *Server) Run(ctx context.Context) {
+	conn, err := net.ListenPacket("udp", s.config.Address)
+	if err != nil {
+		log.Errorf("Error listening on the DNS proxy address %s: %v", s.config.Address, err)
+		return
+	}
+	log.Infof("DNS proxy listening on %s, forwarding to %v", s.config.Address, s.config.Upstreams)
+	go s.refresh(ctx)
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+
+	for {
+		buf := make([]byte, maxMessageSize)
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Errorf("Error reading from the DNS proxy address: %v", err)
+			}
+			return
+		}
+		go func() {
+			resp, err := s.Resolve(buf[:n])
+			if err != nil {
+				log.Debugf("Failed to resolve the DNS query from %v: %v", addr, err)
+				return
+			}
+			if _, err := conn.WriteTo(resp, addr); err != nil {
+				log.Debugf("Failed to answer the DNS query from %v: %v", addr, err)
+			}
+		}()
+	}
+}
+
+func (s *Server) refresh(ctx context.Context) {
+	ticker := time.NewTicker(s.config.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		table, err := FetchNameTable(s.client, s.config.NameTableURL)
+		if err != nil {
+			log.Warnf("Failed to fetch the name table from %s: %v", s.config.NameTableURL, err)
+		} else {
+			s.UpdateNameTable(table)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// UpdateNameTable replaces the name table of the proxy.
+func (s *Server) UpdateNameTable(table *NameTable) {
+	hosts := make(map[string][]net.IP, len(table.Hosts))
+	for host, addresses := range table.Hosts {
+		for _, address := range addresses {
+			if ip := net.ParseIP(address); ip != nil {
+				hosts[strings.ToLower(host)] = append(hosts[strings.ToLower(host)], ip)
+			}
+		}
+	}
+	s.mutex.Lock()
+	s.hosts = hosts
+	s.mutex.Unlock()
+}
+
+// Resolve answers the query from the name table, or forwards it to the upstream resolvers.
+func (s *Server) Resolve(query []byte) ([]byte, error) {
+	var p dnsmessage.Parser
+	h, err := p.Start(query)
+	if err != nil {
+		return nil, err
+	}
+	q, err := p.Question()
+	if err != nil || h.Response || h.OpCode != 0 || q.Class != dnsmessage.ClassINET ||
+		(q.Type != dnsmessage.TypeA && q.Type != dnsmessage.TypeAAAA) {
+		return s.forward(query, h)
+	}
+
+	s.mutex.RLock()
+	ips, found := s.hosts[strings.ToLower(strings.TrimSuffix(q.Name.String(), "."))]
+	s.mutex.RUnlock()
+	if !found {
+		return s.forward(query, h)
+	}
+
+	var matching []net.IP
+	for _, ip := range ips {
+		if (ip.To4() != nil) == (q.Type == dnsmessage.TypeA) {
+			matching = append(matching, ip)
+		}
+	}
+	resp, err := answer(h, q, matching, false)
+	if err != nil || len(resp) <= maxUDPSize {
+		return resp, err
+	}
+
+	// Send as many answers as fit, with the TC bit set so that the client may retry over TCP.
+	// The answers are compressed, so that they all have the same size.
+	empty, err := answer(h, q, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	size := (len(resp) - len(empty)) / len(matching)
+	return answer(h, q, matching[:(maxUDPSize-len(empty))/size], true)
+}
+
+// answer builds the answer to the question with the addresses, which must match its type.
+func answer(h dnsmessage.Header, q dnsmessage.Question, ips []net.IP, truncated bool) ([]byte, error) {
+	b := dnsmessage.NewBuilder(make([]byte, 0, maxUDPSize), dnsmessage.Header{
+		ID:                 h.ID,
+		Response:           true,
+		Authoritative:      true,
+		Truncated:          truncated,
+		RecursionDesired:   h.RecursionDesired,
+		RecursionAvailable: true,
+	})
+	b.EnableCompression()
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := b.Question(q); err != nil {
+		return nil, err
+	}
+	if err := b.StartAnswers(); err != nil {
+		return nil, err
+	}
+	rh := dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: ttl}
+	for _, ip := range ips {
+		var err error
+		if ip4 := ip.To4(); ip4 != nil {
+			r := dnsmessage.AResource{}
+			copy(r.A[:], ip4)
+			err = b.AResource(rh, r)
+		} else {
+			r := dnsmessage.AAAAResource{}
+			copy(r.AAAA[:], ip.To16())
+			err = b.AAAAResource(rh, r)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return b.Finish()
+}
+
+// forward sends the query to the upstream resolvers in order, and answers SERVFAIL if none of
+// them answered.
+func (s *Server) forward(query []byte, h dnsmessage.Header) ([]byte, error) {
+	for _, upstream := range s.config.Upstreams {
+		resp, err := exchange(query, upstream)
+		if err == nil {
+			return resp, nil
+		}
+		log.Debugf("Failed to forward the DNS query to %s: %v", upstream, err)
+	}
+	b := dnsmessage.NewBuilder(make([]byte, 0, 512), dnsmessage.Header{
+		ID:               h.ID,
+		Response:         true,
+		RecursionDesired: h.RecursionDesired,
+		RCode:            dnsmessage.RCodeServerFailure,
+	})
+	return b.Finish()
+}
+
+func exchange(query []byte, upstream string) ([]byte, error) {
+	conn, err := net.DialTimeout("udp", upstream, upstreamTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close() // nolint: errcheck
+	if err := conn.SetDeadline(time.Now().Add(upstreamTimeout)); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, maxMessageSize)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+// FetchNameTable fetches the name table from Pilot.
+func FetchNameTable(client *http.Client, url string) (*NameTable, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	table := &NameTable{}
+	if err := json.NewDecoder(resp.Body).Decode(table); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// UpstreamResolvers returns the addresses of the nameservers of the resolv.conf file, which must
+// be read before the DNS queries are captured.
+func UpstreamResolvers(resolvConf string) ([]string, error) {
+	f, err := os.Open(resolvConf)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() // nolint: errcheck
+
+	var upstreams []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "nameserver" && net.ParseIP(fields[1]) != nil {
+			upstreams = append(upstreams, net.JoinHostPort(fields[1], "53"))
+		}
+	}
+	return upstreams, scanner.Err()
+}
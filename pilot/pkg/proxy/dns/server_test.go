@@ -0,0 +1,251 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dns
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func buildQuery(t *testing.T, name string, qtype dnsmessage.Type) []byte {
+	t.Helper()
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: 42, RecursionDesired: true})
+	if err := b.StartQuestions(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Question(dnsmessage.Question{
+		Name:  dnsmessage.MustNewName(name),
+		Type:  qtype,
+		Class: dnsmessage.ClassINET,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	query, err := b.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return query
+}
+
+func parseAnswers(t *testing.T, resp []byte) (dnsmessage.Header, []string) {
+	t.Helper()
+	var msg dnsmessage.Message
+	if err := msg.Unpack(resp); err != nil {
+		t.Fatal(err)
+	}
+	var answers []string
+	for _, answer := range msg.Answers {
+		switch r := answer.Body.(type) {
+		case *dnsmessage.AResource:
+			answers = append(answers, net.IP(r.A[:]).String())
+		case *dnsmessage.AAAAResource:
+			answers = append(answers, net.IP(r.AAAA[:]).String())
+		}
+	}
+	return msg.Header, answers
+}
+
+// runUpstream answers all the queries with the address until the context is cancelled.
+func runUpstream(ctx context.Context, t *testing.T, address [4]byte) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+	go func() {
+		buf := make([]byte, maxMessageSize)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var msg dnsmessage.Message
+			if err := msg.Unpack(buf[:n]); err != nil {
+				continue
+			}
+			msg.Header.Response = true
+			msg.Answers = []dnsmessage.Resource{{
+				Header: dnsmessage.ResourceHeader{Name: msg.Questions[0].Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET},
+				Body:   &dnsmessage.AResource{A: address},
+			}}
+			resp, _ := msg.Pack()
+			_, _ = conn.WriteTo(resp, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestResolve(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	upstream := runUpstream(ctx, t, [4]byte{8, 8, 8, 8})
+
+	s := NewServer(Config{Upstreams: []string{"127.0.0.1:1", upstream}})
+	s.UpdateNameTable(&NameTable{Hosts: map[string][]string{
+		"reviews.default.svc.cluster.local": {"10.0.0.1"},
+		"db.example.internal":               {"240.0.0.1", "2001:db8::1", "invalid"},
+	}})
+
+	for _, tc := range []struct {
+		name  string
+		query string
+		qtype dnsmessage.Type
+		want  []string
+	}{
+		{name: "service", query: "reviews.default.svc.cluster.local.", qtype: dnsmessage.TypeA, want: []string{"10.0.0.1"}},
+		{name: "case insensitive", query: "Reviews.Default.svc.cluster.local.", qtype: dnsmessage.TypeA, want: []string{"10.0.0.1"}},
+		{name: "service entry", query: "db.example.internal.", qtype: dnsmessage.TypeA, want: []string{"240.0.0.1"}},
+		{name: "service entry IPv6", query: "db.example.internal.", qtype: dnsmessage.TypeAAAA, want: []string{"2001:db8::1"}},
+		{name: "no IPv6", query: "reviews.default.svc.cluster.local.", qtype: dnsmessage.TypeAAAA},
+		{name: "forwarded", query: "istio.io.", qtype: dnsmessage.TypeA, want: []string{"8.8.8.8"}},
+		{name: "other type forwarded", query: "reviews.default.svc.cluster.local.", qtype: dnsmessage.TypeTXT, want: []string{"8.8.8.8"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := s.Resolve(buildQuery(t, tc.query, tc.qtype))
+			if err != nil {
+				t.Fatal(err)
+			}
+			h, answers := parseAnswers(t, resp)
+			if h.ID != 42 || !h.Response || h.RCode != dnsmessage.RCodeSuccess {
+				t.Errorf("Resolve() => got header %+v, want a successful response to the query", h)
+			}
+			if !reflect.DeepEqual(answers, tc.want) {
+				t.Errorf("Resolve() => got answers %v, want %v", answers, tc.want)
+			}
+		})
+	}
+}
+
+func TestResolveNoUpstream(t *testing.T) {
+	s := NewServer(Config{Upstreams: []string{"127.0.0.1:1"}})
+	resp, err := s.Resolve(buildQuery(t, "istio.io.", dnsmessage.TypeA))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h, _ := parseAnswers(t, resp); h.RCode != dnsmessage.RCodeServerFailure {
+		t.Errorf("Resolve() => got rcode %v, want %v", h.RCode, dnsmessage.RCodeServerFailure)
+	}
+}
+
+func TestResolveTruncated(t *testing.T) {
+	var addresses []string
+	for i := 0; i < 100; i++ {
+		addresses = append(addresses, fmt.Sprintf("10.0.%d.%d", i/10, i%10))
+	}
+	s := NewServer(Config{})
+	s.UpdateNameTable(&NameTable{Hosts: map[string][]string{"headless.default.svc.cluster.local": addresses}})
+
+	resp, err := s.Resolve(buildQuery(t, "headless.default.svc.cluster.local.", dnsmessage.TypeA))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp) > maxUDPSize {
+		t.Errorf("Resolve() => got an answer of %d bytes, want at most %d", len(resp), maxUDPSize)
+	}
+	h, answers := parseAnswers(t, resp)
+	if !h.Truncated {
+		t.Errorf("Resolve() => got header %+v, want the TC bit set", h)
+	}
+	// Each compressed A record takes 16 bytes, after the 12 bytes of header and the question.
+	want := addresses[:(maxUDPSize-12-len("headless.default.svc.cluster.local.")-1-4)/16]
+	if !reflect.DeepEqual(answers, want) {
+		t.Errorf("Resolve() => got answers %v, want %v", answers, want)
+	}
+}
+
+func TestRun(t *testing.T) {
+	nameTable := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{"hosts": {"reviews.default.svc.cluster.local": ["10.0.0.1"]}}`)
+	}))
+	defer nameTable.Close()
+
+	// Reserve a port for the proxy.
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := l.LocalAddr().String()
+	_ = l.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := NewServer(Config{
+		Address:         address,
+		NameTableURL:    nameTable.URL + NameTablePath,
+		TLSConfig:       nameTable.Client().Transport.(*http.Transport).TLSClientConfig,
+		RefreshInterval: time.Hour,
+	})
+	go s.Run(ctx)
+
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close() // nolint: errcheck
+	buf := make([]byte, maxMessageSize)
+	for i := 0; ; i++ {
+		if _, err := conn.Write(buildQuery(t, "reviews.default.svc.cluster.local.", dnsmessage.TypeA)); err != nil {
+			t.Fatal(err)
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		if n, err := conn.Read(buf); err == nil {
+			if _, answers := parseAnswers(t, buf[:n]); reflect.DeepEqual(answers, []string{"10.0.0.1"}) {
+				return
+			}
+		}
+		if i == 50 {
+			t.Fatal("got no answer from the name table")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestUpstreamResolvers(t *testing.T) {
+	f, err := ioutil.TempFile("", "resolv.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	_, _ = f.WriteString(`# comment
+nameserver 10.96.0.10
+nameserver 2001:db8::53
+nameserver invalid
+search default.svc.cluster.local svc.cluster.local cluster.local
+options ndots:5
+`)
+	_ = f.Close()
+
+	got, err := UpstreamResolvers(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"10.96.0.10:53", "[2001:db8::53]:53"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("UpstreamResolvers() => got %v, want %v", got, want)
+	}
+}
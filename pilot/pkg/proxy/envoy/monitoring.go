@@ -0,0 +1,38 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoy
+
+import (
+	"istio.io/istio/pilot/pkg/monitoring"
+)
+
+var (
+	fileTag = monitoring.MustCreateTag("file")
+
+	certExpiry = monitoring.NewGauge(
+		"istio_agent_cert_expiry_timestamp",
+		"The unix time in seconds at which the certificate in a watched file expires.",
+		fileTag,
+	)
+
+	certRotations = monitoring.NewSum(
+		"istio_agent_cert_rotations_total",
+		"Total number of times the watched certificate files changed.",
+	)
+)
+
+func init() {
+	monitoring.MustRegisterViews(certExpiry, certRotations)
+}
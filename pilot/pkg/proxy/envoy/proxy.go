@@ -28,7 +28,6 @@ import (
 	meshconfig "istio.io/api/mesh/v1alpha1"
 	"istio.io/istio/pilot/pkg/proxy"
 	"istio.io/istio/pkg/bootstrap"
-	"istio.io/pkg/env"
 	"istio.io/pkg/log"
 )
 
@@ -107,17 +106,19 @@ func (e *envoy) args(fname string, epoch int, bootstrapConfig string) []string {
 	return startupArgs
 }
 
-var istioBootstrapOverrideVar = env.RegisterStringVar("ISTIO_BOOTSTRAP_OVERRIDE", "", "")
-
 func (e *envoy) Run(config interface{}, epoch int, abort <-chan error) error {
 
 	var fname string
+	// Overrides are merged into the generated bootstrap by WriteBootstrap. Only custom
+	// configuration files rely on Envoy to apply them.
+	var bootstrapOverride string
 	// Note: the cert checking still works, the generated file is updated if certs are changed.
 	// We just don't save the generated file, but use a custom one instead. Pilot will keep
 	// monitoring the certs and restart if the content of the certs changes.
 	if len(e.config.CustomConfigFile) > 0 {
 		// there is a custom configuration. Don't write our own config - but keep watching the certs.
 		fname = e.config.CustomConfigFile
+		bootstrapOverride = bootstrap.OverrideFileVar.Get()
 	} else if _, ok := config.(proxy.DrainConfig); ok {
 		fname = drainFile
 	} else {
@@ -132,7 +133,7 @@ func (e *envoy) Run(config interface{}, epoch int, abort <-chan error) error {
 	}
 
 	// spin up a new Envoy process
-	args := e.args(fname, epoch, istioBootstrapOverrideVar.Get())
+	args := e.args(fname, epoch, bootstrapOverride)
 	log.Infof("Envoy command: %v", args)
 
 	/* #nosec */
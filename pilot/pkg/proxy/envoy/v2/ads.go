@@ -95,6 +95,7 @@ type XdsConnection struct {
 	ListenerNonceSent, ListenerNonceAcked string
 	RouteNonceSent, RouteNonceAcked       string
 	RouteVersionInfoSent                  string
+	ListenerVersionAcked                  string
 	EndpointNonceSent, EndpointNonceAcked string
 	EndpointPercent                       int
 
@@ -184,6 +185,9 @@ type XdsEvent struct {
 	// Push context to use for the push.
 	push *model.PushContext
 
+	// scope restricts the full push, nil to push everything.
+	scope *PushScope
+
 	// start represents the time a push was started.
 	start time.Time
 
@@ -230,6 +234,11 @@ func receiveThread(con *XdsConnection, reqChannel chan *xdsapi.DiscoveryRequest,
 
 // StreamAggregatedResources implements the ADS interface.
 func (s *DiscoveryServer) StreamAggregatedResources(stream ads.AggregatedDiscoveryService_StreamAggregatedResourcesServer) error {
+	return s.streamAggregatedResources(stream)
+}
+
+// streamAggregatedResources handles an ADS connection, either state of the world or delta.
+func (s *DiscoveryServer) streamAggregatedResources(stream DiscoveryStream) error {
 	peerInfo, ok := peer.FromContext(stream.Context())
 	peerAddr := "0.0.0.0"
 	if ok {
@@ -310,7 +319,10 @@ func (s *DiscoveryServer) StreamAggregatedResources(stream ads.AggregatedDiscove
 						errCode := codes.Code(discReq.ErrorDetail.Code)
 						incrementXDSRejects(ldsReject, discReq.Node.Id, errCode.String())
 					} else if discReq.ResponseNonce != "" {
+						con.mu.Lock()
 						con.ListenerNonceAcked = discReq.ResponseNonce
+						con.ListenerVersionAcked = discReq.VersionInfo
+						con.mu.Unlock()
 					}
 					adsLog.Debugf("ADS:LDS: ACK %s %s (%s) %s %s", peerAddr, con.ConID, con.modelNode.ID, discReq.VersionInfo, discReq.ResponseNonce)
 					continue
@@ -523,11 +535,6 @@ func (s *DiscoveryServer) initConnectionNode(discReq *xdsapi.DiscoveryRequest, c
 	return nil
 }
 
-// DeltaAggregatedResources is not implemented.
-func (s *DiscoveryServer) DeltaAggregatedResources(stream ads.AggregatedDiscoveryService_DeltaAggregatedResourcesServer) error {
-	return status.Errorf(codes.Unimplemented, "not implemented")
-}
-
 // Compute and send the new configuration for a connection. This is blocking and may be slow
 // for large configs. The method will hold a lock on con.pushMutex.
 func (s *DiscoveryServer) pushConnection(con *XdsConnection, pushEv *XdsEvent) error {
@@ -570,7 +577,7 @@ func (s *DiscoveryServer) pushConnection(con *XdsConnection, pushEv *XdsEvent) e
 	// check version, suppress if changed.
 	currentVersion := versionInfo()
 
-	if con.CDSWatch {
+	if con.CDSWatch && pushEv.scope.includes(CDS) {
 		err := s.pushCds(con, pushEv.push, currentVersion)
 		if err != nil {
 			proxiesConvergeDelayCdsErrors.Record(time.Since(pushEv.start).Seconds())
@@ -578,21 +585,21 @@ func (s *DiscoveryServer) pushConnection(con *XdsConnection, pushEv *XdsEvent) e
 		}
 	}
 
-	if len(con.Clusters) > 0 {
+	if len(con.Clusters) > 0 && pushEv.scope.includes(EDS) {
 		err := s.pushEds(pushEv.push, con, currentVersion, nil)
 		if err != nil {
 			proxiesConvergeDelayEdsErrors.Record(time.Since(pushEv.start).Seconds())
 			return err
 		}
 	}
-	if con.LDSWatch {
+	if con.LDSWatch && pushEv.scope.includes(LDS) {
 		err := s.pushLds(con, pushEv.push, currentVersion)
 		if err != nil {
 			proxiesConvergeDelayLdsErrors.Record(time.Since(pushEv.start).Seconds())
 			return err
 		}
 	}
-	if len(con.Routes) > 0 {
+	if len(con.Routes) > 0 && pushEv.scope.includes(RDS) {
 		err := s.pushRoute(con, pushEv.push, currentVersion)
 		if err != nil {
 			proxiesConvergeDelayRdsErrors.Record(time.Since(pushEv.start).Seconds())
@@ -621,6 +628,12 @@ func AdsPushAll(s *DiscoveryServer) {
 // to the model ConfigStorageCache and Controller.
 func (s *DiscoveryServer) AdsPushAll(version string, push *model.PushContext,
 	full bool, edsUpdates map[string]struct{}) {
+	s.adsPushAll(version, push, full, edsUpdates, nil)
+}
+
+// adsPushAll is AdsPushAll restricted to the scope for the full pushes.
+func (s *DiscoveryServer) adsPushAll(version string, push *model.PushContext,
+	full bool, edsUpdates map[string]struct{}, scope *PushScope) {
 	if !full {
 		s.edsIncremental(version, push, edsUpdates)
 		return
@@ -652,11 +665,11 @@ func (s *DiscoveryServer) AdsPushAll(version string, push *model.PushContext,
 		}
 	}
 	adsLog.Infof("Cluster init time %v %s", time.Since(t0), version)
-	s.startPush(push, true, nil)
+	s.startPush(push, true, nil, scope)
 }
 
 // Send a signal to all connections, with a push event.
-func (s *DiscoveryServer) startPush(push *model.PushContext, full bool, edsUpdates map[string]struct{}) {
+func (s *DiscoveryServer) startPush(push *model.PushContext, full bool, edsUpdates map[string]struct{}, scope *PushScope) {
 
 	// Push config changes, iterating over connected envoys. This cover ADS and EDS(0.7), both share
 	// the same connection table
@@ -664,7 +677,9 @@ func (s *DiscoveryServer) startPush(push *model.PushContext, full bool, edsUpdat
 	// Create a temp map to avoid locking the add/remove
 	pending := []*XdsConnection{}
 	for _, v := range adsClients {
-		pending = append(pending, v)
+		if scope.includesNode(v.modelNode) {
+			pending = append(pending, v)
+		}
 	}
 	adsClientsMutex.RUnlock()
 
@@ -674,7 +689,7 @@ func (s *DiscoveryServer) startPush(push *model.PushContext, full bool, edsUpdat
 	}
 	startTime := time.Now()
 	for _, p := range pending {
-		s.pushQueue.Enqueue(p, &PushInformation{edsUpdates, push, startTime, full})
+		s.pushQueue.Enqueue(p, &PushInformation{edsUpdates, push, startTime, full, scope})
 	}
 }
 
@@ -0,0 +1,209 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/gogo/protobuf/types"
+
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
+)
+
+// perInstanceMetadata are the node metadata specific to each instance of a workload, which are
+// not used to generate clusters and routes.
+var perInstanceMetadata = map[string]bool{
+	"POD_NAME":                    true,
+	model.NodeMetadataInstanceIPs: true,
+}
+
+// xdsCache holds the clusters and routes generated for the groups of proxies having the same
+// inputs to the generation, so that they are generated and serialized once per group. The cache
+// holds the resources generated with the current push context only: a new push context, created
+// by a full push, invalidates it.
+type xdsCache struct {
+	mu      sync.Mutex
+	push    *model.PushContext
+	entries map[string][]types.Any
+}
+
+// get returns the resources of the type cached for the key and the push context.
+func (c *xdsCache) get(push *model.PushContext, typeURL, key string) ([]types.Any, bool) {
+	c.mu.Lock()
+	resources, ok := c.entries[typeURL+"/"+key]
+	ok = ok && c.push == push
+	c.mu.Unlock()
+
+	result := "miss"
+	if ok {
+		result = "hit"
+	}
+	xdsCacheReads.With(typeTag.Value(deltaTypeTag(typeURL)), resultTag.Value(result)).Increment()
+	return resources, ok
+}
+
+// add caches the resources of the type generated for the key with the push context, dropping the
+// resources generated with another push context.
+func (c *xdsCache) add(push *model.PushContext, typeURL, key string, resources []types.Any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.push != push || c.entries == nil {
+		c.push = push
+		c.entries = make(map[string][]types.Any)
+	}
+	c.entries[typeURL+"/"+key] = resources
+	xdsCacheSize.Record(float64(len(c.entries)))
+}
+
+// xdsCacheEnabled returns true if the clusters and routes are cached. The cache is not used when
+// the generated configs are kept for debugging, as they are not generated on cache hits.
+func (s *DiscoveryServer) xdsCacheEnabled() bool {
+	return features.EnableXDSCache() && !s.DebugConfigs
+}
+
+// cachedClusters returns the serialized clusters of the proxy, generating them on cache misses.
+func (s *DiscoveryServer) cachedClusters(node *model.Proxy, push *model.PushContext) ([]types.Any, error) {
+	key := proxyGroupKey(s.Env, node)
+	if resources, ok := s.cache.get(push, ClusterType, key); ok {
+		return resources, nil
+	}
+	rawClusters, err := s.generateRawClusters(node, push)
+	if err != nil {
+		return nil, err
+	}
+	resources := make([]types.Any, 0, len(rawClusters))
+	for _, c := range rawClusters {
+		cc, _ := types.MarshalAny(c)
+		resources = append(resources, *cc)
+	}
+	if push == s.globalPushContext() {
+		s.cache.add(push, ClusterType, key, resources)
+	}
+	return resources, nil
+}
+
+// cachedRoutes returns the serialized routes watched by the connection, generating the routes
+// missing from the cache.
+func (s *DiscoveryServer) cachedRoutes(con *XdsConnection, push *model.PushContext) ([]types.Any, error) {
+	group := proxyGroupKey(s.Env, con.modelNode)
+	resources := make([]types.Any, 0, len(con.Routes))
+	for _, routeName := range con.Routes {
+		key := group + "/" + routeName
+		if cached, ok := s.cache.get(push, RouteType, key); ok {
+			resources = append(resources, cached...)
+			continue
+		}
+		r, err := s.generateRawRoute(con, push, routeName)
+		if err != nil {
+			return nil, err
+		}
+		rr, _ := types.MarshalAny(r)
+		if push == s.globalPushContext() {
+			s.cache.add(push, RouteType, key, []types.Any{*rr})
+		}
+		resources = append(resources, *rr)
+	}
+	return resources, nil
+}
+
+// proxyGroupKey returns the key of the group of the proxy: the proxies with the same key get the
+// same clusters and routes.
+func proxyGroupKey(env *model.Environment, node *model.Proxy) string {
+	h := sha256.New()
+	writeKeyParts(h, string(node.Type), node.ClusterID, node.ConfigNamespace, node.DNSDomain,
+		node.TrustDomain, node.PilotIdentity, node.MixerIdentity)
+	if node.Locality != nil {
+		writeKeyParts(h, "locality", node.Locality.Region, node.Locality.Zone, node.Locality.SubZone)
+	}
+
+	writeKeyParts(h, "metadata")
+	writeSortedMap(h, node.Metadata, perInstanceMetadata)
+	for _, labels := range node.WorkloadLabels {
+		writeKeyParts(h, "labels")
+		writeSortedMap(h, labels, nil)
+	}
+	for _, instance := range node.ServiceInstances {
+		ep := instance.Endpoint
+		writeKeyParts(h, "instance", string(instance.Service.Hostname), instance.ServiceAccount,
+			strconv.Itoa(ep.Port), ep.Network, ep.Locality, ep.TLSMode)
+		if ep.ServicePort != nil {
+			writeKeyParts(h, ep.ServicePort.Name, strconv.Itoa(ep.ServicePort.Port), string(ep.ServicePort.Protocol))
+		}
+		writeSortedMap(h, instance.Labels, nil)
+	}
+	// The listeners and clusters bind to the wildcard and local host addresses of the IP families
+	// of the proxy, and of both families for dual-stack proxies.
+	writeKeyParts(h, "families", strconv.FormatBool(features.EnableDualStack()))
+	writeKeyParts(h, ipFamilies(node.IPAddresses)...)
+	// The inbound clusters of the management ports depend on the addresses of the proxy.
+	for _, ip := range node.IPAddresses {
+		for _, port := range env.ManagementPorts(ip) {
+			writeKeyParts(h, "management", port.Name, strconv.Itoa(port.Port), string(port.Protocol))
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ipFamilies returns the sorted IP families of the addresses, telling loopback addresses apart.
+func ipFamilies(addresses []string) []string {
+	set := map[string]bool{}
+	for _, address := range addresses {
+		ip := net.ParseIP(address)
+		if ip == nil {
+			continue
+		}
+		family := "ipv6"
+		if ip.To4() != nil {
+			family = "ipv4"
+		}
+		if ip.IsLoopback() {
+			family += "-loopback"
+		}
+		set[family] = true
+	}
+	families := make([]string, 0, len(set))
+	for family := range set {
+		families = append(families, family)
+	}
+	sort.Strings(families)
+	return families
+}
+
+func writeKeyParts(h hash.Hash, parts ...string) {
+	for _, p := range parts {
+		_, _ = h.Write([]byte(p))
+		_, _ = h.Write([]byte{0})
+	}
+}
+
+func writeSortedMap(h hash.Hash, m map[string]string, skip map[string]bool) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		if !skip[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		writeKeyParts(h, k, m[k])
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"testing"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/gogo/protobuf/types"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/serviceregistry/aggregate"
+	"istio.io/istio/pkg/config"
+)
+
+func TestXDSCache(t *testing.T) {
+	var c xdsCache
+	push1 := model.NewPushContext()
+	push2 := model.NewPushContext()
+	a := []types.Any{mustMarshalAny(t, &xdsapi.Cluster{Name: "a"})}
+
+	if _, ok := c.get(push1, ClusterType, "key"); ok {
+		t.Fatal("get() => got a hit on an empty cache")
+	}
+	c.add(push1, ClusterType, "key", a)
+	if got, ok := c.get(push1, ClusterType, "key"); !ok || len(got) != 1 {
+		t.Fatalf("get() => got %v, %v, want the added clusters", got, ok)
+	}
+	if _, ok := c.get(push1, RouteType, "key"); ok {
+		t.Error("get() => got a hit for another type")
+	}
+	if _, ok := c.get(push2, ClusterType, "key"); ok {
+		t.Error("get() => got a hit for another push context")
+	}
+
+	// Adding with a new push context drops the resources of the previous one.
+	c.add(push2, ClusterType, "other", a)
+	if _, ok := c.get(push1, ClusterType, "key"); ok {
+		t.Error("get() => got a hit for the resources of a previous push context")
+	}
+	if len(c.entries) != 1 {
+		t.Errorf("got %d entries, want 1", len(c.entries))
+	}
+}
+
+func TestProxyGroupKey(t *testing.T) {
+	env := &model.Environment{ServiceDiscovery: aggregate.NewController()}
+	proxy := func(ip, pod, version, namespace string) *model.Proxy {
+		return &model.Proxy{
+			Type:            model.SidecarProxy,
+			IPAddresses:     []string{ip},
+			ConfigNamespace: namespace,
+			Metadata: map[string]string{
+				"POD_NAME":                    pod,
+				model.NodeMetadataInstanceIPs: ip,
+				"ISTIO_VERSION":               "1.3",
+			},
+			WorkloadLabels: config.LabelsCollection{{"app": "reviews", "version": version}},
+		}
+	}
+
+	key := proxyGroupKey(env, proxy("10.0.0.1", "reviews-v1-a", "v1", "default"))
+	if got := proxyGroupKey(env, proxy("10.0.0.2", "reviews-v1-b", "v1", "default")); got != key {
+		t.Error("proxyGroupKey() => got different keys for replicas of the same workload")
+	}
+	if got := proxyGroupKey(env, proxy("10.0.0.1", "reviews-v1-a", "v2", "default")); got == key {
+		t.Error("proxyGroupKey() => got the same key for proxies with different labels")
+	}
+	if got := proxyGroupKey(env, proxy("10.0.0.1", "reviews-v1-a", "v1", "other")); got == key {
+		t.Error("proxyGroupKey() => got the same key for proxies in different namespaces")
+	}
+	if got := proxyGroupKey(env, proxy("fd00::1", "reviews-v1-a", "v1", "default")); got == key {
+		t.Error("proxyGroupKey() => got the same key for proxies of different IP families")
+	}
+	dualStack := proxy("10.0.0.1", "reviews-v1-a", "v1", "default")
+	dualStack.IPAddresses = append(dualStack.IPAddresses, "fd00::1")
+	if got := proxyGroupKey(env, dualStack); got == key {
+		t.Error("proxyGroupKey() => got the same key for a dual-stack proxy and an ipv4 proxy")
+	}
+}
@@ -46,16 +46,26 @@ func (conn *XdsConnection) clusters(response []*xdsapi.Cluster) *xdsapi.Discover
 }
 
 func (s *DiscoveryServer) pushCds(con *XdsConnection, push *model.PushContext, version string) error {
-	// TODO: Modify interface to take services, and config instead of making library query registry
-	rawClusters, err := s.generateRawClusters(con.modelNode, push)
-	if err != nil {
-		return err
-	}
-	if s.DebugConfigs {
-		con.CDSClusters = rawClusters
+	var response *xdsapi.DiscoveryResponse
+	if s.xdsCacheEnabled() {
+		resources, err := s.cachedClusters(con.modelNode, push)
+		if err != nil {
+			return err
+		}
+		response = con.clusters(nil)
+		response.Resources = resources
+	} else {
+		// TODO: Modify interface to take services, and config instead of making library query registry
+		rawClusters, err := s.generateRawClusters(con.modelNode, push)
+		if err != nil {
+			return err
+		}
+		if s.DebugConfigs {
+			con.CDSClusters = rawClusters
+		}
+		response = con.clusters(rawClusters)
 	}
-	response := con.clusters(rawClusters)
-	err = con.send(response)
+	err := con.send(response)
 	if err != nil {
 		adsLog.Warnf("CDS: Send failure %s: %v", con.ConID, err)
 		recordSendError(cdsSendErrPushes, err)
@@ -65,7 +75,7 @@ func (s *DiscoveryServer) pushCds(con *XdsConnection, push *model.PushContext, v
 
 	// The response can't be easily read due to 'any' marshaling.
 	adsLog.Infof("CDS: PUSH for node:%s clusters:%d services:%d version:%s",
-		con.modelNode.ID, len(rawClusters), len(push.Services(nil)), version)
+		con.modelNode.ID, len(response.Resources), len(push.Services(nil)), version)
 	return nil
 }
 
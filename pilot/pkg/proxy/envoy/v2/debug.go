@@ -19,6 +19,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/gogo/protobuf/jsonpb"
 
@@ -53,15 +56,18 @@ func (s *DiscoveryServer) InitDebug(mux *http.ServeMux, sctl *aggregate.Controll
 	mux.HandleFunc("/debug/edsz", s.edsz)
 	mux.HandleFunc("/debug/adsz", s.adsz)
 	mux.HandleFunc("/debug/cdsz", cdsz)
-	mux.HandleFunc("/debug/syncz", Syncz)
+	mux.HandleFunc("/debug/syncz", s.syncz)
 
 	mux.HandleFunc("/debug/registryz", s.registryz)
 	mux.HandleFunc("/debug/endpointz", s.endpointz)
 	mux.HandleFunc("/debug/endpointShardz", s.endpointShardz)
+	mux.HandleFunc("/debug/endpointConflictz", s.endpointConflictz)
 	mux.HandleFunc("/debug/workloadz", s.workloadz)
 	mux.HandleFunc("/debug/configz", s.configz)
+	mux.HandleFunc("/debug/meshconfig", s.meshConfigz)
 
 	mux.HandleFunc("/debug/authenticationz", s.authenticationz)
+	mux.HandleFunc("/debug/inboundauthnz", s.inboundAuthnz)
 	mux.HandleFunc("/debug/config_dump", s.ConfigDump)
 	mux.HandleFunc("/debug/push_status", s.PushStatusHandler)
 }
@@ -82,6 +88,47 @@ type SyncStatus struct {
 	EndpointPercent int    `json:"endpoint_percent,omitempty"`
 }
 
+// SyncSource is a service registry or config store that must complete its initial
+// sync before Pilot is ready to serve configuration.
+type SyncSource struct {
+	// Kind is the kind of source, either "registry" or "config".
+	Kind string `json:"kind"`
+	// Name identifies the source.
+	Name string `json:"name"`
+	// HasSynced returns true once the source completed its initial sync.
+	HasSynced func() bool `json:"-"`
+}
+
+// SourceSyncStatus is the synchronization status of a registry or config store.
+type SourceSyncStatus struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Synced bool   `json:"synced"`
+}
+
+// syncz dumps the synchronization status of the Envoys connected to this Pilot instance,
+// or of the registries and config stores if the sources parameter is set.
+func (s *DiscoveryServer) syncz(w http.ResponseWriter, req *http.Request) {
+	_ = req.ParseForm()
+	if req.Form.Get("sources") == "" {
+		Syncz(w, req)
+		return
+	}
+
+	sources := make([]SourceSyncStatus, 0, len(s.SyncSources))
+	for _, src := range s.SyncSources {
+		sources = append(sources, SourceSyncStatus{Kind: src.Kind, Name: src.Name, Synced: src.HasSynced()})
+	}
+	out, err := json.MarshalIndent(&sources, "", "    ")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "unable to marshal syncz information: %v", err)
+		return
+	}
+	w.Header().Add("Content-Type", "application/json")
+	_, _ = w.Write(out)
+}
+
 // Syncz dumps the synchronization status of all Envoys connected to this Pilot instance
 func Syncz(w http.ResponseWriter, _ *http.Request) {
 	syncz := make([]SyncStatus, 0)
@@ -153,6 +200,37 @@ func (s *DiscoveryServer) endpointShardz(w http.ResponseWriter, req *http.Reques
 	_, _ = w.Write(out)
 }
 
+// EndpointConflict is an endpoint of a service discovered by several registries.
+type EndpointConflict struct {
+	Service  string   `json:"svc"`
+	Endpoint string   `json:"endpoint"`
+	Clusters []string `json:"clusters"`
+}
+
+// Lists the endpoints with the same network and address found in several shards, e.g. when two
+// primaries watch the same remote cluster, or clusters use overlapping addresses. Only the
+// endpoint of the first shard, by cluster ID, is sent to the proxies.
+func (s *DiscoveryServer) endpointConflictz(w http.ResponseWriter, req *http.Request) {
+	_ = req.ParseForm()
+	w.Header().Add("Content-Type", "application/json")
+	out := []EndpointConflict{}
+	s.mutex.RLock()
+	for svc, shards := range s.EndpointShardsByService {
+		for ep, clusters := range shards.conflicts() {
+			out = append(out, EndpointConflict{Service: svc, Endpoint: ep, Clusters: clusters})
+		}
+	}
+	s.mutex.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Service != out[j].Service {
+			return out[i].Service < out[j].Service
+		}
+		return out[i].Endpoint < out[j].Endpoint
+	})
+	b, _ := json.MarshalIndent(out, " ", " ")
+	_, _ = w.Write(b)
+}
+
 // Tracks info about workloads. Currently only K8S serviceregistry populates this, based
 // on pod labels and annotations. This is used to detect label changes and push.
 func (s *DiscoveryServer) workloadz(w http.ResponseWriter, req *http.Request) {
@@ -211,23 +289,68 @@ func (s *DiscoveryServer) endpointz(w http.ResponseWriter, req *http.Request) {
 }
 
 // Config debugging.
+// The configs can be filtered by the type, namespace and name query parameters, and paginated with
+// the start and limit query parameters.
 func (s *DiscoveryServer) configz(w http.ResponseWriter, req *http.Request) {
-	w.Header().Add("Content-Type", "application/json")
-	_, _ = fmt.Fprintf(w, "\n[\n")
-	for _, typ := range s.Env.IstioConfigStore.ConfigDescriptor() {
-		cfg, _ := s.Env.IstioConfigStore.List(typ.Type, "")
+	page, err := parseDebugPage(req)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprint(w, err)
+		return
+	}
+	query := req.URL.Query()
+	typ, namespace, name := query.Get("type"), query.Get("namespace"), query.Get("name")
+
+	var configs []model.Config
+	for _, schema := range s.Env.IstioConfigStore.ConfigDescriptor() {
+		if typ != "" && typ != schema.Type {
+			continue
+		}
+		cfg, _ := s.Env.IstioConfigStore.List(schema.Type, namespace)
 		for _, c := range cfg {
-			b, err := json.MarshalIndent(c, "  ", "  ")
-			if err != nil {
-				return
+			if name == "" || name == c.Name {
+				configs = append(configs, c)
 			}
-			_, _ = w.Write(b)
-			_, _ = fmt.Fprint(w, ",\n")
 		}
 	}
+	sort.Slice(configs, func(i, j int) bool {
+		if configs[i].Type != configs[j].Type {
+			return configs[i].Type < configs[j].Type
+		}
+		return configName(&configs[i]) < configName(&configs[j])
+	})
+	first, last := page.bounds(len(configs))
+
+	w.Header().Add("Content-Type", "application/json")
+	_, _ = fmt.Fprintf(w, "\n[\n")
+	for _, c := range configs[first:last] {
+		b, err := json.MarshalIndent(c, "  ", "  ")
+		if err != nil {
+			return
+		}
+		_, _ = w.Write(b)
+		_, _ = fmt.Fprint(w, ",\n")
+		flush(w)
+	}
 	_, _ = fmt.Fprint(w, "\n{}]")
 }
 
+// Mesh config debugging. Returns the effective mesh config, after the defaults, the mesh config
+// file and the overlay of the revision have been merged.
+func (s *DiscoveryServer) meshConfigz(w http.ResponseWriter, req *http.Request) {
+	mesh := s.Env.Mesh
+	if mesh == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	jsonm := &jsonpb.Marshaler{Indent: "  "}
+	w.Header().Add("Content-Type", "application/json")
+	if err := jsonm.Marshal(w, mesh); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprint(w, err)
+	}
+}
+
 type authProtocol int
 
 const (
@@ -382,11 +505,76 @@ func (s *DiscoveryServer) authenticationz(w http.ResponseWriter, req *http.Reque
 	_, _ = fmt.Fprint(w, "\n{}]")
 }
 
+// InboundAuthenticationDebug holds the authentication mode of an inbound port of a proxy. The
+// inbound listener of the port is named after the address and endpoint port of the instance.
+type InboundAuthenticationDebug struct {
+	Host                     string `json:"host"`
+	Port                     int    `json:"port"`
+	Address                  string `json:"address"`
+	EndpointPort             int    `json:"endpoint_port"`
+	AuthenticationPolicyName string `json:"authentication_policy_name"`
+	ServerProtocol           string `json:"server_protocol"`
+}
+
+// inboundAuthnz lists the authentication mode of the service instances of a proxy, so that the
+// plaintext and mTLS traffic reported by the inbound listeners of the proxy can be matched with
+// the policies, e.g. while migrating the PERMISSIVE ports to STRICT.
+// It is mapped to /debug/inboundauthnz?proxyID=<pod>.<namespace>
+func (s *DiscoveryServer) inboundAuthnz(w http.ResponseWriter, req *http.Request) {
+	_ = req.ParseForm()
+	w.Header().Add("Content-Type", "application/json")
+
+	proxyID := req.Form.Get("proxyID")
+	adsClientsMutex.RLock()
+	connections, ok := adsSidecarIDConnectionsMap[proxyID]
+	var mostRecentProxy *model.Proxy
+	mostRecent := ""
+	for key := range connections {
+		if mostRecent == "" || key > mostRecent {
+			mostRecent = key
+		}
+	}
+	if ok {
+		mostRecentProxy = connections[mostRecent].modelNode
+	}
+	adsClientsMutex.RUnlock()
+	if mostRecentProxy == nil {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = fmt.Fprint(w, "[]")
+		return
+	}
+
+	out := make([]InboundAuthenticationDebug, 0, len(mostRecentProxy.ServiceInstances))
+	for _, instance := range mostRecentProxy.ServiceInstances {
+		info := InboundAuthenticationDebug{
+			Host:         string(instance.Service.Hostname),
+			Port:         instance.Endpoint.ServicePort.Port,
+			Address:      instance.Endpoint.Address,
+			EndpointPort: instance.Endpoint.Port,
+		}
+		authnConfig := s.Env.IstioConfigStore.AuthenticationPolicyForWorkload(instance.Service, instance.Labels,
+			instance.Endpoint.ServicePort)
+		info.AuthenticationPolicyName = configName(authnConfig)
+		if authnConfig != nil {
+			info.ServerProtocol = authProtocolToString(getServerAuthProtocol(
+				authn_alpha1.GetMutualTLS(authnConfig.Spec.(*authn.Policy))))
+		} else {
+			info.ServerProtocol = authProtocolToString(getServerAuthProtocol(nil))
+		}
+		out = append(out, info)
+	}
+	if b, err := json.MarshalIndent(out, "", "  "); err == nil {
+		_, _ = w.Write(b)
+	}
+}
+
 // adsz implements a status and debug interface for ADS.
 // It is mapped to /debug/adsz
+// The connections can be filtered by the proxyID query parameter, and paginated with the start and
+// limit query parameters. The types query parameter selects the resources to dump, as a comma
+// separated list of lds, rds and cds.
 func (s *DiscoveryServer) adsz(w http.ResponseWriter, req *http.Request) {
 	_ = req.ParseForm()
-	w.Header().Add("Content-Type", "application/json")
 	if req.Form.Get("push") != "" {
 		AdsPushAll(s)
 		adsClientsMutex.RLock()
@@ -394,7 +582,45 @@ func (s *DiscoveryServer) adsz(w http.ResponseWriter, req *http.Request) {
 		adsClientsMutex.RUnlock()
 		return
 	}
-	writeAllADS(w)
+	page, err := parseDebugPage(req)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprint(w, err)
+		return
+	}
+	types := map[string]bool{"lds": true, "rds": true, "cds": true}
+	if t := req.Form.Get("types"); t != "" {
+		types = map[string]bool{}
+		for _, typ := range strings.Split(t, ",") {
+			types[strings.ToLower(typ)] = true
+		}
+	}
+
+	connections := adsConnections(req.Form.Get("proxyID"))
+	first, last := page.bounds(len(connections))
+	w.Header().Add("Content-Type", "application/json")
+	writeADS(w, connections[first:last], types)
+}
+
+// adsConnections returns the ADS connections of the proxy, or all the connections if proxyID is
+// empty, sorted by connection ID.
+func adsConnections(proxyID string) []*XdsConnection {
+	adsClientsMutex.RLock()
+	var connections []*XdsConnection
+	if proxyID != "" {
+		for _, c := range adsSidecarIDConnectionsMap[proxyID] {
+			connections = append(connections, c)
+		}
+	} else {
+		connections = make([]*XdsConnection, 0, len(adsClients))
+		for _, c := range adsClients {
+			connections = append(connections, c)
+		}
+	}
+	adsClientsMutex.RUnlock()
+
+	sort.Slice(connections, func(i, j int) bool { return connections[i].ConID < connections[j].ConID })
+	return connections
 }
 
 // ConfigDump returns information in the form of the Envoy admin API config dump for the specified proxy
@@ -451,30 +677,38 @@ func (s *DiscoveryServer) PushStatusHandler(w http.ResponseWriter, req *http.Req
 	_, _ = w.Write(out)
 }
 
-func writeAllADS(w io.Writer) {
-	adsClientsMutex.RLock()
-	defer adsClientsMutex.RUnlock()
-
+// writeADS writes the resources of the selected types pushed to the connections, flushing the
+// output after each connection.
+func writeADS(w io.Writer, connections []*XdsConnection, types map[string]bool) {
 	// Dirty json generation - because standard json is dirty (struct madness)
 	// Unfortunately we must use the jsonbp to encode part of the json - I'm sure there are
 	// better ways, but this is mainly for debugging.
 	_, _ = fmt.Fprint(w, "[\n")
 	comma := false
-	for _, c := range adsClients {
+	for _, c := range connections {
 		if comma {
 			_, _ = fmt.Fprint(w, ",\n")
 		} else {
 			comma = true
 		}
-		_, _ = fmt.Fprintf(w, "\n\n  {\"node\": \"%s\",\n \"addr\": \"%s\",\n \"connect\": \"%v\",\n \"listeners\":[\n", c.ConID, c.PeerAddr, c.Connect)
-		printListeners(w, c)
-		_, _ = fmt.Fprint(w, "],\n")
-		_, _ = fmt.Fprintf(w, "\"RDSRoutes\":[\n")
-		printRoutes(w, c)
-		_, _ = fmt.Fprint(w, "],\n")
-		_, _ = fmt.Fprintf(w, "\"clusters\":[\n")
-		printClusters(w, c)
-		_, _ = fmt.Fprint(w, "]}\n")
+		_, _ = fmt.Fprintf(w, "\n\n  {\"node\": \"%s\",\n \"addr\": \"%s\",\n \"connect\": \"%v\"", c.ConID, c.PeerAddr, c.Connect)
+		if types["lds"] {
+			_, _ = fmt.Fprint(w, ",\n \"listeners\":[\n")
+			printListeners(w, c)
+			_, _ = fmt.Fprint(w, "]")
+		}
+		if types["rds"] {
+			_, _ = fmt.Fprintf(w, ",\n\"RDSRoutes\":[\n")
+			printRoutes(w, c)
+			_, _ = fmt.Fprint(w, "]")
+		}
+		if types["cds"] {
+			_, _ = fmt.Fprintf(w, ",\n\"clusters\":[\n")
+			printClusters(w, c)
+			_, _ = fmt.Fprint(w, "]")
+		}
+		_, _ = fmt.Fprint(w, "}\n")
+		flush(w)
 	}
 	_, _ = fmt.Fprint(w, "]\n")
 }
@@ -492,41 +726,67 @@ func (s *DiscoveryServer) ready(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	for _, src := range s.SyncSources {
+		if !src.HasSynced() {
+			w.WriteHeader(503)
+			return
+		}
+	}
 	w.WriteHeader(200)
 }
 
 // edsz implements a status and debug interface for EDS.
 // It is mapped to /debug/edsz on the monitor port (15014).
+// The clusters can be filtered by the cluster query parameter, which matches the clusters whose
+// name contains it, and paginated with the start and limit query parameters.
 func (s *DiscoveryServer) edsz(w http.ResponseWriter, req *http.Request) {
 	_ = req.ParseForm()
+	page, err := parseDebugPage(req)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprint(w, err)
+		return
+	}
 	w.Header().Add("Content-Type", "application/json")
 
 	if req.Form.Get("push") != "" {
 		AdsPushAll(s)
 	}
 
+	filter := req.Form.Get("cluster")
 	edsClusterMutex.RLock()
-	comma := false
-	if len(edsClusters) > 0 {
-		_, _ = fmt.Fprintln(w, "[")
-		for cluster := range edsClusters {
-			if comma {
-				_, _ = fmt.Fprint(w, ",\n")
-			} else {
-				comma = true
-			}
-			cla := s.loadAssignmentsForClusterLegacy(s.globalPushContext(), cluster)
-			jsonm := &jsonpb.Marshaler{Indent: "  "}
-			dbgString, _ := jsonm.MarshalToString(cla)
-			if _, err := w.Write([]byte(dbgString)); err != nil {
-				return
-			}
+	clusters := make([]string, 0, len(edsClusters))
+	for cluster := range edsClusters {
+		if strings.Contains(cluster, filter) {
+			clusters = append(clusters, cluster)
 		}
-		_, _ = fmt.Fprintln(w, "]")
-	} else {
-		w.WriteHeader(404)
 	}
 	edsClusterMutex.RUnlock()
+	if len(clusters) == 0 {
+		w.WriteHeader(404)
+		return
+	}
+	sort.Strings(clusters)
+	first, last := page.bounds(len(clusters))
+
+	push := s.globalPushContext()
+	jsonm := &jsonpb.Marshaler{Indent: "  "}
+	comma := false
+	_, _ = fmt.Fprintln(w, "[")
+	for _, cluster := range clusters[first:last] {
+		if comma {
+			_, _ = fmt.Fprint(w, ",\n")
+		} else {
+			comma = true
+		}
+		cla := s.loadAssignmentsForClusterLegacy(push, cluster)
+		dbgString, _ := jsonm.MarshalToString(cla)
+		if _, err := w.Write([]byte(dbgString)); err != nil {
+			return
+		}
+		flush(w)
+	}
+	_, _ = fmt.Fprintln(w, "]")
 }
 
 // cdsz implements a status and debug interface for CDS.
@@ -613,3 +873,47 @@ func printRoutes(w io.Writer, c *XdsConnection) {
 		}
 	}
 }
+
+// debugPage selects the entries of a debug response, from the start query parameter and up to
+// the limit query parameter. All the entries from start are selected if limit is 0 or missing.
+type debugPage struct {
+	start int
+	limit int
+}
+
+func parseDebugPage(req *http.Request) (debugPage, error) {
+	var page debugPage
+	query := req.URL.Query()
+	for param, value := range map[string]*int{"start": &page.start, "limit": &page.limit} {
+		v := query.Get(param)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return page, fmt.Errorf("invalid %s %q: must be a non-negative integer", param, v)
+		}
+		*value = n
+	}
+	return page, nil
+}
+
+// bounds returns the indexes of the first and after the last selected entries among n entries.
+func (p debugPage) bounds(n int) (int, int) {
+	first := p.start
+	if first > n {
+		first = n
+	}
+	last := n
+	if p.limit > 0 && p.limit < n-first {
+		last = first + p.limit
+	}
+	return first, last
+}
+
+// flush sends the response written so far to the client, so that large responses are streamed.
+func flush(w io.Writer) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDebugPage(t *testing.T) {
+	cases := []struct {
+		query       string
+		n           int
+		first, last int
+		wantErr     bool
+	}{
+		{query: "", n: 5, first: 0, last: 5},
+		{query: "limit=2", n: 5, first: 0, last: 2},
+		{query: "start=2&limit=2", n: 5, first: 2, last: 4},
+		{query: "start=4&limit=2", n: 5, first: 4, last: 5},
+		{query: "start=7", n: 5, first: 5, last: 5},
+		{query: "start=2&limit=9223372036854775807", n: 5, first: 2, last: 5},
+		{query: "start=-1", wantErr: true},
+		{query: "limit=abc", wantErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			page, err := parseDebugPage(httptest.NewRequest("GET", "/debug/configz?"+c.query, nil))
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error for %q", c.query)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if first, last := page.bounds(c.n); first != c.first || last != c.last {
+				t.Errorf("bounds(%d) => got [%d, %d), want [%d, %d)", c.n, first, last, c.first, c.last)
+			}
+		})
+	}
+}
@@ -19,12 +19,14 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 	"time"
 
 	"istio.io/istio/istioctl/pkg/util/configdump"
 	"istio.io/istio/pilot/pkg/model"
 	v2 "istio.io/istio/pilot/pkg/proxy/envoy/v2"
+	"istio.io/istio/pilot/pkg/serviceregistry/aggregate"
 	"istio.io/istio/tests/util"
 )
 
@@ -285,3 +287,46 @@ func getConfigDump(t *testing.T, s *v2.DiscoveryServer, proxyID string, wantCode
 	}
 	return got
 }
+
+func TestSynczSources(t *testing.T) {
+	synced := false
+	s := &v2.DiscoveryServer{
+		SyncSources: []v2.SyncSource{
+			{Kind: "registry", Name: "Kubernetes", HasSynced: func() bool { return true }},
+			{Kind: "config", Name: "k8s://", HasSynced: func() bool { return synced }},
+		},
+	}
+	mux := http.NewServeMux()
+	s.InitDebug(mux, aggregate.NewController())
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mux.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := get("/ready"); rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("ready before sync: got %d, want %d", rr.Code, http.StatusServiceUnavailable)
+	}
+
+	var got []v2.SourceSyncStatus
+	if err := json.Unmarshal(get("/debug/syncz?sources=true").Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := []v2.SourceSyncStatus{
+		{Kind: "registry", Name: "Kubernetes", Synced: true},
+		{Kind: "config", Name: "k8s://", Synced: false},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got sources %v, want %v", got, want)
+	}
+
+	synced = true
+	if rr := get("/ready"); rr.Code != http.StatusOK {
+		t.Errorf("ready after sync: got %d, want %d", rr.Code, http.StatusOK)
+	}
+}
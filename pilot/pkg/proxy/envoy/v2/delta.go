@@ -0,0 +1,278 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	"github.com/gogo/protobuf/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"istio.io/istio/pilot/pkg/features"
+)
+
+// DeltaAggregatedResources implements the incremental variant of ADS. The connection is handled
+// like a state of the world ADS connection, through a deltaStream which only sends the resources
+// that changed since they were last acknowledged by the proxy.
+func (s *DiscoveryServer) DeltaAggregatedResources(stream ads.AggregatedDiscoveryService_DeltaAggregatedResourcesServer) error {
+	if !features.EnableDeltaXDS() {
+		return status.Errorf(codes.Unimplemented, "delta xDS is disabled, set PILOT_ENABLE_DELTA_XDS to enable it")
+	}
+	return s.streamAggregatedResources(newDeltaStream(stream))
+}
+
+// deltaStream adapts a delta ADS stream to a DiscoveryStream. The subscriptions of the delta
+// requests are converted to the resource names of state of the world requests, and the state of
+// the world responses are converted to delta responses by comparing their resources with the
+// ledger of the resources acknowledged by the proxy.
+type deltaStream struct {
+	ads.AggregatedDiscoveryService_DeltaAggregatedResourcesServer
+
+	mu sync.Mutex
+	// node is sent by the proxy on the first request only.
+	node *core.Node
+	// subscriptions holds the names of the resources subscribed by the proxy, by type.
+	subscriptions map[string]map[string]struct{}
+	// ledger holds the versions of the resources known by the proxy, by type and name.
+	ledger map[string]map[string]string
+	// pending holds the changes of the responses not acknowledged yet, by type, in the order
+	// they were sent.
+	pending map[string][]*deltaChanges
+	// versionSent holds the version of the last response, by type.
+	versionSent map[string]string
+}
+
+func newDeltaStream(stream ads.AggregatedDiscoveryService_DeltaAggregatedResourcesServer) *deltaStream {
+	return &deltaStream{
+		AggregatedDiscoveryService_DeltaAggregatedResourcesServer: stream,
+		subscriptions: make(map[string]map[string]struct{}),
+		ledger:        make(map[string]map[string]string),
+		pending:       make(map[string][]*deltaChanges),
+		versionSent:   make(map[string]string),
+	}
+}
+
+// deltaChanges are the changes of the ledger carried by a response, applied once the proxy
+// acknowledges its nonce.
+type deltaChanges struct {
+	nonce    string
+	versions map[string]string
+	removed  []string
+}
+
+// Recv receives a delta request, and converts it to a state of the world request for all the
+// resources subscribed so far. An ACK is converted to a request with the version last sent, and
+// records the resources of the acknowledged response in the ledger. The resources of a rejected
+// response are left out of the ledger, so that they are sent again by the next push.
+func (d *deltaStream) Recv() (*xdsapi.DiscoveryRequest, error) {
+	req, err := d.AggregatedDiscoveryService_DeltaAggregatedResourcesServer.Recv()
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if req.Node != nil {
+		d.node = req.Node
+	}
+	subscribed := d.subscriptions[req.TypeUrl]
+	if subscribed == nil {
+		subscribed = make(map[string]struct{})
+		d.subscriptions[req.TypeUrl] = subscribed
+	}
+	ledger := d.ledgerLocked(req.TypeUrl)
+	if req.ResponseNonce != "" {
+		d.ackLocked(req.TypeUrl, req.ResponseNonce, req.ErrorDetail == nil)
+	}
+	for name, version := range req.InitialResourceVersions {
+		// resources already known by the proxy, e.g. after reconnecting to another pilot
+		ledger[name] = version
+	}
+	for _, name := range req.ResourceNamesSubscribe {
+		subscribed[name] = struct{}{}
+	}
+	for _, name := range req.ResourceNamesUnsubscribe {
+		delete(subscribed, name)
+		delete(ledger, name)
+		for _, changes := range d.pending[req.TypeUrl] {
+			delete(changes.versions, name)
+		}
+	}
+
+	var names []string
+	for name := range subscribed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out := &xdsapi.DiscoveryRequest{
+		Node:          d.node,
+		TypeUrl:       req.TypeUrl,
+		ResourceNames: names,
+		ResponseNonce: req.ResponseNonce,
+		ErrorDetail:   req.ErrorDetail,
+	}
+	if req.ResponseNonce != "" {
+		out.VersionInfo = d.versionSent[req.TypeUrl]
+	}
+	return out, nil
+}
+
+// Send converts the state of the world response to a delta response holding the resources that
+// changed since they were last acknowledged. The clusters and listeners missing from the response
+// are removed, as their responses hold all the resources of the proxy. The response is sent even
+// if nothing changed, so that the proxy acknowledges its nonce.
+func (d *deltaStream) Send(res *xdsapi.DiscoveryResponse) error {
+	out := &xdsapi.DeltaDiscoveryResponse{
+		SystemVersionInfo: res.VersionInfo,
+		TypeUrl:           res.TypeUrl,
+		Nonce:             res.Nonce,
+	}
+
+	d.mu.Lock()
+	ledger := d.ledgerLocked(res.TypeUrl)
+	sent := make(map[string]struct{}, len(res.Resources))
+	changes := &deltaChanges{nonce: res.Nonce, versions: make(map[string]string)}
+	unchanged := 0
+	for i := range res.Resources {
+		resource := &res.Resources[i]
+		name, err := resourceName(resource.Value)
+		if err != nil {
+			d.mu.Unlock()
+			return fmt.Errorf("invalid %s resource: %v", res.TypeUrl, err)
+		}
+		sent[name] = struct{}{}
+		version := resourceVersion(resource.Value)
+		if ledger[name] == version {
+			unchanged++
+			continue
+		}
+		changes.versions[name] = version
+		out.Resources = append(out.Resources, xdsapi.Resource{
+			Name:     name,
+			Version:  version,
+			Resource: resource,
+		})
+	}
+	if res.TypeUrl == ClusterType || res.TypeUrl == ListenerType {
+		for name := range ledger {
+			if _, ok := sent[name]; !ok {
+				out.RemovedResources = append(out.RemovedResources, name)
+			}
+		}
+		sort.Strings(out.RemovedResources)
+		changes.removed = out.RemovedResources
+	}
+	d.pending[res.TypeUrl] = append(d.pending[res.TypeUrl], changes)
+	d.versionSent[res.TypeUrl] = res.VersionInfo
+	d.mu.Unlock()
+
+	typ := deltaTypeTag(res.TypeUrl)
+	deltaResources.With(typeTag.Value(typ), resultTag.Value("sent")).Record(float64(len(out.Resources)))
+	deltaResources.With(typeTag.Value(typ), resultTag.Value("unchanged")).Record(float64(unchanged))
+	deltaResources.With(typeTag.Value(typ), resultTag.Value("removed")).Record(float64(len(out.RemovedResources)))
+	return d.AggregatedDiscoveryService_DeltaAggregatedResourcesServer.Send(out)
+}
+
+// ackLocked handles the ACK or NACK of the response with the nonce. The changes of an acknowledged
+// response are applied to the ledger. The proxy answers the responses in order, so the changes of
+// the responses sent before are dropped: they were answered already, or superseded.
+func (d *deltaStream) ackLocked(typeURL, nonce string, acked bool) {
+	pending := d.pending[typeURL]
+	for i, changes := range pending {
+		if changes.nonce != nonce {
+			continue
+		}
+		if acked {
+			ledger := d.ledgerLocked(typeURL)
+			for name, version := range changes.versions {
+				ledger[name] = version
+			}
+			for _, name := range changes.removed {
+				delete(ledger, name)
+			}
+		}
+		d.pending[typeURL] = pending[i+1:]
+		return
+	}
+}
+
+func (d *deltaStream) ledgerLocked(typeURL string) map[string]string {
+	ledger := d.ledger[typeURL]
+	if ledger == nil {
+		ledger = make(map[string]string)
+		d.ledger[typeURL] = ledger
+	}
+	return ledger
+}
+
+// resourceName returns the name of a serialized cluster, listener, route configuration or cluster
+// load assignment, which is their first field.
+func resourceName(value []byte) (string, error) {
+	buf := proto.NewBuffer(value)
+	for {
+		key, err := buf.DecodeVarint()
+		if err != nil {
+			return "", fmt.Errorf("no name: %v", err)
+		}
+		field, wire := key>>3, key&7
+		if field == 1 && wire == proto.WireBytes {
+			return buf.DecodeStringBytes()
+		}
+		switch wire {
+		case proto.WireVarint:
+			_, err = buf.DecodeVarint()
+		case proto.WireFixed64:
+			_, err = buf.DecodeFixed64()
+		case proto.WireBytes:
+			_, err = buf.DecodeRawBytes(false)
+		case proto.WireFixed32:
+			_, err = buf.DecodeFixed32()
+		default:
+			err = fmt.Errorf("unexpected wire type %d", wire)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+// resourceVersion returns the version of a serialized resource, a hash of its content.
+func resourceVersion(value []byte) string {
+	h := fnv.New64a()
+	_, _ = h.Write(value)
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+func deltaTypeTag(typeURL string) string {
+	switch typeURL {
+	case ClusterType:
+		return "cds"
+	case EndpointType:
+		return "eds"
+	case ListenerType:
+		return "lds"
+	case RouteType:
+		return "rds"
+	}
+	return typeURL
+}
@@ -0,0 +1,200 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	"github.com/gogo/googleapis/google/rpc"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+)
+
+type fakeDeltaStream struct {
+	ads.AggregatedDiscoveryService_DeltaAggregatedResourcesServer
+	requests  []*xdsapi.DeltaDiscoveryRequest
+	responses []*xdsapi.DeltaDiscoveryResponse
+}
+
+func (f *fakeDeltaStream) Recv() (*xdsapi.DeltaDiscoveryRequest, error) {
+	req := f.requests[0]
+	f.requests = f.requests[1:]
+	return req, nil
+}
+
+func (f *fakeDeltaStream) Send(res *xdsapi.DeltaDiscoveryResponse) error {
+	f.responses = append(f.responses, res)
+	return nil
+}
+
+func mustMarshalAny(t *testing.T, msg proto.Message) types.Any {
+	t.Helper()
+	any, err := types.MarshalAny(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *any
+}
+
+func deltaNames(res *xdsapi.DeltaDiscoveryResponse) []string {
+	var names []string
+	for _, r := range res.Resources {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+// answer makes the proxy ACK, or NACK if rejected, the last response sent on the stream.
+func answer(t *testing.T, d *deltaStream, fake *fakeDeltaStream, rejected bool) {
+	t.Helper()
+	res := fake.responses[len(fake.responses)-1]
+	req := &xdsapi.DeltaDiscoveryRequest{TypeUrl: res.TypeUrl, ResponseNonce: res.Nonce}
+	if rejected {
+		req.ErrorDetail = &rpc.Status{Message: "rejected"}
+	}
+	fake.requests = append(fake.requests, req)
+	if _, err := d.Recv(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeltaStreamRecv(t *testing.T) {
+	fake := &fakeDeltaStream{requests: []*xdsapi.DeltaDiscoveryRequest{
+		{Node: &core.Node{Id: "sidecar~1.1.1.1~a.default~default.svc.cluster.local"}, TypeUrl: EndpointType,
+			ResourceNamesSubscribe: []string{"outbound|80||b", "outbound|80||a"}},
+		{TypeUrl: EndpointType, ResponseNonce: "n1"},
+		{TypeUrl: EndpointType, ResourceNamesUnsubscribe: []string{"outbound|80||a"}, ResponseNonce: "n1"},
+	}}
+	d := newDeltaStream(fake)
+
+	req, _ := d.Recv()
+	if !reflect.DeepEqual(req.ResourceNames, []string{"outbound|80||a", "outbound|80||b"}) || req.VersionInfo != "" {
+		t.Errorf("Recv() => got %v, want a request for the subscribed clusters", req)
+	}
+	if err := d.Send(&xdsapi.DiscoveryResponse{TypeUrl: EndpointType, VersionInfo: "v1", Nonce: "n1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ = d.Recv()
+	if len(req.ResourceNames) != 2 || req.VersionInfo != "v1" || req.ResponseNonce != "n1" || req.Node == nil {
+		t.Errorf("Recv() of an ACK => got %v, want the subscribed clusters, version v1 and the node", req)
+	}
+	req, _ = d.Recv()
+	if !reflect.DeepEqual(req.ResourceNames, []string{"outbound|80||b"}) {
+		t.Errorf("Recv() after unsubscribing => got %v, want outbound|80||b", req.ResourceNames)
+	}
+}
+
+func TestDeltaStreamSend(t *testing.T) {
+	fake := &fakeDeltaStream{}
+	d := newDeltaStream(fake)
+
+	a := mustMarshalAny(t, &xdsapi.Cluster{Name: "a", ConnectTimeout: 1})
+	b := mustMarshalAny(t, &xdsapi.Cluster{Name: "b", ConnectTimeout: 1})
+	b2 := mustMarshalAny(t, &xdsapi.Cluster{Name: "b", ConnectTimeout: 2})
+	c := mustMarshalAny(t, &xdsapi.Cluster{Name: "c", ConnectTimeout: 1})
+
+	for i, tc := range []struct {
+		resources []types.Any
+		sent      []string
+		removed   []string
+	}{
+		{[]types.Any{a, b}, []string{"a", "b"}, nil},
+		{[]types.Any{a, b}, nil, nil},
+		{[]types.Any{a, b2, c}, []string{"b", "c"}, nil},
+		{[]types.Any{c}, nil, []string{"a", "b"}},
+	} {
+		nonce := fmt.Sprintf("n%d", i)
+		if err := d.Send(&xdsapi.DiscoveryResponse{TypeUrl: ClusterType, Resources: tc.resources, Nonce: nonce}); err != nil {
+			t.Fatal(err)
+		}
+		res := fake.responses[len(fake.responses)-1]
+		if got := deltaNames(res); !reflect.DeepEqual(got, tc.sent) || !reflect.DeepEqual(res.RemovedResources, tc.removed) {
+			t.Errorf("Send() => got resources %v and removed %v, want %v and %v", got, res.RemovedResources, tc.sent, tc.removed)
+		}
+		if res.Nonce != nonce || res.TypeUrl != ClusterType {
+			t.Errorf("Send() => got nonce %q and type %q", res.Nonce, res.TypeUrl)
+		}
+		answer(t, d, fake, false)
+	}
+
+	// The endpoints missing from a response are not removed: the EDS pushes may hold only the
+	// clusters whose endpoints changed.
+	cla := mustMarshalAny(t, &xdsapi.ClusterLoadAssignment{ClusterName: "outbound|80||a"})
+	for _, resources := range [][]types.Any{{cla}, {}} {
+		if err := d.Send(&xdsapi.DiscoveryResponse{TypeUrl: EndpointType, Resources: resources}); err != nil {
+			t.Fatal(err)
+		}
+		if res := fake.responses[len(fake.responses)-1]; len(res.RemovedResources) != 0 {
+			t.Errorf("Send() of endpoints => got removed %v, want none", res.RemovedResources)
+		}
+	}
+}
+
+func TestDeltaStreamInitialResourceVersions(t *testing.T) {
+	a := mustMarshalAny(t, &xdsapi.Listener{Name: "a"})
+	fake := &fakeDeltaStream{requests: []*xdsapi.DeltaDiscoveryRequest{{
+		Node:                    &core.Node{Id: "sidecar~1.1.1.1~a.default~default.svc.cluster.local"},
+		TypeUrl:                 ListenerType,
+		InitialResourceVersions: map[string]string{"a": resourceVersion(a.Value)},
+	}}}
+	d := newDeltaStream(fake)
+	if _, err := d.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Send(&xdsapi.DiscoveryResponse{TypeUrl: ListenerType, Resources: []types.Any{a}}); err != nil {
+		t.Fatal(err)
+	}
+	if res := fake.responses[0]; len(res.Resources) != 0 {
+		t.Errorf("Send() => got %v, want the listener known by the proxy to be skipped", deltaNames(res))
+	}
+}
+
+func TestDeltaStreamNACK(t *testing.T) {
+	fake := &fakeDeltaStream{}
+	d := newDeltaStream(fake)
+
+	a := mustMarshalAny(t, &xdsapi.Cluster{Name: "a", ConnectTimeout: 1})
+	b := mustMarshalAny(t, &xdsapi.Cluster{Name: "b", ConnectTimeout: 1})
+	send := func(nonce string, resources ...types.Any) *xdsapi.DeltaDiscoveryResponse {
+		t.Helper()
+		if err := d.Send(&xdsapi.DiscoveryResponse{TypeUrl: ClusterType, Resources: resources, Nonce: nonce}); err != nil {
+			t.Fatal(err)
+		}
+		return fake.responses[len(fake.responses)-1]
+	}
+
+	send("n1", a)
+	answer(t, d, fake, false)
+
+	// the rejected clusters are sent again by the next push, and so are the removals
+	send("n2", b)
+	answer(t, d, fake, true)
+	if res := send("n3", b); !reflect.DeepEqual(deltaNames(res), []string{"b"}) || !reflect.DeepEqual(res.RemovedResources, []string{"a"}) {
+		t.Errorf("Send() after a NACK => got %v and removed %v, want b and removed a", deltaNames(res), res.RemovedResources)
+	}
+
+	// a response is only recorded once acknowledged, even if another one was sent since
+	send("n4", b)
+	answer(t, d, fake, false)
+	if res := send("n5", b); len(res.Resources) != 0 || len(res.RemovedResources) != 0 {
+		t.Errorf("Send() after an ACK => got %v and removed %v, want nothing", deltaNames(res), res.RemovedResources)
+	}
+}
@@ -15,6 +15,8 @@
 package v2
 
 import (
+	"net"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -29,6 +31,7 @@ import (
 	"istio.io/istio/pilot/pkg/networking/core"
 	authn_model "istio.io/istio/pilot/pkg/security/model"
 	"istio.io/istio/pilot/pkg/serviceregistry/kube/controller"
+	"istio.io/istio/pilot/pkg/status"
 )
 
 var (
@@ -92,8 +95,14 @@ type DiscoveryServer struct {
 	// KubeController provides readiness info (if initial sync is complete)
 	KubeController *controller.Controller
 
+	// SyncSources are the registries and config stores whose initial sync gates readiness.
+	SyncSources []SyncSource
+
 	concurrentPushLimit chan struct{}
 
+	// StatusController, if set, records the configs distributed by each full push.
+	StatusController *status.Controller
+
 	// DebugConfigs controls saving snapshots of configs for /debug/adsz.
 	// Defaults to false, can be enabled with PILOT_DEBUG_ADSZ_CONFIG=1
 	DebugConfigs bool
@@ -128,11 +137,17 @@ type DiscoveryServer struct {
 	proxyUpdates map[string]struct{}
 
 	pushQueue *PushQueue
+
+	// cache holds the clusters and routes generated for the groups of identical proxies.
+	cache xdsCache
 }
 
 // updateReq includes info about the requested update.
 type updateReq struct {
 	full bool
+
+	// scope restricts the full push, nil to push everything.
+	scope *PushScope
 }
 
 // EndpointShards holds the set of endpoint shards of a service. Registries update
@@ -155,6 +170,72 @@ type EndpointShards struct {
 	ServiceAccounts map[string]bool
 }
 
+// tlsModes returns the set of TLS modes of the endpoints of all the shards. Must be called with
+// the mutex held.
+func (e *EndpointShards) tlsModes() map[string]bool {
+	modes := map[string]bool{}
+	for _, endpoints := range e.Shards {
+		for _, ep := range endpoints {
+			modes[ep.TLSMode] = true
+		}
+	}
+	return modes
+}
+
+// clusterIDs returns the sorted keys of the shards. Must be called with the mutex held.
+func (e *EndpointShards) clusterIDs() []string {
+	clusterIDs := make([]string, 0, len(e.Shards))
+	for clusterID := range e.Shards {
+		clusterIDs = append(clusterIDs, clusterID)
+	}
+	sort.Strings(clusterIDs)
+	return clusterIDs
+}
+
+// conflicts returns the endpoints with the same network and address found in several shards,
+// keyed by address, with the sorted keys of their shards. Only the endpoint of the first shard is
+// sent.
+func (e *EndpointShards) conflicts() map[string][]string {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	shardsByEndpoint := map[string][]string{}
+	for _, clusterID := range e.clusterIDs() {
+		found := map[string]bool{}
+		for _, ep := range e.Shards[clusterID] {
+			key := endpointKey(ep)
+			if !found[key] {
+				found[key] = true
+				shardsByEndpoint[key] = append(shardsByEndpoint[key], clusterID)
+			}
+		}
+	}
+	for key, clusterIDs := range shardsByEndpoint {
+		if len(clusterIDs) < 2 {
+			delete(shardsByEndpoint, key)
+		}
+	}
+	return shardsByEndpoint
+}
+
+// endpointKey identifies an endpoint by its network and address. The same endpoint may be listed
+// by several shards, e.g. when two primaries watch the same remote cluster, while the same address
+// in another network is another endpoint.
+func endpointKey(ep *model.IstioEndpoint) string {
+	if ep.Network == "" {
+		return endpointAddress(ep)
+	}
+	return ep.Network + "/" + endpointAddress(ep)
+}
+
+// endpointAddress returns the address and port of the endpoint.
+func endpointAddress(ep *model.IstioEndpoint) string {
+	if ep.Family == model.AddressFamilyUnix {
+		return ep.Address
+	}
+	return net.JoinHostPort(ep.Address, strconv.Itoa(int(ep.EndpointPort)))
+}
+
 // Workload has the minimal info we need to detect if we need to push workloads, and to
 // cache data to avoid expensive model allocations.
 type Workload struct {
@@ -182,6 +263,9 @@ func NewDiscoveryServer(
 		updateChannel:           make(chan *updateReq, 10),
 		pushQueue:               NewPushQueue(),
 	}
+	if features.PushQueueFairness {
+		out.pushQueue = NewFairPushQueue()
+	}
 
 	// Flush cached discovery responses whenever services, service
 	// instances, or routing configuration changes.
@@ -279,6 +363,11 @@ func (s *DiscoveryServer) periodicRefreshMetrics(stopCh <-chan struct{}) {
 // Push is called to push changes on config updates using ADS. This is set in DiscoveryService.Push,
 // to avoid direct dependencies.
 func (s *DiscoveryServer) Push(full bool, edsUpdates map[string]struct{}) {
+	s.push(full, edsUpdates, nil)
+}
+
+// push is Push restricted to the scope for the full pushes.
+func (s *DiscoveryServer) push(full bool, edsUpdates map[string]struct{}, scope *PushScope) {
 	if !full {
 		go s.AdsPushAll(versionInfo(), s.globalPushContext(), false, edsUpdates)
 		return
@@ -291,6 +380,11 @@ func (s *DiscoveryServer) Push(full bool, edsUpdates map[string]struct{}) {
 	// PushContext is reset after a config change. Previous status is
 	// saved.
 	t0 := time.Now()
+	// Record the configs before computing the push context, so that the recorded
+	// generations are never newer than the ones pushed.
+	if s.StatusController != nil {
+		s.StatusController.RecordPush(int64(versionNum.Load()))
+	}
 	push := model.NewPushContext()
 	err := push.InitContext(s.Env)
 	if err != nil {
@@ -317,7 +411,7 @@ func (s *DiscoveryServer) Push(full bool, edsUpdates map[string]struct{}) {
 	version = versionLocal
 	versionMutex.Unlock()
 
-	go s.AdsPushAll(versionLocal, push, true, nil)
+	go s.adsPushAll(versionLocal, push, true, nil, scope)
 }
 
 func nonce() string {
@@ -344,7 +438,7 @@ func (s *DiscoveryServer) ClearCache() {
 }
 
 // Start the actual push. Called from a timer.
-func (s *DiscoveryServer) doPush(full bool) {
+func (s *DiscoveryServer) doPush(full bool, scope *PushScope) {
 	// more config update events may happen while doPush is processing.
 	// we don't want to lose updates.
 	s.mutex.Lock()
@@ -355,7 +449,7 @@ func (s *DiscoveryServer) doPush(full bool) {
 	s.edsUpdates = map[string]struct{}{}
 	s.mutex.Unlock()
 
-	s.Push(full, edsUpdates)
+	s.push(full, edsUpdates, scope)
 }
 
 // clearCache will clear all envoy caches. Called by service, instance and config handlers.
@@ -385,18 +479,33 @@ func (s *DiscoveryServer) handleUpdates(stopCh <-chan struct{}) {
 
 	debouncedEvents := 0
 	fullPush := false
+	var pushScope *PushScope
+
+	edsPush := newCoalescedPush(func() { s.doPush(false, nil) })
 
 	for {
 		select {
 		case r := <-s.updateChannel:
+			if !r.full && !features.EnableEDSDebounce {
+				// The endpoint updates are pushed right away, without waiting for the debounced
+				// config updates. The updates received during a push are coalesced into the next one.
+				edsPush.request()
+				continue
+			}
 			lastConfigUpdateTime = time.Now()
 			if debouncedEvents == 0 {
 				timeChan = time.After(DebounceAfter)
 				startDebounce = lastConfigUpdateTime
 			}
 			debouncedEvents++
-			// fullPush is sticky if any debounced event requires a fullPush
+			// fullPush is sticky if any debounced event requires a fullPush, and its scope covers
+			// all the debounced full events.
 			if r.full {
+				if fullPush {
+					pushScope = pushScope.merge(r.scope)
+				} else {
+					pushScope = r.scope
+				}
 				fullPush = true
 			}
 
@@ -408,23 +517,62 @@ func (s *DiscoveryServer) handleUpdates(stopCh <-chan struct{}) {
 			// it has been too long or quiet enough
 			if eventDelay >= DebounceMax || quietTime >= DebounceAfter {
 				pushCounter++
-				adsLog.Infof("Push debounce stable[%d] %d: %v since last change, %v since last push, full=%v",
+				adsLog.Infof("Push debounce stable[%d] %d: %v since last change, %v since last push, full=%v, scope=%v",
 					pushCounter, debouncedEvents,
-					quietTime, eventDelay, fullPush)
+					quietTime, eventDelay, fullPush, pushScope)
 
-				go s.doPush(fullPush)
+				go s.doPush(fullPush, pushScope)
 				fullPush = false
+				pushScope = nil
 				debouncedEvents = 0
 				continue
 			}
 
 			timeChan = time.After(DebounceAfter - quietTime)
+		case <-edsPush.done:
+			edsPush.completed()
 		case <-stopCh:
 			return
 		}
 	}
 }
 
+// coalescedPush runs a push in the background, one at a time. The requests received while a push
+// is running are coalesced into a single push, started when the running one completes. It is not
+// safe for concurrent use: completed must be called by the goroutine calling request, when done
+// is signaled.
+type coalescedPush struct {
+	push    func()
+	done    chan struct{}
+	running bool
+	pending bool
+}
+
+func newCoalescedPush(push func()) *coalescedPush {
+	// At most one push runs at a time, so it never blocks on done.
+	return &coalescedPush{push: push, done: make(chan struct{}, 1)}
+}
+
+func (c *coalescedPush) request() {
+	if c.running {
+		c.pending = true
+		return
+	}
+	c.running = true
+	go func() {
+		c.push()
+		c.done <- struct{}{}
+	}()
+}
+
+func (c *coalescedPush) completed() {
+	c.running = false
+	if c.pending {
+		c.pending = false
+		c.request()
+	}
+}
+
 func (s *DiscoveryServer) checkProxyNeedsFullPush(node *model.Proxy) bool {
 	full := false
 	s.proxyUpdatesMutex.Lock()
@@ -457,7 +605,10 @@ func doSendPushes(stopCh <-chan struct{}, semaphore chan struct{}, queue *PushQu
 
 			go func() {
 				edsUpdates := info.edsUpdatedServices
-				proxyFull := info.full || checkProxyNeedsFullPush(client.modelNode)
+				proxyFull, scope := info.full, info.scope
+				if checkProxyNeedsFullPush(client.modelNode) {
+					proxyFull, scope = true, nil
+				}
 
 				if proxyFull {
 					// Setting this to nil will trigger a full push
@@ -468,6 +619,7 @@ func doSendPushes(stopCh <-chan struct{}, semaphore chan struct{}, queue *PushQu
 				case client.pushChannel <- &XdsEvent{
 					push:               info.push,
 					edsUpdatedServices: edsUpdates,
+					scope:              scope,
 					done:               doneFunc,
 					start:              info.start,
 				}:
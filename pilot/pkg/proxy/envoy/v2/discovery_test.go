@@ -17,6 +17,7 @@ package v2
 import (
 	"context"
 	"fmt"
+	"os"
 	"reflect"
 	"sync"
 	"testing"
@@ -164,3 +165,71 @@ func (h *fakeStream) Recv() (*xdsapi.DiscoveryRequest, error) {
 func (h *fakeStream) Context() context.Context {
 	return context.Background()
 }
+
+func TestCoalescedPush(t *testing.T) {
+	release := make(chan struct{})
+	pushes := 0
+	c := newCoalescedPush(func() {
+		pushes++
+		<-release
+	})
+
+	// The requests received during a push are coalesced into a single push.
+	c.request()
+	c.request()
+	c.request()
+	release <- struct{}{}
+	<-c.done
+	c.completed()
+	release <- struct{}{}
+	<-c.done
+	c.completed()
+	if pushes != 2 {
+		t.Errorf("got %d pushes, want 2", pushes)
+	}
+	if c.running || c.pending {
+		t.Errorf("got running=%v pending=%v after the pushes completed, want no push", c.running, c.pending)
+	}
+
+	// A request after the pushes completed starts a new push.
+	c.request()
+	release <- struct{}{}
+	<-c.done
+	c.completed()
+	if pushes != 3 {
+		t.Errorf("got %d pushes, want 3", pushes)
+	}
+}
+
+func TestEDSUpdateTLSModesFullPush(t *testing.T) {
+	_ = os.Setenv("PILOT_ENABLE_TLS_MODE_FILTERING", "true")
+	defer os.Unsetenv("PILOT_ENABLE_TLS_MODE_FILTERING")
+	s := &DiscoveryServer{
+		EndpointShardsByService: map[string]*EndpointShards{},
+		edsUpdates:              map[string]struct{}{},
+		updateChannel:           make(chan *updateReq, 10),
+	}
+	update := func(tlsModes ...string) bool {
+		t.Helper()
+		var endpoints []*model.IstioEndpoint
+		for i, tlsMode := range tlsModes {
+			endpoints = append(endpoints, &model.IstioEndpoint{Address: fmt.Sprintf("10.0.0.%d", i), TLSMode: tlsMode})
+		}
+		if err := s.EDSUpdate("cluster1", "reviews.default.svc.cluster.local", endpoints); err != nil {
+			t.Fatal(err)
+		}
+		return (<-s.updateChannel).full
+	}
+
+	// The first update of a service is a full push.
+	update("istio")
+	if update("istio", "istio") {
+		t.Error("EDSUpdate() with the same TLS modes => got a full push")
+	}
+	if !update("istio", "disabled") {
+		t.Error("EDSUpdate() adding plaintext endpoints => got no full push")
+	}
+	if !update("istio") {
+		t.Error("EDSUpdate() removing the plaintext endpoints => got no full push")
+	}
+}
@@ -93,8 +93,11 @@ func loadAssignment(c *EdsCluster) *xdsapi.ClusterLoadAssignment {
 	return c.LoadAssignment
 }
 
-// buildEnvoyLbEndpoint packs the endpoint based on istio info.
-func buildEnvoyLbEndpoint(uid string, family model.AddressFamily, address string, port uint32, network string, weight uint32) *endpoint.LbEndpoint {
+// buildEnvoyLbEndpoint packs the endpoint based on istio info. The cluster is the ID of the
+// registry (k8s cluster in multicluster) the endpoint comes from, and the TLS mode its TLS
+// capability.
+func buildEnvoyLbEndpoint(uid string, family model.AddressFamily, address string, port uint32, network string, cluster string,
+	tlsMode string, weight uint32) *endpoint.LbEndpoint {
 	var addr core.Address
 	switch family {
 	case model.AddressFamilyTCP:
@@ -129,7 +132,7 @@ func buildEnvoyLbEndpoint(uid string, family model.AddressFamily, address string
 
 	// Istio telemetry depends on the metadata value being set for endpoints in the mesh.
 	// Do not remove: mixerfilter depends on this logic.
-	ep.Metadata = endpointMetadata(uid, network)
+	ep.Metadata = endpointMetadata(uid, network, cluster, tlsMode)
 
 	return ep
 }
@@ -159,14 +162,14 @@ func networkEndpointToEnvoyEndpoint(e *model.NetworkEndpoint) (*endpoint.LbEndpo
 
 	// Istio telemetry depends on the metadata value being set for endpoints in the mesh.
 	// Do not remove: mixerfilter depends on this logic.
-	ep.Metadata = endpointMetadata(e.UID, e.Network)
+	ep.Metadata = endpointMetadata(e.UID, e.Network, "", e.TLSMode)
 
 	return ep, nil
 }
 
-// Create an Istio filter metadata object with the UID and Network fields (if exist).
-func endpointMetadata(uid string, network string) *core.Metadata {
-	if uid == "" && network == "" {
+// Create an Istio filter metadata object with the UID, Network, Cluster and TLS mode fields (if exist).
+func endpointMetadata(uid string, network string, cluster string, tlsMode string) *core.Metadata {
+	if uid == "" && network == "" && cluster == "" && tlsMode == "" {
 		return nil
 	}
 
@@ -186,6 +189,14 @@ func endpointMetadata(uid string, network string) *core.Metadata {
 		metadata.FilterMetadata["istio"].Fields["network"] = &types.Value{Kind: &types.Value_StringValue{StringValue: network}}
 	}
 
+	if cluster != "" {
+		metadata.FilterMetadata["istio"].Fields["cluster"] = &types.Value{Kind: &types.Value_StringValue{StringValue: cluster}}
+	}
+
+	if tlsMode != "" {
+		metadata.FilterMetadata["istio"].Fields[tlsModeMetadataKey] = &types.Value{Kind: &types.Value_StringValue{StringValue: tlsMode}}
+	}
+
 	return metadata
 }
 
@@ -407,7 +418,7 @@ func (s *DiscoveryServer) edsIncremental(version string, push *model.PushContext
 	}
 	adsLog.Infof("Cluster init time %v %s", time.Since(t0), version)
 
-	s.startPush(push, false, edsUpdates)
+	s.startPush(push, false, edsUpdates, nil)
 }
 
 // WorkloadUpdate is called when workload labels/annotations are updated.
@@ -544,7 +555,14 @@ func (s *DiscoveryServer) edsUpdate(shard, serviceName string,
 		}
 	}
 	ep.mutex.Lock()
+	tlsModes := ep.tlsModes()
 	ep.Shards[shard] = istioEndpoints
+	if features.EnableTLSModeFiltering() && !requireFull && !internal && !reflect.DeepEqual(tlsModes, ep.tlsModes()) {
+		// The TLS settings of the clusters depend on the TLS modes of their endpoints.
+		// Requires a CDS push and full sync.
+		adsLog.Infof("Endpoint updating TLS modes %v %s", ep.tlsModes(), serviceName)
+		requireFull = true
+	}
 	ep.mutex.Unlock()
 	s.edsUpdates[serviceName] = struct{}{}
 
@@ -696,6 +714,15 @@ func (s *DiscoveryServer) pushEds(push *model.PushContext, con *XdsConnection, v
 			continue
 		}
 
+		// Plaintext endpoints can't be reached with Istio mutual TLS.
+		if features.EnableTLSModeFiltering() && usesIstioMutualTLS(push, con.modelNode, clusterName) {
+			l = &xdsapi.ClusterLoadAssignment{
+				ClusterName: l.ClusterName,
+				Endpoints:   EndpointsByTLSModeFilter(l.Endpoints),
+				Policy:      l.Policy,
+			}
+		}
+
 		// If networks are set (by default they aren't) apply the Split Horizon
 		// EDS filter on the endpoints
 		if s.Env.MeshNetworks != nil && len(s.Env.MeshNetworks.Networks) > 0 {
@@ -790,6 +817,26 @@ func hasOutlierDetection(push *model.PushContext, proxy *model.Proxy, clusterNam
 	return false
 }
 
+// usesIstioMutualTLS returns true if the destination rule of the cluster selects Istio mutual TLS.
+func usesIstioMutualTLS(push *model.PushContext, proxy *model.Proxy, clusterName string) bool {
+	_, subsetName, hostname, portNumber := model.ParseSubsetKey(clusterName)
+
+	destinationRule, port := getDestinationRule(push, proxy, hostname, portNumber)
+	if destinationRule == nil || port == nil {
+		return false
+	}
+
+	_, _, _, tls := networking.SelectTrafficPolicyComponents(destinationRule.TrafficPolicy, port)
+	for _, subset := range destinationRule.Subsets {
+		if subset.Name == subsetName {
+			if _, _, _, subsetTLS := networking.SelectTrafficPolicyComponents(subset.TrafficPolicy, port); subsetTLS != nil {
+				tls = subsetTLS
+			}
+		}
+	}
+	return tls != nil && tls.Mode == networkingapi.TLSSettings_ISTIO_MUTUAL
+}
+
 // addEdsCon will track the eds connection with clusters, for optimized event-based push and debug
 func (s *DiscoveryServer) addEdsCon(clusterName string, node string, connection *XdsConnection) {
 
@@ -890,9 +937,12 @@ func buildLocalityLbEndpointsFromShards(
 
 	shards.mutex.Lock()
 	// The shards are updated independently, now need to filter and merge
-	// for this cluster
-	for _, endpoints := range shards.Shards {
-		for _, ep := range endpoints {
+	// for this cluster. An endpoint listed several times, by the registry of a cluster or by
+	// several shards, is kept once, from the first shard in order.
+	seen := map[string]bool{}
+	duplicates := 0
+	for _, clusterID := range shards.clusterIDs() {
+		for _, ep := range shards.Shards[clusterID] {
 			if svcPort.Name != ep.ServicePortName {
 				continue
 			}
@@ -900,6 +950,12 @@ func buildLocalityLbEndpointsFromShards(
 			if !labels.HasSubsetOf(config.Labels(ep.Labels)) {
 				continue
 			}
+			key := endpointKey(ep)
+			if seen[key] {
+				duplicates++
+				continue
+			}
+			seen[key] = true
 
 			locLbEps, found := localityEpMap[ep.Locality]
 			if !found {
@@ -909,13 +965,16 @@ func buildLocalityLbEndpointsFromShards(
 				localityEpMap[ep.Locality] = locLbEps
 			}
 			if ep.EnvoyEndpoint == nil {
-				ep.EnvoyEndpoint = buildEnvoyLbEndpoint(ep.UID, ep.Family, ep.Address, ep.EndpointPort, ep.Network, ep.LbWeight)
+				ep.EnvoyEndpoint = buildEnvoyLbEndpoint(ep.UID, ep.Family, ep.Address, ep.EndpointPort, ep.Network, clusterID, ep.TLSMode, ep.LbWeight)
 			}
 			locLbEps.LbEndpoints = append(locLbEps.LbEndpoints, *ep.EnvoyEndpoint)
 
 		}
 	}
 	shards.mutex.Unlock()
+	if clusterName != "" {
+		edsDuplicateEndpoints.With(clusterTag.Value(clusterName)).Record(float64(duplicates))
+	}
 
 	locEps := make([]endpoint.LocalityLbEndpoints, 0, len(localityEpMap))
 	for _, locLbEps := range localityEpMap {
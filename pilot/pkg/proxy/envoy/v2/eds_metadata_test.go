@@ -0,0 +1,111 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"reflect"
+	"testing"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config"
+)
+
+func TestEndpointMetadataFromShards(t *testing.T) {
+	shards := &EndpointShards{
+		Shards: map[string][]*model.IstioEndpoint{
+			"cluster1": {{Address: "10.0.0.1", EndpointPort: 80, ServicePortName: "http", UID: "kubernetes://a", Network: "network1"}},
+			"cluster2": {{Address: "10.0.0.2", EndpointPort: 80, ServicePortName: "http", UID: "kubernetes://b", Network: "network2"}},
+		},
+	}
+	locEps := buildLocalityLbEndpointsFromShards(shards, &model.Port{Name: "http", Port: 80}, config.LabelsCollection{}, "", nil)
+
+	got := map[string][2]string{}
+	for _, locEp := range locEps {
+		for _, ep := range locEp.LbEndpoints {
+			fields := ep.Metadata.FilterMetadata["istio"].Fields
+			got[ep.GetEndpoint().Address.GetSocketAddress().Address] = [2]string{
+				fields["cluster"].GetStringValue(), fields["network"].GetStringValue()}
+		}
+	}
+	want := map[string][2]string{
+		"10.0.0.1": {"cluster1", "network1"},
+		"10.0.0.2": {"cluster2", "network2"},
+	}
+	for addr, w := range want {
+		if got[addr] != w {
+			t.Errorf("endpoint %s => got cluster and network %v, want %v", addr, got[addr], w)
+		}
+	}
+}
+
+func TestEndpointMetadataEmpty(t *testing.T) {
+	if md := endpointMetadata("", "", "", ""); md != nil {
+		t.Errorf("endpointMetadata() => got %v, want no metadata", md)
+	}
+}
+
+func TestDuplicateEndpointsFromShards(t *testing.T) {
+	shards := &EndpointShards{
+		Shards: map[string][]*model.IstioEndpoint{
+			"primary2": {
+				{Address: "10.0.0.1", EndpointPort: 80, ServicePortName: "http", Network: "network2"},
+				{Address: "10.0.0.2", EndpointPort: 80, ServicePortName: "http", Network: "network1"},
+			},
+			"primary1": {
+				{Address: "10.0.0.1", EndpointPort: 80, ServicePortName: "http", Network: "network1"},
+				{Address: "10.0.0.2", EndpointPort: 80, ServicePortName: "http", Network: "network1"},
+				{Address: "10.0.0.2", EndpointPort: 80, ServicePortName: "http", Network: "network1"},
+			},
+		},
+	}
+	for i := 0; i < 10; i++ {
+		locEps := buildLocalityLbEndpointsFromShards(shards, &model.Port{Name: "http", Port: 80}, config.LabelsCollection{}, "", nil)
+		if len(locEps) != 1 || len(locEps[0].LbEndpoints) != 3 {
+			t.Fatalf("got endpoints %v, want the endpoints of each network once", locEps)
+		}
+		clusters := map[string]int{}
+		for _, ep := range locEps[0].LbEndpoints {
+			clusters[ep.Metadata.FilterMetadata["istio"].Fields["cluster"].GetStringValue()]++
+		}
+		// The endpoint of network2 is only listed by primary2.
+		if clusters["primary1"] != 2 || clusters["primary2"] != 1 {
+			t.Fatalf("got endpoints by cluster %v, want 2 endpoints of primary1 and 1 of primary2", clusters)
+		}
+	}
+
+	want := map[string][]string{"network1/10.0.0.2:80": {"primary1", "primary2"}}
+	if got := shards.conflicts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("conflicts() => got %v, want %v", got, want)
+	}
+}
+
+func TestDuplicateEndpointsAcrossShards(t *testing.T) {
+	ep := func() *model.IstioEndpoint {
+		return &model.IstioEndpoint{Address: "10.0.0.1", EndpointPort: 80, ServicePortName: "http"}
+	}
+	shards := &EndpointShards{
+		Shards: map[string][]*model.IstioEndpoint{
+			"primary1": {ep()},
+			"primary2": {ep()},
+		},
+	}
+	locEps := buildLocalityLbEndpointsFromShards(shards, &model.Port{Name: "http", Port: 80}, config.LabelsCollection{}, "", nil)
+	if len(locEps) != 1 || len(locEps[0].LbEndpoints) != 1 {
+		t.Fatalf("got endpoints %v, want the endpoint listed by both shards once", locEps)
+	}
+	if cluster := locEps[0].LbEndpoints[0].Metadata.FilterMetadata["istio"].Fields["cluster"].GetStringValue(); cluster != "primary1" {
+		t.Errorf("got the endpoint of cluster %q, want the one of the first shard", cluster)
+	}
+}
@@ -142,6 +142,42 @@ func EndpointsByNetworkFilter(endpoints []endpoint.LocalityLbEndpoints, conn *Xd
 	return filtered
 }
 
+// tlsModeMetadataKey is the key of the TLS mode of an endpoint in its istio metadata.
+const tlsModeMetadataKey = "tlsMode"
+
+// EndpointsByTLSModeFilter filters out the plaintext endpoints of a cluster using Istio mutual TLS,
+// which would fail the TLS handshake. If no endpoint accepts mutual TLS, the endpoints are returned
+// unchanged.
+func EndpointsByTLSModeFilter(endpoints []endpoint.LocalityLbEndpoints) []endpoint.LocalityLbEndpoints {
+	plaintext, total := 0, 0
+	for _, ep := range endpoints {
+		for _, lbEp := range ep.LbEndpoints {
+			if istioMetadata(lbEp, tlsModeMetadataKey) == config.DisabledTLSModeLabel {
+				plaintext++
+			}
+			total++
+		}
+	}
+	if plaintext == 0 || plaintext == total {
+		return endpoints
+	}
+
+	filtered := make([]endpoint.LocalityLbEndpoints, 0, len(endpoints))
+	for _, ep := range endpoints {
+		lbEndpoints := make([]endpoint.LbEndpoint, 0, len(ep.LbEndpoints))
+		for _, lbEp := range ep.LbEndpoints {
+			if istioMetadata(lbEp, tlsModeMetadataKey) != config.DisabledTLSModeLabel {
+				lbEndpoints = append(lbEndpoints, lbEp)
+			}
+		}
+		if len(lbEndpoints) == 0 {
+			continue
+		}
+		filtered = append(filtered, *createLocalityLbEndpoints(&ep, lbEndpoints))
+	}
+	return filtered
+}
+
 // TODO: remove this, filtering should be done before generating the config, and
 // network metadata should not be included in output. A node only receives endpoints
 // in the same network as itself - so passing an network meta, with exactly
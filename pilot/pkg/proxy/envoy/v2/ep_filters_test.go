@@ -15,6 +15,8 @@
 package v2
 
 import (
+	"fmt"
+	"reflect"
 	"sort"
 	"testing"
 
@@ -24,6 +26,7 @@ import (
 
 	meshconfig "istio.io/api/mesh/v1alpha1"
 	"istio.io/istio/pilot/pkg/model"
+	srmemory "istio.io/istio/pilot/pkg/serviceregistry/memory"
 	"istio.io/istio/pkg/config"
 )
 
@@ -348,6 +351,106 @@ func TestEndpointsByNetworkFilter_RegistryServiceName(t *testing.T) {
 	}
 }
 
+func TestEndpointsByNetworkFilter_MemoryRegistry(t *testing.T) {
+	// The v0 instances are in network1 and the v1 instances in network2, whose gateway is the
+	// gateway service of the registry of cluster2.
+	service := srmemory.MakeService("split.default.svc.cluster.local", "10.5.0.0")
+	gateway := srmemory.MakeService("istio-ingressgateway.istio-system.svc.cluster.local", "10.6.0.0")
+	registry := srmemory.NewDiscovery(map[config.Hostname]*model.Service{
+		service.Hostname: service,
+		gateway.Hostname: gateway,
+	}, 2)
+	registry.ClusterID = "cluster2"
+	registry.SetVersionNetwork(0, "network1")
+	registry.SetVersionNetwork(1, "network2")
+	registry.SetGatewayAddresses(gateway.Hostname, "2.2.2.2")
+
+	env := environment()
+	env.ServiceDiscovery = registry
+	env.MeshNetworks.Networks["network2"] = &meshconfig.Network{
+		Endpoints: []*meshconfig.Network_NetworkEndpoints{
+			{Ne: &meshconfig.Network_NetworkEndpoints_FromRegistry{FromRegistry: "cluster2"}},
+		},
+		Gateways: []*meshconfig.Network_IstioNetworkGateway{
+			{
+				Gw:   &meshconfig.Network_IstioNetworkGateway_RegistryServiceName{RegistryServiceName: string(gateway.Hostname)},
+				Port: 15443,
+			},
+		},
+	}
+
+	instances, err := registry.InstancesByPort(service.Hostname, 80, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		network string
+		want    []string
+	}{
+		{network: "network1", want: []string{srmemory.MakeIP(service, 0) + ":80", "2.2.2.2:15443"}},
+		{network: "network2", want: []string{"1.1.1.1:80", srmemory.MakeIP(service, 1) + ":80"}},
+	} {
+		t.Run(tt.network, func(t *testing.T) {
+			filtered := EndpointsByNetworkFilter(localityLbEndpointsFromInstances(instances), xdsConnection(tt.network), env)
+			var got []string
+			for _, ep := range filtered {
+				for _, lbEp := range ep.LbEndpoints {
+					addr := lbEp.GetEndpoint().Address.GetSocketAddress()
+					got = append(got, fmt.Sprintf("%s:%d", addr.Address, addr.GetPortValue()))
+				}
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EndpointsByNetworkFilter() => got endpoints %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEndpointsByTLSModeFilter(t *testing.T) {
+	locality := func(tlsModes ...string) endpoint.LocalityLbEndpoints {
+		var lbEps []endpoint.LbEndpoint
+		for i, mode := range tlsModes {
+			lbEps = append(lbEps, *buildEnvoyLbEndpoint("", model.AddressFamilyTCP, fmt.Sprintf("10.0.0.%d", i), 80, "", "", mode, 1))
+		}
+		return endpoint.LocalityLbEndpoints{LbEndpoints: lbEps}
+	}
+	count := func(endpoints []endpoint.LocalityLbEndpoints) []int {
+		var out []int
+		for _, ep := range endpoints {
+			out = append(out, len(ep.LbEndpoints))
+		}
+		return out
+	}
+
+	cases := []struct {
+		name      string
+		endpoints []endpoint.LocalityLbEndpoints
+		want      []int
+	}{
+		{
+			name:      "mixed",
+			endpoints: []endpoint.LocalityLbEndpoints{locality("istio", "disabled", ""), locality("disabled")},
+			want:      []int{2},
+		},
+		{
+			name:      "all plaintext",
+			endpoints: []endpoint.LocalityLbEndpoints{locality("disabled", "disabled")},
+			want:      []int{2},
+		},
+		{
+			name:      "all mutual TLS",
+			endpoints: []endpoint.LocalityLbEndpoints{locality("istio"), locality("")},
+			want:      []int{1, 1},
+		},
+	}
+	for _, tt := range cases {
+		if got := count(EndpointsByTLSModeFilter(tt.endpoints)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: EndpointsByTLSModeFilter() => got %v endpoints per locality, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
 func xdsConnection(network string) *XdsConnection {
 	var metadata map[string]string
 	if network != "" {
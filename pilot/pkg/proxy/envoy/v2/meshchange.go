@@ -0,0 +1,237 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+
+	"istio.io/istio/pilot/pkg/model"
+)
+
+// XdsTypes is a set of the xDS resource types pushed to the proxies.
+type XdsTypes uint
+
+const (
+	CDS XdsTypes = 1 << iota
+	EDS
+	LDS
+	RDS
+
+	// AllXdsTypes is the set of all the xDS resource types.
+	AllXdsTypes = CDS | EDS | LDS | RDS
+)
+
+// PushScope restricts a full push to some of the xDS resource types and node types. A nil scope
+// pushes everything to all the proxies.
+type PushScope struct {
+	// Types are the xDS resource types pushed.
+	Types XdsTypes
+
+	// NodeTypes are the types of the proxies pushed to, all of them if empty.
+	NodeTypes []model.NodeType
+}
+
+// includes returns true if the resource type is pushed. The load assignments are pushed with the
+// clusters: Envoy requests the load assignments of the updated EDS clusters again with the same
+// names, which is handled as an ACK, so the clusters would stay warming if they were not pushed.
+func (s *PushScope) includes(t XdsTypes) bool {
+	if s != nil && t == EDS && s.Types&CDS != 0 {
+		return true
+	}
+	return s == nil || s.Types&t != 0
+}
+
+// includesNode returns true if the proxy is pushed to.
+func (s *PushScope) includesNode(node *model.Proxy) bool {
+	if s == nil || len(s.NodeTypes) == 0 || node == nil {
+		return true
+	}
+	for _, nodeType := range s.NodeTypes {
+		if node.Type == nodeType {
+			return true
+		}
+	}
+	return false
+}
+
+// merge returns the scope of a push covering both scopes, without modifying them.
+func (s *PushScope) merge(other *PushScope) *PushScope {
+	switch {
+	case s == nil || other == nil:
+		return nil
+	case s.Types == 0:
+		return other
+	case other.Types == 0:
+		return s
+	}
+	merged := &PushScope{Types: s.Types | other.Types}
+	if len(s.NodeTypes) > 0 && len(other.NodeTypes) > 0 {
+		merged.NodeTypes = append(merged.NodeTypes, s.NodeTypes...)
+		for _, nodeType := range other.NodeTypes {
+			if !merged.includesNode(&model.Proxy{Type: nodeType}) {
+				merged.NodeTypes = append(merged.NodeTypes, nodeType)
+			}
+		}
+	}
+	return merged
+}
+
+// String returns the resource and node types of the scope, for logging.
+func (s *PushScope) String() string {
+	if s == nil {
+		return "all"
+	}
+	var types []string
+	for _, t := range []struct {
+		t    XdsTypes
+		name string
+	}{{CDS, "CDS"}, {EDS, "EDS"}, {LDS, "LDS"}, {RDS, "RDS"}} {
+		if s.includes(t.t) {
+			types = append(types, t.name)
+		}
+	}
+	out := strings.Join(types, ",")
+	if len(s.NodeTypes) > 0 {
+		nodeTypes := make([]string, 0, len(s.NodeTypes))
+		for _, nodeType := range s.NodeTypes {
+			nodeTypes = append(nodeTypes, string(nodeType))
+		}
+		out += " to " + strings.Join(nodeTypes, ",")
+	}
+	return out
+}
+
+var (
+	// noPush is the scope of the fields which are not used to generate the xDS resources, either
+	// because they are deprecated or because they are only read on startup.
+	noPush = &PushScope{}
+
+	sidecars = []model.NodeType{model.SidecarProxy}
+
+	// meshFieldScopes maps the fields of the mesh config to the scope of the push needed when they
+	// change. The changes of the fields not listed here push everything to all the proxies.
+	meshFieldScopes = map[string]*PushScope{
+		// Mixer filters, and the session affinity of the telemetry clusters.
+		"MixerCheckServer":                  {Types: CDS | LDS | RDS},
+		"MixerReportServer":                 {Types: CDS | LDS | RDS},
+		"DisablePolicyChecks":               {Types: LDS | RDS},
+		"PolicyCheckFailOpen":               {Types: LDS | RDS},
+		"EnableClientSidePolicyCheck":       {Types: LDS | RDS},
+		"ReportBatchMaxEntries":             {Types: LDS},
+		"ReportBatchMaxTime":                {Types: LDS},
+		"SidecarToTelemetrySessionAffinity": {Types: CDS},
+
+		// HTTP connection manager settings.
+		"EnableTracing":               {Types: LDS},
+		"AccessLogFile":               {Types: LDS},
+		"AccessLogFormat":             {Types: LDS},
+		"AccessLogEncoding":           {Types: LDS},
+		"EnableEnvoyAccessLogService": {Types: LDS},
+
+		// Cluster settings.
+		"ConnectTimeout": {Types: CDS},
+		"TcpKeepalive":   {Types: CDS},
+		"DnsRefreshRate": {Types: CDS},
+
+		// Locality load balancing is applied to both the clusters and the load assignments.
+		"LocalityLbSetting": {Types: CDS | EDS},
+
+		// SDS and SPIFFE identities are referenced by the TLS contexts of the clusters and listeners.
+		"SdsUdsPath":          {Types: CDS | LDS},
+		"EnableSdsTokenMount": {Types: CDS | LDS},
+		"TrustDomain":         {Types: CDS | LDS},
+
+		// Only the virtual and HTTP proxy listeners of the sidecars.
+		"ProxyListenPort": {Types: LDS, NodeTypes: sidecars},
+		"ProxyHttpPort":   {Types: LDS, NodeTypes: sidecars},
+
+		// The passthrough and blackhole clusters, listeners and routes of the sidecars.
+		"OutboundTrafficPolicy": {Types: CDS | LDS | RDS, NodeTypes: sidecars},
+
+		// Read on startup.
+		"IngressClass":          noPush,
+		"IngressService":        noPush,
+		"IngressControllerMode": noPush,
+		"ConfigSources":         noPush,
+		"MixerAddress":          noPush,
+
+		// Deprecated, or only used by the sidecar injector and the proxies.
+		"AuthPolicy":         noPush,
+		"RdsRefreshDelay":    noPush,
+		"SdsRefreshDelay":    noPush,
+		"DisableReportBatch": noPush,
+	}
+)
+
+// MeshConfigChange is the analysis of a change of the mesh config.
+type MeshConfigChange struct {
+	// Fields are the names of the fields which changed.
+	Fields []string
+
+	// Scope is the scope of the push needed to apply the change, nil if everything must be pushed.
+	Scope *PushScope
+}
+
+// NeedsPush returns true if the change must be pushed to some of the proxies.
+func (c *MeshConfigChange) NeedsPush() bool {
+	return len(c.Fields) > 0 && (c.Scope == nil || c.Scope.Types != 0)
+}
+
+// AnalyzeMeshConfigChange compares the mesh configs field by field, and returns the scope of the
+// push needed to apply the change.
+func AnalyzeMeshConfigChange(prev, current *meshconfig.MeshConfig) *MeshConfigChange {
+	change := &MeshConfigChange{Scope: noPush}
+	if prev == nil || current == nil {
+		if prev != current {
+			change.Fields = []string{"*"}
+			change.Scope = nil
+		}
+		return change
+	}
+
+	prevValue, currentValue := reflect.ValueOf(prev).Elem(), reflect.ValueOf(current).Elem()
+	for i := 0; i < prevValue.NumField(); i++ {
+		name := prevValue.Type().Field(i).Name
+		if strings.HasPrefix(name, "XXX_") {
+			continue
+		}
+		if reflect.DeepEqual(prevValue.Field(i).Interface(), currentValue.Field(i).Interface()) {
+			continue
+		}
+		change.Fields = append(change.Fields, name)
+		change.Scope = change.Scope.merge(meshFieldScopes[name])
+	}
+	sort.Strings(change.Fields)
+	return change
+}
+
+// MeshConfigUpdate pushes the change of the mesh config to the proxies it affects, with only the
+// xDS resource types it affects.
+func (s *DiscoveryServer) MeshConfigUpdate(prev, current *meshconfig.MeshConfig) {
+	change := AnalyzeMeshConfigChange(prev, current)
+	if !change.NeedsPush() {
+		if len(change.Fields) > 0 {
+			adsLog.Infof("Mesh config fields %v changed, not pushed to the proxies", change.Fields)
+		}
+		return
+	}
+	adsLog.Infof("Mesh config fields %v changed, pushing %v", change.Fields, change.Scope)
+	inboundConfigUpdates.Increment()
+	s.updateChannel <- &updateReq{full: true, scope: change.Scope}
+}
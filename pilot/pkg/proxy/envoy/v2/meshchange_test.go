@@ -0,0 +1,293 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	networking "istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config"
+)
+
+func TestAnalyzeMeshConfigChange(t *testing.T) {
+	sidecarScope := func(types XdsTypes) *PushScope {
+		return &PushScope{Types: types, NodeTypes: []model.NodeType{model.SidecarProxy}}
+	}
+	cases := []struct {
+		name   string
+		update func(*meshconfig.MeshConfig)
+		fields []string
+		want   *PushScope
+	}{
+		{
+			name:   "mixer check server",
+			update: func(m *meshconfig.MeshConfig) { m.MixerCheckServer = "istio-policy:15004" },
+			fields: []string{"MixerCheckServer"},
+			want:   &PushScope{Types: CDS | LDS | RDS},
+		},
+		{
+			name:   "mixer report server",
+			update: func(m *meshconfig.MeshConfig) { m.MixerReportServer = "istio-telemetry:15004" },
+			fields: []string{"MixerReportServer"},
+			want:   &PushScope{Types: CDS | LDS | RDS},
+		},
+		{
+			name:   "disable policy checks",
+			update: func(m *meshconfig.MeshConfig) { m.DisablePolicyChecks = !m.DisablePolicyChecks },
+			fields: []string{"DisablePolicyChecks"},
+			want:   &PushScope{Types: LDS | RDS},
+		},
+		{
+			name:   "policy check fail open",
+			update: func(m *meshconfig.MeshConfig) { m.PolicyCheckFailOpen = !m.PolicyCheckFailOpen },
+			fields: []string{"PolicyCheckFailOpen"},
+			want:   &PushScope{Types: LDS | RDS},
+		},
+		{
+			name:   "client side policy check",
+			update: func(m *meshconfig.MeshConfig) { m.EnableClientSidePolicyCheck = !m.EnableClientSidePolicyCheck },
+			fields: []string{"EnableClientSidePolicyCheck"},
+			want:   &PushScope{Types: LDS | RDS},
+		},
+		{
+			name: "report batch",
+			update: func(m *meshconfig.MeshConfig) {
+				m.ReportBatchMaxEntries = 1000
+				m.ReportBatchMaxTime = types.DurationProto(time.Second)
+			},
+			fields: []string{"ReportBatchMaxEntries", "ReportBatchMaxTime"},
+			want:   &PushScope{Types: LDS},
+		},
+		{
+			name:   "telemetry session affinity",
+			update: func(m *meshconfig.MeshConfig) { m.SidecarToTelemetrySessionAffinity = true },
+			fields: []string{"SidecarToTelemetrySessionAffinity"},
+			want:   &PushScope{Types: CDS},
+		},
+		{
+			name:   "tracing",
+			update: func(m *meshconfig.MeshConfig) { m.EnableTracing = !m.EnableTracing },
+			fields: []string{"EnableTracing"},
+			want:   &PushScope{Types: LDS},
+		},
+		{
+			name: "access log",
+			update: func(m *meshconfig.MeshConfig) {
+				m.AccessLogFile = "/dev/stderr"
+				m.AccessLogFormat = "%START_TIME%"
+				m.AccessLogEncoding = meshconfig.MeshConfig_JSON
+				m.EnableEnvoyAccessLogService = true
+			},
+			fields: []string{"AccessLogEncoding", "AccessLogFile", "AccessLogFormat", "EnableEnvoyAccessLogService"},
+			want:   &PushScope{Types: LDS},
+		},
+		{
+			name:   "connect timeout",
+			update: func(m *meshconfig.MeshConfig) { m.ConnectTimeout = types.DurationProto(time.Minute) },
+			fields: []string{"ConnectTimeout"},
+			want:   &PushScope{Types: CDS},
+		},
+		{
+			name: "tcp keepalive",
+			update: func(m *meshconfig.MeshConfig) {
+				m.TcpKeepalive = &networking.ConnectionPoolSettings_TCPSettings_TcpKeepalive{Probes: 3}
+			},
+			fields: []string{"TcpKeepalive"},
+			want:   &PushScope{Types: CDS},
+		},
+		{
+			name:   "dns refresh rate",
+			update: func(m *meshconfig.MeshConfig) { m.DnsRefreshRate = types.DurationProto(time.Minute) },
+			fields: []string{"DnsRefreshRate"},
+			want:   &PushScope{Types: CDS},
+		},
+		{
+			name:   "locality load balancing",
+			update: func(m *meshconfig.MeshConfig) { m.LocalityLbSetting = &meshconfig.LocalityLoadBalancerSetting{} },
+			fields: []string{"LocalityLbSetting"},
+			want:   &PushScope{Types: CDS | EDS},
+		},
+		{
+			name: "sds",
+			update: func(m *meshconfig.MeshConfig) {
+				m.SdsUdsPath = "unix:/var/run/sds/uds_path"
+				m.EnableSdsTokenMount = true
+			},
+			fields: []string{"EnableSdsTokenMount", "SdsUdsPath"},
+			want:   &PushScope{Types: CDS | LDS},
+		},
+		{
+			name:   "trust domain",
+			update: func(m *meshconfig.MeshConfig) { m.TrustDomain = "example.com" },
+			fields: []string{"TrustDomain"},
+			want:   &PushScope{Types: CDS | LDS},
+		},
+		{
+			name:   "proxy listen port",
+			update: func(m *meshconfig.MeshConfig) { m.ProxyListenPort = 15002 },
+			fields: []string{"ProxyListenPort"},
+			want:   sidecarScope(LDS),
+		},
+		{
+			name:   "proxy http port",
+			update: func(m *meshconfig.MeshConfig) { m.ProxyHttpPort = 15002 },
+			fields: []string{"ProxyHttpPort"},
+			want:   sidecarScope(LDS),
+		},
+		{
+			name: "outbound traffic policy",
+			update: func(m *meshconfig.MeshConfig) {
+				m.OutboundTrafficPolicy = &meshconfig.MeshConfig_OutboundTrafficPolicy{Mode: meshconfig.MeshConfig_OutboundTrafficPolicy_REGISTRY_ONLY}
+			},
+			fields: []string{"OutboundTrafficPolicy"},
+			want:   sidecarScope(CDS | LDS | RDS),
+		},
+		{
+			name: "sidecars and all proxies",
+			update: func(m *meshconfig.MeshConfig) {
+				m.ProxyListenPort = 15002
+				m.EnableTracing = !m.EnableTracing
+			},
+			fields: []string{"EnableTracing", "ProxyListenPort"},
+			want:   &PushScope{Types: LDS},
+		},
+		{
+			name: "ingress",
+			update: func(m *meshconfig.MeshConfig) {
+				m.IngressClass = "nginx"
+				m.IngressService = "nginx-ingress"
+				m.IngressControllerMode = meshconfig.MeshConfig_STRICT
+			},
+			fields: []string{"IngressClass", "IngressControllerMode", "IngressService"},
+			want:   noPush,
+		},
+		{
+			name:   "config sources",
+			update: func(m *meshconfig.MeshConfig) { m.ConfigSources = []*meshconfig.ConfigSource{{Address: "mcp:15010"}} },
+			fields: []string{"ConfigSources"},
+			want:   noPush,
+		},
+		{
+			name:   "mixer address",
+			update: func(m *meshconfig.MeshConfig) { m.MixerAddress = "istio-mixer:9091" },
+			fields: []string{"MixerAddress"},
+			want:   noPush,
+		},
+		{
+			name: "deprecated",
+			update: func(m *meshconfig.MeshConfig) {
+				m.AuthPolicy = meshconfig.MeshConfig_MUTUAL_TLS
+				m.RdsRefreshDelay = types.DurationProto(time.Minute)
+				m.SdsRefreshDelay = types.DurationProto(time.Minute)
+				m.DisableReportBatch = true
+			},
+			fields: []string{"AuthPolicy", "DisableReportBatch", "RdsRefreshDelay", "SdsRefreshDelay"},
+			want:   noPush,
+		},
+		{
+			name: "no push and cluster settings",
+			update: func(m *meshconfig.MeshConfig) {
+				m.IngressClass = "nginx"
+				m.ConnectTimeout = types.DurationProto(time.Minute)
+			},
+			fields: []string{"ConnectTimeout", "IngressClass"},
+			want:   &PushScope{Types: CDS},
+		},
+		{
+			name:   "export to",
+			update: func(m *meshconfig.MeshConfig) { m.DefaultServiceExportTo = []string{"."} },
+			fields: []string{"DefaultServiceExportTo"},
+		},
+		{
+			name:   "root namespace",
+			update: func(m *meshconfig.MeshConfig) { m.RootNamespace = "istio-config" },
+			fields: []string{"RootNamespace"},
+		},
+		{
+			name:   "default proxy config",
+			update: func(m *meshconfig.MeshConfig) { m.DefaultConfig.DiscoveryAddress = "istio-pilot:15011" },
+			fields: []string{"DefaultConfig"},
+		},
+		{
+			name: "everything and cluster settings",
+			update: func(m *meshconfig.MeshConfig) {
+				m.ConnectTimeout = types.DurationProto(time.Minute)
+				m.DefaultDestinationRuleExportTo = []string{"."}
+			},
+			fields: []string{"ConnectTimeout", "DefaultDestinationRuleExportTo"},
+		},
+		{
+			name:   "unchanged",
+			update: func(m *meshconfig.MeshConfig) {},
+			want:   noPush,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			prev := config.DefaultMeshConfig()
+			current := config.DefaultMeshConfig()
+			c.update(&current)
+
+			change := AnalyzeMeshConfigChange(&prev, &current)
+			if !reflect.DeepEqual(change.Fields, c.fields) {
+				t.Errorf("AnalyzeMeshConfigChange() => got fields %v, want %v", change.Fields, c.fields)
+			}
+			if !reflect.DeepEqual(change.Scope, c.want) {
+				t.Errorf("AnalyzeMeshConfigChange() => got scope %v, want %v", change.Scope, c.want)
+			}
+		})
+	}
+}
+
+func TestPushScope(t *testing.T) {
+	sidecar := &model.Proxy{Type: model.SidecarProxy}
+	router := &model.Proxy{Type: model.Router}
+
+	var all *PushScope
+	if !all.includes(CDS) || !all.includesNode(router) {
+		t.Errorf("nil scope should push everything to all the proxies")
+	}
+	scope := &PushScope{Types: LDS, NodeTypes: []model.NodeType{model.SidecarProxy}}
+	if !scope.includes(LDS) || scope.includes(CDS) {
+		t.Errorf("%v should only push LDS", scope)
+	}
+	if !scope.includesNode(sidecar) || scope.includesNode(router) {
+		t.Errorf("%v should only push to the sidecars", scope)
+	}
+	if got := scope.String(); got != "LDS to sidecar" {
+		t.Errorf("String() => got %q, want %q", got, "LDS to sidecar")
+	}
+	if got := scope.merge(&PushScope{Types: RDS, NodeTypes: []model.NodeType{model.Router}}); !got.includesNode(router) ||
+		!got.includes(RDS) || !got.includes(LDS) {
+		t.Errorf("merge() => got %v, want LDS and RDS to sidecars and routers", got)
+	}
+	if cds := (&PushScope{Types: CDS}); !cds.includes(EDS) || cds.String() != "CDS,EDS" {
+		t.Errorf("%v should push EDS with CDS", cds)
+	}
+	change := AnalyzeMeshConfigChange(&meshconfig.MeshConfig{}, &meshconfig.MeshConfig{ConnectTimeout: types.DurationProto(time.Second)})
+	if !change.Scope.includes(EDS) {
+		t.Errorf("a change of ConnectTimeout should push EDS, got %v", change.Scope)
+	}
+	if got := scope.merge(nil); got != nil {
+		t.Errorf("merge(nil) => got %v, want nil", got)
+	}
+}
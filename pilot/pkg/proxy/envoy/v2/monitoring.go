@@ -26,6 +26,8 @@ var (
 	nodeTag    = monitoring.MustCreateTag("node")
 	typeTag    = monitoring.MustCreateTag("type")
 
+	resultTag = monitoring.MustCreateTag("result")
+
 	cdsReject = monitoring.NewGauge(
 		"pilot_xds_cds_reject",
 		"Pilot rejected CSD configs.",
@@ -44,6 +46,12 @@ var (
 		clusterTag,
 	)
 
+	edsDuplicateEndpoints = monitoring.NewGauge(
+		"pilot_xds_eds_duplicate_endpoints",
+		"Endpoints of each cluster listed several times by the registries, as of last push. Only one is sent.",
+		clusterTag,
+	)
+
 	ldsReject = monitoring.NewGauge(
 		"pilot_xds_lds_reject",
 		"Pilot rejected LDS.",
@@ -114,6 +122,16 @@ var (
 		[]float64{.1, 1, 3, 5, 10, 20, 30},
 	)
 
+	pushQueueDepth = monitoring.NewGauge(
+		"pilot_push_queue_depth",
+		"Number of proxies pending a push in the push queue.",
+	)
+
+	pushQueueNamespaces = monitoring.NewGauge(
+		"pilot_push_queue_namespaces",
+		"Number of namespaces with proxies pending a push in the push queue.",
+	)
+
 	// only supported dimension is millis, unfortunately. default to unitdimensionless.
 	proxiesConvergeDelay = monitoring.NewDistribution(
 		"pilot_proxy_convergence_time",
@@ -141,6 +159,23 @@ var (
 		typeTag,
 	)
 
+	deltaResources = monitoring.NewSum(
+		"pilot_xds_delta_resources",
+		"Resources of the delta xDS responses, sent, unchanged and skipped, or removed.",
+		typeTag, resultTag,
+	)
+
+	xdsCacheReads = monitoring.NewSum(
+		"pilot_xds_cache_reads",
+		"Reads of the cache of the clusters and routes generated for groups of identical proxies, hit or miss.",
+		typeTag, resultTag,
+	)
+
+	xdsCacheSize = monitoring.NewGauge(
+		"pilot_xds_cache_size",
+		"Entries of the cache of the clusters and routes generated for groups of identical proxies.",
+	)
+
 	inboundConfigUpdates   = inboundUpdates.With(typeTag.Value("config"))
 	inboundEDSUpdates      = inboundUpdates.With(typeTag.Value("eds"))
 	inboundServiceUpdates  = inboundUpdates.With(typeTag.Value("svc"))
@@ -168,6 +203,7 @@ func init() {
 		ldsReject,
 		rdsReject,
 		edsInstances,
+		edsDuplicateEndpoints,
 		rdsExpiredNonce,
 		totalXDSRejects,
 		monServices,
@@ -177,6 +213,8 @@ func init() {
 		pushes,
 		proxiesConvergeDelay,
 		proxiesQueueTime,
+		pushQueueDepth,
+		pushQueueNamespaces,
 		proxiesConvergeDelayCdsErrors,
 		proxiesConvergeDelayEdsErrors,
 		proxiesConvergeDelayRdsErrors,
@@ -184,5 +222,8 @@ func init() {
 		pushContextErrors,
 		totalXDSInternalErrors,
 		inboundUpdates,
+		deltaResources,
+		xdsCacheReads,
+		xdsCacheSize,
 	)
 }
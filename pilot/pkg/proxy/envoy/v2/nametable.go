@@ -0,0 +1,130 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/proxy/dns"
+	"istio.io/istio/pkg/config"
+)
+
+// NameTable serves the name table of a proxy connected to this Pilot, identified by the proxyID
+// query parameter. It is only served to clients with a certificate of the mesh whose identity
+// is in the namespace of the proxy, so it must be mounted on the secure port.
+func (s *DiscoveryServer) NameTable(w http.ResponseWriter, req *http.Request) {
+	if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 {
+		http.Error(w, "a client certificate of the mesh is required", http.StatusUnauthorized)
+		return
+	}
+	proxyID := req.URL.Query().Get("proxyID")
+	if proxyID == "" {
+		http.Error(w, "You must provide a proxyID in the query string", http.StatusBadRequest)
+		return
+	}
+
+	var proxy *model.Proxy
+	adsClientsMutex.RLock()
+	mostRecent := ""
+	for key, con := range adsSidecarIDConnectionsMap[proxyID] {
+		if mostRecent == "" || key > mostRecent {
+			mostRecent = key
+			proxy = con.modelNode
+		}
+	}
+	adsClientsMutex.RUnlock()
+	if proxy == nil {
+		http.Error(w, "Proxy not connected to this Pilot instance", http.StatusNotFound)
+		return
+	}
+	if !inNamespace(req.TLS.VerifiedChains[0][0], proxy.ConfigNamespace) {
+		http.Error(w, "the client certificate is not in the namespace of the proxy", http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.nameTable(s.globalPushContext(), proxy)); err != nil {
+		adsLog.Warnf("Failed to write the name table of %s: %v", proxyID, err)
+	}
+}
+
+// nameTable returns the addresses of the hosts of the services visible to the proxy, as scoped by
+// the Sidecar and exportTo settings, including the ServiceEntry hosts. The services without a VIP
+// are resolved to the addresses of their endpoints, from the shards rather than the registries,
+// except the services resolved with DNS and the wildcard hosts, which are left to the upstream
+// resolvers.
+func (s *DiscoveryServer) nameTable(push *model.PushContext, proxy *model.Proxy) *dns.NameTable {
+	table := &dns.NameTable{Hosts: make(map[string][]string)}
+	for _, service := range push.Services(proxy) {
+		hostname := string(service.Hostname)
+		if strings.HasPrefix(hostname, "*") {
+			continue
+		}
+		if service.Address != "" && service.Address != config.UnspecifiedIP {
+			table.Hosts[hostname] = []string{service.Address}
+			continue
+		}
+		if service.Resolution == model.DNSLB {
+			continue
+		}
+		if addresses := s.endpointAddresses(hostname); len(addresses) > 0 {
+			table.Hosts[hostname] = addresses
+		}
+	}
+	return table
+}
+
+// endpointAddresses returns the sorted addresses of the endpoints of all the shards of the
+// service, as last updated by the registries.
+func (s *DiscoveryServer) endpointAddresses(hostname string) []string {
+	s.mutex.RLock()
+	shards, f := s.EndpointShardsByService[hostname]
+	s.mutex.RUnlock()
+	if !f {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var addresses []string
+	shards.mutex.RLock()
+	for _, endpoints := range shards.Shards {
+		for _, ep := range endpoints {
+			if !seen[ep.Address] {
+				seen[ep.Address] = true
+				addresses = append(addresses, ep.Address)
+			}
+		}
+	}
+	shards.mutex.RUnlock()
+	sort.Strings(addresses)
+	return addresses
+}
+
+// inNamespace returns true if one of the SPIFFE identities of the certificate is in the namespace.
+func inNamespace(cert *x509.Certificate, namespace string) bool {
+	for _, uri := range cert.URIs {
+		// spiffe://<trust domain>/ns/<namespace>/sa/<service account>
+		parts := strings.Split(uri.Path, "/")
+		if uri.Scheme == "spiffe" && len(parts) == 5 && parts[1] == "ns" && parts[2] == namespace {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"istio.io/istio/pilot/pkg/config/memory"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/proxy/dns"
+	srmemory "istio.io/istio/pilot/pkg/serviceregistry/memory"
+	"istio.io/istio/pkg/config"
+)
+
+func TestNameTable(t *testing.T) {
+	inNamespace := func(service *model.Service, namespace string, exportTo config.Visibility) *model.Service {
+		service.Attributes = model.ServiceAttributes{
+			Namespace: namespace,
+			ExportTo:  map[config.Visibility]bool{exportTo: true},
+		}
+		return service
+	}
+	dnsService := srmemory.MakeExternalHTTPService("dns.example.com", true, "")
+	dnsService.Resolution = model.DNSLB
+	services := []*model.Service{
+		inNamespace(srmemory.MakeService("hello.default.svc.cluster.local", "10.1.0.0"), "default", config.VisibilityPublic),
+		inNamespace(srmemory.MakeService("headless.default.svc.cluster.local", config.UnspecifiedIP), "default", config.VisibilityPublic),
+		inNamespace(srmemory.MakeService("private.other.svc.cluster.local", "10.2.0.0"), "other", config.VisibilityPrivate),
+		inNamespace(srmemory.MakeExternalHTTPService("db.example.internal", true, "240.0.0.1"), "default", config.VisibilityPrivate),
+		inNamespace(srmemory.MakeExternalHTTPService("*.example.com", true, ""), "default", config.VisibilityPublic),
+		inNamespace(dnsService, "default", config.VisibilityPublic),
+	}
+	registry := make(map[config.Hostname]*model.Service)
+	for _, service := range services {
+		registry[service.Hostname] = service
+	}
+	mesh := config.DefaultMeshConfig()
+	env := &model.Environment{
+		ServiceDiscovery: NewMemServiceDiscovery(registry, 0),
+		IstioConfigStore: model.MakeIstioStore(memory.Make(model.IstioConfigTypes)),
+		Mesh:             &mesh,
+	}
+	push := model.NewPushContext()
+	if err := push.InitContext(env); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &DiscoveryServer{EndpointShardsByService: map[string]*EndpointShards{
+		"headless.default.svc.cluster.local": {Shards: map[string][]*model.IstioEndpoint{
+			"cluster1": {{Address: "10.0.0.2"}, {Address: "10.0.0.1"}},
+			"cluster2": {{Address: "10.0.0.1"}, {Address: "10.0.0.3"}},
+		}},
+	}}
+
+	for _, tc := range []struct {
+		namespace string
+		want      map[string][]string
+	}{
+		{
+			namespace: "default",
+			want: map[string][]string{
+				"hello.default.svc.cluster.local":    {"10.1.0.0"},
+				"headless.default.svc.cluster.local": {"10.0.0.1", "10.0.0.2", "10.0.0.3"},
+				"db.example.internal":                {"240.0.0.1"},
+			},
+		},
+		{
+			namespace: "other",
+			want: map[string][]string{
+				"hello.default.svc.cluster.local":    {"10.1.0.0"},
+				"headless.default.svc.cluster.local": {"10.0.0.1", "10.0.0.2", "10.0.0.3"},
+				"private.other.svc.cluster.local":    {"10.2.0.0"},
+			},
+		},
+	} {
+		t.Run(tc.namespace, func(t *testing.T) {
+			proxy := &model.Proxy{Type: model.SidecarProxy, ConfigNamespace: tc.namespace}
+			if got := s.nameTable(push, proxy).Hosts; !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("nameTable() => got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNameTableHandler(t *testing.T) {
+	adsClientsMutex.Lock()
+	adsSidecarIDConnectionsMap["reviews-v1.default"] = map[string]*XdsConnection{
+		"reviews-v1.default-1": {modelNode: &model.Proxy{Type: model.SidecarProxy, ConfigNamespace: "default"}},
+	}
+	adsClientsMutex.Unlock()
+	defer func() {
+		adsClientsMutex.Lock()
+		delete(adsSidecarIDConnectionsMap, "reviews-v1.default")
+		adsClientsMutex.Unlock()
+	}()
+	mesh := config.DefaultMeshConfig()
+	env := &model.Environment{
+		ServiceDiscovery: NewMemServiceDiscovery(nil, 0),
+		IstioConfigStore: model.MakeIstioStore(memory.Make(model.IstioConfigTypes)),
+		Mesh:             &mesh,
+		PushContext:      model.NewPushContext(),
+	}
+	if err := env.PushContext.InitContext(env); err != nil {
+		t.Fatal(err)
+	}
+	s := &DiscoveryServer{Env: env, EndpointShardsByService: map[string]*EndpointShards{}}
+
+	identity := func(ns string) *tls.ConnectionState {
+		uri, _ := url.Parse("spiffe://cluster.local/ns/" + ns + "/sa/default")
+		return &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{URIs: []*url.URL{uri}}}}}
+	}
+	for _, tc := range []struct {
+		name    string
+		proxyID string
+		tls     *tls.ConnectionState
+		want    int
+	}{
+		{name: "plain text", proxyID: "reviews-v1.default", want: http.StatusUnauthorized},
+		{name: "no proxy ID", tls: identity("default"), want: http.StatusBadRequest},
+		{name: "proxy not connected", proxyID: "ratings-v1.default", tls: identity("default"), want: http.StatusNotFound},
+		{name: "other namespace", proxyID: "reviews-v1.default", tls: identity("other"), want: http.StatusForbidden},
+		{name: "namespace of the proxy", proxyID: "reviews-v1.default", tls: identity("default"), want: http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, dns.NameTablePath+"?proxyID="+tc.proxyID, nil)
+			req.TLS = tc.tls
+			rr := httptest.NewRecorder()
+			s.NameTable(rr, req)
+			if rr.Code != tc.want {
+				t.Fatalf("NameTable() => got status %d, want %d: %s", rr.Code, tc.want, rr.Body.String())
+			}
+			if tc.want == http.StatusOK {
+				if err := json.Unmarshal(rr.Body.Bytes(), &dns.NameTable{}); err != nil {
+					t.Errorf("NameTable() => got an invalid name table: %v", err)
+				}
+			}
+		})
+	}
+}
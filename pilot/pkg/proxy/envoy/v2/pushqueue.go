@@ -32,26 +32,60 @@ type PushInformation struct {
 	start time.Time
 
 	full bool
+
+	// scope restricts the full push, nil to push everything.
+	scope *PushScope
 }
 
 type PushQueue struct {
 	mu          *sync.RWMutex
 	cond        *sync.Cond
 	connections map[*XdsConnection]*PushInformation
-	order       []*XdsConnection
+
+	// fair dequeues the proxies of the namespaces in turn, so that a namespace with many proxies
+	// pending does not delay the pushes to the other namespaces. Otherwise the proxies are pushed in
+	// the order they were enqueued.
+	fair bool
+	// order holds the pending proxies of each namespace, in the order they were enqueued. All the
+	// proxies are in the same list if the queue is not fair.
+	order map[string][]*XdsConnection
+	// namespaces are the namespaces with pending proxies, in the order they are dequeued.
+	namespaces []string
+	pending    int
 }
 
+// NewPushQueue creates a push queue dequeuing the proxies in the order they were enqueued.
 func NewPushQueue() *PushQueue {
+	return newPushQueue(false)
+}
+
+// NewFairPushQueue creates a push queue dequeuing the proxies of the namespaces in turn.
+func NewFairPushQueue() *PushQueue {
+	return newPushQueue(true)
+}
+
+func newPushQueue(fair bool) *PushQueue {
 	mu := &sync.RWMutex{}
 	return &PushQueue{
 		mu:          mu,
 		connections: make(map[*XdsConnection]*PushInformation),
 		cond:        sync.NewCond(mu),
+		fair:        fair,
+		order:       make(map[string][]*XdsConnection),
+	}
+}
+
+// namespace returns the key the proxy is queued under.
+func (p *PushQueue) namespace(proxy *XdsConnection) string {
+	if !p.fair || proxy.modelNode == nil {
+		return ""
 	}
+	return proxy.modelNode.ConfigNamespace
 }
 
 // Add will mark a proxy as pending a push. If it is already pending, pushInfo will be merged.
-// edsUpdatedServices will be added together, and full will be set if either were full
+// edsUpdatedServices will be added together, and full will be set if either were full, with a scope
+// covering both.
 func (p *PushQueue) Enqueue(proxy *XdsConnection, pushInfo *PushInformation) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
@@ -59,9 +93,22 @@ func (p *PushQueue) Enqueue(proxy *XdsConnection, pushInfo *PushInformation) {
 	info, exists := p.connections[proxy]
 	if !exists {
 		p.connections[proxy] = pushInfo
-		p.order = append(p.order, proxy)
+		ns := p.namespace(proxy)
+		if len(p.order[ns]) == 0 {
+			p.namespaces = append(p.namespaces, ns)
+		}
+		p.order[ns] = append(p.order[ns], proxy)
+		p.pending++
+		pushQueueDepth.Record(float64(p.pending))
+		pushQueueNamespaces.Record(float64(len(p.namespaces)))
 	} else {
 		info.push = pushInfo.push
+		switch {
+		case info.full && pushInfo.full:
+			info.scope = info.scope.merge(pushInfo.scope)
+		case pushInfo.full:
+			info.scope = pushInfo.scope
+		}
 		info.full = info.full || pushInfo.full
 
 		edsUpdates := map[string]struct{}{}
@@ -72,6 +119,10 @@ func (p *PushQueue) Enqueue(proxy *XdsConnection, pushInfo *PushInformation) {
 			edsUpdates[endpoint] = struct{}{}
 		}
 		info.edsUpdatedServices = edsUpdates
+		// The incremental EDS updates merged in a scoped full push must still be sent.
+		if info.full && len(edsUpdates) > 0 {
+			info.scope = info.scope.merge(&PushScope{Types: EDS})
+		}
 	}
 	p.cond.Signal()
 }
@@ -80,13 +131,24 @@ func (p *PushQueue) Enqueue(proxy *XdsConnection, pushInfo *PushInformation) {
 func (p *PushQueue) Dequeue() (*XdsConnection, *PushInformation) {
 	p.mu.Lock()
 	// Block until there is one to remove. Enqueue will signal when one is added.
-	for len(p.order) == 0 {
+	for p.pending == 0 {
 		p.cond.Wait()
 	}
 
 	defer p.mu.Unlock()
-	head := p.order[0]
-	p.order = p.order[1:]
+	ns := p.namespaces[0]
+	p.namespaces = p.namespaces[1:]
+	head := p.order[ns][0]
+	if len(p.order[ns]) > 1 {
+		p.order[ns] = p.order[ns][1:]
+		// The namespace is dequeued again after all the other pending namespaces.
+		p.namespaces = append(p.namespaces, ns)
+	} else {
+		delete(p.order, ns)
+	}
+	p.pending--
+	pushQueueDepth.Record(float64(p.pending))
+	pushQueueNamespaces.Record(float64(len(p.namespaces)))
 	info := p.connections[head]
 	delete(p.connections, head)
 	return head, info
@@ -96,5 +158,5 @@ func (p *PushQueue) Dequeue() (*XdsConnection, *PushInformation) {
 func (p *PushQueue) Pending() int {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	return len(p.order)
+	return p.pending
 }
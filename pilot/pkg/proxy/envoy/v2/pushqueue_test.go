@@ -20,6 +20,8 @@ import (
 	"sync"
 	"testing"
 	"time"
+
+	"istio.io/istio/pilot/pkg/model"
 )
 
 // Helper function to remove an item or timeout and return nil if there are no pending pushes
@@ -152,6 +154,52 @@ func TestProxyQueue(t *testing.T) {
 		}
 	})
 
+	t.Run("should merge push scopes", func(t *testing.T) {
+		p := NewPushQueue()
+		p.Enqueue(proxies[0], &PushInformation{
+			full:               false,
+			edsUpdatedServices: map[string]struct{}{"foo": {}},
+		})
+		p.Enqueue(proxies[0], &PushInformation{full: true, scope: &PushScope{Types: LDS}})
+		p.Enqueue(proxies[0], &PushInformation{full: true, scope: &PushScope{Types: CDS}})
+		_, info := p.Dequeue()
+
+		if !info.full {
+			t.Errorf("Expected full to be true, got false")
+		}
+		expectedScope := &PushScope{Types: CDS | EDS | LDS}
+		if !reflect.DeepEqual(info.scope, expectedScope) {
+			t.Errorf("Expected scope to be %v, got %v", expectedScope, info.scope)
+		}
+
+		p.Enqueue(proxies[0], &PushInformation{full: true, scope: &PushScope{Types: LDS}})
+		p.Enqueue(proxies[0], &PushInformation{full: true})
+		if _, info := p.Dequeue(); info.scope != nil {
+			t.Errorf("Expected scope to be nil, got %v", info.scope)
+		}
+	})
+
+	t.Run("fair queue should alternate namespaces", func(t *testing.T) {
+		node := func(conID, ns string) *XdsConnection {
+			return &XdsConnection{ConID: conID, modelNode: &model.Proxy{ConfigNamespace: ns}}
+		}
+		a1, a2, a3 := node("a1", "a"), node("a2", "a"), node("a3", "a")
+		b1, b2 := node("b1", "b"), node("b2", "b")
+		c1 := node("c1", "c")
+
+		p := NewFairPushQueue()
+		for _, con := range []*XdsConnection{a1, a2, a3, b1, b2, c1, a1} {
+			p.Enqueue(con, &PushInformation{})
+		}
+		if p.Pending() != 6 {
+			t.Errorf("Expected 6 pending proxies, got %d", p.Pending())
+		}
+		for _, con := range []*XdsConnection{a1, b1, c1, a2, b2, a3} {
+			ExpectDequeue(t, p, con)
+		}
+		ExpectTimeout(t, p)
+	})
+
 	t.Run("two removes, one should block one should return", func(t *testing.T) {
 		p := NewPushQueue()
 		wg := &sync.WaitGroup{}
@@ -27,22 +27,32 @@ import (
 )
 
 func (s *DiscoveryServer) pushRoute(con *XdsConnection, push *model.PushContext, version string) error {
-	rawRoutes, err := s.generateRawRoutes(con, push)
-	if err != nil {
-		return err
-	}
-	if s.DebugConfigs {
-		for _, r := range rawRoutes {
-			con.RouteConfigs[r.Name] = r
-			if adsLog.DebugEnabled() {
-				resp, _ := config.ToJSONWithIndent(r, " ")
-				adsLog.Debugf("RDS: Adding route:%s for node:%v", resp, con.modelNode.ID)
+	var response *xdsapi.DiscoveryResponse
+	if s.xdsCacheEnabled() {
+		resources, err := s.cachedRoutes(con, push)
+		if err != nil {
+			return err
+		}
+		response = routeDiscoveryResponse(nil, version)
+		response.Resources = resources
+	} else {
+		rawRoutes, err := s.generateRawRoutes(con, push)
+		if err != nil {
+			return err
+		}
+		if s.DebugConfigs {
+			for _, r := range rawRoutes {
+				con.RouteConfigs[r.Name] = r
+				if adsLog.DebugEnabled() {
+					resp, _ := config.ToJSONWithIndent(r, " ")
+					adsLog.Debugf("RDS: Adding route:%s for node:%v", resp, con.modelNode.ID)
+				}
 			}
 		}
+		response = routeDiscoveryResponse(rawRoutes, version)
 	}
 
-	response := routeDiscoveryResponse(rawRoutes, version)
-	err = con.send(response)
+	err := con.send(response)
 	if err != nil {
 		adsLog.Warnf("RDS: Send failure for node:%v: %v", con.modelNode.ID, err)
 		recordSendError(rdsSendErrPushes, err)
@@ -50,7 +60,7 @@ func (s *DiscoveryServer) pushRoute(con *XdsConnection, push *model.PushContext,
 	}
 	rdsPushes.Increment()
 
-	adsLog.Infof("RDS: PUSH for node:%s routes:%d", con.modelNode.ID, len(rawRoutes))
+	adsLog.Infof("RDS: PUSH for node:%s routes:%d", con.modelNode.ID, len(response.Resources))
 	return nil
 }
 
@@ -59,37 +69,46 @@ func (s *DiscoveryServer) generateRawRoutes(con *XdsConnection, push *model.Push
 	// TODO: Follow this logic for other xDS resources as well
 	// TODO: once per config update
 	for _, routeName := range con.Routes {
-		r, err := s.ConfigGenerator.BuildHTTPRoutes(s.Env, con.modelNode, push, routeName)
+		r, err := s.generateRawRoute(con, push, routeName)
 		if err != nil {
-			retErr := fmt.Errorf("RDS: Failed to generate route %s for node %v: %v", routeName, con.modelNode, err)
-			adsLog.Warnf("RDS: Failed to generate routes for route:%s for node:%v: %v", routeName, con.modelNode.ID, err)
-			rdsBuildErrPushes.Increment()
-			return nil, retErr
+			return nil, err
 		}
+		rc = append(rc, r)
+	}
+	return rc, nil
+}
 
-		if r == nil {
-			adsLog.Warnf("RDS: Got nil value for route:%s for node:%v", routeName, con.modelNode)
+func (s *DiscoveryServer) generateRawRoute(con *XdsConnection, push *model.PushContext,
+	routeName string) (*xdsapi.RouteConfiguration, error) {
+	r, err := s.ConfigGenerator.BuildHTTPRoutes(s.Env, con.modelNode, push, routeName)
+	if err != nil {
+		retErr := fmt.Errorf("RDS: Failed to generate route %s for node %v: %v", routeName, con.modelNode, err)
+		adsLog.Warnf("RDS: Failed to generate routes for route:%s for node:%v: %v", routeName, con.modelNode.ID, err)
+		rdsBuildErrPushes.Increment()
+		return nil, retErr
+	}
 
-			// Explicitly send an empty route configuration
-			r = &xdsapi.RouteConfiguration{
-				Name:             routeName,
-				VirtualHosts:     []route.VirtualHost{},
-				ValidateClusters: proto.BoolFalse,
-			}
-		}
+	if r == nil {
+		adsLog.Warnf("RDS: Got nil value for route:%s for node:%v", routeName, con.modelNode)
 
-		if err = r.Validate(); err != nil {
-			retErr := fmt.Errorf("RDS: Generated invalid route %s for node %v: %v", routeName, con.modelNode, err)
-			adsLog.Errorf("RDS: Generated invalid routes for route:%s for node:%v: %v, %v", routeName, con.modelNode.ID, err, r)
-			rdsBuildErrPushes.Increment()
-			// Generating invalid routes is a bug.
-			// Panic instead of trying to recover from that, since we can't
-			// assume anything about the state.
-			panic(retErr.Error())
+		// Explicitly send an empty route configuration
+		r = &xdsapi.RouteConfiguration{
+			Name:             routeName,
+			VirtualHosts:     []route.VirtualHost{},
+			ValidateClusters: proto.BoolFalse,
 		}
-		rc = append(rc, r)
 	}
-	return rc, nil
+
+	if err = r.Validate(); err != nil {
+		retErr := fmt.Errorf("RDS: Generated invalid route %s for node %v: %v", routeName, con.modelNode, err)
+		adsLog.Errorf("RDS: Generated invalid routes for route:%s for node:%v: %v, %v", routeName, con.modelNode.ID, err, r)
+		rdsBuildErrPushes.Increment()
+		// Generating invalid routes is a bug.
+		// Panic instead of trying to recover from that, since we can't
+		// assume anything about the state.
+		panic(retErr.Error())
+	}
+	return r, nil
 }
 
 func routeDiscoveryResponse(rs []*xdsapi.RouteConfiguration, version string) *xdsapi.DiscoveryResponse {
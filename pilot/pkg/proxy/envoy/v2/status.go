@@ -0,0 +1,56 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"strconv"
+	"strings"
+
+	"istio.io/istio/pilot/pkg/status"
+)
+
+// AckedVersions returns, for each connected proxy, the number of the last push version
+// whose listeners the proxy acknowledged. Proxies that did not acknowledge any push yet
+// are reported with version -1.
+func AckedVersions() []status.ProxyVersion {
+	adsClientsMutex.RLock()
+	defer adsClientsMutex.RUnlock()
+	versions := make([]status.ProxyVersion, 0, len(adsClients))
+	for _, con := range adsClients {
+		con.mu.RLock()
+		if con.modelNode != nil {
+			versions = append(versions, status.ProxyVersion{
+				Proxy:   con.modelNode,
+				Version: parseVersionNum(con.ListenerVersionAcked),
+			})
+		}
+		con.mu.RUnlock()
+	}
+	return versions
+}
+
+// parseVersionNum extracts the push number from a version created by Push, of the form
+// <timestamp>/<number>. It returns -1 for versions not created by a full push.
+func parseVersionNum(version string) int64 {
+	i := strings.LastIndex(version, "/")
+	if i < 0 {
+		return -1
+	}
+	num, err := strconv.ParseInt(version[i+1:], 10, 64)
+	if err != nil {
+		return -1
+	}
+	return num
+}
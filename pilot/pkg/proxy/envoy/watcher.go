@@ -15,8 +15,11 @@
 package envoy
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
 	"hash"
 	"io/ioutil"
 	"os"
@@ -42,6 +45,9 @@ type Watcher interface {
 type watcher struct {
 	certs   []string
 	updates chan<- interface{}
+
+	// certHash is the hash of the certificates sent with the last update
+	certHash []byte
 }
 
 // NewWatcher creates a new watcher instance from a proxy agent and a set of monitored certificate file paths
@@ -68,7 +74,13 @@ func (w *watcher) Run(ctx context.Context) {
 func (w *watcher) SendConfig() {
 	h := sha256.New()
 	generateCertHash(h, w.certs)
-	w.updates <- h.Sum(nil)
+	certHash := h.Sum(nil)
+	if w.certHash != nil && !bytes.Equal(certHash, w.certHash) {
+		certRotations.Increment()
+	}
+	w.certHash = certHash
+	recordCertExpiry(w.certs)
+	w.updates <- certHash
 }
 
 type watchFileEventsFn func(ctx context.Context, wch <-chan *fsnotify.FileEvent,
@@ -144,6 +156,40 @@ func watchCerts(ctx context.Context, certs []string, watchFileEventsFn watchFile
 	watchFileEventsFn(ctx, fw.Event, minDelay, updateFunc)
 }
 
+// recordCertExpiry records the expiry time of the first certificate of each file, which is the
+// leaf certificate of a chain. Files without certificate, such as keys, are skipped.
+func recordCertExpiry(certs []string) {
+	for _, cert := range certs {
+		if notAfter, ok := certExpiryTime(cert); ok {
+			certExpiry.With(fileTag.Value(filepath.Base(cert))).Record(float64(notAfter.Unix()))
+		}
+	}
+}
+
+// certExpiryTime returns the expiry time of the first certificate of the PEM file.
+func certExpiryTime(file string) (time.Time, bool) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return time.Time{}, false
+	}
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return time.Time{}, false
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			log.Warnf("failed to parse certificate in %s: %v", file, err)
+			return time.Time{}, false
+		}
+		return cert.NotAfter, true
+	}
+}
+
 func generateCertHash(h hash.Hash, certs []string) {
 	for _, cert := range certs {
 		if _, err := os.Stat(cert); os.IsNotExist(err) {
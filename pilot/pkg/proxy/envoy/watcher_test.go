@@ -17,8 +17,14 @@ package envoy
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
 	"io/ioutil"
+	"math/big"
 	"os"
 	"path"
 	"sync"
@@ -222,3 +228,42 @@ func TestGenerateCertHash(t *testing.T) {
 		t.Error("hash should not be affected by empty directory")
 	}
 }
+
+func TestCertExpiryTime(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "certs")
+	if err != nil {
+		t.Fatalf("failed to create a temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notAfter := time.Now().Add(time.Hour).Truncate(time.Second).UTC()
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), NotBefore: time.Now(), NotAfter: notAfter}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile := path.Join(dir, "cert-chain.pem"), path.Join(dir, "key.pem")
+	if err := ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, ok := certExpiryTime(certFile); !ok || !got.Equal(notAfter) {
+		t.Errorf("certExpiryTime(%s) => got %v, %t, want %v", certFile, got, ok, notAfter)
+	}
+	for _, file := range []string{keyFile, path.Join(dir, "missing-file")} {
+		if got, ok := certExpiryTime(file); ok {
+			t.Errorf("certExpiryTime(%s) => got %v, want no certificate", file, got)
+		}
+	}
+}
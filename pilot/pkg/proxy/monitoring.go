@@ -0,0 +1,48 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"istio.io/istio/pilot/pkg/monitoring"
+)
+
+var (
+	outcomeTag = monitoring.MustCreateTag("outcome")
+
+	epochStarts = monitoring.NewSum(
+		"istio_agent_proxy_starts_total",
+		"Total number of proxy epochs started by the agent.",
+	)
+
+	epochExits = monitoring.NewSum(
+		"istio_agent_proxy_exits_total",
+		"Total number of proxy epochs that exited, by outcome (aborted, error or normal).",
+		outcomeTag,
+	)
+
+	activeEpochs = monitoring.NewGauge(
+		"istio_agent_proxy_active_epochs",
+		"Number of proxy epochs currently running.",
+	)
+
+	restartRetries = monitoring.NewSum(
+		"istio_agent_proxy_restart_retries_total",
+		"Total number of proxy restarts scheduled after a proxy epoch failed.",
+	)
+)
+
+func init() {
+	monitoring.MustRegisterViews(epochStarts, epochExits, activeEpochs, restartRetries)
+}
@@ -64,6 +64,14 @@ func (b *v2Generator) Generate(forTCPFilter bool) *http_config.RBAC {
 		Action:   envoy_rbac.RBAC_ALLOW,
 		Policies: map[string]*envoy_rbac.Policy{},
 	}
+	// The shadow rules only have the dry-run policies, evaluated as if they were the only policies
+	// of the workload. All the rules are ALLOW rules, so adding the enforced policies could only
+	// allow more requests, and the requests allowed today would never be reported as denied.
+	shadowRbac := &envoy_rbac.RBAC{
+		Action:   envoy_rbac.RBAC_ALLOW,
+		Policies: map[string]*envoy_rbac.Policy{},
+	}
+	hasDryRun := false
 
 	if b.isGlobalPermissiveEnabled {
 		// TODO(pitlv2109): Handle permissive mode in the future.
@@ -97,11 +105,22 @@ func (b *v2Generator) Generate(forTCPFilter bool) *http_config.RBAC {
 			if p := b.generatePolicy(role, bindings, forTCPFilter); p != nil {
 				rbacLog.Debugf("generated policy for role: %s", roleName)
 				policyName := fmt.Sprintf("authz-[%s]-allow[%d]", authzPolicy.Name, i)
-				rbac.Policies[policyName] = p
+				if authzPolicy.DryRun {
+					hasDryRun = true
+					shadowRbac.Policies[policyName] = p
+				} else {
+					rbac.Policies[policyName] = p
+				}
 			}
 		}
 	}
-	return &http_config.RBAC{Rules: rbac}
+
+	ret := &http_config.RBAC{Rules: rbac}
+	// As for the permissive mode of v1, set ShadowRules only when there is a policy in dry-run.
+	if hasDryRun {
+		ret.ShadowRules = shadowRbac
+	}
+	return ret
 }
 
 // TODO: refactor this into model.AuthorizationPolicies.
@@ -18,6 +18,7 @@ import (
 	"testing"
 
 	"github.com/davecgh/go-spew/spew"
+	envoy_rbac "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v2"
 
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/security/authz/policy"
@@ -33,11 +34,16 @@ func TestBuilder_buildV2(t *testing.T) {
 	}
 	namespaceB := "b"
 	serviceFooInNamespaceA := policy.NewServiceMetadata("foo.a.svc.cluster.local", labelFoo, t)
+	dryRun := func(cfg *model.Config) *model.Config {
+		cfg.Annotations = map[string]string{model.AuthorizationPolicyDryRunAnnotation: "true"}
+		return cfg
+	}
 	testCases := []struct {
-		name         string
-		policies     []*model.Config
-		wantRules    map[string][]string
-		forTCPFilter bool
+		name            string
+		policies        []*model.Config
+		wantRules       map[string][]string
+		wantShadowRules map[string][]string
+		forTCPFilter    bool
 	}{
 		{
 			name: "no policy",
@@ -121,6 +127,37 @@ func TestBuilder_buildV2(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "one policy in dry-run",
+			policies: []*model.Config{
+				policy.SimpleRole("role", namespaceA, ""),
+				dryRun(policy.SimpleAuthorizationPolicy("policy", namespaceA, labelFoo, "role")),
+			},
+			wantShadowRules: map[string][]string{
+				"authz-[policy]-allow[0]": {policy.RoleTag("role"), policy.AuthzPolicyTag("policy")},
+			},
+		},
+		{
+			name: "one policy enforced and one policy in dry-run",
+			policies: []*model.Config{
+				policy.SimpleRole("role-1", namespaceA, ""),
+				policy.SimpleAuthorizationPolicy("policy-1", namespaceA, labelFoo, "role-1"),
+				policy.SimpleRole("role-2", namespaceA, ""),
+				dryRun(policy.SimpleAuthorizationPolicy("policy-2", namespaceA, labelFoo, "role-2")),
+			},
+			wantRules: map[string][]string{
+				"authz-[policy-1]-allow[0]": {
+					policy.RoleTag("role-1"),
+					policy.AuthzPolicyTag("policy-1"),
+				},
+			},
+			wantShadowRules: map[string][]string{
+				"authz-[policy-2]-allow[0]": {
+					policy.RoleTag("role-2"),
+					policy.AuthzPolicyTag("policy-2"),
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -143,6 +180,94 @@ func TestBuilder_buildV2(t *testing.T) {
 			if err := policy.Verify(got.GetRules(), tc.wantRules); err != nil {
 				t.Fatalf("%s\n%s", err, gotStr)
 			}
+			if tc.wantShadowRules == nil {
+				if got.GetShadowRules() != nil {
+					t.Fatalf("shadow rules must be nil\n%s", gotStr)
+				}
+			} else if err := policy.Verify(got.GetShadowRules(), tc.wantShadowRules); err != nil {
+				t.Fatalf("%s\n%s", err, gotStr)
+			}
 		})
 	}
 }
+
+func TestBuilder_buildV2DryRunDenial(t *testing.T) {
+	labelFoo := map[string]string{
+		"app": "foo",
+	}
+	dryRun := policy.SimpleAuthorizationPolicy("policy-2", "a", labelFoo, "role-2")
+	dryRun.Annotations = map[string]string{model.AuthorizationPolicyDryRunAnnotation: "true"}
+	authzPolicies := policy.NewAuthzPolicies([]*model.Config{
+		policy.SimpleRole("role-1", "a", ""),
+		policy.SimpleAuthorizationPolicy("policy-1", "a", labelFoo, "role-1"),
+		policy.SimpleRole("role-2", "a", ""),
+		dryRun,
+	}, t)
+	got := NewGenerator(policy.NewServiceMetadata("foo.a.svc.cluster.local", labelFoo, t), authzPolicies, false).
+		Generate(false)
+
+	// A request allowed by the enforced policy only would be denied if the dry-run policy replaced it.
+	method, user := "MethodFromRole[role-1]", "UserFromPolicy[policy-1]"
+	if !allows(got.GetRules(), method, user) {
+		t.Errorf("rules => got the request denied, want it allowed\n%s", spew.Sdump(got))
+	}
+	if allows(got.GetShadowRules(), method, user) {
+		t.Errorf("shadow rules => got the request allowed, want it denied\n%s", spew.Sdump(got))
+	}
+	// The requests allowed by the dry-run policy are allowed by the shadow rules.
+	if !allows(got.GetShadowRules(), "MethodFromRole[role-2]", "UserFromPolicy[policy-2]") {
+		t.Errorf("shadow rules => got the request denied, want it allowed\n%s", spew.Sdump(got))
+	}
+}
+
+// allows evaluates the ALLOW rules for a request with the method and source principal. Only the
+// matchers generated by the simple roles and policies of the tests are supported.
+func allows(rbac *envoy_rbac.RBAC, method, user string) bool {
+	var permitted func(p *envoy_rbac.Permission) bool
+	permitted = func(p *envoy_rbac.Permission) bool {
+		switch rule := p.Rule.(type) {
+		case *envoy_rbac.Permission_AndRules:
+			for _, r := range rule.AndRules.Rules {
+				if !permitted(r) {
+					return false
+				}
+			}
+			return true
+		case *envoy_rbac.Permission_OrRules:
+			for _, r := range rule.OrRules.Rules {
+				if permitted(r) {
+					return true
+				}
+			}
+			return false
+		case *envoy_rbac.Permission_Header:
+			return rule.Header.Name == ":method" && rule.Header.GetExactMatch() == method
+		}
+		return false
+	}
+	var identified func(p *envoy_rbac.Principal) bool
+	identified = func(p *envoy_rbac.Principal) bool {
+		switch id := p.Identifier.(type) {
+		case *envoy_rbac.Principal_AndIds:
+			for _, i := range id.AndIds.Ids {
+				if !identified(i) {
+					return false
+				}
+			}
+			return true
+		case *envoy_rbac.Principal_Metadata:
+			return id.Metadata.GetValue().GetStringMatch().GetExact() == user
+		}
+		return false
+	}
+	for _, p := range rbac.GetPolicies() {
+		for _, permission := range p.Permissions {
+			for _, principal := range p.Principals {
+				if permitted(permission) && identified(principal) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -86,6 +86,27 @@ func (c *Controller) DeleteRegistry(clusterID string) {
 	log.Infof("Registry for the cluster %s has been deleted.", clusterID)
 }
 
+// ReplaceRegistry replaces the registry of the same cluster in the aggregated controller, or adds it
+// if there is none, so that the services of the cluster do not disappear in between.
+func (c *Controller) ReplaceRegistry(registry Registry) {
+	c.storeLock.Lock()
+	defer c.storeLock.Unlock()
+
+	registries := make([]Registry, 0, len(c.registries)+1)
+	replaced := false
+	for _, r := range c.registries {
+		if r.ClusterID == registry.ClusterID && !replaced {
+			r = registry
+			replaced = true
+		}
+		registries = append(registries, r)
+	}
+	if !replaced {
+		registries = append(registries, registry)
+	}
+	c.registries = registries
+}
+
 // GetRegistries returns a copy of all registries
 func (c *Controller) GetRegistries() []Registry {
 	c.storeLock.RLock()
@@ -506,6 +506,22 @@ func TestDeleteRegistry(t *testing.T) {
 	}
 }
 
+func TestReplaceRegistry(t *testing.T) {
+	ctrl := NewController()
+	ctrl.AddRegistry(Registry{Name: "registry1", ClusterID: "cluster1"})
+	ctrl.AddRegistry(Registry{Name: "registry2", ClusterID: "cluster2"})
+	ctrl.ReplaceRegistry(Registry{Name: "registry3", ClusterID: "cluster1"})
+	ctrl.ReplaceRegistry(Registry{Name: "registry4", ClusterID: "cluster4"})
+
+	var names []string
+	for _, r := range ctrl.GetRegistries() {
+		names = append(names, string(r.Name))
+	}
+	if want := []string{"registry3", "registry2", "registry4"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("Expected the registries %v, got %v", want, names)
+	}
+}
+
 func TestGetRegistries(t *testing.T) {
 	registries := []Registry{
 		{
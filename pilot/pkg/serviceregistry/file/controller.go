@@ -0,0 +1,265 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/serviceregistry/memory"
+	"istio.io/istio/pkg/config"
+	"istio.io/pkg/log"
+)
+
+var (
+	supportedExtensions = map[string]bool{
+		".yaml": true,
+		".yml":  true,
+		".json": true,
+	}
+
+	// debounceDelay is the delay between a change of the directory and the reload of the services,
+	// so that the files written together are loaded together.
+	debounceDelay = 100 * time.Millisecond
+)
+
+// Controller is a service registry loading the services and their endpoints from the YAML or JSON
+// files of a directory, and reloading them when the files change. The services are held by a
+// memory ServiceDiscovery, which pushes the endpoint changes incrementally.
+type Controller struct {
+	*memory.ServiceDiscovery
+
+	root string
+
+	mutex sync.Mutex
+	// services holds the definitions last loaded, keyed by hostname.
+	services map[config.Hostname]*Service
+	synced   bool
+}
+
+// NewController creates a controller for the service definitions of the directory.
+func NewController(root string, clusterID string) *Controller {
+	sd := memory.NewDiscovery(make(map[config.Hostname]*model.Service), 0)
+	sd.ClusterID = clusterID
+	return &Controller{
+		ServiceDiscovery: sd,
+		root:             root,
+		services:         make(map[config.Hostname]*Service),
+	}
+}
+
+// HasSynced returns true once the service definitions have been loaded.
+func (c *Controller) HasSynced() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.synced
+}
+
+// Run loads the service definitions, and reloads them on changes until the stop channel is closed.
+func (c *Controller) Run(stop <-chan struct{}) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Errorf("Failed to watch the service definitions in %s: %v", c.root, err)
+	} else {
+		defer watcher.Close() // nolint: errcheck
+		if err := addWatches(watcher, c.root); err != nil {
+			log.Errorf("Failed to watch the service definitions in %s: %v", c.root, err)
+		}
+	}
+
+	if err := c.Reload(); err != nil {
+		log.Errorf("Failed to load the service definitions of %s: %v", c.root, err)
+	}
+	if watcher == nil {
+		<-stop
+		return
+	}
+
+	var timerC <-chan time.Time
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := addWatches(watcher, event.Name); err != nil {
+						log.Warnf("Failed to watch %s: %v", event.Name, err)
+					}
+				}
+			}
+			if timerC == nil {
+				timerC = time.After(debounceDelay)
+			}
+		case <-timerC:
+			timerC = nil
+			if err := c.Reload(); err != nil {
+				log.Warnf("Failed to reload the service definitions of %s, keeping the current ones: %v", c.root, err)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Warnf("Error watching %s: %v", c.root, err)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Reload reads the service definitions of the directory, and applies the changes to the registry.
+// The registry is left unchanged if any definition is invalid.
+func (c *Controller) Reload() error {
+	defs, err := c.readServices()
+	if err != nil {
+		return err
+	}
+	type converted struct {
+		service   *model.Service
+		instances []*model.ServiceInstance
+	}
+	services := make(map[config.Hostname]converted, len(defs))
+	for hostname, def := range defs {
+		svc, instances, err := convertService(def)
+		if err != nil {
+			return err
+		}
+		services[hostname] = converted{svc, instances}
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for hostname := range c.services {
+		if _, ok := defs[hostname]; !ok {
+			log.Infof("Removing service %s", hostname)
+			c.RemoveService(hostname)
+		}
+	}
+	for hostname, def := range defs {
+		previous, ok := c.services[hostname]
+		if ok && reflect.DeepEqual(previous, def) {
+			continue
+		}
+		if !ok || !reflect.DeepEqual(previous.withoutEndpoints(), def.withoutEndpoints()) {
+			c.AddService(hostname, services[hostname].service)
+		}
+		c.SetInstances(hostname, services[hostname].instances)
+	}
+	c.services = defs
+	c.synced = true
+	return nil
+}
+
+// ManagementPorts implements a service catalog operation. The service definitions have no
+// management ports.
+func (c *Controller) ManagementPorts(addr string) model.PortList {
+	return nil
+}
+
+// GetProxyWorkloadLabels returns the labels of the endpoints at the addresses of the proxy.
+func (c *Controller) GetProxyWorkloadLabels(proxy *model.Proxy) (config.LabelsCollection, error) {
+	instances, err := c.GetProxyServiceInstances(proxy)
+	if err != nil {
+		return nil, err
+	}
+	var out config.LabelsCollection
+	for _, instance := range instances {
+		if len(instance.Labels) > 0 {
+			out = append(out, instance.Labels)
+		}
+	}
+	return out, nil
+}
+
+// GetIstioServiceAccounts returns the service accounts of the endpoints of the service.
+func (c *Controller) GetIstioServiceAccounts(hostname config.Hostname, ports []int) []string {
+	saSet := make(map[string]bool)
+	for _, port := range ports {
+		instances, err := c.InstancesByPort(hostname, port, nil)
+		if err != nil {
+			continue
+		}
+		for _, instance := range instances {
+			if instance.ServiceAccount != "" {
+				saSet[instance.ServiceAccount] = true
+			}
+		}
+	}
+	out := make([]string, 0, len(saSet))
+	for sa := range saSet {
+		out = append(out, sa)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// readServices reads the service definitions of the files of the directory and its subdirectories.
+func (c *Controller) readServices() (map[config.Hostname]*Service, error) {
+	out := make(map[config.Hostname]*Service)
+	err := filepath.Walk(c.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		} else if !supportedExtensions[filepath.Ext(path)] || (info.Mode()&os.ModeType) != 0 {
+			return nil
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		defs, err := parseServices(data)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		for _, def := range defs {
+			hostname := config.Hostname(def.Hostname)
+			if _, ok := out[hostname]; ok {
+				return fmt.Errorf("%s: service %s is defined more than once", path, hostname)
+			}
+			out[hostname] = def
+		}
+		return nil
+	})
+	return out, err
+}
+
+// withoutEndpoints returns a copy of the definition of the service without its endpoints.
+func (s *Service) withoutEndpoints() *Service {
+	out := *s
+	out.Endpoints = nil
+	return &out
+}
+
+// addWatches adds a watch for the directory and each of its subdirectories.
+func addWatches(watcher *fsnotify.Watcher, root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return watcher.Add(path)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,171 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config"
+)
+
+const reviews = `
+hostname: reviews.bookinfo.svc.cluster.local
+address: 10.0.0.1
+ports:
+- name: http
+  port: 9080
+  protocol: HTTP
+endpoints:
+- address: 10.1.0.1
+  labels:
+    version: v1
+  serviceAccount: spiffe://cluster.local/ns/bookinfo/sa/reviews
+- address: 10.1.0.2
+  ports:
+    http: 8080
+  labels:
+    version: v2
+`
+
+const ratings = `{
+  "hostname": "ratings.bookinfo.svc.cluster.local",
+  "ports": [{"name": "grpc", "port": 9090, "protocol": "GRPC"}],
+  "endpoints": [{"address": "10.2.0.1", "locality": "us-east1/us-east1-b"}]
+}`
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "services")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeFile(t, filepath.Join(dir, "reviews.yaml"), reviews)
+	writeFile(t, filepath.Join(dir, "ratings.json"), ratings)
+	writeFile(t, filepath.Join(dir, "README.md"), "not a service")
+
+	c := NewController(dir, "file")
+	var serviceEvents []model.Event
+	_ = c.AppendServiceHandler(func(_ *model.Service, e model.Event) { serviceEvents = append(serviceEvents, e) })
+	if c.HasSynced() {
+		t.Fatal("HasSynced() => got true before the first load")
+	}
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if !c.HasSynced() {
+		t.Fatal("HasSynced() => got false after the first load")
+	}
+
+	services, _ := c.Services()
+	if len(services) != 2 || len(serviceEvents) != 2 {
+		t.Fatalf("Services() => got %d services and %d events, want 2", len(services), len(serviceEvents))
+	}
+	instances, _ := c.InstancesByPort("reviews.bookinfo.svc.cluster.local", 9080, nil)
+	if len(instances) != 2 || instances[0].Endpoint.Port != 9080 || instances[1].Endpoint.Port != 8080 {
+		t.Fatalf("InstancesByPort() => got %v, want the endpoints on ports 9080 and 8080", instances)
+	}
+	v2, _ := c.InstancesByPort("reviews.bookinfo.svc.cluster.local", 9080,
+		config.LabelsCollection{{"version": "v2"}})
+	if len(v2) != 1 || v2[0].Endpoint.Address != "10.1.0.2" {
+		t.Errorf("InstancesByPort() of v2 => got %v, want the endpoint 10.1.0.2", v2)
+	}
+	if sa := c.GetIstioServiceAccounts("reviews.bookinfo.svc.cluster.local", []int{9080}); len(sa) != 1 {
+		t.Errorf("GetIstioServiceAccounts() => got %v, want the reviews service account", sa)
+	}
+	labels, _ := c.GetProxyWorkloadLabels(&model.Proxy{IPAddresses: []string{"10.1.0.1"}})
+	if len(labels) != 1 || labels[0]["version"] != "v1" {
+		t.Errorf("GetProxyWorkloadLabels() => got %v, want version v1", labels)
+	}
+	if ports := c.ManagementPorts("10.1.0.1"); ports != nil {
+		t.Errorf("ManagementPorts() => got %v, want none", ports)
+	}
+
+	// Only the endpoints change: the service is not updated.
+	serviceEvents = nil
+	writeFile(t, filepath.Join(dir, "reviews.yaml"), reviews+"- address: 10.1.0.3\n")
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	instances, _ = c.InstancesByPort("reviews.bookinfo.svc.cluster.local", 9080, nil)
+	if len(instances) != 3 || len(serviceEvents) != 0 {
+		t.Errorf("InstancesByPort() => got %d instances and %d service events, want 3 and 0", len(instances), len(serviceEvents))
+	}
+
+	// An invalid definition keeps the current services.
+	writeFile(t, filepath.Join(dir, "invalid.yaml"), "hostname: invalid\nports:\n- name: http\n  port: 80\n  protocol: FOO\n")
+	if err := c.Reload(); err == nil {
+		t.Error("Reload() => got no error for an invalid definition")
+	}
+	if services, _ := c.Services(); len(services) != 2 {
+		t.Errorf("Services() => got %d services after an invalid definition, want 2", len(services))
+	}
+
+	if err := os.Remove(filepath.Join(dir, "invalid.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "ratings.json")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if svc, _ := c.GetService("ratings.bookinfo.svc.cluster.local"); svc != nil {
+		t.Errorf("GetService() => got %v, want the removed service to be gone", svc)
+	}
+}
+
+func TestRunWatchesDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "services")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := NewController(dir, "file")
+	stop := make(chan struct{})
+	defer close(stop)
+	go c.Run(stop)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !c.HasSynced() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the first load")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	writeFile(t, filepath.Join(dir, "ratings.json"), ratings)
+	for {
+		if svc, _ := c.GetService("ratings.bookinfo.svc.cluster.local"); svc != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the new service definition to be loaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+
+	kubeyaml "k8s.io/apimachinery/pkg/util/yaml"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config"
+)
+
+// Service is the definition of a service and its endpoints, read from a YAML or JSON file.
+type Service struct {
+	// Hostname of the service, e.g. reviews.bookinfo.svc.cluster.local.
+	Hostname string `json:"hostname"`
+	// Address is the virtual IP of the service, if any.
+	Address string `json:"address,omitempty"`
+	// MeshExternal is set for the services outside of the mesh.
+	MeshExternal bool `json:"meshExternal,omitempty"`
+	// Ports of the service.
+	Ports []Port `json:"ports"`
+	// Endpoints of the service.
+	Endpoints []Endpoint `json:"endpoints,omitempty"`
+}
+
+// Port is a port of a service.
+type Port struct {
+	Name     string `json:"name"`
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"`
+}
+
+// Endpoint is a workload instance of a service.
+type Endpoint struct {
+	// Address is the IP address of the workload.
+	Address string `json:"address"`
+	// Ports maps the names of the service ports to the ports of the workload. The service ports
+	// missing from the map are served on the same port by the workload.
+	Ports          map[string]int    `json:"ports,omitempty"`
+	Labels         map[string]string `json:"labels,omitempty"`
+	ServiceAccount string            `json:"serviceAccount,omitempty"`
+	Network        string            `json:"network,omitempty"`
+	Locality       string            `json:"locality,omitempty"`
+	Weight         uint32            `json:"weight,omitempty"`
+}
+
+// parseServices parses the service definitions of a YAML stream, or of a JSON document.
+func parseServices(data []byte) ([]*Service, error) {
+	var out []*Service
+	decoder := kubeyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 512*1024)
+	for {
+		svc := &Service{}
+		err := decoder.Decode(svc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse service: %v", err)
+		}
+		if reflect.DeepEqual(svc, &Service{}) {
+			continue
+		}
+		out = append(out, svc)
+	}
+	return out, nil
+}
+
+// convertService converts the definition of a service to the service and its instances.
+func convertService(def *Service) (*model.Service, []*model.ServiceInstance, error) {
+	svc := &model.Service{
+		Hostname:     config.Hostname(def.Hostname),
+		Address:      def.Address,
+		MeshExternal: def.MeshExternal,
+		Resolution:   model.ClientSideLB,
+		Attributes: model.ServiceAttributes{
+			Name: def.Hostname,
+		},
+	}
+	if svc.Address == "" {
+		svc.Address = config.UnspecifiedIP
+	}
+	for _, p := range def.Ports {
+		protocol := config.ParseProtocol(p.Protocol)
+		if protocol == config.ProtocolUnsupported {
+			return nil, nil, fmt.Errorf("service %s: unsupported protocol %q of port %s", def.Hostname, p.Protocol, p.Name)
+		}
+		svc.Ports = append(svc.Ports, &model.Port{Name: p.Name, Port: p.Port, Protocol: protocol})
+	}
+	if err := svc.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("invalid service %s: %v", def.Hostname, err)
+	}
+
+	var instances []*model.ServiceInstance
+	for _, ep := range def.Endpoints {
+		if net.ParseIP(ep.Address) == nil {
+			return nil, nil, fmt.Errorf("service %s: invalid endpoint address %q", def.Hostname, ep.Address)
+		}
+		for _, port := range svc.Ports {
+			target := port.Port
+			if p, ok := ep.Ports[port.Name]; ok {
+				target = p
+			}
+			instances = append(instances, &model.ServiceInstance{
+				Endpoint: model.NetworkEndpoint{
+					Family:      model.AddressFamilyTCP,
+					Address:     ep.Address,
+					Port:        target,
+					ServicePort: port,
+					Network:     ep.Network,
+					Locality:    ep.Locality,
+					LbWeight:    ep.Weight,
+				},
+				Service:        svc,
+				Labels:         ep.Labels,
+				ServiceAccount: ep.ServiceAccount,
+			})
+		}
+	}
+	return svc, instances, nil
+}
@@ -96,6 +96,10 @@ type Options struct {
 	// TrustDomain used in SPIFFE identity
 	TrustDomain string
 
+	// Revision of the control plane. Config resources labeled with istio.io/rev are only
+	// processed by the matching revision, unlabeled resources by the default revision.
+	Revision string
+
 	stop chan struct{}
 }
 
@@ -457,11 +461,12 @@ func (c *Controller) InstancesByPort(hostname config.Hostname, reqSvcPort int,
 			}
 
 			pod := c.pods.getPodByIP(ea.IP)
-			az, sa, uid := "", "", ""
+			az, sa, uid, tlsMode := "", "", "", ""
 			if pod != nil {
 				az = c.GetPodLocality(pod)
 				sa = kube.SecureNamingSAN(pod)
 				uid = fmt.Sprintf("kubernetes://%s.%s", pod.Name, pod.Namespace)
+				tlsMode = kube.PodTLSMode(pod)
 			}
 
 			// identify the port by name. K8S EndpointPort uses the service port name
@@ -476,6 +481,7 @@ func (c *Controller) InstancesByPort(hostname config.Hostname, reqSvcPort int,
 							UID:         uid,
 							Network:     c.endpointNetwork(ea.IP),
 							Locality:    az,
+							TLSMode:     tlsMode,
 						},
 						Service:        svc,
 						Labels:         labels,
@@ -635,10 +641,11 @@ func (c *Controller) GetProxyWorkloadLabels(proxy *model.Proxy) (config.LabelsCo
 func (c *Controller) getEndpoints(ip string, endpointPort int32, svcPort *model.Port, svc *model.Service) *model.ServiceInstance {
 	labels, _ := c.pods.labelsByIP(ip)
 	pod := c.pods.getPodByIP(ip)
-	az, sa := "", ""
+	az, sa, tlsMode := "", "", ""
 	if pod != nil {
 		az = c.GetPodLocality(pod)
 		sa = kube.SecureNamingSAN(pod)
+		tlsMode = kube.PodTLSMode(pod)
 	}
 	return &model.ServiceInstance{
 		Endpoint: model.NetworkEndpoint{
@@ -647,6 +654,7 @@ func (c *Controller) getEndpoints(ip string, endpointPort int32, svcPort *model.
 			ServicePort: svcPort,
 			Network:     c.endpointNetwork(ip),
 			Locality:    az,
+			TLSMode:     tlsMode,
 		},
 		Service:        svc,
 		Labels:         labels,
@@ -821,6 +829,7 @@ func (c *Controller) updateEDS(ep *v1.Endpoints, event model.Event) {
 						ServiceAccount:  kube.SecureNamingSAN(pod),
 						Network:         c.endpointNetwork(ea.IP),
 						Locality:        c.GetPodLocality(pod),
+						TLSMode:         kube.PodTLSMode(pod),
 					})
 				}
 			}
@@ -39,6 +39,10 @@ const (
 	// responsible for it
 	IngressClassAnnotation = "kubernetes.io/ingress.class"
 
+	// ExposeToNetworksAnnotation on a service, set to "true", exposes the service to the other
+	// networks of the mesh through the east-west gateway.
+	ExposeToNetworksAnnotation = "networking.istio.io/exposeToNetworks"
+
 	managementPortPrefix = "mgmt-"
 )
 
@@ -75,6 +79,7 @@ func ConvertService(svc coreV1.Service, domainSuffix string, clusterID string) *
 	}
 
 	var exportTo map[config.Visibility]bool
+	exposedToNetworks := false
 	serviceaccounts := make([]string, 0)
 	if svc.Annotations != nil {
 		if svc.Annotations[annotation.AlphaCanonicalServiceAccounts.Name] != "" {
@@ -91,6 +96,7 @@ func ConvertService(svc coreV1.Service, domainSuffix string, clusterID string) *
 				exportTo[config.Visibility(e)] = true
 			}
 		}
+		exposedToNetworks = svc.Annotations[ExposeToNetworksAnnotation] == "true"
 	}
 	sort.Strings(serviceaccounts)
 
@@ -103,10 +109,11 @@ func ConvertService(svc coreV1.Service, domainSuffix string, clusterID string) *
 		Resolution:      resolution,
 		CreationTime:    svc.CreationTimestamp.Time,
 		Attributes: model.ServiceAttributes{
-			Name:      svc.Name,
-			Namespace: svc.Namespace,
-			UID:       fmt.Sprintf("istio://%s/services/%s", svc.Namespace, svc.Name),
-			ExportTo:  exportTo,
+			Name:              svc.Name,
+			Namespace:         svc.Namespace,
+			UID:               fmt.Sprintf("istio://%s/services/%s", svc.Namespace, svc.Name),
+			ExportTo:          exportTo,
+			ExposedToNetworks: exposedToNetworks,
 		},
 	}
 
@@ -170,6 +177,18 @@ func SecureNamingSAN(pod *coreV1.Pod) string {
 	return spiffe.MustGenSpiffeURI(pod.Namespace, pod.Spec.ServiceAccountName)
 }
 
+// PodTLSMode returns the TLS capability of the pod: the value of the TLS mode label if set,
+// otherwise Istio mutual TLS if a sidecar was injected, and plaintext if not.
+func PodTLSMode(pod *coreV1.Pod) string {
+	if mode, ok := pod.Labels[config.TLSModeLabel]; ok && mode != "" {
+		return mode
+	}
+	if _, ok := pod.Annotations[annotation.SidecarStatus.Name]; ok {
+		return config.IstioMutualTLSModeLabel
+	}
+	return config.DisabledTLSModeLabel
+}
+
 // KeyFunc is the internal API key function that returns "namespace"/"name" or
 // "name" if "namespace" is empty
 func KeyFunc(name, namespace string) string {
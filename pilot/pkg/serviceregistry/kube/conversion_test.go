@@ -138,7 +138,8 @@ func TestServiceConversion(t *testing.T) {
 			Annotations: map[string]string{
 				annotation.AlphaKubernetesServiceAccounts.Name: saA + "," + saB,
 				annotation.AlphaCanonicalServiceAccounts.Name:  saC + "," + saD,
-				"other/annotation": "test",
+				ExposeToNetworksAnnotation:                     "true",
+				"other/annotation":                             "test",
 			},
 			CreationTimestamp: metaV1.Time{Time: tnow},
 		},
@@ -168,6 +169,10 @@ func TestServiceConversion(t *testing.T) {
 		t.Fatalf("incorrect creation time => %v, want %v", service.CreationTime, tnow)
 	}
 
+	if !service.Attributes.ExposedToNetworks {
+		t.Fatalf("service not exposed to networks, despite the %s annotation", ExposeToNetworksAnnotation)
+	}
+
 	if len(service.Ports) != len(localSvc.Spec.Ports) {
 		t.Fatalf("incorrect number of ports => %v, want %v",
 			len(service.Ports), len(localSvc.Spec.Ports))
@@ -510,3 +515,23 @@ func TestSecureNamingSAN(t *testing.T) {
 		t.Fatalf("SAN match failed, SAN:%v  expectedSAN:%v", san, expectedSAN)
 	}
 }
+
+func TestPodTLSMode(t *testing.T) {
+	injected := map[string]string{annotation.SidecarStatus.Name: "{}"}
+	cases := []struct {
+		name        string
+		labels      map[string]string
+		annotations map[string]string
+		want        string
+	}{
+		{"no sidecar", nil, nil, config.DisabledTLSModeLabel},
+		{"sidecar", nil, injected, config.IstioMutualTLSModeLabel},
+		{"label override", map[string]string{config.TLSModeLabel: config.DisabledTLSModeLabel}, injected, config.DisabledTLSModeLabel},
+	}
+	for _, c := range cases {
+		pod := &coreV1.Pod{ObjectMeta: metaV1.ObjectMeta{Labels: c.labels, Annotations: c.annotations}}
+		if got := PodTLSMode(pod); got != c.want {
+			t.Errorf("%s: PodTLSMode() => got %q, want %q", c.name, got, c.want)
+		}
+	}
+}
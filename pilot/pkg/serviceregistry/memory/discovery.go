@@ -17,6 +17,8 @@ package memory
 import (
 	"fmt"
 	"net"
+	"sort"
+	"sync"
 	"time"
 
 	"istio.io/istio/pilot/pkg/model"
@@ -27,6 +29,9 @@ import (
 var (
 	// PortHTTPName is the HTTP port name
 	PortHTTPName = "http"
+
+	// DefaultLocality is the locality of the instances generated for the versions without locality
+	DefaultLocality = "region/zone"
 )
 
 // NewDiscovery builds a memory ServiceDiscovery
@@ -127,22 +132,45 @@ func MakeInstance(service *model.Service, port *model.Port, version int, az stri
 	}
 }
 
-// MakeIP creates a fake IP address for a service and instance version
+// MakeIP creates a fake IP address for a service and instance version, of the same family as the
+// service address
 func MakeIP(service *model.Service, version int) string {
 	// external services have no instances
 	if service.External() {
 		return ""
 	}
-	ip := net.ParseIP(service.Address).To4()
-	ip[2] = byte(1)
-	ip[3] = byte(version)
+	ip := net.ParseIP(service.Address)
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	ip[len(ip)-2] = byte(1)
+	ip[len(ip)-1] = byte(version)
 	return ip.String()
 }
 
 // ServiceDiscovery is a memory discovery interface
 type ServiceDiscovery struct {
-	services                      map[config.Hostname]*model.Service
-	versions                      int
+	services map[config.Hostname]*model.Service
+	versions int
+	// instances holds the instances added at runtime, in addition to the ones synthesized from
+	// versions.
+	instances        map[config.Hostname][]*model.ServiceInstance
+	managementPorts  map[config.Hostname]model.PortList
+	probes           map[config.Hostname]model.ProbeList
+	serviceHandlers  []func(*model.Service, model.Event)
+	instanceHandlers []func(*model.ServiceInstance, model.Event)
+	mutex            sync.RWMutex
+
+	// versionLocalities and versionNetworks hold the localities and networks of the instances
+	// generated for the versions.
+	versionLocalities map[int]string
+	versionNetworks   map[int]string
+
+	// XDSUpdater is notified of the instance changes, to push the endpoints incrementally.
+	XDSUpdater model.XDSUpdater
+	// ClusterID identifies the registry in the endpoints pushed to the XDSUpdater.
+	ClusterID string
+
 	WantGetProxyServiceInstances  []*model.ServiceInstance
 	ServicesError                 error
 	GetServiceError               error
@@ -158,9 +186,265 @@ func (sd *ServiceDiscovery) ClearErrors() {
 	sd.GetProxyServiceInstancesError = nil
 }
 
+// AppendServiceHandler implements the model.Controller interface.
+func (sd *ServiceDiscovery) AppendServiceHandler(f func(*model.Service, model.Event)) error {
+	sd.mutex.Lock()
+	sd.serviceHandlers = append(sd.serviceHandlers, f)
+	sd.mutex.Unlock()
+	return nil
+}
+
+// AppendInstanceHandler implements the model.Controller interface.
+func (sd *ServiceDiscovery) AppendInstanceHandler(f func(*model.ServiceInstance, model.Event)) error {
+	sd.mutex.Lock()
+	sd.instanceHandlers = append(sd.instanceHandlers, f)
+	sd.mutex.Unlock()
+	return nil
+}
+
+// Run implements the model.Controller interface. The memory registry has nothing to watch.
+func (sd *ServiceDiscovery) Run(<-chan struct{}) {}
+
 // AddService will add to the registry the provided service
 func (sd *ServiceDiscovery) AddService(name config.Hostname, svc *model.Service) {
+	sd.mutex.Lock()
+	event := model.EventAdd
+	if _, ok := sd.services[name]; ok {
+		event = model.EventUpdate
+	}
 	sd.services[name] = svc
+	handlers := sd.serviceHandlers
+	sd.mutex.Unlock()
+
+	for _, h := range handlers {
+		h(svc, event)
+	}
+}
+
+// SetGatewayAddresses sets the external addresses of the service in the cluster of the registry,
+// which are the addresses of the network gateway when the service is the RegistryServiceName of
+// a gateway in the mesh networks, and the network is from the registry.
+func (sd *ServiceDiscovery) SetGatewayAddresses(hostname config.Hostname, addresses ...string) {
+	sd.mutex.RLock()
+	svc, ok := sd.services[hostname]
+	sd.mutex.RUnlock()
+	if !ok {
+		return
+	}
+	svc.Mutex.Lock()
+	if svc.Attributes.ClusterExternalAddresses == nil {
+		svc.Attributes.ClusterExternalAddresses = make(map[string][]string)
+	}
+	svc.Attributes.ClusterExternalAddresses[sd.ClusterID] = addresses
+	svc.Mutex.Unlock()
+	sd.AddService(hostname, svc)
+}
+
+// RemoveService removes the service and its instances from the registry.
+func (sd *ServiceDiscovery) RemoveService(name config.Hostname) {
+	sd.mutex.Lock()
+	svc, ok := sd.services[name]
+	delete(sd.services, name)
+	instances := sd.instances[name]
+	delete(sd.instances, name)
+	handlers := sd.serviceHandlers
+	sd.mutex.Unlock()
+	if !ok {
+		return
+	}
+
+	for _, instance := range instances {
+		sd.notifyInstance(instance, model.EventDelete)
+	}
+	if len(instances) > 0 {
+		sd.pushEndpoints(name)
+	}
+	for _, h := range handlers {
+		h(svc, model.EventDelete)
+	}
+}
+
+// SetVersionLocality sets the locality of the instances generated for the version, and pushes the
+// endpoints of the services, e.g. to move the instances to another locality in failover tests.
+func (sd *ServiceDiscovery) SetVersionLocality(version int, locality string) {
+	sd.mutex.Lock()
+	if sd.versionLocalities == nil {
+		sd.versionLocalities = make(map[int]string)
+	}
+	sd.versionLocalities[version] = locality
+	sd.mutex.Unlock()
+	sd.pushAllEndpoints()
+}
+
+// SetVersionNetwork sets the network of the instances generated for the version, and pushes the
+// endpoints of the services.
+func (sd *ServiceDiscovery) SetVersionNetwork(version int, network string) {
+	sd.mutex.Lock()
+	if sd.versionNetworks == nil {
+		sd.versionNetworks = make(map[int]string)
+	}
+	sd.versionNetworks[version] = network
+	sd.mutex.Unlock()
+	sd.pushAllEndpoints()
+}
+
+// makeInstanceLocked creates the instance of the service for the version, in the locality and
+// network of the version.
+func (sd *ServiceDiscovery) makeInstanceLocked(service *model.Service, port *model.Port, version int) *model.ServiceInstance {
+	locality, ok := sd.versionLocalities[version]
+	if !ok {
+		locality = DefaultLocality
+	}
+	instance := MakeInstance(service, port, version, locality)
+	instance.Endpoint.Network = sd.versionNetworks[version]
+	return instance
+}
+
+// AddInstance adds an instance of the service, replacing the instance with the same address and
+// port, and pushes the endpoints of the service.
+func (sd *ServiceDiscovery) AddInstance(hostname config.Hostname, instance *model.ServiceInstance) {
+	sd.mutex.Lock()
+	event := model.EventAdd
+	instances := make([]*model.ServiceInstance, 0, len(sd.instances[hostname])+1)
+	for _, existing := range sd.instances[hostname] {
+		if sameEndpoint(existing, instance) {
+			event = model.EventUpdate
+			continue
+		}
+		instances = append(instances, existing)
+	}
+	sd.setInstancesLocked(hostname, append(instances, instance))
+	sd.mutex.Unlock()
+
+	sd.notifyInstance(instance, event)
+	sd.pushEndpoints(hostname)
+}
+
+// RemoveInstance removes the instances of the service at the address, and pushes the endpoints
+// of the service.
+func (sd *ServiceDiscovery) RemoveInstance(hostname config.Hostname, address string) {
+	sd.mutex.Lock()
+	var instances, removed []*model.ServiceInstance
+	for _, instance := range sd.instances[hostname] {
+		if instance.Endpoint.Address == address {
+			removed = append(removed, instance)
+			continue
+		}
+		instances = append(instances, instance)
+	}
+	sd.setInstancesLocked(hostname, instances)
+	sd.mutex.Unlock()
+
+	if len(removed) == 0 {
+		return
+	}
+	for _, instance := range removed {
+		sd.notifyInstance(instance, model.EventDelete)
+	}
+	sd.pushEndpoints(hostname)
+}
+
+// SetInstances replaces the instances of the service added at runtime, and pushes the endpoints
+// of the service.
+func (sd *ServiceDiscovery) SetInstances(hostname config.Hostname, instances []*model.ServiceInstance) {
+	sd.mutex.Lock()
+	previous := sd.instances[hostname]
+	sd.setInstancesLocked(hostname, instances)
+	sd.mutex.Unlock()
+
+	for _, old := range previous {
+		kept := false
+		for _, instance := range instances {
+			if sameEndpoint(old, instance) {
+				kept = true
+				break
+			}
+		}
+		if !kept {
+			sd.notifyInstance(old, model.EventDelete)
+		}
+	}
+	for _, instance := range instances {
+		sd.notifyInstance(instance, model.EventUpdate)
+	}
+	sd.pushEndpoints(hostname)
+}
+
+func (sd *ServiceDiscovery) setInstancesLocked(hostname config.Hostname, instances []*model.ServiceInstance) {
+	if len(instances) == 0 {
+		delete(sd.instances, hostname)
+		return
+	}
+	if sd.instances == nil {
+		sd.instances = make(map[config.Hostname][]*model.ServiceInstance)
+	}
+	sd.instances[hostname] = instances
+}
+
+func (sd *ServiceDiscovery) notifyInstance(instance *model.ServiceInstance, event model.Event) {
+	sd.mutex.RLock()
+	handlers := sd.instanceHandlers
+	sd.mutex.RUnlock()
+	for _, h := range handlers {
+		h(instance, event)
+	}
+}
+
+// pushEndpoints sends all the endpoints of the service to the XDSUpdater, if any.
+func (sd *ServiceDiscovery) pushEndpoints(hostname config.Hostname) {
+	if sd.XDSUpdater == nil {
+		return
+	}
+	sd.mutex.RLock()
+	var instances []*model.ServiceInstance
+	if service, ok := sd.services[hostname]; ok {
+		for _, port := range service.Ports {
+			instances = append(instances, sd.instancesByPortLocked(service, port, nil)...)
+		}
+	} else {
+		instances = sd.instances[hostname]
+	}
+	endpoints := make([]*model.IstioEndpoint, 0, len(instances))
+	for _, instance := range instances {
+		endpoints = append(endpoints, &model.IstioEndpoint{
+			Labels:          instance.Labels,
+			Family:          instance.Endpoint.Family,
+			Address:         instance.Endpoint.Address,
+			ServicePortName: instance.Endpoint.ServicePort.Name,
+			UID:             instance.Endpoint.UID,
+			ServiceAccount:  instance.ServiceAccount,
+			Network:         instance.Endpoint.Network,
+			Locality:        instance.Endpoint.Locality,
+			EndpointPort:    uint32(instance.Endpoint.Port),
+			LbWeight:        instance.Endpoint.LbWeight,
+			TLSMode:         instance.Endpoint.TLSMode,
+		})
+	}
+	sd.mutex.RUnlock()
+	_ = sd.XDSUpdater.EDSUpdate(sd.ClusterID, string(hostname), endpoints)
+}
+
+// pushAllEndpoints sends the endpoints of all the services to the XDSUpdater, if any.
+func (sd *ServiceDiscovery) pushAllEndpoints() {
+	if sd.XDSUpdater == nil {
+		return
+	}
+	sd.mutex.RLock()
+	hostnames := make([]config.Hostname, 0, len(sd.services))
+	for hostname, service := range sd.services {
+		if !service.External() {
+			hostnames = append(hostnames, hostname)
+		}
+	}
+	sd.mutex.RUnlock()
+	for _, hostname := range hostnames {
+		sd.pushEndpoints(hostname)
+	}
+}
+
+func sameEndpoint(a, b *model.ServiceInstance) bool {
+	return a.Endpoint.Address == b.Endpoint.Address && a.Endpoint.Port == b.Endpoint.Port &&
+		a.Endpoint.ServicePort.Name == b.Endpoint.ServicePort.Name
 }
 
 // Services implements discovery interface
@@ -168,6 +452,8 @@ func (sd *ServiceDiscovery) Services() ([]*model.Service, error) {
 	if sd.ServicesError != nil {
 		return nil, sd.ServicesError
 	}
+	sd.mutex.RLock()
+	defer sd.mutex.RUnlock()
 	out := make([]*model.Service, 0, len(sd.services))
 	for _, service := range sd.services {
 		out = append(out, service)
@@ -180,7 +466,9 @@ func (sd *ServiceDiscovery) GetService(hostname config.Hostname) (*model.Service
 	if sd.GetServiceError != nil {
 		return nil, sd.GetServiceError
 	}
+	sd.mutex.RLock()
 	val := sd.services[hostname]
+	sd.mutex.RUnlock()
 	return val, sd.GetServiceError
 }
 
@@ -190,6 +478,8 @@ func (sd *ServiceDiscovery) InstancesByPort(hostname config.Hostname, num int,
 	if sd.InstancesError != nil {
 		return nil, sd.InstancesError
 	}
+	sd.mutex.RLock()
+	defer sd.mutex.RUnlock()
 	service, ok := sd.services[hostname]
 	if !ok {
 		return nil, sd.InstancesError
@@ -199,15 +489,30 @@ func (sd *ServiceDiscovery) InstancesByPort(hostname config.Hostname, num int,
 		return out, sd.InstancesError
 	}
 	if port, ok := service.Ports.GetByPort(num); ok {
-		for v := 0; v < sd.versions; v++ {
-			if labels.HasSubsetOf(map[string]string{"version": fmt.Sprintf("v%d", v)}) {
-				out = append(out, MakeInstance(service, port, v, "zone/region"))
-			}
-		}
+		out = append(out, sd.instancesByPortLocked(service, port, labels)...)
 	}
 	return out, sd.InstancesError
 }
 
+func (sd *ServiceDiscovery) instancesByPortLocked(service *model.Service, port *model.Port,
+	labels config.LabelsCollection) []*model.ServiceInstance {
+	if service.External() {
+		return nil
+	}
+	var out []*model.ServiceInstance
+	for v := 0; v < sd.versions; v++ {
+		if labels.HasSubsetOf(map[string]string{"version": fmt.Sprintf("v%d", v)}) {
+			out = append(out, sd.makeInstanceLocked(service, port, v))
+		}
+	}
+	for _, instance := range sd.instances[service.Hostname] {
+		if instance.Endpoint.ServicePort.Name == port.Name && labels.HasSubsetOf(instance.Labels) {
+			out = append(out, instance)
+		}
+	}
+	return out
+}
+
 // GetProxyServiceInstances implements discovery interface
 func (sd *ServiceDiscovery) GetProxyServiceInstances(node *model.Proxy) ([]*model.ServiceInstance, error) {
 	if sd.GetProxyServiceInstancesError != nil {
@@ -216,23 +521,43 @@ func (sd *ServiceDiscovery) GetProxyServiceInstances(node *model.Proxy) ([]*mode
 	if sd.WantGetProxyServiceInstances != nil {
 		return sd.WantGetProxyServiceInstances, nil
 	}
+	sd.mutex.RLock()
+	defer sd.mutex.RUnlock()
 	out := make([]*model.ServiceInstance, 0)
 	for _, service := range sd.services {
 		if !service.External() {
 			for v := 0; v < sd.versions; v++ {
-				// Only one IP for memory discovery?
-				if node.IPAddresses[0] == MakeIP(service, v) {
+				if hasIP(node, MakeIP(service, v)) {
 					for _, port := range service.Ports {
-						out = append(out, MakeInstance(service, port, v, "region/zone"))
+						out = append(out, sd.makeInstanceLocked(service, port, v))
 					}
 				}
 			}
 
 		}
 	}
+	for _, instances := range sd.instances {
+		for _, instance := range instances {
+			if hasIP(node, instance.Endpoint.Address) {
+				out = append(out, instance)
+			}
+		}
+	}
 	return out, sd.GetProxyServiceInstancesError
 }
 
+// hasIP returns true if the address is one of the IP addresses of the proxy, which may have several
+// addresses, e.g. IPv4 and IPv6 ones.
+func hasIP(node *model.Proxy, address string) bool {
+	ip := net.ParseIP(address)
+	for _, addr := range node.IPAddresses {
+		if addr == address || (ip != nil && ip.Equal(net.ParseIP(addr))) {
+			return true
+		}
+	}
+	return false
+}
+
 func (sd *ServiceDiscovery) GetProxyWorkloadLabels(proxy *model.Proxy) (config.LabelsCollection, error) {
 	if sd.GetProxyServiceInstancesError != nil {
 		return nil, sd.GetProxyServiceInstancesError
@@ -241,8 +566,37 @@ func (sd *ServiceDiscovery) GetProxyWorkloadLabels(proxy *model.Proxy) (config.L
 	return nil, nil
 }
 
+// SetManagementPorts sets the management ports of the instances of the service, returned by
+// ManagementPorts instead of the default ones.
+func (sd *ServiceDiscovery) SetManagementPorts(hostname config.Hostname, ports model.PortList) {
+	sd.mutex.Lock()
+	defer sd.mutex.Unlock()
+	if sd.managementPorts == nil {
+		sd.managementPorts = make(map[config.Hostname]model.PortList)
+	}
+	sd.managementPorts[hostname] = ports
+}
+
+// SetWorkloadHealthCheckInfo sets the health check probes of the instances of the service,
+// returned by WorkloadHealthCheckInfo.
+func (sd *ServiceDiscovery) SetWorkloadHealthCheckInfo(hostname config.Hostname, probes model.ProbeList) {
+	sd.mutex.Lock()
+	defer sd.mutex.Unlock()
+	if sd.probes == nil {
+		sd.probes = make(map[config.Hostname]model.ProbeList)
+	}
+	sd.probes[hostname] = probes
+}
+
 // ManagementPorts implements discovery interface
 func (sd *ServiceDiscovery) ManagementPorts(addr string) model.PortList {
+	sd.mutex.RLock()
+	defer sd.mutex.RUnlock()
+	for _, hostname := range sd.hostnamesAtLocked(addr) {
+		if ports, ok := sd.managementPorts[hostname]; ok {
+			return ports
+		}
+	}
 	return model.PortList{{
 		Name:     "http",
 		Port:     3333,
@@ -256,9 +610,40 @@ func (sd *ServiceDiscovery) ManagementPorts(addr string) model.PortList {
 
 // WorkloadHealthCheckInfo implements discovery interface
 func (sd *ServiceDiscovery) WorkloadHealthCheckInfo(addr string) model.ProbeList {
+	sd.mutex.RLock()
+	defer sd.mutex.RUnlock()
+	for _, hostname := range sd.hostnamesAtLocked(addr) {
+		if probes, ok := sd.probes[hostname]; ok {
+			return probes
+		}
+	}
 	return nil
 }
 
+// hostnamesAtLocked returns the sorted hostnames of the services having an instance at the address.
+func (sd *ServiceDiscovery) hostnamesAtLocked(addr string) []config.Hostname {
+	node := &model.Proxy{IPAddresses: []string{addr}}
+	var out []config.Hostname
+	for hostname, service := range sd.services {
+		for v := 0; v < sd.versions; v++ {
+			if hasIP(node, MakeIP(service, v)) {
+				out = append(out, hostname)
+				break
+			}
+		}
+	}
+	for hostname, instances := range sd.instances {
+		for _, instance := range instances {
+			if hasIP(node, instance.Endpoint.Address) {
+				out = append(out, hostname)
+				break
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
 // GetIstioServiceAccounts gets the Istio service accounts for a service hostname.
 func (sd *ServiceDiscovery) GetIstioServiceAccounts(hostname config.Hostname, ports []int) []string {
 	if hostname == "world.default.svc.cluster.local" {
@@ -15,9 +15,11 @@
 package memory
 
 import (
+	"reflect"
 	"testing"
 
 	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config"
 )
 
 func TestMemoryServices(t *testing.T) {
@@ -53,3 +55,210 @@ func TestMemoryServices(t *testing.T) {
 		}
 	}
 }
+
+type fakeXDSUpdater struct {
+	endpoints map[string][]*model.IstioEndpoint
+}
+
+func (f *fakeXDSUpdater) EDSUpdate(shard, hostname string, entry []*model.IstioEndpoint) error {
+	f.endpoints[shard+"/"+hostname] = entry
+	return nil
+}
+
+func (f *fakeXDSUpdater) SvcUpdate(shard, hostname string, ports map[string]uint32, rports map[uint32]string) {
+}
+
+func (f *fakeXDSUpdater) WorkloadUpdate(id string, labels map[string]string, annotations map[string]string) {
+}
+
+func (f *fakeXDSUpdater) ConfigUpdate(full bool) {}
+
+func TestDynamicInstances(t *testing.T) {
+	service := MakeService("dynamic.default.svc.cluster.local", "10.3.0.0")
+	sd := NewDiscovery(map[config.Hostname]*model.Service{service.Hostname: service}, 0)
+	xds := &fakeXDSUpdater{endpoints: make(map[string][]*model.IstioEndpoint)}
+	sd.XDSUpdater = xds
+	sd.ClusterID = "memory"
+	var events []model.Event
+	_ = sd.AppendInstanceHandler(func(_ *model.ServiceInstance, e model.Event) {
+		events = append(events, e)
+	})
+
+	instance := func(address string) *model.ServiceInstance {
+		return &model.ServiceInstance{
+			Endpoint: model.NetworkEndpoint{Address: address, Port: 8080, ServicePort: service.Ports[0]},
+			Service:  service,
+			Labels:   map[string]string{"version": "v1"},
+		}
+	}
+	expect := func(step string, addresses ...string) {
+		t.Helper()
+		instances, err := sd.InstancesByPort(service.Hostname, service.Ports[0].Port, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		endpoints := xds.endpoints["memory/"+string(service.Hostname)]
+		if len(instances) != len(addresses) || len(endpoints) != len(addresses) {
+			t.Fatalf("%s: got %d instances and %d endpoints, want %v", step, len(instances), len(endpoints), addresses)
+		}
+		for i, address := range addresses {
+			if instances[i].Endpoint.Address != address || endpoints[i].Address != address ||
+				endpoints[i].EndpointPort != 8080 || endpoints[i].ServicePortName != service.Ports[0].Name {
+				t.Errorf("%s: got instance %v and endpoint %v, want %s", step, instances[i].Endpoint, endpoints[i], address)
+			}
+		}
+	}
+
+	sd.AddInstance(service.Hostname, instance("10.3.1.1"))
+	sd.AddInstance(service.Hostname, instance("10.3.1.2"))
+	expect("add", "10.3.1.1", "10.3.1.2")
+
+	sd.RemoveInstance(service.Hostname, "10.3.1.1")
+	expect("remove", "10.3.1.2")
+
+	sd.SetInstances(service.Hostname, []*model.ServiceInstance{instance("10.3.1.3")})
+	expect("set", "10.3.1.3")
+
+	proxyInstances, err := sd.GetProxyServiceInstances(&model.Proxy{IPAddresses: []string{"10.3.1.3"}})
+	if err != nil || len(proxyInstances) != 1 {
+		t.Errorf("GetProxyServiceInstances() => got %v (%v), want the instance at 10.3.1.3", proxyInstances, err)
+	}
+
+	want := []model.Event{model.EventAdd, model.EventAdd, model.EventDelete, model.EventDelete, model.EventUpdate}
+	if len(events) != len(want) {
+		t.Fatalf("got events %v, want %v", events, want)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("got events %v, want %v", events, want)
+			break
+		}
+	}
+}
+
+func TestMakeIPDualStack(t *testing.T) {
+	for _, tc := range []struct {
+		address string
+		want    string
+	}{
+		{"10.3.0.0", "10.3.1.2"},
+		{"2001:db8::", "2001:db8::102"},
+	} {
+		if got := MakeIP(MakeService("svc.default.svc.cluster.local", tc.address), 2); got != tc.want {
+			t.Errorf("MakeIP() for %s => got %s, want %s", tc.address, got, tc.want)
+		}
+	}
+}
+
+func TestGetProxyServiceInstancesMultipleIPs(t *testing.T) {
+	v4 := MakeService("v4.default.svc.cluster.local", "10.3.0.0")
+	v6 := MakeService("v6.default.svc.cluster.local", "2001:db8::")
+	sd := NewDiscovery(map[config.Hostname]*model.Service{v4.Hostname: v4, v6.Hostname: v6}, 2)
+
+	for _, tc := range []struct {
+		ips  []string
+		want int
+	}{
+		{[]string{"10.3.1.1"}, len(v4.Ports)},
+		{[]string{"2001:db8:0:0:0:0:0:101"}, len(v6.Ports)},
+		{[]string{"10.3.1.1", "2001:db8::101"}, len(v4.Ports) + len(v6.Ports)},
+		{[]string{"192.168.0.1", "2001:db8::101"}, len(v6.Ports)},
+		{[]string{"192.168.0.1"}, 0},
+	} {
+		instances, err := sd.GetProxyServiceInstances(&model.Proxy{IPAddresses: tc.ips})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(instances) != tc.want {
+			t.Errorf("GetProxyServiceInstances() for %v => got %d instances, want %d", tc.ips, len(instances), tc.want)
+		}
+	}
+}
+
+func TestManagementPortsAndProbes(t *testing.T) {
+	service := MakeService("probed.default.svc.cluster.local", "10.3.0.0")
+	sd := NewDiscovery(map[config.Hostname]*model.Service{service.Hostname: service}, 2)
+	addr := MakeIP(service, 1)
+
+	if got := sd.ManagementPorts(addr); len(got) != 2 || got[0].Port != 3333 {
+		t.Errorf("ManagementPorts() => got %v, want the default ports", got)
+	}
+	if got := sd.WorkloadHealthCheckInfo(addr); got != nil {
+		t.Errorf("WorkloadHealthCheckInfo() => got %v, want nil", got)
+	}
+
+	ports := model.PortList{{Name: "http-health", Port: 8081, Protocol: config.ProtocolHTTP}}
+	probes := model.ProbeList{{Port: ports[0], Path: "/healthz"}}
+	sd.SetManagementPorts(service.Hostname, ports)
+	sd.SetWorkloadHealthCheckInfo(service.Hostname, probes)
+	if got := sd.ManagementPorts(addr); len(got) != 1 || got[0] != ports[0] {
+		t.Errorf("ManagementPorts() => got %v, want %v", got, ports)
+	}
+	if got := sd.WorkloadHealthCheckInfo(addr); len(got) != 1 || got[0] != probes[0] {
+		t.Errorf("WorkloadHealthCheckInfo() => got %v, want %v", got, probes)
+	}
+	if got := sd.WorkloadHealthCheckInfo("192.168.0.1"); got != nil {
+		t.Errorf("WorkloadHealthCheckInfo() of another address => got %v, want nil", got)
+	}
+}
+
+func TestVersionLocalities(t *testing.T) {
+	service := MakeService("local.default.svc.cluster.local", "10.3.0.0")
+	sd := NewDiscovery(map[config.Hostname]*model.Service{service.Hostname: service}, 2)
+	xds := &fakeXDSUpdater{endpoints: make(map[string][]*model.IstioEndpoint)}
+	sd.XDSUpdater = xds
+
+	sd.SetVersionLocality(1, "us-east1/us-east1-b")
+	sd.SetVersionNetwork(1, "network2")
+
+	instances, err := sd.InstancesByPort(service.Hostname, 80, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(instances) != 2 {
+		t.Fatalf("InstancesByPort() => got %d instances, want 2", len(instances))
+	}
+	if got := instances[0].Endpoint; got.Locality != DefaultLocality || got.Network != "" {
+		t.Errorf("InstancesByPort() v0 => got locality %q and network %q, want %q and none", got.Locality, got.Network, DefaultLocality)
+	}
+	if got := instances[1].Endpoint; got.Locality != "us-east1/us-east1-b" || got.Network != "network2" {
+		t.Errorf("InstancesByPort() v1 => got locality %q and network %q, want us-east1/us-east1-b and network2",
+			got.Locality, got.Network)
+	}
+
+	proxyInstances, _ := sd.GetProxyServiceInstances(&model.Proxy{IPAddresses: []string{MakeIP(service, 1)}})
+	if len(proxyInstances) == 0 || proxyInstances[0].GetLocality() != "us-east1/us-east1-b" {
+		t.Errorf("GetProxyServiceInstances() => got %v, want the instances in us-east1/us-east1-b", proxyInstances)
+	}
+
+	endpoints := xds.endpoints["/"+string(service.Hostname)]
+	pushed := false
+	for _, ep := range endpoints {
+		if ep.Locality == "us-east1/us-east1-b" && ep.Network == "network2" {
+			pushed = true
+		}
+	}
+	if !pushed {
+		t.Errorf("got pushed endpoints %v, want the v1 endpoints in us-east1/us-east1-b and network2", endpoints)
+	}
+}
+
+func TestGatewayAddresses(t *testing.T) {
+	gateway := MakeService("istio-ingressgateway.istio-system.svc.cluster.local", "10.4.0.0")
+	sd := NewDiscovery(map[config.Hostname]*model.Service{gateway.Hostname: gateway}, 1)
+	sd.ClusterID = "cluster2"
+	var events []model.Event
+	_ = sd.AppendServiceHandler(func(_ *model.Service, event model.Event) { events = append(events, event) })
+
+	sd.SetGatewayAddresses(gateway.Hostname, "2.2.2.2", "2.2.2.20")
+	sd.SetGatewayAddresses("unknown.default.svc.cluster.local", "3.3.3.3")
+
+	got, _ := sd.GetService(gateway.Hostname)
+	want := map[string][]string{"cluster2": {"2.2.2.2", "2.2.2.20"}}
+	if !reflect.DeepEqual(got.Attributes.ClusterExternalAddresses, want) {
+		t.Errorf("GetService() => got external addresses %v, want %v", got.Attributes.ClusterExternalAddresses, want)
+	}
+	if !reflect.DeepEqual(events, []model.Event{model.EventUpdate}) {
+		t.Errorf("got service events %v, want a single update", events)
+	}
+}
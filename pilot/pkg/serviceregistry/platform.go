@@ -26,4 +26,8 @@ const (
 	ConsulRegistry ServiceRegistry = "Consul"
 	// MCPRegistry is a service registry backed by MCP ServiceEntries
 	MCPRegistry ServiceRegistry = "MCP"
+	// FileRegistry is a service registry backed by the service definitions of a directory
+	FileRegistry ServiceRegistry = "File"
+	// ExternalRegistry is a service registry backed by the ServiceEntries an external process serves over MCP
+	ExternalRegistry ServiceRegistry = "External"
 )
@@ -0,0 +1,272 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package status reports the distribution of configuration to the proxies
+// in the status of the configuration resources.
+package status
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config"
+	"istio.io/pkg/log"
+)
+
+var scope = log.RegisterScope("status", "config distribution status", 0)
+
+// Types lists the config types whose distribution status is reported.
+var Types = []string{
+	model.VirtualService.Type,
+	model.DestinationRule.Type,
+	model.Gateway.Type,
+	model.Sidecar.Type,
+}
+
+// ReconciledCondition is the type of the condition set on a config once it has been processed
+// and distributed by pilot, e.g. for `kubectl wait --for=condition=Reconciled`.
+const ReconciledCondition = "Reconciled"
+
+// Writer writes the status of a config resource.
+type Writer interface {
+	UpdateStatus(config model.Config, status map[string]interface{}) error
+}
+
+// ProxyVersion is the push version most recently acknowledged by a connected proxy, or -1 if
+// the proxy did not acknowledge any push.
+type ProxyVersion struct {
+	Proxy   *model.Proxy
+	Version int64
+}
+
+// ProxyVersions returns the push versions acknowledged by the connected proxies.
+type ProxyVersions func() []ProxyVersion
+
+// Controller tracks the push version in which each generation of a config was first
+// distributed, and periodically reports for each config how many of the connected
+// proxies it applies to acknowledged that push or a later one.
+type Controller struct {
+	store   model.ConfigStore
+	writer  Writer
+	proxies ProxyVersions
+	now     func() time.Time
+
+	mu sync.Mutex
+	// distributed records, for each config, the generation tracked and the push version
+	// in which it was first distributed.
+	distributed map[string]distribution
+	// written holds the last status written for each config.
+	written map[string]map[string]interface{}
+}
+
+type distribution struct {
+	generation      int64
+	resourceVersion string
+	version         int64
+	// reconciled is the time at which the config was first distributed, i.e. the last transition
+	// of the Reconciled condition, which stays true for the later generations.
+	reconciled time.Time
+}
+
+// tracks returns true if the distribution tracks the current state of the config. Stores
+// without generations are tracked by resource version.
+func (d distribution) tracks(config model.Config) bool {
+	if config.Generation == 0 {
+		return d.resourceVersion == config.ResourceVersion
+	}
+	return d.generation == config.Generation
+}
+
+// NewController creates a status controller reading configs from the store and
+// writing their status with the writer. The store should only hold the configs
+// whose status the writer can store.
+func NewController(store model.ConfigStore, writer Writer, proxies ProxyVersions) *Controller {
+	return &Controller{
+		store:       store,
+		writer:      writer,
+		proxies:     proxies,
+		now:         time.Now,
+		distributed: make(map[string]distribution),
+		written:     make(map[string]map[string]interface{}),
+	}
+}
+
+// RecordPush records the configs that will be distributed by the push with the given version.
+// It must be called before the push context is computed, so that the recorded generations
+// are never newer than the ones distributed.
+func (c *Controller) RecordPush(version int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	seen := make(map[string]struct{}, len(c.distributed))
+	for _, typ := range Types {
+		configs, err := c.store.List(typ, model.NamespaceAll)
+		if err != nil {
+			scope.Warnf("failed to list %s: %v", typ, err)
+			return
+		}
+		for _, config := range configs {
+			k := key(config)
+			seen[k] = struct{}{}
+			reconciled := now
+			if d, ok := c.distributed[k]; ok {
+				if d.tracks(config) {
+					continue
+				}
+				reconciled = d.reconciled
+			}
+			c.distributed[k] = distribution{
+				generation:      config.Generation,
+				resourceVersion: config.ResourceVersion,
+				version:         version,
+				reconciled:      reconciled,
+			}
+		}
+	}
+	for k := range c.distributed {
+		if _, ok := seen[k]; !ok {
+			delete(c.distributed, k)
+			delete(c.written, k)
+		}
+	}
+}
+
+// Report writes the distribution status of the configs whose status changed since the
+// last report.
+func (c *Controller) Report() {
+	versions := c.proxies()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, typ := range Types {
+		configs, err := c.store.List(typ, model.NamespaceAll)
+		if err != nil {
+			scope.Warnf("failed to list %s: %v", typ, err)
+			continue
+		}
+		for _, config := range configs {
+			k := key(config)
+			d, ok := c.distributed[k]
+			if !ok || !d.tracks(config) {
+				// Not distributed yet.
+				continue
+			}
+			status := distributionStatus(config, d, versions)
+			if reflect.DeepEqual(c.written[k], status) {
+				continue
+			}
+			if err := c.writer.UpdateStatus(config, status); err != nil {
+				scope.Warnf("failed to update status of %s: %v", k, err)
+				continue
+			}
+			c.written[k] = status
+		}
+	}
+}
+
+// Run reports the distribution status at the given interval until the stop channel is closed.
+func (c *Controller) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.Report()
+		case <-stop:
+			return
+		}
+	}
+}
+
+func distributionStatus(config model.Config, d distribution, versions []ProxyVersion) map[string]interface{} {
+	updated, total := 0, 0
+	for _, v := range versions {
+		if !appliesTo(config, v.Proxy) {
+			continue
+		}
+		total++
+		if v.Version >= d.version {
+			updated++
+		}
+	}
+	return map[string]interface{}{
+		"observedGeneration": d.generation,
+		"distribution": map[string]interface{}{
+			"proxiesUpdated": updated,
+			"proxiesTotal":   total,
+		},
+		"conditions": []interface{}{
+			map[string]interface{}{
+				"type":               ReconciledCondition,
+				"status":             "True",
+				"observedGeneration": d.generation,
+				"lastTransitionTime": d.reconciled.UTC().Format(time.RFC3339),
+				"reason":             "Distributed",
+				"message":            "The config has been processed and its distribution to the proxies has started",
+			},
+		},
+	}
+}
+
+// appliesTo returns true if the config applies to the proxy: the gateways to the routers they
+// select, the sidecars to the proxies of their namespace they select, and the virtual services
+// and destination rules to the proxies of the namespaces they are exported to. The virtual
+// services bound to gateways only apply to the routers, unless they are bound to the mesh too.
+func appliesTo(cfg model.Config, proxy *model.Proxy) bool {
+	if proxy == nil {
+		return false
+	}
+	switch spec := cfg.Spec.(type) {
+	case *networking.Gateway:
+		return proxy.Type == model.Router &&
+			(len(spec.Selector) == 0 || proxy.WorkloadLabels.IsSupersetOf(spec.Selector))
+	case *networking.Sidecar:
+		if proxy.Type != model.SidecarProxy || proxy.ConfigNamespace != cfg.Namespace {
+			return false
+		}
+		return spec.WorkloadSelector == nil || proxy.WorkloadLabels.IsSupersetOf(spec.WorkloadSelector.Labels)
+	case *networking.VirtualService:
+		if !exportedTo(spec.ExportTo, cfg.Namespace, proxy) {
+			return false
+		}
+		if len(spec.Gateways) == 0 {
+			return proxy.Type == model.SidecarProxy
+		}
+		for _, gateway := range spec.Gateways {
+			if (gateway == config.IstioMeshGateway) == (proxy.Type == model.SidecarProxy) {
+				return true
+			}
+		}
+		return false
+	case *networking.DestinationRule:
+		return exportedTo(spec.ExportTo, cfg.Namespace, proxy)
+	}
+	return true
+}
+
+// exportedTo returns true if a config of the namespace exported to the namespaces is visible to
+// the proxy.
+func exportedTo(exportTo []string, namespace string, proxy *model.Proxy) bool {
+	return len(exportTo) == 0 || config.Visibility(exportTo[0]) != config.VisibilityPrivate ||
+		proxy.ConfigNamespace == namespace
+}
+
+func key(config model.Config) string {
+	return config.Type + "/" + config.Namespace + "/" + config.Name
+}
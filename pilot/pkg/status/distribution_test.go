@@ -0,0 +1,181 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/onsi/gomega"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/config/memory"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config"
+)
+
+type fakeWriter struct {
+	statuses map[string]map[string]interface{}
+	writes   int
+}
+
+func (w *fakeWriter) UpdateStatus(config model.Config, status map[string]interface{}) error {
+	w.statuses[key(config)] = status
+	w.writes++
+	return nil
+}
+
+func expectedStatus(generation int64, updated, total int, reconciled string) map[string]interface{} {
+	return map[string]interface{}{
+		"observedGeneration": generation,
+		"distribution": map[string]interface{}{
+			"proxiesUpdated": updated,
+			"proxiesTotal":   total,
+		},
+		"conditions": []interface{}{
+			map[string]interface{}{
+				"type":               ReconciledCondition,
+				"status":             "True",
+				"observedGeneration": generation,
+				"lastTransitionTime": reconciled,
+				"reason":             "Distributed",
+				"message":            "The config has been processed and its distribution to the proxies has started",
+			},
+		},
+	}
+}
+
+func TestDistributionStatus(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	store := memory.Make(model.IstioConfigTypes)
+	vs := model.Config{
+		ConfigMeta: model.ConfigMeta{
+			Type:       model.VirtualService.Type,
+			Version:    model.VirtualService.Version,
+			Name:       "reviews",
+			Namespace:  "default",
+			Generation: 1,
+		},
+		Spec: &networking.VirtualService{
+			Hosts: []string{"reviews"},
+			Http: []*networking.HTTPRoute{{
+				Route: []*networking.HTTPRouteDestination{{Destination: &networking.Destination{Host: "reviews"}}},
+			}},
+		},
+	}
+	if _, err := store.Create(vs); err != nil {
+		t.Fatal(err)
+	}
+	vsKey := key(vs)
+
+	writer := &fakeWriter{statuses: make(map[string]map[string]interface{})}
+	sidecar := &model.Proxy{Type: model.SidecarProxy, ConfigNamespace: "default"}
+	router := &model.Proxy{Type: model.Router, ConfigNamespace: "istio-system"}
+	// The virtual service does not apply to the routers.
+	versions := []ProxyVersion{{sidecar, -1}, {sidecar, 3}, {router, 4}}
+	c := NewController(store, writer, func() []ProxyVersion { return versions })
+	now := time.Date(2019, 8, 1, 10, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	// Not pushed yet.
+	c.Report()
+	g.Expect(writer.writes).To(gomega.Equal(0))
+
+	c.RecordPush(4)
+	c.Report()
+	g.Expect(writer.statuses[vsKey]).To(gomega.Equal(expectedStatus(1, 0, 2, "2019-08-01T10:00:00Z")))
+
+	versions = []ProxyVersion{{sidecar, 4}, {sidecar, 5}, {sidecar, -1}, {router, 5}}
+	c.Report()
+	g.Expect(writer.statuses[vsKey]).To(gomega.Equal(expectedStatus(1, 2, 3, "2019-08-01T10:00:00Z")))
+
+	// Unchanged status is not written again.
+	c.Report()
+	g.Expect(writer.writes).To(gomega.Equal(2))
+
+	// Pushes without changes keep the version and time of the first push of the generation.
+	now = now.Add(time.Minute)
+	c.RecordPush(5)
+	c.Report()
+	g.Expect(writer.writes).To(gomega.Equal(2))
+
+	// A new generation is tracked from the next push. The condition does not transition again.
+	stored := store.Get(vs.Type, vs.Name, vs.Namespace)
+	stored.Generation = 2
+	if _, err := store.Update(*stored); err != nil {
+		t.Fatal(err)
+	}
+	c.Report()
+	g.Expect(writer.writes).To(gomega.Equal(2))
+	c.RecordPush(6)
+	c.Report()
+	g.Expect(writer.statuses[vsKey]).To(gomega.Equal(expectedStatus(2, 0, 3, "2019-08-01T10:00:00Z")))
+
+	// A config created again transitions again.
+	if err := store.Delete(vs.Type, vs.Name, vs.Namespace); err != nil {
+		t.Fatal(err)
+	}
+	c.RecordPush(7)
+	if _, err := store.Create(vs); err != nil {
+		t.Fatal(err)
+	}
+	c.RecordPush(8)
+	c.Report()
+	g.Expect(writer.statuses[vsKey]).To(gomega.Equal(expectedStatus(1, 0, 3, "2019-08-01T10:01:00Z")))
+}
+
+func TestAppliesTo(t *testing.T) {
+	sidecar := &model.Proxy{Type: model.SidecarProxy, ConfigNamespace: "default",
+		WorkloadLabels: config.LabelsCollection{{"app": "reviews"}}}
+	otherSidecar := &model.Proxy{Type: model.SidecarProxy, ConfigNamespace: "other"}
+	router := &model.Proxy{Type: model.Router, ConfigNamespace: "istio-system",
+		WorkloadLabels: config.LabelsCollection{{"istio": "ingressgateway"}}}
+
+	cases := []struct {
+		name string
+		spec proto.Message
+		want []*model.Proxy
+	}{
+		{"mesh virtual service", &networking.VirtualService{}, []*model.Proxy{sidecar, otherSidecar}},
+		{"private virtual service", &networking.VirtualService{ExportTo: []string{"."}}, []*model.Proxy{sidecar}},
+		{"gateway virtual service", &networking.VirtualService{Gateways: []string{"istio-system/ingress"}}, []*model.Proxy{router}},
+		{"gateway and mesh virtual service", &networking.VirtualService{Gateways: []string{"ingress", "mesh"}},
+			[]*model.Proxy{sidecar, otherSidecar, router}},
+		{"destination rule", &networking.DestinationRule{}, []*model.Proxy{sidecar, otherSidecar, router}},
+		{"private destination rule", &networking.DestinationRule{ExportTo: []string{"."}}, []*model.Proxy{sidecar}},
+		{"gateway", &networking.Gateway{Selector: map[string]string{"istio": "ingressgateway"}}, []*model.Proxy{router}},
+		{"other gateway", &networking.Gateway{Selector: map[string]string{"istio": "egressgateway"}}, nil},
+		{"sidecar", &networking.Sidecar{}, []*model.Proxy{sidecar}},
+		{"selected sidecar", &networking.Sidecar{WorkloadSelector: &networking.WorkloadSelector{
+			Labels: map[string]string{"app": "ratings"}}}, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cfg := model.Config{ConfigMeta: model.ConfigMeta{Namespace: "default"}, Spec: c.spec}
+			var got []*model.Proxy
+			for _, proxy := range []*model.Proxy{sidecar, otherSidecar, router} {
+				if appliesTo(cfg, proxy) {
+					got = append(got, proxy)
+				}
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("appliesTo() => got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
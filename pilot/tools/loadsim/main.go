@@ -0,0 +1,236 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Tool to simulate the load of many proxies on pilot. Each simulated proxy opens an ADS connection
+// with sidecar node metadata, and subscribes to CDS, EDS, LDS and RDS in the order used by Envoy.
+//
+// The tool reports the distribution of the initial load time, from the connection to the first
+// routes, and of the push latency. Pilot sends a full or incremental push to all the proxies with
+// the same version, and the push latency of a proxy is the time it received the push after the
+// first proxy did. Pushes can be triggered during the run by changing services or configs.
+//
+// Usage:
+//
+// ```bash
+// kubectl port-forward $(kubectl get pod -l istio=pilot -o jsonpath={.items[0].metadata.name} -n istio-system) -n istio-system 15010
+// go run ./pilot/tools/loadsim -pilot localhost:15010 -proxies 2000 -namespaces 10 -duration 5m
+// ```
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/adsc"
+)
+
+var (
+	pilotAddr    = flag.String("pilot", "localhost:15010", "Address of the pilot gRPC port")
+	certDir      = flag.String("certDir", "", "Directory of the client certificates, for mTLS connections to pilot")
+	proxies      = flag.Int("proxies", 100, "Number of simulated proxies")
+	namespaces   = flag.Int("namespaces", 1, "Number of namespaces the proxies are spread over")
+	nodeType     = flag.String("nodeType", "sidecar", "Node type of the simulated proxies: sidecar or router")
+	proxyVersion = flag.String("proxyVersion", "1.2.0", "Istio version reported by the simulated proxies")
+	connectRate  = flag.Int("connectRate", 50, "Number of connections opened per second")
+	duration     = flag.Duration("duration", time.Minute, "Duration of the simulation, after all the proxies are connected")
+	timeout      = flag.Duration("timeout", 30*time.Second, "Time for a proxy to receive its initial config")
+)
+
+// pushKey identifies a push of a resource type.
+type pushKey struct {
+	typ     string
+	version string
+}
+
+// recorder collects the latencies of the simulated proxies.
+type recorder struct {
+	mu           sync.Mutex
+	initialLoads []time.Duration
+	pushes       map[pushKey][]time.Time
+	failures     int
+}
+
+func newRecorder() *recorder {
+	return &recorder{pushes: make(map[pushKey][]time.Time)}
+}
+
+func (r *recorder) initialLoad(d time.Duration) {
+	r.mu.Lock()
+	r.initialLoads = append(r.initialLoads, d)
+	r.mu.Unlock()
+}
+
+func (r *recorder) push(typ, version string, received time.Time) {
+	if version == "" {
+		return
+	}
+	r.mu.Lock()
+	key := pushKey{typ, version}
+	r.pushes[key] = append(r.pushes[key], received)
+	r.mu.Unlock()
+}
+
+func (r *recorder) failure() {
+	r.mu.Lock()
+	r.failures++
+	r.mu.Unlock()
+}
+
+// pushLatencies returns the latencies of the proxies for each push of the type, after the first
+// proxy received the push.
+func (r *recorder) pushLatencies(typ string) (int, []time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pushes := 0
+	var latencies []time.Duration
+	for key, received := range r.pushes {
+		if key.typ != typ || len(received) < 2 {
+			continue
+		}
+		pushes++
+		first := received[0]
+		for _, t := range received[1:] {
+			if t.Before(first) {
+				first = t
+			}
+		}
+		for _, t := range received {
+			latencies = append(latencies, t.Sub(first))
+		}
+	}
+	return pushes, latencies
+}
+
+func main() {
+	flag.Parse()
+	if *proxies <= 0 || *namespaces <= 0 || *connectRate <= 0 {
+		log.Fatal("proxies, namespaces and connectRate must be positive")
+	}
+
+	rec := newRecorder()
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+
+	ticker := time.NewTicker(time.Second / time.Duration(*connectRate))
+	start := time.Now()
+	for i := 0; i < *proxies; i++ {
+		<-ticker.C
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			simulate(i, rec, stop)
+		}(i)
+	}
+	ticker.Stop()
+	log.Printf("Opened %d connections in %v", *proxies, time.Since(start))
+
+	time.Sleep(*duration)
+	close(stop)
+	wg.Wait()
+
+	report(rec)
+	if rec.failures > 0 {
+		os.Exit(1)
+	}
+}
+
+// simulate connects a proxy to pilot and records its initial load and push latencies until stop
+// is closed.
+func simulate(i int, rec *recorder, stop <-chan struct{}) {
+	namespace := fmt.Sprintf("loadsim-%d", i%*namespaces)
+	workload := fmt.Sprintf("loadsim-%d", i)
+	ip := net.IPv4(10, byte(i>>16), byte(i>>8), byte(i)).String()
+
+	connected := time.Now()
+	c, err := adsc.Dial(*pilotAddr, *certDir, &adsc.Config{
+		Namespace: namespace,
+		Workload:  workload,
+		NodeType:  *nodeType,
+		IP:        ip,
+		Meta: map[string]string{
+			model.NodeMetadataIstioProxyVersion: *proxyVersion,
+			model.NodeMetadataIstioVersion:      *proxyVersion,
+			model.NodeMetadataConfigNamespace:   namespace,
+			model.NodeMetadataInterceptionMode:  string(model.InterceptionRedirect),
+			model.NodeMetadataInstanceIPs:       ip,
+			"POD_NAME":                          workload,
+		},
+	})
+	if err != nil {
+		log.Printf("Proxy %s failed to connect: %v", workload, err)
+		rec.failure()
+		return
+	}
+	defer c.Close()
+
+	c.Watch()
+	if _, err := c.Wait(adsc.RDS, *timeout); err != nil {
+		log.Printf("Proxy %s did not receive its initial config: %v", workload, err)
+		rec.failure()
+		return
+	}
+	rec.initialLoad(time.Since(connected))
+	// Only the pushes after the initial config are recorded, since the proxies connect at
+	// different times.
+	initial := map[string]string{}
+	for _, typ := range []string{adsc.CDS, adsc.EDS, adsc.LDS, adsc.RDS} {
+		initial[typ] = c.Version(typ)
+	}
+
+	for {
+		select {
+		case update := <-c.Updates:
+			if update == "close" {
+				log.Printf("Proxy %s was disconnected", workload)
+				rec.failure()
+				return
+			}
+			if version := c.Version(update); version != initial[update] {
+				rec.push(update, version, time.Now())
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+func report(rec *recorder) {
+	fmt.Printf("Proxies: %d, failures: %d\n", *proxies, rec.failures)
+	fmt.Printf("Initial load: %s\n", distribution(rec.initialLoads))
+	for _, typ := range []string{adsc.CDS, adsc.EDS, adsc.LDS, adsc.RDS} {
+		pushes, latencies := rec.pushLatencies(typ)
+		fmt.Printf("%s pushes: %d, latency: %s\n", typ, pushes, distribution(latencies))
+	}
+}
+
+// distribution formats the percentiles of the durations.
+func distribution(durations []time.Duration) string {
+	if len(durations) == 0 {
+		return "no samples"
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	percentile := func(p int) time.Duration {
+		return durations[(len(durations)-1)*p/100]
+	}
+	return fmt.Sprintf("p50=%v p90=%v p99=%v max=%v (%d samples)",
+		percentile(50), percentile(90), percentile(99), durations[len(durations)-1], len(durations))
+}
@@ -267,7 +267,6 @@ func (a *ADSC) handleRecv() {
 		routes := []*xdsapi.RouteConfiguration{}
 		eds := []*xdsapi.ClusterLoadAssignment{}
 		for _, rsc := range msg.Resources { // Any
-			a.VersionInfo[rsc.TypeUrl] = msg.VersionInfo
 			valBytes := rsc.Value
 			if rsc.TypeUrl == listenerType {
 				ll := &xdsapi.Listener{}
@@ -290,6 +289,9 @@ func (a *ADSC) handleRecv() {
 
 		// TODO: add hook to inject nacks
 		a.mutex.Lock()
+		if len(msg.Resources) > 0 {
+			a.VersionInfo[msg.TypeUrl] = msg.VersionInfo
+		}
 		a.ack(msg)
 		a.mutex.Unlock()
 
@@ -627,6 +629,14 @@ func (a *ADSC) Wait(update string, to time.Duration) (string, error) {
 	}
 }
 
+// Version returns the version of the last received resources of the type, one of "cds", "eds",
+// "lds" or "rds".
+func (a *ADSC) Version(typ string) string {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.VersionInfo[typeURLs[typ]]
+}
+
 // EndpointsJSON returns the endpoints, formatted as JSON, for debugging.
 func (a *ADSC) EndpointsJSON() string {
 	out, _ := json.MarshalIndent(a.EDS, " ", " ")
@@ -0,0 +1,179 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adsc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Resource types, as used by Subscribe, Expect and the Updates channel.
+const (
+	CDS = "cds"
+	EDS = "eds"
+	LDS = "lds"
+	RDS = "rds"
+)
+
+var (
+	// ErrClosed is returned when the connection to the server is closed while waiting for resources.
+	ErrClosed = errors.New("connection closed")
+
+	typeURLs = map[string]string{
+		CDS: clusterType,
+		EDS: endpointType,
+		LDS: listenerType,
+		RDS: routeType,
+	}
+)
+
+// Subscribe requests the named resources of the type from the server. All the resources of the
+// type are requested if no name is given.
+func (a *ADSC) Subscribe(typ string, names ...string) error {
+	typeURL, ok := typeURLs[typ]
+	if !ok {
+		return fmt.Errorf("unknown resource type %q", typ)
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.stream.Send(&xdsapi.DiscoveryRequest{
+		Node:          a.node(),
+		TypeUrl:       typeURL,
+		ResourceNames: names,
+	})
+}
+
+// Resource returns the last received resource of the type with the given name, or nil if it was
+// not received.
+func (a *ADSC) Resource(typ, name string) proto.Message {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	switch typ {
+	case CDS:
+		if c, ok := a.Clusters[name]; ok {
+			return c
+		}
+		if c, ok := a.EDSClusters[name]; ok {
+			return c
+		}
+	case EDS:
+		if cla, ok := a.EDS[name]; ok {
+			return cla
+		}
+	case LDS:
+		if l, ok := a.HTTPListeners[name]; ok {
+			return l
+		}
+		if l, ok := a.TCPListeners[name]; ok {
+			return l
+		}
+	case RDS:
+		if r, ok := a.Routes[name]; ok {
+			return r
+		}
+	}
+	return nil
+}
+
+// Expect waits until the named resources of the type are received, and returns them in the
+// order of the names. ErrTimeout is returned, with the missing names, if they are not all
+// received in the given time.
+func (a *ADSC) Expect(typ string, names []string, timeout time.Duration) ([]proto.Message, error) {
+	var missing []string
+	err := a.waitFor(timeout, func() bool {
+		missing = nil
+		for _, name := range names {
+			if a.Resource(typ, name) == nil {
+				missing = append(missing, name)
+			}
+		}
+		return len(missing) == 0
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%v: %s %v not received", err, typ, missing)
+	}
+
+	resources := make([]proto.Message, 0, len(names))
+	for _, name := range names {
+		resources = append(resources, a.Resource(typ, name))
+	}
+	return resources, nil
+}
+
+// ExpectEqual waits until the named resource of the type is received and is equal to want. If
+// it is not received in the given time, the returned error includes the diff of the last
+// received resource against want.
+func (a *ADSC) ExpectEqual(typ, name string, want proto.Message, timeout time.Duration) error {
+	var got proto.Message
+	err := a.waitFor(timeout, func() bool {
+		got = a.Resource(typ, name)
+		return got != nil && proto.Equal(got, want)
+	})
+	if err == nil {
+		return nil
+	}
+	if got == nil {
+		return fmt.Errorf("%v: %s %s not received", err, typ, name)
+	}
+	return fmt.Errorf("%v: %s %s differs from the expected resource:\n%s", err, typ, name, Diff(want, got))
+}
+
+// waitFor waits for updates until done returns true.
+func (a *ADSC) waitFor(timeout time.Duration, done func() bool) error {
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	for !done() {
+		select {
+		case update := <-a.Updates:
+			if update == "close" {
+				return ErrClosed
+			}
+		case <-t.C:
+			return ErrTimeout
+		}
+	}
+	return nil
+}
+
+// Diff returns the unified diff of the JSON representation of the resources, or an empty string
+// if they are equal.
+func Diff(want, got proto.Message) string {
+	jsonm := &jsonpb.Marshaler{Indent: "  "}
+	wantJSON, gotJSON := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := jsonm.Marshal(wantJSON, want); err != nil {
+		return err.Error()
+	}
+	if err := jsonm.Marshal(gotJSON, got); err != nil {
+		return err.Error()
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		FromFile: "Want",
+		A:        difflib.SplitLines(wantJSON.String()),
+		ToFile:   "Got",
+		B:        difflib.SplitLines(gotJSON.String()),
+		Context:  3,
+	})
+	if err != nil {
+		return err.Error()
+	}
+	return diff
+}
@@ -0,0 +1,73 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adsc
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+)
+
+func TestExpect(t *testing.T) {
+	a := &ADSC{Updates: make(chan string, 100)}
+
+	if _, err := a.Expect(CDS, []string{"outbound|80||a.local"}, 10*time.Millisecond); err == nil ||
+		!strings.Contains(err.Error(), ErrTimeout.Error()) {
+		t.Fatalf("Expect() => got %v, want timeout", err)
+	}
+
+	go func() {
+		a.mutex.Lock()
+		a.Clusters = map[string]*xdsapi.Cluster{"outbound|80||a.local": {Name: "outbound|80||a.local"}}
+		a.mutex.Unlock()
+		a.Updates <- CDS
+	}()
+	got, err := a.Expect(CDS, []string{"outbound|80||a.local"}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].(*xdsapi.Cluster).Name != "outbound|80||a.local" {
+		t.Fatalf("Expect() => got %v", got)
+	}
+
+	a.Updates <- "close"
+	if _, err := a.Expect(CDS, []string{"outbound|80||b.local"}, time.Second); err == nil ||
+		!strings.Contains(err.Error(), ErrClosed.Error()) {
+		t.Fatalf("Expect() => got %v, want connection closed", err)
+	}
+}
+
+func TestExpectEqual(t *testing.T) {
+	a := &ADSC{
+		Updates: make(chan string, 100),
+		Routes: map[string]*xdsapi.RouteConfiguration{
+			"80": {Name: "80"},
+		},
+	}
+
+	if err := a.ExpectEqual(RDS, "80", &xdsapi.RouteConfiguration{Name: "80"}, 10*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	err := a.ExpectEqual(RDS, "80", &xdsapi.RouteConfiguration{Name: "8080"}, 10*time.Millisecond)
+	if err == nil {
+		t.Fatal("ExpectEqual() => got no error for different routes")
+	}
+	if !strings.Contains(err.Error(), `-  "name": "8080"`) || !strings.Contains(err.Error(), `+  "name": "80"`) {
+		t.Errorf("ExpectEqual() => got %v, want a diff of the names", err)
+	}
+}
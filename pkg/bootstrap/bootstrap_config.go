@@ -15,6 +15,7 @@
 package bootstrap
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -24,6 +25,8 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"regexp"
+	"sort"
 	"strings"
 	"text/template"
 	"time"
@@ -55,18 +58,35 @@ const (
 	// IstioMetaJSONPrefix is used to pass annotations and similar environment info.
 	IstioMetaJSONPrefix = "ISTIO_METAJSON_"
 
+	// IstioStatsTagPrefix is used to pass env vars as tags with a fixed value on all the proxy stats.
+	IstioStatsTagPrefix = "ISTIO_STATS_TAG_"
+
 	lightstepAccessTokenBase = "lightstep_access_token.txt"
 
+	// DiscoveryFailoverAddresses is the option holding the ordered discovery addresses, as a
+	// []string, that Envoy fails over to when the discovery address is unhealthy.
+	DiscoveryFailoverAddresses = "discovery_failover_addresses"
+
 	// Options are used in the boostrap template.
 	envoyStatsMatcherInclusionPrefixOption = "inclusionPrefix"
 	envoyStatsMatcherInclusionSuffixOption = "inclusionSuffix"
 	envoyStatsMatcherInclusionRegexpOption = "inclusionRegexps"
+	envoyExtraStatsTagsOption              = "extraStatsTags"
 )
 
 var (
+	// labelReference matches the references to pod labels, e.g. {label:team}, in the values of the
+	// ISTIO_META_* and ISTIO_STATS_TAG_* env vars.
+	labelReference = regexp.MustCompile(`{label:([^}]+)}`)
+
 	// required stats are used by readiness checks.
 	requiredEnvoyStatsMatcherInclusionPrefixes = "cluster_manager,listener_manager,http_mixer_filter,tcp_mixer_filter,server,cluster.xds-grpc"
 	requiredEnvoyStatsMatcherInclusionSuffix   = "ssl_context_update_by_sds"
+
+	// requiredEnvoyStatsMatcherInclusionRegexps are the connections and TLS handshakes of the
+	// inbound listeners, used to report the plaintext and mTLS traffic of the PERMISSIVE ports. In
+	// regexps, {pod_ip} is replaced by the quoted address of the pod in the stat names.
+	requiredEnvoyStatsMatcherInclusionRegexps = `listener\.{pod_ip}_[0-9]+\.(downstream_cx_total|ssl\.handshake)`
 )
 
 // substituteValues substitutes variables known to the boostrap like pod_ip.
@@ -89,7 +109,7 @@ func substituteValues(patterns []string, varName string, values []string) []stri
 // setStatsOptions configures stats inclusion list based on annotations.
 func setStatsOptions(opts map[string]interface{}, meta map[string]interface{}, nodeIPs []string) {
 
-	setStatsOption := func(metaKey string, optKey string, required string) {
+	setStatsOption := func(metaKey string, optKey string, required string, podIPs []string) {
 		var inclusionOption []string
 		if inclusionPatterns, ok := meta[metaKey]; ok {
 			inclusionOption = strings.Split(inclusionPatterns.(string), ",")
@@ -104,17 +124,68 @@ func setStatsOptions(opts map[string]interface{}, meta map[string]interface{}, n
 		// Inbound downstream metrics are named as: http.{pod_ip}_{port}.downstream_rq_*
 		// Other outbound downstream metrics are numerous and not very interesting for a sidecar.
 		// specifying http.{pod_ip}_  as a prefix will capture these downstream metrics.
-		inclusionOption = substituteValues(inclusionOption, "{pod_ip}", nodeIPs)
+		inclusionOption = substituteValues(inclusionOption, "{pod_ip}", podIPs)
 
 		if len(inclusionOption) > 0 {
 			opts[optKey] = inclusionOption
 		}
 	}
-	setStatsOption(annotation.SidecarStatsInclusionPrefixes.Name, envoyStatsMatcherInclusionPrefixOption, requiredEnvoyStatsMatcherInclusionPrefixes)
+	setStatsOption(annotation.SidecarStatsInclusionPrefixes.Name, envoyStatsMatcherInclusionPrefixOption,
+		requiredEnvoyStatsMatcherInclusionPrefixes, nodeIPs)
 
-	setStatsOption(annotation.SidecarStatsInclusionSuffixes.Name, envoyStatsMatcherInclusionSuffixOption, requiredEnvoyStatsMatcherInclusionSuffix)
+	setStatsOption(annotation.SidecarStatsInclusionSuffixes.Name, envoyStatsMatcherInclusionSuffixOption,
+		requiredEnvoyStatsMatcherInclusionSuffix, nodeIPs)
 
-	setStatsOption(annotation.SidecarStatsInclusionRegexps.Name, envoyStatsMatcherInclusionRegexpOption, "")
+	setStatsOption(annotation.SidecarStatsInclusionRegexps.Name, envoyStatsMatcherInclusionRegexpOption,
+		requiredEnvoyStatsMatcherInclusionRegexps, statsRegexpAddresses(nodeIPs))
+}
+
+// statsRegexpAddresses returns the IPs as they appear in the listener stat names, quoted for a
+// regexp. Envoy replaces the colons of the IPv6 addresses with underscores, e.g. [fd00__1].
+func statsRegexpAddresses(ips []string) []string {
+	out := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		if strings.Contains(ip, ":") {
+			ip = "[" + strings.Replace(ip, ":", "_", -1) + "]"
+		}
+		out = append(out, regexp.QuoteMeta(ip))
+	}
+	return out
+}
+
+// statsTag is a tag with a fixed value added to all the proxy stats.
+type statsTag struct {
+	Name  string
+	Value string
+}
+
+// setExtraStatsTags configures the stats tags set by the ISTIO_STATS_TAG_* env vars. Tags whose
+// value is empty, e.g. because they reference a missing pod label, are skipped.
+func setExtraStatsTags(opts map[string]interface{}, envs []string, labels map[string]string) {
+	var tags []statsTag
+	extractMetadata(envs, IstioStatsTagPrefix, func(_ map[string]interface{}, key string, val string) {
+		if val = substituteLabels(val, labels); val != "" {
+			tags = append(tags, statsTag{Name: key, Value: val})
+		}
+	}, nil)
+	if len(tags) > 0 {
+		sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+		opts[envoyExtraStatsTagsOption] = tags
+	}
+}
+
+// toJSON returns the JSON encoding of the value, used to insert strings in the bootstrap template.
+func toJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// substituteLabels replaces the references to pod labels in the value with the label values.
+// References to missing labels are replaced with an empty string.
+func substituteLabels(val string, labels map[string]string) string {
+	return labelReference.ReplaceAllStringFunc(val, func(ref string) string {
+		return labels[labelReference.FindStringSubmatch(ref)[1]]
+	})
 }
 
 func defaultPilotSan() []string {
@@ -201,7 +272,19 @@ func GetHostPort(name, addr string) (host string, port string, err error) {
 // StoreHostPort encodes the host and port as key/value pair strings in
 // the provided map.
 func StoreHostPort(host, port, field string, opts map[string]interface{}) {
-	opts[field] = fmt.Sprintf("{\"address\": \"%s\", \"port_value\": %s}", host, port)
+	opts[field] = hostPortJSON(host, port)
+}
+
+// hostPortJSON encodes the host and port as an Envoy socket address.
+func hostPortJSON(host, port string) string {
+	return fmt.Sprintf("{\"address\": \"%s\", \"port_value\": %s}", host, port)
+}
+
+// failoverAddress is a discovery address of the xds-grpc cluster used when the addresses of
+// higher priority are unhealthy.
+type failoverAddress struct {
+	Priority int
+	Address  string
 }
 
 type setMetaFunc func(m map[string]interface{}, key string, val string)
@@ -289,9 +372,10 @@ func extractIstioMetadata(envVars []string, plat platform.Environment) istioMeta
 // ISTIO_META_* env variables are passed thru
 func getNodeMetaData(envs []string, plat platform.Environment) map[string]interface{} {
 	meta := map[string]interface{}{}
+	istioMeta := extractIstioMetadata(envs, plat)
 
 	extractMetadata(envs, IstioMetaPrefix, func(m map[string]interface{}, key string, val string) {
-		m[key] = val
+		m[key] = substituteLabels(val, istioMeta.Labels)
 	}, meta)
 
 	extractMetadata(envs, IstioMetaJSONPrefix, func(m map[string]interface{}, key string, val string) {
@@ -302,7 +386,7 @@ func getNodeMetaData(envs []string, plat platform.Environment) map[string]interf
 	}, meta)
 	meta["istio"] = "sidecar"
 
-	meta["istio.io/metadata"] = extractIstioMetadata(envs, plat)
+	meta["istio.io/metadata"] = istioMeta
 
 	return meta
 }
@@ -346,7 +430,7 @@ func writeBootstrapForPlatform(config *meshconfig.ProxyConfig, node string, epoc
 		return "", err
 	}
 
-	t, err := template.New("bootstrap").Parse(string(cfgTmpl))
+	t, err := template.New("bootstrap").Funcs(template.FuncMap{"toJSON": toJSON}).Parse(string(cfgTmpl))
 	if err != nil {
 		return "", err
 	}
@@ -397,6 +481,7 @@ func writeBootstrapForPlatform(config *meshconfig.ProxyConfig, node string, epoc
 	nodeIPs = newNodeIPs
 
 	setStatsOptions(opts, meta, nodeIPs)
+	setExtraStatsTags(opts, localEnv, extractIstioMetadata(localEnv, nil).Labels)
 
 	// Support multiple network interfaces
 	meta[model.NodeMetadataInstanceIPs] = strings.Join(nodeIPs, ",")
@@ -430,6 +515,22 @@ func writeBootstrapForPlatform(config *meshconfig.ProxyConfig, node string, epoc
 	}
 	StoreHostPort(h, p, "pilot_grpc_address", opts)
 
+	// The failover discovery addresses are used, in order, when the previous ones are unhealthy.
+	if addrs, ok := opts[DiscoveryFailoverAddresses].([]string); ok && len(addrs) > 0 {
+		failover := make([]failoverAddress, 0, len(addrs))
+		for i, addr := range addrs {
+			h, p, err := GetHostPort("Discovery failover", addr)
+			if err != nil {
+				return "", err
+			}
+			failover = append(failover, failoverAddress{
+				Priority: i + 1,
+				Address:  hostPortJSON(h, p),
+			})
+		}
+		opts["pilot_grpc_failover_addresses"] = failover
+	}
+
 	// Pass unmodified config.DiscoveryAddress for Google gRPC Envoy client target_uri parameter
 	opts["discovery_address"] = config.DiscoveryAddress
 
@@ -520,15 +621,19 @@ func writeBootstrapForPlatform(config *meshconfig.ProxyConfig, node string, epoc
 		StoreHostPort(h, p, "envoy_accesslog_service", opts)
 	}
 
-	fout, err := os.Create(fname)
-	if err != nil {
+	var out bytes.Buffer
+	if err := t.Execute(&out, opts); err != nil {
 		return "", err
 	}
-	defer fout.Close()
 
-	// Execute needs some sort of io.Writer
-	err = t.Execute(fout, opts)
-	return fname, err
+	generated := out.Bytes()
+	if config.CustomConfigFile == "" {
+		if generated, err = applyOverrideFile(generated, OverrideFileVar.Get()); err != nil {
+			return "", err
+		}
+	}
+
+	return fname, ioutil.WriteFile(fname, generated, 0644)
 }
 
 func setOptsWithDefaults(src *types.Int64Value, name string, opts map[string]interface{}, defaultVal int64) {
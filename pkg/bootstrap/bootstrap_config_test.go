@@ -22,6 +22,7 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"text/template"
 
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	v2 "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v2"
@@ -66,6 +67,8 @@ var (
 // cp $TOP/out/linux_amd64/release/bootstrap/all/envoy-rev0.json pkg/bootstrap/testdata/all_golden.json
 // cp $TOP/out/linux_amd64/release/bootstrap/auth/envoy-rev0.json pkg/bootstrap/testdata/auth_golden.json
 // cp $TOP/out/linux_amd64/release/bootstrap/default/envoy-rev0.json pkg/bootstrap/testdata/default_golden.json
+// cp $TOP/out/linux_amd64/release/bootstrap/discovery_failover/envoy-rev0.json pkg/bootstrap/testdata/discovery_failover_golden.json
+// cp $TOP/out/linux_amd64/release/bootstrap/stats_tags/envoy-rev0.json pkg/bootstrap/testdata/stats_tags_golden.json
 // cp $TOP/out/linux_amd64/release/bootstrap/tracing_datadog/envoy-rev0.json pkg/bootstrap/testdata/tracing_datadog_golden.json
 // cp $TOP/out/linux_amd64/release/bootstrap/tracing_lightstep/envoy-rev0.json pkg/bootstrap/testdata/tracing_lightstep_golden.json
 // cp $TOP/out/linux_amd64/release/bootstrap/tracing_zipkin/envoy-rev0.json pkg/bootstrap/testdata/tracing_zipkin_golden.json
@@ -97,6 +100,53 @@ func TestGolden(t *testing.T) {
 		{
 			base: "default",
 		},
+		{
+			base: "stats_tags",
+			envVars: map[string]string{
+				IstioStatsTagPrefix + "cost_center": "cc-42",
+				IstioStatsTagPrefix + "owner":       `O'Brien "ops" <ops@example.com>`,
+				IstioStatsTagPrefix + "team":        "{label:team}",
+			},
+			check: func(got *v2.Bootstrap, t *testing.T) {
+				tags := got.StatsConfig.StatsTags
+				// The team label is missing, so only the cost center and owner tags are added.
+				costCenter, owner := tags[len(tags)-2], tags[len(tags)-1]
+				if costCenter.TagName != "cost_center" || costCenter.GetFixedValue() != "cc-42" {
+					t.Errorf("stats tag => got %v, want the cost center fixed value tag", costCenter)
+				}
+				if owner.TagName != "owner" || owner.GetFixedValue() != `O'Brien "ops" <ops@example.com>` {
+					t.Errorf("stats tag => got %v, want the owner fixed value tag with its quotes", owner)
+				}
+				for _, tag := range tags {
+					if tag.TagName == "team" {
+						t.Errorf("stats tags => got %v, want no team tag", tag)
+					}
+				}
+			},
+		},
+		{
+			base: "discovery_failover",
+			opts: map[string]interface{}{
+				DiscoveryFailoverAddresses: []string{"istio-pilot-canary:15010", "10.1.1.1:15010"},
+			},
+			check: func(got *v2.Bootstrap, t *testing.T) {
+				for _, c := range got.StaticResources.Clusters {
+					if c.Name != "xds-grpc" {
+						continue
+					}
+					if len(c.Hosts) != 0 || len(c.LoadAssignment.Endpoints) != 3 || len(c.HealthChecks) != 1 {
+						t.Fatalf("xds-grpc cluster => got %v, want 3 endpoints and a health check", c)
+					}
+					for i, e := range c.LoadAssignment.Endpoints {
+						if e.Priority != uint32(i) {
+							t.Errorf("xds-grpc endpoint %d => got priority %d, want %d", i, e.Priority, i)
+						}
+					}
+					return
+				}
+				t.Fatal("missing xds-grpc cluster")
+			},
+		},
 		{
 			base: "running",
 			envVars: map[string]string{
@@ -388,6 +438,14 @@ func checkStatsMatcher(t *testing.T, got, want *v2.Bootstrap, stats stats) {
 		stats.suffixes += "," + requiredEnvoyStatsMatcherInclusionSuffix
 	}
 
+	requiredRegexps := strings.Join(substituteValues([]string{requiredEnvoyStatsMatcherInclusionRegexps}, "{pod_ip}",
+		statsRegexpAddresses([]string{"10.3.3.3", "10.4.4.4", "10.5.5.5", "10.6.6.6"})), ",")
+	if stats.regexps == "" {
+		stats.regexps = requiredRegexps
+	} else {
+		stats.regexps += "," + requiredRegexps
+	}
+
 	if err := gsm.Validate(); err != nil {
 		t.Fatalf("Generated invalid matcher: %v", err)
 	}
@@ -696,6 +754,73 @@ func TestNodeMetadataEncodeEnvWithIstioMetaPrefix(t *testing.T) {
 	}
 }
 
+func TestNodeMetadataLabelReferences(t *testing.T) {
+	_, envs := createEnv(t, map[string]string{"team": "payments"}, nil)
+	envs = append(envs, IstioMetaPrefix+"owner=team-{label:team}", IstioMetaPrefix+"missing={label:missing}")
+
+	nm := getNodeMetaData(envs, nil)
+	if nm["owner"] != "team-payments" || nm["missing"] != "" {
+		t.Errorf("getNodeMetaData() => got owner %q and missing %q, want the substituted labels", nm["owner"], nm["missing"])
+	}
+}
+
+func TestSetExtraStatsTags(t *testing.T) {
+	envs := []string{
+		IstioStatsTagPrefix + "team={label:team}",
+		IstioStatsTagPrefix + "cost_center=cc-{label:cost-center}",
+		IstioStatsTagPrefix + "missing={label:missing}",
+		"NOT_" + IstioStatsTagPrefix + "other=value",
+	}
+	opts := map[string]interface{}{}
+	setExtraStatsTags(opts, envs, map[string]string{"team": "payments", "cost-center": "42"})
+
+	want := []statsTag{{Name: "cost_center", Value: "cc-42"}, {Name: "team", Value: "payments"}}
+	if got := opts[envoyExtraStatsTagsOption]; !reflect.DeepEqual(got, want) {
+		t.Errorf("setExtraStatsTags() => got %v, want %v", got, want)
+	}
+}
+
+func TestStatsRegexpAddresses(t *testing.T) {
+	cases := []struct {
+		ip        string
+		stat      string
+		otherStat string
+	}{
+		{"10.3.3.3", "listener.10.3.3.3_9080.downstream_cx_total", "listener.10x3x3x3_9080.downstream_cx_total"},
+		{"fd00::1", "listener.[fd00__1]_9080.ssl.handshake", "listener.[fd00__12]_9080.ssl.handshake"},
+	}
+	for _, c := range cases {
+		addresses := statsRegexpAddresses([]string{c.ip})
+		re := regexp.MustCompile("^" + substituteValues([]string{requiredEnvoyStatsMatcherInclusionRegexps}, "{pod_ip}", addresses)[0] + "$")
+		if !re.MatchString(c.stat) {
+			t.Errorf("%s => got %s not matching %s", c.ip, re, c.stat)
+		}
+		if re.MatchString(c.otherStat) {
+			t.Errorf("%s => got %s matching %s", c.ip, re, c.otherStat)
+		}
+	}
+}
+
+func TestToJSONTemplate(t *testing.T) {
+	tmpl := template.Must(template.New("tag").Funcs(template.FuncMap{"toJSON": toJSON}).Parse(
+		`{"tag_name": {{toJSON .Name}}, "fixed_value": {{toJSON .Value}}}`))
+	want := statsTag{Name: "owner", Value: `O'Brien "ops" <ops@example.com> \ & more`}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, want); err != nil {
+		t.Fatal(err)
+	}
+	got := struct {
+		Name  string `json:"tag_name"`
+		Value string `json:"fixed_value"`
+	}{}
+	if err := json.Unmarshal([]byte(b.String()), &got); err != nil {
+		t.Fatalf("toJSON => got invalid JSON %s: %v", b.String(), err)
+	}
+	if got.Name != want.Name || got.Value != want.Value {
+		t.Errorf("toJSON => got %q: %q, want %q: %q", got.Name, got.Value, want.Name, want.Value)
+	}
+}
+
 func mergeMap(to map[string]string, from map[string]string) {
 	for k, v := range from {
 		to[k] = v
@@ -0,0 +1,106 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ghodss/yaml"
+
+	"istio.io/pkg/env"
+)
+
+// OverrideFileVar is the path of a JSON or YAML file holding bootstrap overrides. When set, the
+// file is deep merged into the generated bootstrap. The sidecar injector points it at the
+// ConfigMap named by the sidecar.istio.io/bootstrapOverride annotation.
+var OverrideFileVar = env.RegisterStringVar("ISTIO_BOOTSTRAP_OVERRIDE", "",
+	"Path to a JSON or YAML file deep merged into the generated Envoy bootstrap")
+
+// applyOverrideFile merges the override file, if any, into the generated bootstrap.
+func applyOverrideFile(bootstrap []byte, overrideFile string) ([]byte, error) {
+	if overrideFile == "" {
+		return bootstrap, nil
+	}
+	override, err := ioutil.ReadFile(overrideFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bootstrap override %s: %v", overrideFile, err)
+	}
+	return mergeOverride(bootstrap, override)
+}
+
+// mergeOverride deep merges the override document into the bootstrap. Objects are merged
+// recursively, lists in the override are appended to the bootstrap lists, and any other value
+// replaces the one in the bootstrap. These are the semantics Envoy applies to --config-yaml.
+func mergeOverride(bootstrap []byte, override []byte) ([]byte, error) {
+	// The generated bootstrap is not strict JSON, e.g. it has trailing commas, so it is parsed as
+	// YAML like the override.
+	bootstrapJSON, err := yaml.YAMLToJSON(bootstrap)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bootstrap: %v", err)
+	}
+	base, err := decodeObject(bootstrapJSON)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bootstrap: %v", err)
+	}
+	overrideJSON, err := yaml.YAMLToJSON(override)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bootstrap override: %v", err)
+	}
+	patch, err := decodeObject(overrideJSON)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bootstrap override: %v", err)
+	}
+	return json.MarshalIndent(mergeValue(base, patch), "", "  ")
+}
+
+// decodeObject decodes a JSON object, keeping the numbers as json.Number so that large
+// integers, e.g. byte limits, are not rounded through float64.
+func decodeObject(in []byte) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+	decoder := json.NewDecoder(bytes.NewReader(in))
+	decoder.UseNumber()
+	if err := decoder.Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func mergeValue(dst, src interface{}) interface{} {
+	switch s := src.(type) {
+	case map[string]interface{}:
+		d, ok := dst.(map[string]interface{})
+		if !ok {
+			return s
+		}
+		for k, v := range s {
+			if existing, ok := d[k]; ok {
+				d[k] = mergeValue(existing, v)
+			} else {
+				d[k] = v
+			}
+		}
+		return d
+	case []interface{}:
+		if d, ok := dst.([]interface{}); ok {
+			return append(d, s...)
+		}
+		return s
+	default:
+		return s
+	}
+}
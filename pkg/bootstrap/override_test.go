@@ -0,0 +1,209 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMergeOverride(t *testing.T) {
+	bootstrap := `{
+  "admin": {"address": {"socket_address": {"address": "127.0.0.1", "port_value": 15000}}},
+  "stats_sinks": [{"name": "envoy.statsd"}],
+  "node": {"id": "sidecar"}
+}`
+	cases := []struct {
+		name     string
+		override string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "yaml object",
+			override: "overload_manager:\n  refresh_interval: 0.25s\n",
+			want: `{
+  "admin": {"address": {"socket_address": {"address": "127.0.0.1", "port_value": 15000}}},
+  "stats_sinks": [{"name": "envoy.statsd"}],
+  "node": {"id": "sidecar"},
+  "overload_manager": {"refresh_interval": "0.25s"}
+}`,
+		},
+		{
+			name:     "nested scalar replaced",
+			override: `{"admin": {"address": {"socket_address": {"port_value": 15001}}}}`,
+			want: `{
+  "admin": {"address": {"socket_address": {"address": "127.0.0.1", "port_value": 15001}}},
+  "stats_sinks": [{"name": "envoy.statsd"}],
+  "node": {"id": "sidecar"}
+}`,
+		},
+		{
+			name:     "list appended",
+			override: `{"stats_sinks": [{"name": "envoy.dog_statsd"}]}`,
+			want: `{
+  "admin": {"address": {"socket_address": {"address": "127.0.0.1", "port_value": 15000}}},
+  "stats_sinks": [{"name": "envoy.statsd"}, {"name": "envoy.dog_statsd"}],
+  "node": {"id": "sidecar"}
+}`,
+		},
+		{
+			name:     "invalid override",
+			override: `[`,
+			wantErr:  true,
+		},
+		{
+			name:     "non object override",
+			override: `- a`,
+			wantErr:  true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := mergeOverride([]byte(bootstrap), []byte(c.override))
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertJSONEqual(t, got, []byte(c.want))
+		})
+	}
+}
+
+func TestMergeOverrideLargeIntegers(t *testing.T) {
+	// Both integers are above 2^53 and can't be represented exactly as float64.
+	bootstrap := `{"overload_manager": {"resource_monitors": [{"config": {"max_heap_size_bytes": 9007199254740993}}]}}`
+	override := "layered_runtime:\n  max_requests_per_connection: 18014398509481985\n"
+	got, err := mergeOverride([]byte(bootstrap), []byte(override))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSONEqual(t, got, []byte(`{
+  "overload_manager": {"resource_monitors": [{"config": {"max_heap_size_bytes": 9007199254740993}}]},
+  "layered_runtime": {"max_requests_per_connection": 18014398509481985}
+}`))
+}
+
+func TestApplyOverrideFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bootstrap-override")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	bootstrap := []byte(`{"node": {"id": "sidecar"}}`)
+
+	got, err := applyOverrideFile(bootstrap, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(bootstrap) {
+		t.Errorf("bootstrap modified without override: %s", got)
+	}
+
+	if _, err := applyOverrideFile(bootstrap, filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("expected error for missing override file")
+	}
+
+	overrideFile := filepath.Join(dir, "custom_bootstrap.json")
+	if err := ioutil.WriteFile(overrideFile, []byte(`{"node": {"cluster": "foo"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err = applyOverrideFile(bootstrap, overrideFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSONEqual(t, got, []byte(`{"node": {"id": "sidecar", "cluster": "foo"}}`))
+}
+
+func assertJSONEqual(t *testing.T, got, want []byte) {
+	t.Helper()
+	gotV, err := decodeObject(got)
+	if err != nil {
+		t.Fatalf("invalid json %s: %v", got, err)
+	}
+	wantV, err := decodeObject(want)
+	if err != nil {
+		t.Fatalf("invalid json %s: %v", want, err)
+	}
+	if !reflect.DeepEqual(gotV, wantV) {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteBootstrapWithOverride(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bootstrap-override")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cfg, err := loadProxyConfig("default", dir, t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Overrides are only applied to the bootstrap generated from the template.
+	cfg.ProxyBootstrapTemplatePath, cfg.CustomConfigFile = cfg.CustomConfigFile, ""
+	defer os.Unsetenv(OverrideFileVar.Name)
+
+	overrideFile := filepath.Join(dir, "custom_bootstrap.yaml")
+	if err := ioutil.WriteFile(overrideFile, []byte("overload_manager:\n  refresh_interval: 0.25s\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		name     string
+		override string
+		wantErr  bool
+	}{
+		{name: "override merged", override: overrideFile},
+		{name: "missing override", override: filepath.Join(dir, "missing.yaml"), wantErr: true},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			_ = os.Setenv(OverrideFileVar.Name, c.override)
+			fn, err := writeBootstrapForPlatform(cfg, "sidecar~1.2.3.4~foo~bar", 0, nil, nil, nil,
+				[]string{"10.3.3.3"}, "60s", &fakePlatform{})
+			if c.wantErr {
+				if err == nil {
+					t.Fatal("expected error for an override which fails to merge")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ioutil.ReadFile(fn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			bootstrap := map[string]interface{}{}
+			if err := json.Unmarshal(got, &bootstrap); err != nil {
+				t.Fatalf("invalid merged bootstrap: %v", err)
+			}
+			if !reflect.DeepEqual(bootstrap["overload_manager"], map[string]interface{}{"refresh_interval": "0.25s"}) ||
+				bootstrap["node"] == nil {
+				t.Errorf("override not merged into the generated bootstrap:\n%s", got)
+			}
+		})
+	}
+}
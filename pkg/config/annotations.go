@@ -0,0 +1,81 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"istio.io/api/annotation"
+)
+
+// Alpha annotations understood by Pilot on Istio config resources. These cover settings that
+// are not yet part of the istio.io/api protos; once the corresponding fields land upstream the
+// annotations should be deprecated in favor of the API.
+var (
+	// AlphaNetworkingMirrorPercent is set on a VirtualService to mirror only a fraction of the
+	// traffic matched by its HTTP routes to the mirror destination.
+	AlphaNetworkingMirrorPercent = annotation.Instance{
+		Name: "networking.alpha.istio.io/mirrorPercent",
+		Description: "Percentage (0.0 - 100.0) of requests mirrored to the mirror " +
+			"destination of every HTTP route in the VirtualService. Defaults to 100. " +
+			"NOTE This API is Alpha and has no stability guarantees.",
+		Hidden:     true,
+		Deprecated: false,
+	}
+
+	// AlphaNetworkingIdleTimeout is set on a VirtualService to override the stream idle timeout
+	// of its HTTP routes, e.g. for long-polling or streaming gRPC services.
+	AlphaNetworkingIdleTimeout = annotation.Instance{
+		Name: "networking.alpha.istio.io/idleTimeout",
+		Description: "Idle timeout applied to every HTTP route in the VirtualService, " +
+			"overriding the stream idle timeout of the connection manager. Format: " +
+			"1h/1m/1s/1ms; 0s disables the timeout. NOTE This API is Alpha and has " +
+			"no stability guarantees.",
+		Hidden:     true,
+		Deprecated: false,
+	}
+
+	// AlphaWorkloadSelector is set on a ProxyConfig to restrict it to the workloads of its
+	// namespace carrying the given labels.
+	AlphaWorkloadSelector = annotation.Instance{
+		Name: "networking.alpha.istio.io/workloadSelector",
+		Description: "Comma separated list of key=value labels selecting the workloads " +
+			"the resource applies to. Without it the resource applies to all workloads " +
+			"in its namespace. NOTE This API is Alpha and has no stability guarantees.",
+		Hidden:     true,
+		Deprecated: false,
+	}
+
+	// AlphaProxyEnvironment is set on a ProxyConfig to add environment variables to the
+	// injected proxy container of the selected workloads.
+	AlphaProxyEnvironment = annotation.Instance{
+		Name: "proxy.alpha.istio.io/environment",
+		Description: "Comma separated list of NAME=value environment variables set on " +
+			"the injected proxy container. NOTE This API is Alpha and has no stability " +
+			"guarantees.",
+		Hidden:     true,
+		Deprecated: false,
+	}
+
+	// InjectTemplates is set on a pod to inject it with a named template of the sidecar injector
+	// instead of the sidecar template, e.g. "gateway" to run the pod as a gateway.
+	InjectTemplates = annotation.Instance{
+		Name: "inject.istio.io/templates",
+		Description: "Name of the sidecar injector template used to inject the pod, " +
+			"e.g. gateway. Pods with this annotation are injected unless " +
+			"sidecar.istio.io/inject is false. NOTE This API is Alpha and has no " +
+			"stability guarantees.",
+		Hidden:     true,
+		Deprecated: false,
+	}
+)
@@ -74,6 +74,23 @@ const (
 	// IstioIngressNamespace is the namespace where Istio ingress controller is deployed
 	IstioIngressNamespace = "istio-system"
 
+	// RevisionLabel targets a config resource at the control plane revision named by its value.
+	RevisionLabel = "istio.io/rev"
+
+	// DefaultRevision is the revision of a control plane installed without an explicit revision.
+	DefaultRevision = "default"
+
+	// TLSModeLabel overrides the TLS capability of a workload, which is otherwise derived from the
+	// presence of an injected sidecar. The value is one of IstioMutualTLSModeLabel and
+	// DisabledTLSModeLabel.
+	TLSModeLabel = "security.istio.io/tlsMode"
+
+	// IstioMutualTLSModeLabel marks a workload accepting Istio mutual TLS.
+	IstioMutualTLSModeLabel = "istio"
+
+	// DisabledTLSModeLabel marks a workload only accepting plaintext, e.g. without a sidecar.
+	DisabledTLSModeLabel = "disabled"
+
 	// IstioLabel indicates that a workload is part of a named Istio system component.
 	IstioLabel = "istio"
 
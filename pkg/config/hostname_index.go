@@ -0,0 +1,202 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"strings"
+)
+
+// HostnameIndex indexes a set of (possibly wildcarded) hostnames by their labels, from the
+// rightmost one, so that the hostnames matching a given hostname are found in time proportional
+// to its number of labels rather than to the size of the set.
+//
+// A wildcarded hostname "*.foo.com" is indexed at the node of "foo.com": it matches the
+// hostnames below that node, and the wildcarded hostnames on the path to that node or below it.
+type HostnameIndex struct {
+	root *hostnameNode
+	// irregular holds the wildcarded hostnames whose wildcard is not a whole label, e.g. "*foo.com".
+	// They are not valid in configs, but are still matched, by scanning.
+	irregular Hostnames
+}
+
+type hostnameNode struct {
+	children map[string]*hostnameNode
+	// exact is the hostname made of the labels leading to this node, if indexed.
+	exact *Hostname
+	// wildcard is the wildcarded hostname of this node ("*." followed by the labels leading to
+	// this node, or "*" for the root), if indexed.
+	wildcard *Hostname
+	// bestExact and bestWildcard are the most specific hostnames indexed below this node.
+	bestExact    *Hostname
+	bestWildcard *Hostname
+}
+
+// NewHostnameIndex returns an index of the hostnames.
+func NewHostnameIndex(hosts []Hostname) *HostnameIndex {
+	index := &HostnameIndex{root: &hostnameNode{}}
+	for _, h := range hosts {
+		index.Insert(h)
+	}
+	return index
+}
+
+// Insert adds the hostname to the index.
+func (i *HostnameIndex) Insert(h Hostname) {
+	labels, wildcard, regular := splitHostname(h)
+	if !regular {
+		i.irregular = append(i.irregular, h)
+		return
+	}
+
+	n := i.root
+	for _, label := range labels {
+		if wildcard {
+			n.bestWildcard = moreSpecific(n.bestWildcard, &h)
+		} else {
+			n.bestExact = moreSpecific(n.bestExact, &h)
+		}
+		child, ok := n.children[label]
+		if !ok {
+			if n.children == nil {
+				n.children = make(map[string]*hostnameNode)
+			}
+			child = &hostnameNode{}
+			n.children[label] = child
+		}
+		n = child
+	}
+	if wildcard {
+		n.wildcard = &h
+	} else {
+		n.exact = &h
+	}
+}
+
+// MostSpecificMatch returns the most specific indexed hostname matching the needle, following
+// the order of Hostnames, or false if no indexed hostname matches the needle. It returns the same
+// hostname as scanning the sorted hostnames for the first one matching the needle.
+func (i *HostnameIndex) MostSpecificMatch(needle Hostname) (Hostname, bool) {
+	if i == nil {
+		return "", false
+	}
+
+	var best *Hostname
+	if labels, wildcard, regular := splitHostname(needle); regular {
+		best = i.root.match(labels, wildcard)
+	} else {
+		// An irregular needle is compared with every indexed hostname.
+		i.root.walk(func(h *Hostname) {
+			if needle.Matches(*h) {
+				best = moreSpecific(best, h)
+			}
+		})
+	}
+	for j := range i.irregular {
+		if needle.Matches(i.irregular[j]) {
+			best = moreSpecific(best, &i.irregular[j])
+		}
+	}
+
+	if best == nil {
+		return "", false
+	}
+	return *best, true
+}
+
+// match returns the most specific hostname indexed below the node matching the labels.
+func (n *hostnameNode) match(labels []string, wildcard bool) *Hostname {
+	// Deepest wildcard on the path to the node of the labels, excluding that node.
+	var ancestor *Hostname
+	for _, label := range labels {
+		if n.wildcard != nil {
+			ancestor = n.wildcard
+		}
+		child, ok := n.children[label]
+		if !ok {
+			return ancestor
+		}
+		n = child
+	}
+
+	if !wildcard {
+		if n.exact != nil {
+			return n.exact
+		}
+		return ancestor
+	}
+
+	// A wildcarded needle matches everything below its node, and the wildcards on its path.
+	if n.bestExact != nil {
+		return n.bestExact
+	}
+	if n.bestWildcard != nil {
+		return n.bestWildcard
+	}
+	if n.wildcard != nil {
+		return n.wildcard
+	}
+	return ancestor
+}
+
+func (n *hostnameNode) walk(fn func(h *Hostname)) {
+	if n.exact != nil {
+		fn(n.exact)
+	}
+	if n.wildcard != nil {
+		fn(n.wildcard)
+	}
+	for _, child := range n.children {
+		child.walk(fn)
+	}
+}
+
+// splitHostname returns the labels of the hostname from the rightmost one, excluding the
+// wildcard, whether the hostname is wildcarded, and false if the wildcard is not a whole label.
+func splitHostname(h Hostname) ([]string, bool, bool) {
+	s := string(h)
+	wildcard := h.isWildcard()
+	if wildcard {
+		if s == "*" {
+			return nil, true, true
+		}
+		if !strings.HasPrefix(s, "*.") {
+			return nil, true, false
+		}
+		s = s[2:]
+	}
+	labels := strings.Split(s, ".")
+	for l, r := 0, len(labels)-1; l < r; l, r = l+1, r-1 {
+		labels[l], labels[r] = labels[r], labels[l]
+	}
+	return labels, wildcard, true
+}
+
+func (h Hostname) isWildcard() bool {
+	return len(h) > 0 && h[0] == '*'
+}
+
+// moreSpecific returns the hostname sorted first by Hostnames.
+func moreSpecific(a, b *Hostname) *Hostname {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	if *a == *b || Hostnames([]Hostname{*a, *b}).Less(0, 1) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,93 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+)
+
+// scanMatch is the linear scan the index replaces.
+func scanMatch(needle Hostname, sorted Hostnames) (Hostname, bool) {
+	for _, h := range sorted {
+		if needle.Matches(h) {
+			return h, true
+		}
+	}
+	return "", false
+}
+
+func TestHostnameIndexMostSpecificMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		needle Hostname
+		in     Hostnames
+		want   Hostname
+		found  bool
+	}{
+		{"empty", "foo.com", nil, "", false},
+		{"exact", "foo.com", Hostnames{"bar.com", "foo.com"}, "foo.com", true},
+		{"exact over wildcard", "foo.com", Hostnames{"*.com", "*", "foo.com"}, "foo.com", true},
+		{"longest wildcard", "a.foo.com", Hostnames{"*", "*.com", "*.foo.com"}, "*.foo.com", true},
+		{"wildcard does not match its domain", "foo.com", Hostnames{"*.foo.com"}, "", false},
+		{"catch all", "foo.com", Hostnames{"*", "bar.com"}, "*", true},
+		{"wildcard needle prefers hosts", "*.com", Hostnames{"*.foo.com", "a.com", "bb.com"}, "bb.com", true},
+		{"wildcard needle alphabetical", "*.com", Hostnames{"ab.com", "aa.com"}, "aa.com", true},
+		{"wildcard needle longer wildcard", "*.com", Hostnames{"*", "*.com", "*.foo.com"}, "*.foo.com", true},
+		{"wildcard needle itself", "*.foo.com", Hostnames{"*", "*.foo.com", "foo.com"}, "*.foo.com", true},
+		{"wildcard needle ancestor", "*.foo.com", Hostnames{"*", "*.com", "foo.com"}, "*.com", true},
+		{"catch all needle", "*", Hostnames{"*.com", "foo.com"}, "foo.com", true},
+		{"irregular wildcard", "barfoo.com", Hostnames{"*.com", "*foo.com"}, "*foo.com", true},
+		{"irregular needle", "*foo.com", Hostnames{"*.com", "barfoo.com"}, "barfoo.com", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := NewHostnameIndex(tt.in).MostSpecificMatch(tt.needle)
+			if got != tt.want || found != tt.found {
+				t.Errorf("MostSpecificMatch(%q) in %v = %q, %t; want %q, %t", tt.needle, tt.in, got, found, tt.want, tt.found)
+			}
+		})
+	}
+}
+
+func TestHostnameIndexMatchesScan(t *testing.T) {
+	var hosts Hostnames
+	for _, domain := range []string{"com", "foo.com", "bar.foo.com", "svc.cluster.local", "ns.svc.cluster.local"} {
+		hosts = append(hosts, Hostname(domain), Hostname("*."+domain))
+		for i := 0; i < 3; i++ {
+			hosts = append(hosts, Hostname(fmt.Sprintf("s%d.%s", i, domain)))
+		}
+	}
+	needles := append(Hostnames{"*", "other.org", "*.org", "*oo.com"}, hosts...)
+
+	for n := range hosts {
+		// Check all the prefixes of the set, with and without the catch all.
+		in := append(Hostnames{}, hosts[:n]...)
+		for _, set := range []Hostnames{in, append(in, "*")} {
+			index := NewHostnameIndex(set)
+			sorted := append(Hostnames{}, set...)
+			sort.Sort(sorted)
+			for _, needle := range needles {
+				want, wantFound := scanMatch(needle, sorted)
+				got, found := index.MostSpecificMatch(needle)
+				if got != want || found != wantFound {
+					t.Fatalf("MostSpecificMatch(%q) in %v = %q, %t; want %q, %t", needle, set, got, found, want, wantFound)
+				}
+			}
+		}
+	}
+}
@@ -113,3 +113,17 @@ func ParseLabelsString(s string) Labels {
 	}
 	return tag
 }
+
+// InRevision returns true if a resource with the given labels is processed by the control plane
+// revision. Resources labeled with RevisionLabel are processed by the matching revision only,
+// while unlabeled resources are processed by the default revision.
+func InRevision(labels map[string]string, revision string) bool {
+	if revision == "" {
+		revision = DefaultRevision
+	}
+	rev, ok := labels[RevisionLabel]
+	if !ok || rev == "" {
+		return revision == DefaultRevision
+	}
+	return rev == revision
+}
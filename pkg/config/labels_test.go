@@ -62,3 +62,26 @@ func TestLabels(t *testing.T) {
 		}
 	}
 }
+
+func TestInRevision(t *testing.T) {
+	cases := []struct {
+		labels   map[string]string
+		revision string
+		want     bool
+	}{
+		{nil, "", true},
+		{nil, DefaultRevision, true},
+		{nil, "canary", false},
+		{map[string]string{RevisionLabel: ""}, DefaultRevision, true},
+		{map[string]string{RevisionLabel: "canary"}, "canary", true},
+		{map[string]string{RevisionLabel: "canary"}, DefaultRevision, false},
+		{map[string]string{RevisionLabel: "canary"}, "", false},
+		{map[string]string{RevisionLabel: DefaultRevision}, "", true},
+		{map[string]string{RevisionLabel: DefaultRevision}, "canary", false},
+	}
+	for _, c := range cases {
+		if got := InRevision(c.labels, c.revision); got != c.want {
+			t.Errorf("InRevision(%v, %q) => got %v, want %v", c.labels, c.revision, got, c.want)
+		}
+	}
+}
@@ -25,6 +25,7 @@ package config
 import (
 	"time"
 
+	"github.com/gogo/protobuf/proto"
 	"github.com/gogo/protobuf/types"
 	"github.com/hashicorp/go-multierror"
 
@@ -83,20 +84,32 @@ func DefaultMeshConfig() meshconfig.MeshConfig {
 // ApplyMeshConfigDefaults returns a new MeshConfig decoded from the
 // input YAML with defaults applied to omitted configuration values.
 func ApplyMeshConfigDefaults(yaml string) (*meshconfig.MeshConfig, error) {
-	out := DefaultMeshConfig()
+	return ApplyMeshConfig(yaml, DefaultMeshConfig())
+}
+
+// ApplyMeshConfig returns a new MeshConfig decoded from the input YAML on top of
+// the base config, e.g. a revision overlay on top of the mesh ConfigMap. Omitted
+// configuration values keep the value of the base, including the values of the
+// default ProxyConfig.
+func ApplyMeshConfig(yaml string, base meshconfig.MeshConfig) (*meshconfig.MeshConfig, error) {
+	out := *proto.Clone(&base).(*meshconfig.MeshConfig)
+	baseProxyConfig := out.DefaultConfig
+	if baseProxyConfig == nil {
+		defaultProxyConfig := DefaultProxyConfig()
+		baseProxyConfig = &defaultProxyConfig
+	}
 	if err := ApplyYAML(yaml, &out); err != nil {
 		return nil, multierror.Prefix(err, "failed to convert to proto.")
 	}
 
-	// Reset the default ProxyConfig as jsonpb.UnmarshalString doesn't
+	// Reset the base ProxyConfig as jsonpb.UnmarshalString doesn't
 	// handled nested decode properly for our use case.
 	prevDefaultConfig := out.DefaultConfig
-	defaultProxyConfig := DefaultProxyConfig()
-	out.DefaultConfig = &defaultProxyConfig
+	out.DefaultConfig = proto.Clone(baseProxyConfig).(*meshconfig.ProxyConfig)
 
-	// Re-apply defaults to ProxyConfig if they were defined in the
+	// Re-apply the ProxyConfig values if they were defined in the
 	// original input MeshConfig.ProxyConfig.
-	if prevDefaultConfig != nil {
+	if prevDefaultConfig != nil && prevDefaultConfig != baseProxyConfig {
 		origProxyConfigYAML, err := ToYAML(prevDefaultConfig)
 		if err != nil {
 			return nil, multierror.Prefix(err, "failed to re-encode default proxy config")
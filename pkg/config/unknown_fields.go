@@ -0,0 +1,114 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/gogo/protobuf/proto"
+)
+
+// wellKnownType is implemented by the well known protobuf types, whose JSON representation is
+// not made of their fields.
+type wellKnownType interface {
+	XXX_WellKnownType() string
+}
+
+// UnknownFields returns the fields of the JSON object that are not fields of the proto message
+// or of its nested messages, as sorted paths from the object root, e.g. "trafficPolicy.tlss" or
+// "http[0].rout". ApplyJSON silently discards such fields.
+//
+// Fields in the allowlist, given as the full name of their message followed by the field name
+// (e.g. "istio.networking.v1alpha3.TrafficPolicy.tls"), are not reported. This allows configs
+// to set fields added by newer versions of the API.
+func UnknownFields(obj map[string]interface{}, pb proto.Message, allowlist map[string]bool) []string {
+	var unknown []string
+	unknownFields(obj, reflect.TypeOf(pb), "", allowlist, &unknown)
+	sort.Strings(unknown)
+	return unknown
+}
+
+// unknownFields adds the unknown fields of the JSON object for the message type t to unknown.
+func unknownFields(obj map[string]interface{}, t reflect.Type, path string, allowlist map[string]bool, unknown *[]string) {
+	if t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return
+	}
+	msg, ok := reflect.New(t.Elem()).Interface().(proto.Message)
+	if !ok {
+		return
+	}
+	if _, ok := msg.(wellKnownType); ok {
+		return
+	}
+	name := proto.MessageName(msg)
+
+	props := proto.GetProperties(t.Elem())
+	fields := make(map[string]reflect.Type)
+	for i, p := range props.Prop {
+		if p.OrigName == "" {
+			// XXX_ fields and oneof interfaces
+			continue
+		}
+		fields[p.OrigName] = t.Elem().Field(i).Type
+		if p.JSONName != "" {
+			fields[p.JSONName] = t.Elem().Field(i).Type
+		}
+	}
+	for _, oneof := range props.OneofTypes {
+		fieldType := oneof.Type.Elem().Field(0).Type
+		fields[oneof.Prop.OrigName] = fieldType
+		if oneof.Prop.JSONName != "" {
+			fields[oneof.Prop.JSONName] = fieldType
+		}
+	}
+
+	for key, value := range obj {
+		fieldPath := key
+		if path != "" {
+			fieldPath = path + "." + key
+		}
+		fieldType, ok := fields[key]
+		if !ok {
+			if !allowlist[name+"."+key] {
+				*unknown = append(*unknown, fieldPath)
+			}
+			continue
+		}
+		unknownValueFields(value, fieldType, fieldPath, allowlist, unknown)
+	}
+}
+
+// unknownValueFields adds the unknown fields of the JSON value of a field of type t to unknown.
+func unknownValueFields(value interface{}, t reflect.Type, path string, allowlist map[string]bool, unknown *[]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if t.Kind() == reflect.Map {
+			for k, elem := range v {
+				unknownValueFields(elem, t.Elem(), fmt.Sprintf("%s[%s]", path, k), allowlist, unknown)
+			}
+			return
+		}
+		unknownFields(v, t, path, allowlist, unknown)
+	case []interface{}:
+		if t.Kind() != reflect.Slice {
+			return
+		}
+		for i, elem := range v {
+			unknownValueFields(elem, t.Elem(), fmt.Sprintf("%s[%d]", path, i), allowlist, unknown)
+		}
+	}
+}
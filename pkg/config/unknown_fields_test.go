@@ -0,0 +1,119 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ghodss/yaml"
+
+	networking "istio.io/api/networking/v1alpha3"
+)
+
+func TestUnknownFields(t *testing.T) {
+	cases := []struct {
+		name      string
+		spec      string
+		allowlist map[string]bool
+		want      []string
+	}{
+		{
+			name: "known fields",
+			spec: `
+hosts: [reviews]
+http:
+- match:
+  - uri:
+      prefix: /v1
+    headers:
+      end-user:
+        exact: jason
+  route:
+  - destination:
+      host: reviews
+      subset: v1
+  timeout: 1s
+  headers:
+    request:
+      add:
+        foo: bar
+`,
+		},
+		{
+			name: "original field names",
+			spec: `
+hosts: [reviews]
+http:
+- route:
+  - destination:
+      host: reviews
+  cors_policy:
+    allow_origin: ["*"]
+`,
+		},
+		{
+			name: "unknown fields",
+			spec: `
+hosts: [reviews]
+tlss: []
+http:
+- match:
+  - uri:
+      prefixx: /v1
+    headers:
+      end-user:
+        exactt: jason
+  route:
+  - destination:
+      host: reviews
+    wieght: 10
+`,
+			want: []string{
+				"http[0].match[0].headers[end-user].exactt",
+				"http[0].match[0].uri.prefixx",
+				"http[0].route[0].wieght",
+				"tlss",
+			},
+		},
+		{
+			name: "allowlist",
+			spec: `
+hosts: [reviews]
+newField: true
+http:
+- route:
+  - destination:
+      host: reviews
+    wieght: 10
+`,
+			allowlist: map[string]bool{"istio.networking.v1alpha3.VirtualService.newField": true},
+			want:      []string{"http[0].route[0].wieght"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			spec := map[string]interface{}{}
+			if err := yaml.Unmarshal([]byte(c.spec), &spec); err != nil {
+				t.Fatal(err)
+			}
+			got := UnknownFields(spec, &networking.VirtualService{}, c.allowlist)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("UnknownFields() => got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
@@ -1107,6 +1107,51 @@ func ValidateMeshConfig(mesh *meshconfig.MeshConfig) (errs error) {
 	return
 }
 
+// ValidateWorkloadProxyConfig checks that a ProxyConfig resource is well-formed. Unlike
+// ValidateProxyConfig, all fields are optional since the resource only overrides the mesh defaults.
+func ValidateWorkloadProxyConfig(_, _ string, msg proto.Message) (errs error) {
+	config, ok := msg.(*meshconfig.ProxyConfig)
+	if !ok {
+		return fmt.Errorf("cannot cast to ProxyConfig")
+	}
+
+	if config.Concurrency < 0 {
+		errs = multierror.Append(errs, fmt.Errorf("concurrency must be non-negative: %d", config.Concurrency))
+	}
+
+	if config.DiscoveryAddress != "" {
+		if err := ValidateProxyAddress(config.DiscoveryAddress); err != nil {
+			errs = multierror.Append(errs, multierror.Prefix(err, "invalid discovery address:"))
+		}
+	}
+
+	if tracer := config.GetTracing().GetLightstep(); tracer != nil {
+		if err := ValidateLightstepCollector(tracer); err != nil {
+			errs = multierror.Append(errs, multierror.Prefix(err, "invalid lightstep config:"))
+		}
+	}
+
+	if tracer := config.GetTracing().GetZipkin(); tracer != nil {
+		if err := ValidateZipkinCollector(tracer); err != nil {
+			errs = multierror.Append(errs, multierror.Prefix(err, "invalid zipkin config:"))
+		}
+	}
+
+	if tracer := config.GetTracing().GetDatadog(); tracer != nil {
+		if err := ValidateDatadogCollector(tracer); err != nil {
+			errs = multierror.Append(errs, multierror.Prefix(err, "invalid datadog config:"))
+		}
+	}
+
+	if config.ConnectTimeout != nil {
+		if err := ValidateConnectTimeout(config.ConnectTimeout); err != nil {
+			errs = multierror.Append(errs, multierror.Prefix(err, "invalid connect timeout:"))
+		}
+	}
+
+	return
+}
+
 // ValidateProxyConfig checks that the mesh config is well-formed
 func ValidateProxyConfig(config *meshconfig.ProxyConfig) (errs error) {
 	if config.ConfigPath == "" {
@@ -374,6 +374,32 @@ func TestValidateMeshConfig(t *testing.T) {
 	}
 }
 
+func TestValidateWorkloadProxyConfig(t *testing.T) {
+	cases := []struct {
+		name    string
+		in      proto.Message
+		isValid bool
+	}{
+		{name: "empty", in: &meshconfig.ProxyConfig{}, isValid: true},
+		{name: "concurrency", in: &meshconfig.ProxyConfig{Concurrency: 2}, isValid: true},
+		{name: "negative concurrency", in: &meshconfig.ProxyConfig{Concurrency: -1}, isValid: false},
+		{name: "invalid discovery address", in: &meshconfig.ProxyConfig{DiscoveryAddress: "10.0.0.100"}, isValid: false},
+		{
+			name: "invalid zipkin",
+			in: &meshconfig.ProxyConfig{Tracing: &meshconfig.Tracing{
+				Tracer: &meshconfig.Tracing_Zipkin_{Zipkin: &meshconfig.Tracing_Zipkin{Address: "zipkin"}},
+			}},
+			isValid: false,
+		},
+		{name: "wrong type", in: &networking.Sidecar{}, isValid: false},
+	}
+	for _, c := range cases {
+		if got := ValidateWorkloadProxyConfig("", "", c.in); (got == nil) != c.isValid {
+			t.Errorf("%s: got error %v, want valid %v", c.name, got, c.isValid)
+		}
+	}
+}
+
 func TestValidateProxyConfig(t *testing.T) {
 	valid := &meshconfig.ProxyConfig{
 		ConfigPath:                   "/etc/istio/proxy",
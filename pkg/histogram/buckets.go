@@ -0,0 +1,61 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package histogram holds the helpers shared by the components configuring the bucket boundaries
+// of their histograms.
+package histogram
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseBuckets parses the bucket boundaries of histograms, as a semicolon separated list of
+// <metric>=<bound>,<bound>,...
+func ParseBuckets(spec string) (map[string][]float64, error) {
+	out := make(map[string][]float64)
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("invalid histogram buckets %q: expected <metric>=<bound>,<bound>,...", entry)
+		}
+		bounds, err := ParseBounds(kv[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid histogram buckets of %s: %v", kv[0], err)
+		}
+		out[strings.TrimSpace(kv[0])] = bounds
+	}
+	return out, nil
+}
+
+// ParseBounds parses a comma separated list of increasing bucket boundaries.
+func ParseBounds(s string) ([]float64, error) {
+	var bounds []float64
+	for _, b := range strings.Split(s, ",") {
+		bound, err := strconv.ParseFloat(strings.TrimSpace(b), 64)
+		if err != nil {
+			return nil, err
+		}
+		if len(bounds) > 0 && bound <= bounds[len(bounds)-1] {
+			return nil, fmt.Errorf("bounds must be increasing: %v after %v", bound, bounds[len(bounds)-1])
+		}
+		bounds = append(bounds, bound)
+	}
+	return bounds, nil
+}
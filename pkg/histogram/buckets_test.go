@@ -0,0 +1,52 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package histogram
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseBuckets(t *testing.T) {
+	got, err := ParseBuckets("pilot_proxy_convergence_time=0.01, 0.1,1; grpc_server_handling_seconds=0.5;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]float64{
+		"pilot_proxy_convergence_time": {0.01, 0.1, 1},
+		"grpc_server_handling_seconds": {0.5},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseBuckets() => got %v, want %v", got, want)
+	}
+
+	for _, spec := range []string{"pilot_proxy_queue_time", "=1,2", "pilot_proxy_queue_time=1,a", "pilot_proxy_queue_time=2,1"} {
+		if _, err := ParseBuckets(spec); err == nil {
+			t.Errorf("ParseBuckets(%q) => got no error", spec)
+		}
+	}
+}
+
+func TestParseBounds(t *testing.T) {
+	got, err := ParseBounds("0.5, 1,5")
+	if err != nil || !reflect.DeepEqual(got, []float64{0.5, 1, 5}) {
+		t.Errorf("ParseBounds() => got %v, %v, want [0.5 1 5]", got, err)
+	}
+	for _, s := range []string{"", "1,a", "2,1", "1,1"} {
+		if _, err := ParseBounds(s); err == nil {
+			t.Errorf("ParseBounds(%q) => got no error", s)
+		}
+	}
+}
@@ -44,6 +44,12 @@ type Options struct {
 	// MaxServerConnectionAgeGrace is an additive period after MaxServerConnectionAge
 	// after which the connection will be forcibly closed by the server.
 	MaxServerConnectionAgeGrace time.Duration // default value 10s
+	// MinClientPingInterval is the minimum amount of time a client should wait before sending a
+	// keepalive ping. Clients pinging more often are disconnected by the server with a GoAway.
+	MinClientPingInterval time.Duration // default value 5m, as in grpc
+	// PermitPingWithoutStream allows the clients to send keepalive pings when there are no
+	// active streams.
+	PermitPingWithoutStream bool
 }
 
 // DefaultOption returns the default keepalive options.
@@ -53,6 +59,7 @@ func DefaultOption() *Options {
 		Timeout:                     10 * time.Second,
 		MaxServerConnectionAge:      Infinity,
 		MaxServerConnectionAgeGrace: 10 * time.Second,
+		MinClientPingInterval:       5 * time.Minute,
 	}
 }
 
@@ -68,4 +75,11 @@ func (o *Options) AttachCobraFlags(cmd *cobra.Command) {
 			"and if no activity is seen even after that the connection is closed.")
 	cmd.PersistentFlags().DurationVar(&o.MaxServerConnectionAge, "keepaliveMaxServerConnectionAge",
 		o.MaxServerConnectionAge, "Maximum duration a connection will be kept open on the server before a graceful close.")
+	cmd.PersistentFlags().DurationVar(&o.MaxServerConnectionAgeGrace, "keepaliveMaxServerConnectionAgeGrace",
+		o.MaxServerConnectionAgeGrace, "Grace period after the maximum connection age before the connection is forcibly closed.")
+	cmd.PersistentFlags().DurationVar(&o.MinClientPingInterval, "keepaliveMinClientPingInterval",
+		o.MinClientPingInterval, "Minimum interval between the keepalive pings of a client; clients pinging more often "+
+			"are disconnected by the server.")
+	cmd.PersistentFlags().BoolVar(&o.PermitPingWithoutStream, "keepalivePermitWithoutStream",
+		o.PermitPingWithoutStream, "Allow the clients to send keepalive pings when there are no active streams.")
 }
@@ -55,3 +55,27 @@ func TestSetConnectionAgeCommandlineOptions(t *testing.T) {
 		t.Errorf("%s maximum connection age %v", t.Name(), ko.MaxServerConnectionAge)
 	}
 }
+
+// Confirm the keepalive enforcement policy can be set from the command line.
+func TestSetEnforcementPolicyCommandlineOptions(t *testing.T) {
+	ko := keepalive.DefaultOption()
+	cmd := &cobra.Command{}
+	ko.AttachCobraFlags(cmd)
+
+	buf := new(bytes.Buffer)
+	cmd.SetOutput(buf)
+	sec := 10 * time.Second
+	cmd.SetArgs([]string{
+		fmt.Sprintf("--keepaliveMinClientPingInterval=%v", sec),
+		"--keepalivePermitWithoutStream",
+		fmt.Sprintf("--keepaliveMaxServerConnectionAgeGrace=%v", sec),
+	})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("%s %s", t.Name(), err.Error())
+	}
+	if ko.MinClientPingInterval != sec || !ko.PermitPingWithoutStream || ko.MaxServerConnectionAgeGrace != sec {
+		t.Errorf("%s enforcement policy %v %v, connection age grace %v", t.Name(),
+			ko.MinClientPingInterval, ko.PermitPingWithoutStream, ko.MaxServerConnectionAgeGrace)
+	}
+}
@@ -16,6 +16,7 @@ package secretcontroller
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -27,6 +28,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	"k8s.io/client-go/util/workqueue"
 
 	"istio.io/istio/pkg/kube"
@@ -36,6 +38,9 @@ import (
 const (
 	mcLabel    = "istio/multiCluster"
 	maxRetries = 5
+	// failoverAnnotation opts a secret in to using each context of its kubeconfigs as an API
+	// server endpoint of the remote cluster, instead of the current context only.
+	failoverAnnotation = "istio/multiClusterFailover"
 )
 
 // LoadKubeConfig is a unit test override variable for loading the k8s config.
@@ -51,6 +56,10 @@ var CreateInterfaceFromClusterConfig = kube.CreateInterfaceFromClusterConfig
 // addSecretCallback prototype for the add secret callback function.
 type addSecretCallback func(clientset kubernetes.Interface, dataKey string) error
 
+// addEndpointsSecretCallback prototype for the add secret callback function receiving the
+// clients of all the API server endpoints of the remote cluster, in order of preference.
+type addEndpointsSecretCallback func(clientsets []kubernetes.Interface, dataKey string) error
+
 // removeSecretCallback prototype for the remove secret callback function.
 type removeSecretCallback func(dataKey string) error
 
@@ -61,7 +70,7 @@ type Controller struct {
 	cs             *ClusterStore
 	queue          workqueue.RateLimitingInterface
 	informer       cache.SharedIndexInformer
-	addCallback    addSecretCallback
+	addCallback    addEndpointsSecretCallback
 	removeCallback removeSecretCallback
 }
 
@@ -88,7 +97,7 @@ func NewController(
 	kubeclientset kubernetes.Interface,
 	namespace string,
 	cs *ClusterStore,
-	addCallback addSecretCallback,
+	addCallback addEndpointsSecretCallback,
 	removeCallback removeSecretCallback) *Controller {
 
 	secretsInformer := cache.NewSharedIndexInformer(
@@ -161,6 +170,20 @@ func StartSecretController(k8s kubernetes.Interface,
 	addCallback addSecretCallback,
 	removeCallback removeSecretCallback,
 	namespace string) error {
+	return StartEndpointsSecretController(k8s, func(clientsets []kubernetes.Interface, dataKey string) error {
+		return addCallback(clientsets[0], dataKey)
+	}, removeCallback, namespace)
+}
+
+// StartEndpointsSecretController creates the secret controller, passing to the add callback the
+// clients of all the API server endpoints of each remote cluster. The current context of the
+// kubeconfig of a remote cluster is its only endpoint, unless the secret is annotated with
+// istio/multiClusterFailover: "true", in which case each context is an endpoint, starting with
+// the current context.
+func StartEndpointsSecretController(k8s kubernetes.Interface,
+	addCallback addEndpointsSecretCallback,
+	removeCallback removeSecretCallback,
+	namespace string) error {
 	stopCh := make(chan struct{})
 	clusterStore := newClustersStore()
 	controller := NewController(k8s, namespace, clusterStore, addCallback, removeCallback)
@@ -240,12 +263,24 @@ func (c *Controller) addMemberCluster(secretName string, s *corev1.Secret) {
 			log.Infof("Adding new cluster member: %s", clusterID)
 			c.cs.remoteClusters[clusterID] = &RemoteCluster{}
 			c.cs.remoteClusters[clusterID].secretName = secretName
-			client, err := CreateInterfaceFromClusterConfig(clientConfig)
-			if err != nil {
-				log.Errorf("error during create of kubernetes client interface for cluster: %s %v", clusterID, err)
+			configs := []*clientcmdapi.Config{clientConfig}
+			if s.Annotations[failoverAnnotation] == "true" {
+				configs = endpointConfigs(clientConfig)
+			}
+			var clients []kubernetes.Interface
+			for _, endpointConfig := range configs {
+				client, err := CreateInterfaceFromClusterConfig(endpointConfig)
+				if err != nil {
+					log.Errorf("error during create of kubernetes client interface for cluster: %s context: %s %v",
+						clusterID, endpointConfig.CurrentContext, err)
+					continue
+				}
+				clients = append(clients, client)
+			}
+			if len(clients) == 0 {
 				continue
 			}
-			err = c.addCallback(client, clusterID)
+			err = c.addCallback(clients, clusterID)
 			if err != nil {
 				log.Errorf("error during create of clusterID: %s %v", clusterID, err)
 			}
@@ -270,3 +305,29 @@ func (c *Controller) deleteMemberCluster(secretName string) {
 	}
 	log.Infof("Number of remote clusters: %d", len(c.cs.remoteClusters))
 }
+
+// endpointConfigs returns a config for each context of the kubeconfig, which are the API server
+// endpoints of the cluster, starting with the current context.
+func endpointConfigs(config *clientcmdapi.Config) []*clientcmdapi.Config {
+	contexts := make([]string, 0, len(config.Contexts))
+	for name := range config.Contexts {
+		if name != config.CurrentContext {
+			contexts = append(contexts, name)
+		}
+	}
+	if len(contexts) == 0 {
+		return []*clientcmdapi.Config{config}
+	}
+	sort.Strings(contexts)
+	if _, ok := config.Contexts[config.CurrentContext]; ok {
+		contexts = append([]string{config.CurrentContext}, contexts...)
+	}
+
+	configs := make([]*clientcmdapi.Config, 0, len(contexts))
+	for _, context := range contexts {
+		endpointConfig := config.DeepCopy()
+		endpointConfig.CurrentContext = context
+		configs = append(configs, endpointConfig)
+	}
+	return configs
+}
@@ -15,6 +15,7 @@
 package secretcontroller
 
 import (
+	"reflect"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -138,3 +139,69 @@ func Test_SecretController(t *testing.T) {
 		t.Fatalf("Test failed on delete secret, create callback function called")
 	}
 }
+
+func Test_EndpointConfigs(t *testing.T) {
+	single := &clientcmdapi.Config{}
+	if got := endpointConfigs(single); len(got) != 1 || got[0] != single {
+		t.Errorf("endpointConfigs() => got %v, want the config itself", got)
+	}
+
+	config := &clientcmdapi.Config{
+		CurrentContext: "b",
+		Contexts: map[string]*clientcmdapi.Context{
+			"c": {Cluster: "c"},
+			"a": {Cluster: "a"},
+			"b": {Cluster: "b"},
+		},
+	}
+	got := endpointConfigs(config)
+	var contexts []string
+	for _, c := range got {
+		contexts = append(contexts, c.CurrentContext)
+	}
+	if !reflect.DeepEqual(contexts, []string{"b", "a", "c"}) {
+		t.Errorf("endpointConfigs() => got contexts %v, want the current context first", contexts)
+	}
+	if config.CurrentContext != "b" {
+		t.Errorf("endpointConfigs() modified the config")
+	}
+}
+
+func Test_FailoverOptIn(t *testing.T) {
+	LoadKubeConfig = func(_ []byte) (*clientcmdapi.Config, error) {
+		return &clientcmdapi.Config{
+			CurrentContext: "a",
+			Contexts: map[string]*clientcmdapi.Context{
+				"a": {Cluster: "a"},
+				"b": {Cluster: "b"},
+			},
+		}, nil
+	}
+	ValidateClientConfig = mockValidateClientConfig
+	CreateInterfaceFromClusterConfig = mockCreateInterfaceFromClusterConfig
+
+	for _, tc := range []struct {
+		annotations map[string]string
+		endpoints   int
+	}{
+		{nil, 1},
+		{map[string]string{failoverAnnotation: "false"}, 1},
+		{map[string]string{failoverAnnotation: "true"}, 2},
+	} {
+		endpoints := 0
+		c := &Controller{
+			cs: newClustersStore(),
+			addCallback: func(clientsets []kubernetes.Interface, _ string) error {
+				endpoints = len(clientsets)
+				return nil
+			},
+		}
+		c.addMemberCluster(secretName, &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: secretNameSpace, Annotations: tc.annotations},
+			Data:       map[string][]byte{"testRemoteCluster": []byte("Test")},
+		})
+		if endpoints != tc.endpoints {
+			t.Errorf("addMemberCluster() with annotations %v => got %d endpoints, want %d", tc.annotations, endpoints, tc.endpoints)
+		}
+	}
+}
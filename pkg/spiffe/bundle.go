@@ -0,0 +1,102 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spiffe
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+)
+
+// X509SVIDUse is the use of the keys of a trust bundle which verify X.509 SVIDs.
+const X509SVIDUse = "x509-svid"
+
+// bundleDocument is the JWK set served by a SPIFFE bundle endpoint, as defined by the SPIFFE
+// Trust Domain and Bundle specification.
+type bundleDocument struct {
+	Keys        []jose.JSONWebKey `json:"keys"`
+	RefreshHint int64             `json:"spiffe_refresh_hint,omitempty"`
+}
+
+// MarshalBundle returns the SPIFFE bundle of the PEM root certificates of a trust domain. The
+// refresh hint, if set, tells the consumers how often to fetch the bundle.
+func MarshalBundle(rootCertsPEM []byte, refreshHint time.Duration) ([]byte, error) {
+	doc := bundleDocument{Keys: []jose.JSONWebKey{}, RefreshHint: int64(refreshHint / time.Second)}
+	for block, rest := pem.Decode(rootCertsPEM); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		doc.Keys = append(doc.Keys, jose.JSONWebKey{
+			Key:          cert.PublicKey,
+			Certificates: []*x509.Certificate{cert},
+			Use:          X509SVIDUse,
+		})
+	}
+	if len(doc.Keys) == 0 {
+		return nil, fmt.Errorf("no certificate found")
+	}
+	return json.Marshal(doc)
+}
+
+// ParseBundle returns the root certificates of the X.509 SVIDs held by a SPIFFE bundle. The keys
+// with another use, e.g. those verifying JWT SVIDs, are ignored.
+func ParseBundle(data []byte) ([]*x509.Certificate, error) {
+	doc := bundleDocument{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid SPIFFE bundle: %v", err)
+	}
+	var out []*x509.Certificate
+	for _, key := range doc.Keys {
+		if key.Use != X509SVIDUse {
+			continue
+		}
+		if len(key.Certificates) != 1 {
+			return nil, fmt.Errorf("invalid SPIFFE bundle: %s key with %d certificates, want 1", X509SVIDUse, len(key.Certificates))
+		}
+		out = append(out, key.Certificates[0])
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("invalid SPIFFE bundle: no %s key", X509SVIDUse)
+	}
+	return out, nil
+}
+
+// FetchBundle fetches the SPIFFE bundle served at the endpoint URL, and returns its root
+// certificates of X.509 SVIDs.
+func FetchBundle(client *http.Client, endpoint string) ([]*x509.Certificate, error) {
+	resp, err := client.Get(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: unexpected status %s", endpoint, resp.Status)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %v", endpoint, err)
+	}
+	return ParseBundle(data)
+}
@@ -0,0 +1,95 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spiffe
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func makeRootCert(t *testing.T, name string) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{Organization: []string{name}},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestMarshalParseBundle(t *testing.T) {
+	roots := append(makeRootCert(t, "a"), makeRootCert(t, "b")...)
+	data, err := MarshalBundle(roots, 5*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["spiffe_refresh_hint"] != float64(300) {
+		t.Errorf("MarshalBundle() => got refresh hint %v, want 300", doc["spiffe_refresh_hint"])
+	}
+
+	certs, err := ParseBundle(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(certs) != 2 || certs[0].Subject.Organization[0] != "a" || certs[1].Subject.Organization[0] != "b" {
+		t.Errorf("ParseBundle() => got %d certificates, want the roots a and b", len(certs))
+	}
+}
+
+func TestMarshalBundleNoCertificate(t *testing.T) {
+	if _, err := MarshalBundle([]byte("not a certificate"), 0); err == nil {
+		t.Error("MarshalBundle() => got no error without certificate")
+	}
+}
+
+func TestParseBundleIgnoresJWTKeys(t *testing.T) {
+	data, err := MarshalBundle(makeRootCert(t, "a"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwtOnly := strings.Replace(string(data), `"use":"`+X509SVIDUse+`"`, `"use":"jwt-svid"`, 1)
+	if _, err := ParseBundle([]byte(jwtOnly)); err == nil {
+		t.Error("ParseBundle() => got no error for a bundle without X.509 SVID keys")
+	}
+	if _, err := ParseBundle([]byte("{")); err == nil {
+		t.Error("ParseBundle() => got no error for an invalid bundle")
+	}
+}
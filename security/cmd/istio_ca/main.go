@@ -23,15 +23,18 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/cobra/doc"
+	"k8s.io/client-go/kubernetes"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"istio.io/istio/pkg/histogram"
 	kubelib "istio.io/istio/pkg/kube"
 	"istio.io/istio/pkg/spiffe"
 	"istio.io/istio/security/pkg/caclient"
 	"istio.io/istio/security/pkg/cmd"
 	"istio.io/istio/security/pkg/k8s/controller"
+	"istio.io/istio/security/pkg/k8s/csr"
 	"istio.io/istio/security/pkg/pki/ca"
 	probecontroller "istio.io/istio/security/pkg/probe"
 	"istio.io/istio/security/pkg/registry"
@@ -60,6 +63,10 @@ type cliOptions struct { // nolint: maligned
 
 	selfSignedCA        bool
 	selfSignedCACertTTL time.Duration
+	// The duration of each phase of the rotation of the self-signed root certificate.
+	rootRotationGracePeriod time.Duration
+	// The interval between two checks of the rotation of the self-signed root certificate.
+	rootRotationCheckInterval time.Duration
 
 	// if set, namespaces require explicit labeling to have Citadel generate secrets.
 	explicitOptInRequired bool
@@ -89,6 +96,8 @@ type cliOptions struct { // nolint: maligned
 	monitoringPort int
 	// Enable profiling in monitoring
 	enableProfiling bool
+	// Bucket boundaries of the grpc handling time histogram, comma separated
+	grpcHandlingTimeBuckets string
 
 	// The path to the file which indicates the liveness of the server by its existence.
 	// This will be used for k8s liveness probe. If empty, it does nothing.
@@ -113,6 +122,16 @@ type cliOptions struct { // nolint: maligned
 
 	// Whether SDS is enabled on.
 	sdsEnabled bool
+
+	// Name of the Kubernetes CSR signer which signs the workload certificates of the GRPC server.
+	// If empty, Citadel signs them. Citadel always signs the certificates of the workload secrets.
+	csrSignerName string
+	// The root certificate of the Kubernetes CSR signer.
+	csrSignerRootCertFile string
+	// The maximum time to wait for the Kubernetes CSR signer to issue a certificate.
+	csrSignerTimeout time.Duration
+	// Whether Citadel signs the CSRs when the Kubernetes CSR signer fails.
+	csrSignerFallback bool
 }
 
 var (
@@ -190,6 +209,13 @@ func init() {
 			"When set to true, the '--signing-cert' and '--signing-key' options are ignored.")
 	flags.DurationVar(&opts.selfSignedCACertTTL, "self-signed-ca-cert-ttl", cmd.DefaultSelfSignedCACertTTL,
 		"The TTL of self-signed CA root certificate.")
+	flags.DurationVar(&opts.rootRotationGracePeriod, "root-rotation-grace-period", 24*time.Hour,
+		"The duration for which a new self-signed root certificate is trusted before it signs certificates, "+
+			"and for which the previous root certificate is trusted after, both at least for --max-workload-cert-ttl. "+
+			"The rotation is started with "+
+			"'istioctl experimental root-rotation start'.")
+	flags.DurationVar(&opts.rootRotationCheckInterval, "root-rotation-check-interval", time.Minute,
+		"The interval between two checks of the rotation of the self-signed root certificate.")
 	flags.StringVar(&opts.trustDomain, "trust-domain", "",
 		"The domain serves to identify the system with SPIFFE.")
 	// Upstream CA configuration if Citadel interacts with upstream CA.
@@ -221,11 +247,24 @@ func init() {
 
 	flags.BoolVar(&opts.signCACerts, "sign-ca-certs", false, "Whether Citadel signs certificates for other CAs.")
 	flags.BoolVar(&opts.pkcs8Keys, "pkcs8-keys", false, "Whether to generate PKCS#8 private keys.")
+	flags.StringVar(&opts.csrSignerName, "csr-signer-name", "", "Name of the signer of the Kubernetes "+
+		"certificates.k8s.io API which signs the workload certificates requested to the GRPC server, set in the "+
+		"annotation "+csr.SignerNameAnnotation+" of the CSRs. If unspecified, Citadel signs all of them. "+
+		"The certificates of the workload secrets are always signed by Citadel.")
+	flags.StringVar(&opts.csrSignerRootCertFile, "csr-signer-root-cert", "",
+		"Path to the root certificate file of the Kubernetes CSR signer.")
+	flags.DurationVar(&opts.csrSignerTimeout, "csr-signer-timeout", 10*time.Second,
+		"The maximum time to wait for the Kubernetes CSR signer to issue a certificate.")
+	flags.BoolVar(&opts.csrSignerFallback, "csr-signer-fallback", false,
+		"Whether Citadel signs the CSRs when the Kubernetes CSR signer fails. If false, the CSRs are rejected.")
 
 	// Monitoring configuration
 	flags.IntVar(&opts.monitoringPort, "monitoring-port", 15014, "The port number for monitoring Citadel. "+
 		"If unspecified, Citadel will disable monitoring.")
 	flags.BoolVar(&opts.enableProfiling, "enable-profiling", false, "Enabling profiling when monitoring Citadel.")
+	flags.StringVar(&opts.grpcHandlingTimeBuckets, "grpc-handling-time-buckets", "",
+		"Comma separated bucket boundaries, in seconds, of the histogram of the handling time of the Citadel "+
+			"GRPC requests. If unspecified, the default boundaries are used.")
 
 	// Liveness Probe configuration
 	flags.StringVar(&opts.LivenessProbeOptions.Path, "liveness-probe-path", "",
@@ -309,6 +348,9 @@ func runCA() {
 	ca := createCA(cs.CoreV1())
 
 	stopCh := make(chan struct{})
+	if opts.selfSignedCA {
+		go createRootRotator(ca, cs.CoreV1()).Run(opts.rootRotationCheckInterval, stopCh)
+	}
 	if !opts.serverOnly {
 		log.Infof("Creating Kubernetes controller to write issued keys and certs into secret ...")
 		// For workloads in K8s, we apply the configured workload cert TTL.
@@ -350,8 +392,15 @@ func runCA() {
 
 		// The CA API uses cert with the max workload cert TTL.
 		hostnames := append(strings.Split(opts.grpcHosts, ","), fqdn())
+		var buckets []float64
+		if opts.grpcHandlingTimeBuckets != "" {
+			if buckets, err = histogram.ParseBounds(opts.grpcHandlingTimeBuckets); err != nil {
+				fatalf("Invalid GRPC handling time buckets: %v", err)
+			}
+		}
 		caServer, startErr := caserver.New(ca, opts.maxWorkloadCertTTL, opts.signCACerts, hostnames,
-			opts.grpcPort, spiffe.GetTrustDomain(), opts.sdsEnabled)
+			opts.grpcPort, spiffe.GetTrustDomain(), opts.sdsEnabled, buckets, createSigningBackend(cs),
+			opts.csrSignerFallback)
 		if startErr != nil {
 			fatalf("Failed to create istio ca server: %v", startErr)
 		}
@@ -411,6 +460,30 @@ func runCA() {
 	}
 }
 
+// createSigningBackend returns the Kubernetes CSR signer if its name is set, or nil.
+func createSigningBackend(cs kubernetes.Interface) ca.SigningBackend {
+	if opts.csrSignerName == "" {
+		return nil
+	}
+	if opts.csrSignerRootCertFile == "" {
+		fatalf("The root certificate of the Kubernetes CSR signer must be set.")
+	}
+	log.Infof("Workload certificates requested to the GRPC server are signed by the Kubernetes CSR signer %s",
+		opts.csrSignerName)
+	if !opts.serverOnly {
+		log.Warnf("The certificates of the workload secrets are signed by Citadel, not by the Kubernetes CSR signer %s. "+
+			"Set --server-only to only issue the workload certificates over GRPC.", opts.csrSignerName)
+	}
+	return csr.NewSigner(cs.CertificatesV1beta1().CertificateSigningRequests(),
+		opts.csrSignerName, opts.csrSignerRootCertFile, opts.csrSignerTimeout)
+}
+
+// createRootRotator returns the rotator of the root certificate of the self-signed CA.
+func createRootRotator(istioCA *ca.IstioCA, client corev1.CoreV1Interface) *ca.RootRotator {
+	return ca.NewRootRotator(istioCA, client, opts.istioCaStorageNamespace, opts.selfSignedCACertTTL,
+		spiffe.GetTrustDomain(), opts.dualUse, opts.rootCertFile, opts.rootRotationGracePeriod, opts.readSigningCertOnly)
+}
+
 func createCA(client corev1.CoreV1Interface) *ca.IstioCA {
 	var caOpts *ca.IstioCAOptions
 	var err error
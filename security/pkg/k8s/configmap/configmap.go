@@ -26,6 +26,10 @@ import (
 const (
 	istioSecurityConfigMapName = "istio-security"
 	caTLSRootCertName          = "caTLSRootCert"
+
+	// federatedTrustBundlesConfigMapName holds the root certificates of the federated trust
+	// domains, keyed by trust domain. Citadel appends them to the trust bundle of the workloads.
+	federatedTrustBundlesConfigMapName = "istio-federated-trust-bundles"
 )
 
 // Controller manages the CA TLS root cert in ConfigMap.
@@ -88,3 +92,52 @@ func (c *Controller) GetCATLSRootCert() (string, error) {
 
 	return rootCert, nil
 }
+
+// InsertTrustBundle updates the PEM root certificates of a federated trust domain in the
+// configmap.
+func (c *Controller) InsertTrustBundle(trustDomain string, bundle []byte) error {
+	configmap, err := c.core.ConfigMaps(c.namespace).Get(federatedTrustBundlesConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to insert the trust bundle of %s: %v", trustDomain, err)
+		}
+		configmap = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      federatedTrustBundlesConfigMapName,
+				Namespace: c.namespace,
+			},
+			Data: map[string]string{trustDomain: string(bundle)},
+		}
+		if _, err = c.core.ConfigMaps(c.namespace).Create(configmap); err != nil {
+			return fmt.Errorf("failed to insert the trust bundle of %s: %v", trustDomain, err)
+		}
+		return nil
+	}
+	if configmap.Data == nil {
+		configmap.Data = map[string]string{}
+	}
+	configmap.Data[trustDomain] = string(bundle)
+	if _, err = c.core.ConfigMaps(c.namespace).Update(configmap); err != nil {
+		return fmt.Errorf("failed to insert the trust bundle of %s: %v", trustDomain, err)
+	}
+	return nil
+}
+
+// GetTrustBundles gets the PEM root certificates of the federated trust domains, by trust domain.
+// No certificate is returned if the configmap does not exist.
+func (c *Controller) GetTrustBundles() (map[string][]byte, error) {
+	configmap, err := c.core.ConfigMaps(c.namespace).Get(federatedTrustBundlesConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get the federated trust bundles: %v", err)
+	}
+	bundles := make(map[string][]byte, len(configmap.Data))
+	for trustDomain, bundle := range configmap.Data {
+		if bundle != "" {
+			bundles[trustDomain] = []byte(bundle)
+		}
+	}
+	return bundles, nil
+}
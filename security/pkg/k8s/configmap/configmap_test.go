@@ -16,6 +16,7 @@ package configmap
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 
 	v1 "k8s.io/api/core/v1"
@@ -178,6 +179,28 @@ func TestGetCATLSRootCert(t *testing.T) {
 	}
 }
 
+func TestTrustBundles(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	controller := NewController("test-ns", client.CoreV1())
+
+	if bundles, err := controller.GetTrustBundles(); err != nil || len(bundles) != 0 {
+		t.Errorf("GetTrustBundles() without configmap => got %q, %v, want no bundle", bundles, err)
+	}
+	for trustDomain, bundle := range map[string]string{"foo.example.org": "FOO\n", "bar.example.org": "BAR"} {
+		if err := controller.InsertTrustBundle(trustDomain, []byte(bundle)); err != nil {
+			t.Fatalf("InsertTrustBundle(%s) => got error %v", trustDomain, err)
+		}
+	}
+	if err := controller.InsertTrustBundle("foo.example.org", []byte("FOO2\n")); err != nil {
+		t.Fatalf("InsertTrustBundle() of an existing trust domain => got error %v", err)
+	}
+	bundles, err := controller.GetTrustBundles()
+	want := map[string][]byte{"foo.example.org": []byte("FOO2\n"), "bar.example.org": []byte("BAR")}
+	if err != nil || !reflect.DeepEqual(bundles, want) {
+		t.Errorf("GetTrustBundles() => got %q, %v, want %q", bundles, err, want)
+	}
+}
+
 func createConfigMap(namespace string, data map[string]string) *v1.ConfigMap {
 	return &v1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
@@ -0,0 +1,158 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package csr delegates the signing of workload certificates to the signers of the Kubernetes
+// certificates.k8s.io API, e.g. a controller integrating a corporate PKI.
+package csr
+
+import (
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	cert "k8s.io/api/certificates/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	certclient "k8s.io/client-go/kubernetes/typed/certificates/v1beta1"
+
+	"istio.io/istio/security/pkg/pki/util"
+	"istio.io/pkg/log"
+)
+
+const (
+	// SignerNameAnnotation is set on the CSRs to the name of the signer expected to sign them. The
+	// certificates.k8s.io/v1beta1 API of the supported Kubernetes versions has no signer name, so
+	// the external signers select the CSRs by the annotation.
+	SignerNameAnnotation = "certificates.istio.io/signer-name"
+
+	// approvalReason is the reason of the approval of the CSRs, which Citadel approves itself as
+	// it authenticated the workloads.
+	approvalReason = "IstioApproved"
+
+	// pollInterval is the interval between two reads of a CSR waiting for its certificate.
+	pollInterval = 100 * time.Millisecond
+)
+
+// Signer creates a Kubernetes CSR for each workload certificate, approves it, and waits for the
+// external signer to issue the certificate. The TTL of the certificates is set by the signer.
+type Signer struct {
+	client       certclient.CertificateSigningRequestInterface
+	signerName   string
+	rootCertFile string
+	timeout      time.Duration
+}
+
+// NewSigner creates a signer of the CSRs annotated with the signer name. The root certificate of
+// the signer is read from the file on every signing, so that a rotated root certificate is used.
+func NewSigner(client certclient.CertificateSigningRequestInterface, signerName, rootCertFile string,
+	timeout time.Duration) *Signer {
+	return &Signer{
+		client:       client,
+		signerName:   signerName,
+		rootCertFile: rootCertFile,
+		timeout:      timeout,
+	}
+}
+
+// Name returns the name of the signer.
+func (s *Signer) Name() string {
+	return "kubernetes CSR signer " + s.signerName
+}
+
+// Sign implements ca.SigningBackend. The identities requested by the CSR must be a subset of
+// the identities of the workload, as the external signer issues them as they are requested.
+func (s *Signer) Sign(csrPEM []byte, subjectIDs []string, ttl time.Duration) ([]byte, []byte, error) {
+	if err := checkIdentities(csrPEM, subjectIDs); err != nil {
+		return nil, nil, err
+	}
+	rootCert, err := ioutil.ReadFile(s.rootCertFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read the root certificate of %s: %v", s.signerName, err)
+	}
+
+	csr, err := s.client.Create(&cert.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "istio-csr-",
+			Annotations:  map[string]string{SignerNameAnnotation: s.signerName},
+		},
+		Spec: cert.CertificateSigningRequestSpec{
+			Request: csrPEM,
+			Usages: []cert.KeyUsage{
+				cert.UsageDigitalSignature,
+				cert.UsageKeyEncipherment,
+				cert.UsageServerAuth,
+				cert.UsageClientAuth,
+			},
+		},
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create the CSR: %v", err)
+	}
+	defer func() {
+		if err := s.client.Delete(csr.Name, &metav1.DeleteOptions{}); err != nil {
+			log.Warnf("Failed to delete the CSR %s: %v", csr.Name, err)
+		}
+	}()
+
+	csr.Status.Conditions = append(csr.Status.Conditions, cert.CertificateSigningRequestCondition{
+		Type:           cert.CertificateApproved,
+		Reason:         approvalReason,
+		Message:        "The workload was authenticated by Citadel",
+		LastUpdateTime: metav1.Now(),
+	})
+	if _, err := s.client.UpdateApproval(csr); err != nil {
+		return nil, nil, fmt.Errorf("failed to approve the CSR %s: %v", csr.Name, err)
+	}
+
+	var certChain []byte
+	err = wait.PollImmediate(pollInterval, s.timeout, func() (bool, error) {
+		r, err := s.client.Get(csr.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		for _, c := range r.Status.Conditions {
+			if c.Type == cert.CertificateDenied {
+				return false, fmt.Errorf("the CSR %s was denied: %s", csr.Name, c.Message)
+			}
+		}
+		certChain = r.Status.Certificate
+		return len(certChain) > 0, nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get the certificate of the CSR %s: %v", csr.Name, err)
+	}
+	return certChain, rootCert, nil
+}
+
+// checkIdentities checks that the identities requested by the CSR are identities of the workload.
+func checkIdentities(csrPEM []byte, subjectIDs []string) error {
+	csr, err := util.ParsePemEncodedCSR(csrPEM)
+	if err != nil {
+		return err
+	}
+	ids, err := util.ExtractIDs(csr.Extensions)
+	if err != nil {
+		return err
+	}
+	allowed := make(map[string]bool, len(subjectIDs))
+	for _, id := range subjectIDs {
+		allowed[id] = true
+	}
+	for _, id := range ids {
+		if !allowed[id] {
+			return fmt.Errorf("the CSR requests the identity %s, which is not an identity of the workload %v", id, subjectIDs)
+		}
+	}
+	return nil
+}
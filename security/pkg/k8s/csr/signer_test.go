@@ -0,0 +1,151 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csr
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	cert "k8s.io/api/certificates/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	certclient "k8s.io/client-go/kubernetes/typed/certificates/v1beta1"
+	k8stesting "k8s.io/client-go/testing"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+const workloadID = "spiffe://cluster.local/ns/default/sa/reviews"
+
+func genCSR(t *testing.T, host string) []byte {
+	t.Helper()
+	csrPEM, _, err := util.GenCSR(util.CertOptions{Host: host, RSAKeySize: 2048})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return csrPEM
+}
+
+// newFakeClient returns a fake CSR client generating the names of the created CSRs, like the API
+// server does.
+func newFakeClient() certclient.CertificateSigningRequestInterface {
+	client := fake.NewSimpleClientset()
+	var generated int
+	client.PrependReactor("create", "certificatesigningrequests", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		csr := action.(k8stesting.CreateAction).GetObject().(*cert.CertificateSigningRequest)
+		if csr.Name == "" && csr.GenerateName != "" {
+			generated++
+			csr.Name = fmt.Sprintf("%s%d", csr.GenerateName, generated)
+		}
+		return false, nil, nil
+	})
+	return client.CertificatesV1beta1().CertificateSigningRequests()
+}
+
+func writeRootCert(t *testing.T) string {
+	t.Helper()
+	f, err := ioutil.TempFile("", "root-cert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close() // nolint: errcheck
+	if _, err := f.WriteString("root"); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+// runFakeSigner issues a certificate for, or denies, the approved CSRs with the signer name until
+// the stop channel is closed.
+func runFakeSigner(client certclient.CertificateSigningRequestInterface, signerName string, deny bool, stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+		list, err := client.List(metav1.ListOptions{})
+		if err != nil {
+			continue
+		}
+		for i := range list.Items {
+			csr := &list.Items[i]
+			if csr.Annotations[SignerNameAnnotation] != signerName || len(csr.Status.Conditions) != 1 ||
+				csr.Status.Conditions[0].Type != cert.CertificateApproved {
+				continue
+			}
+			if deny {
+				csr.Status.Conditions = append(csr.Status.Conditions,
+					cert.CertificateSigningRequestCondition{Type: cert.CertificateDenied, Message: "not allowed"})
+			} else {
+				csr.Status.Certificate = []byte("cert")
+			}
+			_, _ = client.UpdateStatus(csr)
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	rootCert := writeRootCert(t)
+	defer os.Remove(rootCert)
+
+	for _, tc := range []struct {
+		name    string
+		host    string
+		deny    bool
+		wantErr string
+	}{
+		{name: "signed", host: workloadID},
+		{name: "denied", host: workloadID, deny: true, wantErr: "denied"},
+		{name: "other identity", host: "spiffe://cluster.local/ns/default/sa/ratings", wantErr: "not an identity of the workload"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newFakeClient()
+			stop := make(chan struct{})
+			defer close(stop)
+			go runFakeSigner(client, "example.com/pki", tc.deny, stop)
+
+			signer := NewSigner(client, "example.com/pki", rootCert, 5*time.Second)
+			certChain, root, err := signer.Sign(genCSR(t, tc.host), []string{workloadID}, time.Hour)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("Sign() => got error %v, want %q", err, tc.wantErr)
+				}
+			} else if err != nil || string(certChain) != "cert" || string(root) != "root" {
+				t.Fatalf("Sign() => got %q, %q, %v, want the issued certificate and the root certificate", certChain, root, err)
+			}
+
+			if list, _ := client.List(metav1.ListOptions{}); len(list.Items) != 0 {
+				t.Errorf("got %d CSRs left, want the CSR to be deleted", len(list.Items))
+			}
+		})
+	}
+}
+
+func TestSignTimeout(t *testing.T) {
+	rootCert := writeRootCert(t)
+	defer os.Remove(rootCert)
+
+	client := newFakeClient()
+	signer := NewSigner(client, "example.com/pki", rootCert, 300*time.Millisecond)
+	if _, _, err := signer.Sign(genCSR(t, workloadID), []string{workloadID}, time.Hour); err == nil {
+		t.Error("Sign() => got no error without signer")
+	}
+}
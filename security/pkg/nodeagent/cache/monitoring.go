@@ -22,7 +22,8 @@ const (
 )
 
 var (
-	RequestType = monitoring.MustCreateLabel("request_type")
+	RequestType  = monitoring.MustCreateLabel("request_type")
+	ResourceName = monitoring.MustCreateLabel("resource_name")
 )
 
 // Metrics for outgoing requests from citadel agent to external services such as token exchange server or a CA.
@@ -50,11 +51,25 @@ var (
 		monitoring.WithLabels(RequestType))
 )
 
+// Metrics for the certificates cached by citadel agent.
+var (
+	certExpiryTimestamp = monitoring.NewGauge(
+		"cert_expiry_timestamp",
+		"The unix time in seconds at which the earliest expiring cached certificate of a resource expires.",
+		monitoring.WithLabels(ResourceName))
+
+	numCertRotations = monitoring.NewSum(
+		"num_cert_rotations",
+		"Number of cached certificates rotated before they expire.")
+)
+
 func init() {
 	monitoring.MustRegister(
 		outgoingLatency,
 		numOutgoingRequests,
 		numOutgoingRetries,
 		numFailedOutgoingRequests,
+		certExpiryTimestamp,
+		numCertRotations,
 	)
 }
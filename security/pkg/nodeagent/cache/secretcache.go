@@ -211,6 +211,7 @@ func (sc *SecretCache) GenerateSecret(ctx context.Context, connectionID, resourc
 		}
 
 		sc.secrets.Store(connKey, *ns)
+		sc.recordCertExpiry()
 		return ns, nil
 	}
 
@@ -434,6 +435,8 @@ func (sc *SecretCache) UpdateK8sSecret(secretName string, ns model.SecretItem) {
 		sc.secrets.Store(key, *e)
 		return true
 	})
+
+	sc.recordCertExpiry()
 }
 
 func (sc *SecretCache) rotate(updateRootFlag bool) {
@@ -514,6 +517,7 @@ func (sc *SecretCache) rotate(updateRootFlag bool) {
 				}
 
 				secretMap.Store(connKey, ns)
+				numCertRotations.Increment()
 				cacheLog.Debugf("%s secret cache is updated", conIDresourceNamePrefix)
 				sc.callbackWithTimeout(connKey, ns)
 
@@ -531,6 +535,30 @@ func (sc *SecretCache) rotate(updateRootFlag bool) {
 		sc.secrets.Store(key, *e)
 		return true
 	})
+
+	if !updateRootFlag {
+		sc.recordCertExpiry()
+	}
+}
+
+// recordCertExpiry records the expiry time of the earliest expiring cached certificate of each
+// resource. The root cert expiry is recorded by the SDS server.
+func (sc *SecretCache) recordCertExpiry() {
+	earliest := make(map[string]time.Time)
+	sc.secrets.Range(func(k interface{}, v interface{}) bool {
+		connKey := k.(ConnKey)
+		e := v.(model.SecretItem)
+		if connKey.ResourceName == RootCertReqResourceName || e.ExpireTime.IsZero() {
+			return true
+		}
+		if t, ok := earliest[connKey.ResourceName]; !ok || e.ExpireTime.Before(t) {
+			earliest[connKey.ResourceName] = e.ExpireTime
+		}
+		return true
+	})
+	for resourceName, t := range earliest {
+		certExpiryTimestamp.With(ResourceName.Value(resourceName)).Record(float64(t.Unix()))
+	}
 }
 
 // generateGatewaySecret returns secret for ingress gateway proxy.
@@ -26,6 +26,8 @@ import (
 	"testing"
 	"time"
 
+	"go.opencensus.io/stats/view"
+
 	"istio.io/istio/security/pkg/nodeagent/cache/mock"
 	"istio.io/istio/security/pkg/nodeagent/plugin"
 
@@ -905,3 +907,27 @@ func convertToBytes(ss []string) []byte {
 	}
 	return res
 }
+
+func TestRecordCertExpiry(t *testing.T) {
+	sc := &SecretCache{}
+	now := time.Now()
+	sc.secrets.Store(ConnKey{ConnectionID: "c1", ResourceName: testResourceName}, model.SecretItem{ExpireTime: now.Add(2 * time.Hour)})
+	sc.secrets.Store(ConnKey{ConnectionID: "c2", ResourceName: testResourceName}, model.SecretItem{ExpireTime: now.Add(time.Hour)})
+	sc.secrets.Store(ConnKey{ConnectionID: "c1", ResourceName: RootCertReqResourceName}, model.SecretItem{ExpireTime: now})
+	sc.recordCertExpiry()
+
+	rows, err := view.RetrieveData("cert_expiry_timestamp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if len(row.Tags) != 1 || row.Tags[0].Value != testResourceName {
+			continue
+		}
+		if got, want := row.Data.(*view.LastValueData).Value, float64(now.Add(time.Hour).Unix()); got != want {
+			t.Errorf("cert_expiry_timestamp => got %v, want the earliest expiry %v", got, want)
+		}
+		return
+	}
+	t.Errorf("cert_expiry_timestamp => got %v, want a row for %q", rows, testResourceName)
+}
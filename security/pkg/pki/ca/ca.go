@@ -189,7 +189,8 @@ func NewSelfSignedIstioCAOptions(ctx context.Context, caCertTTL, certTTL, maxCer
 		log.Infof("Using self-generated public key: %v", string(rootCerts))
 	} else {
 		log.Infof("Load signing key and cert from existing secret %s:%s", caSecret.Namespace, caSecret.Name)
-		rootCerts, err := appendRootCerts(caSecret.Data[caCertID], rootCertFile)
+		// During a rotation of the root, the trust bundle also holds the staged or previous root.
+		rootCerts, err := appendRootCerts(RootRotationTrustBundle(caSecret), rootCertFile)
 		if err != nil {
 			return nil, fmt.Errorf("failed to append root certificates (%v)", err)
 		}
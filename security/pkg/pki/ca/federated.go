@@ -0,0 +1,98 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+// crossSignFederatedRoots returns the roots of the federated trust domains cross-signed by the
+// signing certificate, ordered by trust domain. The cross-signed roots are constrained to the
+// SPIFFE identities of their trust domain, so that the workloads trusting the signing root verify
+// the peers of a federated trust domain, but a federated CA cannot issue the identities of another
+// trust domain, including the local one.
+func crossSignFederatedRoots(bundles map[string][]byte, signingCertPem, signingKeyPem []byte) ([]byte, error) {
+	signingCert, err := util.ParsePemEncodedCertificate(signingCertPem)
+	if err != nil {
+		return nil, err
+	}
+	signingKey, err := util.ParsePemEncodedKey(signingKeyPem)
+	if err != nil {
+		return nil, err
+	}
+
+	trustDomains := make([]string, 0, len(bundles))
+	for trustDomain := range bundles {
+		trustDomains = append(trustDomains, trustDomain)
+	}
+	sort.Strings(trustDomains)
+	var out []byte
+	for _, trustDomain := range trustDomains {
+		rest := bundles[trustDomain]
+		for {
+			var block *pem.Block
+			if block, rest = pem.Decode(rest); block == nil {
+				break
+			}
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			root, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("invalid root of the trust domain %s: %v", trustDomain, err)
+			}
+			cert, err := crossSign(root, trustDomain, signingCert, signingKey)
+			if err != nil {
+				return nil, fmt.Errorf("failed to cross-sign the root of the trust domain %s: %v", trustDomain, err)
+			}
+			out = append(out, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert})...)
+		}
+	}
+	return out, nil
+}
+
+// crossSign issues a CA certificate with the subject and public key of the root, signed by the
+// signing certificate and only valid for the URIs of the trust domain.
+func crossSign(root *x509.Certificate, trustDomain string, signingCert *x509.Certificate,
+	signingKey crypto.PrivateKey) ([]byte, error) {
+	serialNum, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	notAfter := root.NotAfter
+	if signingCert.NotAfter.Before(notAfter) {
+		notAfter = signingCert.NotAfter
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serialNum,
+		RawSubject:            root.RawSubject,
+		SubjectKeyId:          root.SubjectKeyId,
+		NotBefore:             root.NotBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		PermittedURIDomains:   []string{trustDomain},
+	}
+	return x509.CreateCertificate(rand.Reader, template, signingCert, root.PublicKey, signingKey)
+}
@@ -47,3 +47,25 @@ func (ca *FakeCA) GetCAKeyCertBundle() util.KeyCertBundle {
 	}
 	return ca.KeyCertBundle
 }
+
+// FakeSigningBackend is a mock of SigningBackend.
+type FakeSigningBackend struct {
+	CertChain   []byte
+	RootCert    []byte
+	SignErr     error
+	ReceivedIDs []string
+}
+
+// Sign returns the SignErr if SignErr is not nil, otherwise, it returns CertChain and RootCert.
+func (b *FakeSigningBackend) Sign(csr []byte, identities []string, ttl time.Duration) ([]byte, []byte, error) {
+	b.ReceivedIDs = identities
+	if b.SignErr != nil {
+		return nil, nil, b.SignErr
+	}
+	return b.CertChain, b.RootCert, nil
+}
+
+// Name returns the name of the fake backend.
+func (b *FakeSigningBackend) Name() string {
+	return "fake"
+}
@@ -0,0 +1,287 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"istio.io/istio/security/pkg/k8s/configmap"
+	"istio.io/istio/security/pkg/pki/util"
+	"istio.io/pkg/log"
+)
+
+const (
+	// RootRotationPhaseAnnotation is set on the CA secret to the phase of the rotation of the root
+	// certificate of the self-signed CA.
+	RootRotationPhaseAnnotation = "security.istio.io/root-rotation-phase"
+	// RootRotationTimeAnnotation is set on the CA secret to the time, in RFC 3339 format, at which
+	// the rotation entered its phase.
+	RootRotationTimeAnnotation = "security.istio.io/root-rotation-time"
+
+	// nextCACertID and nextCAPrivateKeyID hold the new root while it is staged.
+	nextCACertID       = "next-ca-cert.pem"
+	nextCAPrivateKeyID = "next-ca-key.pem"
+	// previousCACertID holds the retiring root once the new root is activated.
+	previousCACertID = "previous-ca-cert.pem"
+)
+
+// RootRotationPhase is a phase of the rotation of the root certificate of the self-signed CA.
+type RootRotationPhase string
+
+const (
+	// RootRotationIdle means that no rotation is in progress.
+	RootRotationIdle RootRotationPhase = ""
+	// RootRotationRequested means that a rotation was requested, e.g. by istioctl. Citadel
+	// generates the new root and stages it.
+	RootRotationRequested RootRotationPhase = "Requested"
+	// RootRotationStaged means that both roots are distributed in the trust bundle, and the
+	// current root still signs the certificates.
+	RootRotationStaged RootRotationPhase = "Staged"
+	// RootRotationActivated means that the new root signs the certificates, and the previous root
+	// remains in the trust bundle until the certificates it signed are replaced.
+	RootRotationActivated RootRotationPhase = "Activated"
+)
+
+// RootRotator drives the rotation of the root certificate of the self-signed CA. The state of the
+// rotation is kept in the CA secret, so that it survives restarts of Citadel. Staging the new root
+// lets the proxies trust it before it signs certificates, and the previous root is kept after the
+// activation. Both last for the grace period, but at least for the maximum workload certificate TTL
+// of the CA: the workload secrets are issued again by the secret controller when the trust bundle
+// changes, but the proxies only get the roots over SDS with their certificates, which are only
+// replaced when they expire.
+type RootRotator struct {
+	ca           *IstioCA
+	client       corev1.CoreV1Interface
+	namespace    string
+	caCertTTL    time.Duration
+	org          string
+	dualUse      bool
+	rootCertFile string
+	gracePeriod  time.Duration
+	// readOnly is set for the Citadels reading the CA secret written by another Citadel: they load
+	// the rotated roots but do not drive the rotation.
+	readOnly bool
+
+	// federatedBundles, federatedSigner and federatedRoots cache the last cross-signed roots of the
+	// federated trust domains, which are only signed again when the bundles or the signing root
+	// change.
+	federatedBundles map[string][]byte
+	federatedSigner  []byte
+	federatedRoots   []byte
+
+	// now returns the current time, replaced in tests.
+	now func() time.Time
+}
+
+// NewRootRotator creates a rotator of the root of the self-signed CA stored in the namespace.
+// The new roots are generated with the TTL, organization and dual use of the self-signed CA, and
+// the root certificates of the file are appended to the trust bundle. A read-only rotator only
+// loads the roots of the rotation driven by another Citadel.
+func NewRootRotator(ca *IstioCA, client corev1.CoreV1Interface, namespace string, caCertTTL time.Duration,
+	org string, dualUse bool, rootCertFile string, gracePeriod time.Duration, readOnly bool) *RootRotator {
+	return &RootRotator{
+		ca:           ca,
+		client:       client,
+		namespace:    namespace,
+		caCertTTL:    caCertTTL,
+		org:          org,
+		dualUse:      dualUse,
+		rootCertFile: rootCertFile,
+		gracePeriod:  gracePeriod,
+		readOnly:     readOnly,
+		now:          time.Now,
+	}
+}
+
+// Run reconciles the rotation at the interval until the stop channel is closed.
+func (r *RootRotator) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := r.Reconcile(); err != nil {
+			log.Errorf("Failed to reconcile the rotation of the root certificate: %v", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Reconcile moves the rotation to its next phase if it is due, and loads the signing key and
+// certificate, and the trust bundle, of the phase in the CA.
+func (r *RootRotator) Reconcile() error {
+	secret, err := r.client.Secrets(r.namespace).Get(CASecret, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	if r.readOnly {
+		return r.load(secret)
+	}
+
+	phase := RootRotationPhase(secret.Annotations[RootRotationPhaseAnnotation])
+	since, _ := time.Parse(time.RFC3339, secret.Annotations[RootRotationTimeAnnotation])
+	due := !r.now().Before(since.Add(r.overlapPeriod()))
+	next := phase
+	switch phase {
+	case RootRotationIdle:
+	case RootRotationRequested:
+		cert, key, err := util.GenCertKeyFromOptions(util.CertOptions{
+			TTL:          r.caCertTTL,
+			Org:          r.org,
+			IsCA:         true,
+			IsSelfSigned: true,
+			RSAKeySize:   caKeySize,
+			IsDualUse:    r.dualUse,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to generate the new root: %v", err)
+		}
+		secret.Data[nextCACertID] = cert
+		secret.Data[nextCAPrivateKeyID] = key
+		next = RootRotationStaged
+	case RootRotationStaged:
+		if due {
+			secret.Data[previousCACertID] = secret.Data[caCertID]
+			secret.Data[caCertID] = secret.Data[nextCACertID]
+			secret.Data[caPrivateKeyID] = secret.Data[nextCAPrivateKeyID]
+			delete(secret.Data, nextCACertID)
+			delete(secret.Data, nextCAPrivateKeyID)
+			next = RootRotationActivated
+		}
+	case RootRotationActivated:
+		// The certificates signed by the previous root were all issued before its retirement
+		// started, so they are expired after the maximum workload certificate TTL.
+		if due {
+			delete(secret.Data, previousCACertID)
+			next = RootRotationIdle
+		}
+	default:
+		return fmt.Errorf("unknown root rotation phase %q", phase)
+	}
+
+	if next != phase {
+		if secret.Annotations == nil {
+			secret.Annotations = make(map[string]string)
+		}
+		if next == RootRotationIdle {
+			delete(secret.Annotations, RootRotationPhaseAnnotation)
+			delete(secret.Annotations, RootRotationTimeAnnotation)
+		} else {
+			secret.Annotations[RootRotationPhaseAnnotation] = string(next)
+			secret.Annotations[RootRotationTimeAnnotation] = r.now().UTC().Format(time.RFC3339)
+		}
+		if secret, err = r.client.Secrets(r.namespace).Update(secret); err != nil {
+			return fmt.Errorf("failed to update the CA secret to the root rotation phase %q: %v", next, err)
+		}
+		log.Infof("Root certificate rotation: %q -> %q", phase, next)
+	}
+	return r.load(secret)
+}
+
+// overlapPeriod returns how long the new root is trusted before it is activated, and how long the
+// previous root is trusted after.
+func (r *RootRotator) overlapPeriod() time.Duration {
+	if r.ca.maxCertTTL > r.gracePeriod {
+		return r.ca.maxCertTTL
+	}
+	return r.gracePeriod
+}
+
+// load sets the signing key and certificate, and the trust bundle, of the CA secret in the CA,
+// and publishes the trust bundle if it changed. The trust bundle also holds the roots of the
+// federated trust domains, cross-signed so that they only validate their own trust domain.
+func (r *RootRotator) load(secret *v1.Secret) error {
+	rootCerts, err := appendRootCerts(RootRotationTrustBundle(secret), r.rootCertFile)
+	if err != nil {
+		return err
+	}
+	federated, err := r.federatedTrustBundle(secret)
+	if err != nil {
+		return err
+	}
+	if len(federated) > 0 {
+		rootCerts = append([]byte(strings.TrimSuffix(string(rootCerts), "\n")+"\n"), federated...)
+	}
+	bundle := r.ca.GetCAKeyCertBundle()
+	cert, _, _, _ := bundle.GetAllPem()
+	if bytes.Equal(cert, secret.Data[caCertID]) && bytes.Equal(bundle.GetRootCertPem(), rootCerts) {
+		return nil
+	}
+	if err := bundle.VerifyAndSetAll(secret.Data[caCertID], secret.Data[caPrivateKeyID], nil, rootCerts); err != nil {
+		return fmt.Errorf("failed to load the CA key and certificate: %v", err)
+	}
+	log.Infof("Loaded the root certificates with SHA-256 fingerprints %v", fingerprints(rootCerts))
+	return updateCertInConfigmap(r.namespace, r.client, rootCerts)
+}
+
+// federatedTrustBundle returns the roots of the federated trust domains, fetched by pilot, which let
+// the workloads verify the peers of other trust domains. They are cross-signed by the signing root
+// of the CA secret.
+func (r *RootRotator) federatedTrustBundle(secret *v1.Secret) ([]byte, error) {
+	bundles, err := configmap.NewController(r.namespace, r.client).GetTrustBundles()
+	if err != nil {
+		return nil, err
+	}
+	if len(bundles) == 0 {
+		return nil, nil
+	}
+	signer := secret.Data[caCertID]
+	if reflect.DeepEqual(bundles, r.federatedBundles) && bytes.Equal(signer, r.federatedSigner) {
+		return r.federatedRoots, nil
+	}
+	roots, err := crossSignFederatedRoots(bundles, signer, secret.Data[caPrivateKeyID])
+	if err != nil {
+		return nil, err
+	}
+	r.federatedBundles, r.federatedSigner, r.federatedRoots = bundles, signer, roots
+	return roots, nil
+}
+
+// RootRotationTrustBundle returns the root certificates of the CA secret: the signing root first,
+// followed by the staged or previous root during a rotation. The order changes when the new root
+// is activated, so that the workload secrets are issued again by the new root.
+func RootRotationTrustBundle(secret *v1.Secret) []byte {
+	bundle := secret.Data[caCertID]
+	for _, id := range []string{nextCACertID, previousCACertID} {
+		if root := secret.Data[id]; len(root) > 0 {
+			bundle = []byte(strings.TrimSuffix(string(bundle), "\n") + "\n" + string(root))
+		}
+	}
+	return bundle
+}
+
+// fingerprints returns the hex encoded SHA-256 fingerprints of the PEM encoded certificates.
+func fingerprints(certsPem []byte) []string {
+	var out []string
+	for block, rest := pem.Decode(certsPem); block != nil; block, rest = pem.Decode(rest) {
+		sum := sha256.Sum256(block.Bytes)
+		out = append(out, hex.EncodeToString(sum[:]))
+	}
+	return out
+}
@@ -0,0 +1,230 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"istio.io/istio/security/pkg/k8s/configmap"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+func countCerts(pemCerts []byte) int {
+	return strings.Count(string(pemCerts), "BEGIN CERTIFICATE")
+}
+
+func TestRootRotation(t *testing.T) {
+	const caNamespace = "default"
+	client := fake.NewSimpleClientset()
+	// The workload certificates live longer than the grace period.
+	caopts, err := NewSelfSignedIstioCAOptions(context.Background(), 24*time.Hour, time.Hour, 2*time.Hour,
+		"test.ca.org", false, caNamespace, -1, client.CoreV1(), "")
+	if err != nil {
+		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
+	}
+	ca, err := NewIstioCA(caopts)
+	if err != nil {
+		t.Fatalf("Failed to create a self-signed CA: %v", err)
+	}
+	oldRoot := ca.GetCAKeyCertBundle().GetRootCertPem()
+
+	now := time.Now()
+	rotator := NewRootRotator(ca, client.CoreV1(), caNamespace, 24*time.Hour, "test.ca.org", false, "", time.Hour, false)
+	rotator.now = func() time.Time { return now }
+	reconcile := func(want RootRotationPhase) {
+		t.Helper()
+		if err := rotator.Reconcile(); err != nil {
+			t.Fatal(err)
+		}
+		secret, err := client.CoreV1().Secrets(caNamespace).Get(CASecret, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := RootRotationPhase(secret.Annotations[RootRotationPhaseAnnotation]); got != want {
+			t.Fatalf("Reconcile() => got phase %q, want %q", got, want)
+		}
+	}
+
+	reconcile(RootRotationIdle)
+	if !bytes.Equal(ca.GetCAKeyCertBundle().GetRootCertPem(), oldRoot) {
+		t.Fatal("Reconcile() => got the root changed without rotation")
+	}
+
+	secret, _ := client.CoreV1().Secrets(caNamespace).Get(CASecret, metav1.GetOptions{})
+	secret.Annotations = map[string]string{RootRotationPhaseAnnotation: string(RootRotationRequested)}
+	if _, err := client.CoreV1().Secrets(caNamespace).Update(secret); err != nil {
+		t.Fatal(err)
+	}
+	reconcile(RootRotationStaged)
+	roots := ca.GetCAKeyCertBundle().GetRootCertPem()
+	cert, _, _, _ := ca.GetCAKeyCertBundle().GetAllPem()
+	if countCerts(roots) != 2 || !bytes.HasPrefix(roots, oldRoot) || !bytes.Equal(cert, oldRoot) {
+		t.Fatalf("Reconcile() => got %d roots, want the old root signing and the new root staged", countCerts(roots))
+	}
+
+	// A restarted Citadel trusts the staged root.
+	restarted, err := NewSelfSignedIstioCAOptions(context.Background(), 24*time.Hour, time.Hour, time.Hour,
+		"test.ca.org", false, caNamespace, -1, client.CoreV1(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(restarted.KeyCertBundle.GetRootCertPem(), roots) {
+		t.Error("NewSelfSignedIstioCAOptions() => got a trust bundle without the staged root")
+	}
+
+	// The new root is staged until the certificates of the proxies, and so their roots, are
+	// replaced, which takes the maximum workload certificate TTL, longer than the grace period.
+	reconcile(RootRotationStaged)
+	now = now.Add(time.Hour)
+	reconcile(RootRotationStaged)
+	now = now.Add(time.Hour)
+	reconcile(RootRotationActivated)
+	newRoot, _, _, _ := ca.GetCAKeyCertBundle().GetAllPem()
+	roots = ca.GetCAKeyCertBundle().GetRootCertPem()
+	if bytes.Equal(newRoot, oldRoot) || countCerts(roots) != 2 || !bytes.HasPrefix(roots, newRoot) {
+		t.Fatalf("Reconcile() => got %d roots, want the new root signing and the old root trusted", countCerts(roots))
+	}
+	csr, _, err := util.GenCSR(util.CertOptions{Host: "spiffe://test.ca.org/ns/default/sa/test", RSAKeySize: 2048})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := ca.Sign(csr, []string{"spiffe://test.ca.org/ns/default/sa/test"}, time.Hour, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedCert, _ := util.ParsePemEncodedCertificate(signed)
+	newRootCert, _ := util.ParsePemEncodedCertificate(newRoot)
+	if err := signedCert.CheckSignatureFrom(newRootCert); err != nil {
+		t.Errorf("Sign() => got a certificate not signed by the new root: %v", err)
+	}
+
+	// The old root is trusted until the certificates it signed, before the activation, expire.
+	now = now.Add(time.Hour)
+	reconcile(RootRotationActivated)
+	if roots = ca.GetCAKeyCertBundle().GetRootCertPem(); countCerts(roots) != 2 {
+		t.Fatalf("Reconcile() => got %d roots after the grace period, want the old root still trusted", countCerts(roots))
+	}
+	now = now.Add(time.Hour)
+	reconcile(RootRotationIdle)
+	if roots = ca.GetCAKeyCertBundle().GetRootCertPem(); !bytes.Equal(roots, newRoot) {
+		t.Fatalf("Reconcile() => got %d roots, want the new root only", countCerts(roots))
+	}
+
+	// A read-only rotator loads the roots, and does not drive the rotation.
+	secret, _ = client.CoreV1().Secrets(caNamespace).Get(CASecret, metav1.GetOptions{})
+	secret.Annotations[RootRotationPhaseAnnotation] = string(RootRotationRequested)
+	if _, err := client.CoreV1().Secrets(caNamespace).Update(secret); err != nil {
+		t.Fatal(err)
+	}
+	rotator.readOnly = true
+	reconcile(RootRotationRequested)
+}
+
+func TestRootRotatorFederatedTrustBundles(t *testing.T) {
+	const caNamespace = "default"
+	client := fake.NewSimpleClientset()
+	caopts, err := NewSelfSignedIstioCAOptions(context.Background(), 24*time.Hour, time.Hour, time.Hour,
+		"test.ca.org", false, caNamespace, -1, client.CoreV1(), "")
+	if err != nil {
+		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
+	}
+	ca, err := NewIstioCA(caopts)
+	if err != nil {
+		t.Fatalf("Failed to create a self-signed CA: %v", err)
+	}
+	root := ca.GetCAKeyCertBundle().GetRootCertPem()
+	federatedRoot, federatedKey, err := util.GenCertKeyFromOptions(util.CertOptions{
+		TTL: time.Hour, Org: "federated.example.org", IsCA: true, IsSelfSigned: true, RSAKeySize: 2048})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := configmap.NewController(caNamespace, client.CoreV1()).InsertTrustBundle("federated.example.org", federatedRoot); err != nil {
+		t.Fatal(err)
+	}
+
+	rotator := NewRootRotator(ca, client.CoreV1(), caNamespace, 24*time.Hour, "test.ca.org", false, "", time.Hour, false)
+	if err := rotator.Reconcile(); err != nil {
+		t.Fatal(err)
+	}
+	roots := ca.GetCAKeyCertBundle().GetRootCertPem()
+	if countCerts(roots) != 2 || !bytes.HasPrefix(roots, root) || bytes.Contains(roots, federatedRoot) {
+		t.Errorf("Reconcile() => got %d roots, want the root of the mesh and the cross-signed federated root", countCerts(roots))
+	}
+	if err := rotator.Reconcile(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ca.GetCAKeyCertBundle().GetRootCertPem(), roots) {
+		t.Errorf("Reconcile() of unchanged trust bundles => got the federated roots cross-signed again")
+	}
+
+	// The federated CA only issues the identities of its trust domain.
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(roots)
+	signer, err := util.ParsePemEncodedCertificate(federatedRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signerKey, err := util.ParsePemEncodedKey(federatedKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, valid := range map[string]bool{
+		"spiffe://federated.example.org/ns/foo/sa/bar": true,
+		"spiffe://cluster.local/ns/foo/sa/bar":         false,
+	} {
+		certPem, _, err := util.GenCertKeyFromOptions(util.CertOptions{
+			Host: id, TTL: time.Hour, SignerCert: signer, SignerPriv: signerKey, IsClient: true, IsServer: true, RSAKeySize: 2048})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := util.ParsePemEncodedCertificate(certPem)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = cert.Verify(x509.VerifyOptions{Roots: pool, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+		if valid && err != nil {
+			t.Errorf("Verify() of %s issued by the federated CA => got error %v", id, err)
+		}
+		if !valid && err == nil {
+			t.Errorf("Verify() of %s issued by the federated CA => got no error, want it rejected", id)
+		}
+	}
+}
+
+func TestFingerprints(t *testing.T) {
+	cert, _, err := util.GenCertKeyFromOptions(util.CertOptions{
+		TTL: time.Hour, Org: "test.ca.org", IsCA: true, IsSelfSigned: true, RSAKeySize: 2048})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := fingerprints(append(cert, cert...))
+	block, _ := pem.Decode(cert)
+	sum := sha256.Sum256(block.Bytes)
+	want := hex.EncodeToString(sum[:])
+	if len(got) != 2 || got[0] != want || got[1] != want {
+		t.Errorf("fingerprints() => got %v, want two times %v", got, want)
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"time"
+)
+
+// SigningBackend signs the certificates of workloads in place of the CA, e.g. by delegating them
+// to an external PKI.
+type SigningBackend interface {
+	// Sign signs the CSR of a workload having the identities, with the requested TTL. It returns
+	// the certificate chain, leaf first, and the root certificate, both PEM encoded.
+	Sign(csrPEM []byte, subjectIDs []string, ttl time.Duration) (certChain []byte, rootCert []byte, err error)
+	// Name returns the name of the backend.
+	Name() string
+}
@@ -65,6 +65,13 @@ var (
 		Help:      "The number of certificates issuances that have succeeded.",
 	}, []string{})
 
+	signingBackendFallbackCounts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "citadel",
+		Subsystem: "server",
+		Name:      "signing_backend_fallback_count",
+		Help:      "The number of CSRs signed by Citadel because the signing backend failed to sign them.",
+	}, []string{})
+
 	rootCertExpiryTimestamp = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Namespace: "citadel",
@@ -82,6 +89,7 @@ func init() {
 	prometheus.MustRegister(idExtractionErrorCounts)
 	prometheus.MustRegister(certSignErrorCounts)
 	prometheus.MustRegister(successCounts)
+	prometheus.MustRegister(signingBackendFallbackCounts)
 	prometheus.MustRegister(rootCertExpiryTimestamp)
 }
 
@@ -92,7 +100,9 @@ type monitoringMetrics struct {
 	Success           prometheus.Counter
 	CSRError          prometheus.Counter
 	IDExtractionError prometheus.Counter
-	certSignErrors    *prometheus.CounterVec
+	// SigningBackendFallback counts the CSRs signed by the CA after the signing backend failed.
+	SigningBackendFallback prometheus.Counter
+	certSignErrors         *prometheus.CounterVec
 }
 
 // newMonitoringMetrics creates a new monitoringMetrics.
@@ -104,6 +114,8 @@ func newMonitoringMetrics() monitoringMetrics {
 		CSRError:          csrParsingErrorCounts.With(prometheus.Labels{}),
 		IDExtractionError: idExtractionErrorCounts.With(prometheus.Labels{}),
 		certSignErrors:    certSignErrorCounts,
+
+		SigningBackendFallback: signingBackendFallbackCounts.With(prometheus.Labels{}),
 	}
 }
 
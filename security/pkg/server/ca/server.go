@@ -62,6 +62,13 @@ type Server struct {
 	certificate    *tls.Certificate
 	port           int
 	forCA          bool
+	// handlingTimeBuckets are the bucket boundaries of the grpc handling time histogram, or the
+	// defaults if empty.
+	handlingTimeBuckets []float64
+	// signingBackend signs the workload certificates if set. If it fails, the CSR is rejected
+	// unless signingBackendFallback is set, in which case the CA signs it.
+	signingBackend         ca.SigningBackend
+	signingBackendFallback bool
 }
 
 // CreateCertificate handles an incoming certificate signing request (CSR). It does
@@ -81,9 +88,23 @@ func (s *Server) CreateCertificate(ctx context.Context, request *pb.IstioCertifi
 
 	// TODO: Call authorizer.
 
+	ttl := time.Duration(request.ValidityDuration) * time.Second
+	if s.signingBackend != nil {
+		certChain, rootCert, err := s.signingBackend.Sign([]byte(request.Csr), caller.Identities, ttl)
+		if err == nil {
+			log.Debugf("CSR successfully signed by %s.", s.signingBackend.Name())
+			return &pb.IstioCertificateResponse{CertChain: []string{string(certChain), string(rootCert)}}, nil
+		}
+		if !s.signingBackendFallback {
+			log.Errorf("Failed to sign the CSR with %s: %v", s.signingBackend.Name(), err)
+			return nil, status.Errorf(codes.Unavailable, "CSR signing error (%v)", err)
+		}
+		log.Warnf("Failed to sign the CSR with %s, falling back to the CA: %v", s.signingBackend.Name(), err)
+		s.monitoring.SigningBackendFallback.Inc()
+	}
+
 	_, _, certChainBytes, rootCertBytes := s.ca.GetCAKeyCertBundle().GetAll()
-	cert, signErr := s.ca.Sign(
-		[]byte(request.Csr), caller.Identities, time.Duration(request.ValidityDuration)*time.Second, false)
+	cert, signErr := s.ca.Sign([]byte(request.Csr), caller.Identities, ttl, false)
 	if signErr != nil {
 		log.Errorf("CSR signing error (%v)", signErr.Error())
 		s.monitoring.GetCertSignError(signErr.(*ca.Error).ErrorType()).Inc()
@@ -181,7 +202,11 @@ func (s *Server) Run() error {
 	pb.RegisterIstioCAServiceServer(grpcServer, s)
 	pb.RegisterIstioCertificateServiceServer(grpcServer, s)
 
-	grpc_prometheus.EnableHandlingTimeHistogram()
+	if len(s.handlingTimeBuckets) > 0 {
+		grpc_prometheus.EnableHandlingTimeHistogram(grpc_prometheus.WithHistogramBuckets(s.handlingTimeBuckets))
+	} else {
+		grpc_prometheus.EnableHandlingTimeHistogram()
+	}
 	grpc_prometheus.Register(grpcServer)
 
 	// grpcServer.Serve() is a blocking call, so run it in a goroutine.
@@ -197,9 +222,14 @@ func (s *Server) Run() error {
 	return nil
 }
 
-// New creates a new instance of `IstioCAServiceServer`.
+// New creates a new instance of `IstioCAServiceServer`. The handling time buckets are the bucket
+// boundaries of the grpc handling time histogram; the defaults are used if empty. The signing
+// backend, if not nil, signs the workload certificates requested to the server in place of the CA;
+// the certificates of the workload secrets are still signed by the CA. The CA only signs them
+// when the signing backend fails if signingBackendFallback is set.
 func New(ca ca.CertificateAuthority, ttl time.Duration, forCA bool, hostlist []string, port int,
-	trustDomain string, sdsEnabled bool) (*Server, error) {
+	trustDomain string, sdsEnabled bool, handlingTimeBuckets []float64, signingBackend ca.SigningBackend,
+	signingBackendFallback bool) (*Server, error) {
 
 	if len(hostlist) == 0 {
 		return nil, fmt.Errorf("failed to create grpc server hostlist empty")
@@ -246,6 +276,10 @@ func New(ca ca.CertificateAuthority, ttl time.Duration, forCA bool, hostlist []s
 		forCA:          forCA,
 		port:           port,
 		monitoring:     newMonitoringMetrics(),
+
+		handlingTimeBuckets:    handlingTimeBuckets,
+		signingBackend:         signingBackend,
+		signingBackendFallback: signingBackendFallback,
 	}
 	return server, nil
 }
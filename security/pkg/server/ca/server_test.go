@@ -152,6 +152,8 @@ func TestCreateCertificate(t *testing.T) {
 		authenticators []authenticator
 		authorizer     *mockAuthorizer
 		ca             ca.CertificateAuthority
+		signingBackend ca.SigningBackend
+		fallback       bool
 		certChain      []string
 		code           codes.Code
 	}{
@@ -206,12 +208,53 @@ func TestCreateCertificate(t *testing.T) {
 			certChain: []string{"cert", "cert_chain", "root_cert"},
 			code:      codes.OK,
 		},
+		"Successful signing by the signing backend": {
+			authenticators: []authenticator{&mockAuthenticator{}},
+			authorizer:     &mockAuthorizer{},
+			ca:             &mockca.FakeCA{SignErr: ca.NewError(ca.CertGenError, fmt.Errorf("cannot sign"))},
+			signingBackend: &mockca.FakeSigningBackend{
+				CertChain: []byte("backend_cert"),
+				RootCert:  []byte("backend_root_cert"),
+			},
+			certChain: []string{"backend_cert", "backend_root_cert"},
+			code:      codes.OK,
+		},
+		"Fallback to the CA on signing backend failure": {
+			authenticators: []authenticator{&mockAuthenticator{}},
+			authorizer:     &mockAuthorizer{},
+			ca: &mockca.FakeCA{
+				SignedCert: []byte("cert"),
+				KeyCertBundle: &mockutil.FakeKeyCertBundle{
+					CertChainBytes: []byte("cert_chain"),
+					RootCertBytes:  []byte("root_cert"),
+				},
+			},
+			signingBackend: &mockca.FakeSigningBackend{SignErr: fmt.Errorf("timed out")},
+			fallback:       true,
+			certChain:      []string{"cert", "cert_chain", "root_cert"},
+			code:           codes.OK,
+		},
+		"Signing backend failure without fallback": {
+			authenticators: []authenticator{&mockAuthenticator{}},
+			authorizer:     &mockAuthorizer{},
+			ca: &mockca.FakeCA{
+				SignedCert: []byte("cert"),
+				KeyCertBundle: &mockutil.FakeKeyCertBundle{
+					CertChainBytes: []byte("cert_chain"),
+					RootCertBytes:  []byte("root_cert"),
+				},
+			},
+			signingBackend: &mockca.FakeSigningBackend{SignErr: fmt.Errorf("timed out")},
+			code:           codes.Unavailable,
+		},
 	}
 
 	for id, c := range testCases {
 		server := &Server{
-			ca:             c.ca,
-			hostnames:      []string{"hostname"},
+			ca:                     c.ca,
+			signingBackend:         c.signingBackend,
+			signingBackendFallback: c.fallback,
+			hostnames:              []string{"hostname"},
 			port:           8080,
 			authorizer:     c.authorizer,
 			authenticators: c.authenticators,
@@ -453,7 +496,7 @@ func TestRun(t *testing.T) {
 			// K8s JWT authenticator is added in k8s env.
 			tc.expectedAuthenticatorsLen++
 		}
-		server, err := New(tc.ca, time.Hour, false, tc.hostname, tc.port, "testdomain.com", true)
+		server, err := New(tc.ca, time.Hour, false, tc.hostname, tc.port, "testdomain.com", true, nil, nil, false)
 		if err == nil {
 			err = server.Run()
 		}
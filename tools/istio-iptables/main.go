@@ -79,6 +79,7 @@ func run(args []string, flagSet *flag.FlagSet) {
 
 	proxyPort := env.RegisterStringVar("ENVOY_PORT", "15001", "").Get()
 	inboundCapturePort := env.RegisterStringVar("INBOUND_CAPTURE_PORT", "15006", "").Get()
+	dnsCapturePort := env.RegisterStringVar("ISTIO_DNS_CAPTURE_PORT", "", "").Get()
 	flagSet.StringVar(&proxyPort, "p", proxyPort,
 		"Specify the envoy port to which redirect all TCP traffic (default $ENVOY_PORT = 15001)")
 	flagSet.StringVar(&inboundCapturePort, "z", inboundCapturePort,
@@ -175,6 +176,7 @@ func run(args []string, flagSet *flag.FlagSet) {
 	ext.RunQuietlyAndIgnore(dep.IPTABLES, "-t", "nat", "-D", "PREROUTING", "-p", "tcp", "-j", "ISTIO_INBOUND")
 	ext.RunQuietlyAndIgnore(dep.IPTABLES, "-t", "mangle", "-D", "PREROUTING", "-p", "tcp", "-j", "ISTIO_INBOUND")
 	ext.RunQuietlyAndIgnore(dep.IPTABLES, "-t", "nat", "-D", "OUTPUT", "-p", "tcp", "-j", "ISTIO_OUTPUT")
+	ext.RunQuietlyAndIgnore(dep.IPTABLES, "-t", "nat", "-D", "OUTPUT", "-p", "udp", "--dport", "53", "-j", "ISTIO_DNS")
 	// Flush and delete the istio chains.
 	ext.RunQuietlyAndIgnore(dep.IPTABLES, "-t", "nat", "-F", "ISTIO_OUTPUT")
 	ext.RunQuietlyAndIgnore(dep.IPTABLES, "-t", "nat", "-X", "ISTIO_OUTPUT")
@@ -186,6 +188,8 @@ func run(args []string, flagSet *flag.FlagSet) {
 	ext.RunQuietlyAndIgnore(dep.IPTABLES, "-t", "mangle", "-X", "ISTIO_DIVERT")
 	ext.RunQuietlyAndIgnore(dep.IPTABLES, "-t", "mangle", "-F", "ISTIO_TPROXY")
 	ext.RunQuietlyAndIgnore(dep.IPTABLES, "-t", "mangle", "-X", "ISTIO_TPROXY")
+	ext.RunQuietlyAndIgnore(dep.IPTABLES, "-t", "nat", "-F", "ISTIO_DNS")
+	ext.RunQuietlyAndIgnore(dep.IPTABLES, "-t", "nat", "-X", "ISTIO_DNS")
 	// Must be last, the others refer to it
 	ext.RunQuietlyAndIgnore(dep.IPTABLES, "-t", "nat", "-F", "ISTIO_REDIRECT")
 	ext.RunQuietlyAndIgnore(dep.IPTABLES, "-t", "nat", "-X", "ISTIO_REDIRECT")
@@ -208,6 +212,7 @@ func run(args []string, flagSet *flag.FlagSet) {
 	fmt.Printf("ISTIO_LOCAL_EXCLUDE_PORTS=%s\n", os.Getenv("ISTIO_LOCAL_EXCLUDE_PORTS"))
 	fmt.Printf("ISTIO_SERVICE_CIDR=%s\n", os.Getenv("ISTIO_SERVICE_CIDR"))
 	fmt.Printf("ISTIO_SERVICE_EXCLUDE_CIDR=%s\n", os.Getenv("ISTIO_SERVICE_EXCLUDE_CIDR"))
+	fmt.Printf("ISTIO_DNS_CAPTURE_PORT=%s\n", dnsCapturePort)
 	fmt.Println("")
 	fmt.Println("Variables:")
 	fmt.Println("----------")
@@ -380,6 +385,19 @@ func run(args []string, flagSet *flag.FlagSet) {
 		// All other traffic is not redirected.
 		ext.RunOrFail(dep.IPTABLES, "-t", "nat", "-A", "ISTIO_OUTPUT", "-j", "RETURN")
 	}
+	// Redirect the DNS queries of the applications to the DNS proxy of the agent, if enabled.
+	if dnsCapturePort != "" {
+		ext.RunOrFail(dep.IPTABLES, "-t", "nat", "-N", "ISTIO_DNS")
+		ext.RunOrFail(dep.IPTABLES, "-t", "nat", "-A", "OUTPUT", "-p", "udp", "--dport", "53", "-j", "ISTIO_DNS")
+		// Don't redirect the queries of the agent and Envoy, which are forwarded to the upstream resolvers.
+		for _, uid := range split(proxyUID) {
+			ext.RunOrFail(dep.IPTABLES, "-t", "nat", "-A", "ISTIO_DNS", "-m", "owner", "--uid-owner", uid, "-j", "RETURN")
+		}
+		for _, gid := range split(proxyGID) {
+			ext.RunOrFail(dep.IPTABLES, "-t", "nat", "-A", "ISTIO_DNS", "-m", "owner", "--gid-owner", gid, "-j", "RETURN")
+		}
+		ext.RunOrFail(dep.IPTABLES, "-t", "nat", "-A", "ISTIO_DNS", "-p", "udp", "-j", "REDIRECT", "--to-port", dnsCapturePort)
+	}
 	// If ENABLE_INBOUND_IPV6 is unset (default unset), restrict IPv6 traffic.
 	if enableInboundIPv6s != nil {
 		// Remove the old chains, to generate new configs.
@@ -258,6 +258,7 @@ fi
 iptables -t nat -D PREROUTING -p tcp -j ISTIO_INBOUND 2>/dev/null
 iptables -t mangle -D PREROUTING -p tcp -j ISTIO_INBOUND 2>/dev/null
 iptables -t nat -D OUTPUT -p tcp -j ISTIO_OUTPUT 2>/dev/null
+iptables -t nat -D OUTPUT -p udp --dport 53 -j ISTIO_DNS 2>/dev/null
 
 # Flush and delete the istio chains.
 iptables -t nat -F ISTIO_OUTPUT 2>/dev/null
@@ -270,6 +271,8 @@ iptables -t mangle -F ISTIO_DIVERT 2>/dev/null
 iptables -t mangle -X ISTIO_DIVERT 2>/dev/null
 iptables -t mangle -F ISTIO_TPROXY 2>/dev/null
 iptables -t mangle -X ISTIO_TPROXY 2>/dev/null
+iptables -t nat -F ISTIO_DNS 2>/dev/null
+iptables -t nat -X ISTIO_DNS 2>/dev/null
 
 # Must be last, the others refer to it
 iptables -t nat -F ISTIO_REDIRECT 2>/dev/null
@@ -294,6 +297,7 @@ echo "ISTIO_INBOUND_PORTS=${ISTIO_INBOUND_PORTS-}"
 echo "ISTIO_LOCAL_EXCLUDE_PORTS=${ISTIO_LOCAL_EXCLUDE_PORTS-}"
 echo "ISTIO_SERVICE_CIDR=${ISTIO_SERVICE_CIDR-}"
 echo "ISTIO_SERVICE_EXCLUDE_CIDR=${ISTIO_SERVICE_EXCLUDE_CIDR-}"
+echo "ISTIO_DNS_CAPTURE_PORT=${ISTIO_DNS_CAPTURE_PORT-}"
 echo
 echo "Variables:"
 echo "----------"
@@ -467,6 +471,20 @@ if [ ${#ipv4_ranges_include[@]} -gt 0 ]; then
     fi
 fi
 
+# Redirect the DNS queries of the applications to the DNS proxy of the agent, if enabled.
+if [ -n "${ISTIO_DNS_CAPTURE_PORT-}" ]; then
+  iptables -t nat -N ISTIO_DNS
+  iptables -t nat -A OUTPUT -p udp --dport 53 -j ISTIO_DNS
+  # Don't redirect the queries of the agent and Envoy, which are forwarded to the upstream resolvers.
+  for uid in ${PROXY_UID}; do
+    iptables -t nat -A ISTIO_DNS -m owner --uid-owner "${uid}" -j RETURN
+  done
+  for gid in ${PROXY_GID}; do
+    iptables -t nat -A ISTIO_DNS -m owner --gid-owner "${gid}" -j RETURN
+  done
+  iptables -t nat -A ISTIO_DNS -p udp -j REDIRECT --to-port "${ISTIO_DNS_CAPTURE_PORT}"
+fi
+
 # If ENABLE_INBOUND_IPV6 is unset (default unset), restrict IPv6 traffic.
 set +o nounset
 if [ -n "${ENABLE_INBOUND_IPV6}" ]; then